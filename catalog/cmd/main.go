@@ -3,20 +3,28 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // availability schedules need time zones on images without tzdata
 
 	"github.com/cebeuygun/platform/services/catalog/internal/config"
 	"github.com/cebeuygun/platform/services/catalog/internal/db"
+	"github.com/cebeuygun/platform/services/catalog/internal/grpcserver"
 	"github.com/cebeuygun/platform/services/catalog/internal/handler"
+	catalogv1 "github.com/cebeuygun/platform/services/catalog/internal/pb/catalog/v1"
 	"github.com/cebeuygun/platform/services/catalog/internal/repository"
 	"github.com/cebeuygun/platform/services/catalog/internal/service"
+	"github.com/cebeuygun/platform/services/catalog/internal/storage"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 // @title Catalog Service API
@@ -59,13 +67,73 @@ func main() {
 	// Initialize repositories
 	categoryRepo := repository.NewCategoryRepository(database)
 	productRepo := repository.NewProductRepository(database)
+	importJobRepo := repository.NewImportJobRepository(database)
+	mediaUploadRepo := repository.NewMediaUploadRepository(database)
+	revisionRepo := repository.NewRevisionRepository(database)
+	bundleRepo := repository.NewBundleRepository(database)
+	translationRepo := repository.NewTranslationRepository(database)
+	locationRepo := repository.NewSellerLocationRepository(database)
+	reviewRepo := repository.NewReviewRepository(database)
+	duplicateRepo := repository.NewDuplicateRepository(database)
+	searchSettingsRepo := repository.NewSearchSettingsRepository(database)
+	recommendationRepo := repository.NewRecommendationRepository(database)
+
+	// Initialize media storage
+	mediaStore, err := storage.NewMediaStore(cfg)
+	if err != nil {
+		log.Fatal("Failed to create media store:", err)
+	}
+
+	// Initialize Redis cache; without Redis the service serves uncached reads
+	var redisClient *redis.Client
+	if cfg.CacheEnabled {
+		redisClient, err = db.NewRedisClient(cfg.RedisURL)
+		if err != nil {
+			log.Printf("Failed to connect to Redis, running without cache: %v", err)
+			redisClient = nil
+		} else {
+			defer redisClient.Close()
+		}
+	}
 
 	// Initialize service
-	catalogService, err := service.NewCatalogService(categoryRepo, productRepo, cfg)
+	catalogService, err := service.NewCatalogService(categoryRepo, productRepo, importJobRepo, mediaUploadRepo, revisionRepo, bundleRepo, translationRepo, locationRepo, reviewRepo, duplicateRepo, searchSettingsRepo, recommendationRepo, mediaStore, redisClient, cfg)
 	if err != nil {
 		log.Fatal("Failed to create catalog service:", err)
 	}
 
+	// Start stock alert publisher
+	go catalogService.StartStockAlertPublisher()
+
+	// Start bulk import worker
+	go catalogService.StartImportWorker()
+
+	// Start Google Merchant feed scheduler
+	go catalogService.StartMerchantFeedScheduler()
+
+	// Start media processing worker
+	go catalogService.StartMediaWorker()
+
+	// Start availability scheduler
+	go catalogService.StartAvailabilityScheduler()
+
+	// Start order delivered consumer, which makes delivered items reviewable
+	go catalogService.StartOrderDeliveredConsumer()
+
+	// Start order created consumer, which keeps ordered products from being purged
+	go catalogService.StartOrderCreatedConsumer()
+
+	// Start product event consumer, which drops changed products from the cache
+	go catalogService.StartProductEventConsumer()
+
+	// Start retention purge of deleted products and categories
+	go catalogService.StartRetentionPurge()
+
+	// Initialize gRPC server
+	grpcServer := grpc.NewServer()
+	catalogv1.RegisterCatalogServiceServer(grpcServer, grpcserver.NewCatalogServer(catalogService))
+	reflection.Register(grpcServer)
+
 	// Initialize HTTP server
 	if cfg.Environment != "production" {
 		gin.SetMode(gin.DebugMode)
@@ -80,7 +148,8 @@ func main() {
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-ID, If-None-Match")
+		c.Header("Access-Control-Expose-Headers", "ETag, Last-Modified, Content-Language")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -105,15 +174,42 @@ func main() {
 		// Initialize handlers
 		categoryHandler := handler.NewCategoryHandler(catalogService)
 		productHandler := handler.NewProductHandler(catalogService)
+		sellerHandler := handler.NewSellerHandler(catalogService)
+		moderationHandler := handler.NewModerationHandler(catalogService)
+		reviewHandler := handler.NewReviewHandler(catalogService)
+		trashHandler := handler.NewTrashHandler(catalogService)
+		searchSettingsHandler := handler.NewSearchSettingsHandler(catalogService)
 
 		// Register routes
 		categoryHandler.RegisterRoutes(v1)
 		productHandler.RegisterRoutes(v1)
+		sellerHandler.RegisterRoutes(v1)
+		moderationHandler.RegisterRoutes(v1)
+		reviewHandler.RegisterRoutes(v1)
+		trashHandler.RegisterRoutes(v1)
+		searchSettingsHandler.RegisterRoutes(v1)
+	}
+
+	// The local media store serves its objects and accepts presigned uploads itself
+	if localStore, ok := mediaStore.(*storage.LocalStore); ok {
+		router.Any("/media/*path", gin.WrapH(http.StripPrefix("/media", localStore)))
 	}
 
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Start gRPC server
+	go func() {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			log.Fatal("Failed to listen for gRPC:", err)
+		}
+		log.Printf("🚀 gRPC Catalog service listening on port %s", cfg.GRPCPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatal("Failed to serve gRPC:", err)
+		}
+	}()
+
 	// Start HTTP server
 	srv := &http.Server{
 		Addr:    ":" + cfg.Port,
@@ -137,6 +233,8 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	grpcServer.GracefulStop()
+
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
@@ -0,0 +1,255 @@
+package grpcserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	catalogv1 "github.com/cebeuygun/platform/services/catalog/internal/pb/catalog/v1"
+	commonv1 "github.com/cebeuygun/platform/services/catalog/internal/pb/common/v1"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toProduct converts a product and whatever related data was loaded with it
+func toProduct(product *models.Product) *catalogv1.Product {
+	message := &catalogv1.Product{
+		Base: &commonv1.BaseEntity{
+			Id:        product.ID.String(),
+			CreatedAt: timestamppb.New(product.CreatedAt),
+			UpdatedAt: timestamppb.New(product.UpdatedAt),
+		},
+		Name:                   product.Name,
+		Description:            stringValue(product.Description),
+		CategoryId:             product.CategoryID.String(),
+		Status:                 toProductStatus(product),
+		Tags:                   product.Tags,
+		ImageUrls:              imageURLs(product.Media),
+		Attributes:             toAttributes(product.Attributes),
+		IsExpressDelivery:      product.IsExpressDelivery,
+		PreparationTimeMinutes: int32(product.PreparationTime),
+		Sku:                    stringValue(product.SKU),
+		Barcode:                stringValue(product.Barcode),
+		Brand:                  stringValue(product.Brand),
+		BasePrice:              toMoney(product.BasePrice, product.Currency),
+		InventoryQuantity:      int32(productStock(product)),
+		Locale:                 product.Locale,
+	}
+	if product.SubmittedBy != nil {
+		message.SellerId = product.SubmittedBy.String()
+	}
+
+	// The price range covers the base price and every variant price
+	minPrice, maxPrice := product.BasePrice, product.BasePrice
+	for i, variant := range product.Variants {
+		price := variantPrice(product, variant)
+		if i == 0 || price.LessThan(minPrice) {
+			minPrice = price
+		}
+		if i == 0 || price.GreaterThan(maxPrice) {
+			maxPrice = price
+		}
+		message.Variants = append(message.Variants, toVariant(product, variant))
+	}
+	message.MinPrice = toMoney(minPrice, product.Currency)
+	message.MaxPrice = toMoney(maxPrice, product.Currency)
+
+	return message
+}
+
+func toVariant(product *models.Product, variant *models.ProductVariant) *catalogv1.ProductVariant {
+	message := &catalogv1.ProductVariant{
+		Id:         variant.ID.String(),
+		Name:       variant.Name,
+		Sku:        stringValue(variant.SKU),
+		Price:      toMoney(variantPrice(product, variant), product.Currency),
+		Attributes: toAttributes(variant.Attributes),
+		ImageUrls:  imageURLs(variant.Media),
+	}
+	if variant.Stock != nil {
+		message.InventoryQuantity = int32(*variant.Stock)
+	}
+	return message
+}
+
+// toProductStatus folds the active flag, moderation state and stock of a
+// product into the status of the contract
+func toProductStatus(product *models.Product) catalogv1.ProductStatus {
+	switch {
+	case !product.IsActive:
+		return catalogv1.ProductStatus_PRODUCT_STATUS_DISCONTINUED
+	case product.Status != "" && product.Status != models.ProductStatusApproved:
+		return catalogv1.ProductStatus_PRODUCT_STATUS_DRAFT
+	case productStock(product) <= 0:
+		return catalogv1.ProductStatus_PRODUCT_STATUS_OUT_OF_STOCK
+	default:
+		return catalogv1.ProductStatus_PRODUCT_STATUS_ACTIVE
+	}
+}
+
+// productStock returns the sellable stock of a product: the derived stock of
+// a bundle, the total of its variants' stock, or its base stock
+func productStock(product *models.Product) int {
+	if product.Bundle != nil {
+		return product.Bundle.Stock
+	}
+
+	stock := 0
+	hasVariantStock := false
+	for _, variant := range product.Variants {
+		if variant.Stock != nil {
+			stock += *variant.Stock
+			hasVariantStock = true
+		}
+	}
+	if hasVariantStock {
+		return stock
+	}
+	return product.BaseStock
+}
+
+func variantPrice(product *models.Product, variant *models.ProductVariant) decimal.Decimal {
+	if variant.Price != nil {
+		return *variant.Price
+	}
+	return product.BasePrice
+}
+
+func toCategory(category *models.Category) *catalogv1.Category {
+	message := &catalogv1.Category{
+		Base: &commonv1.BaseEntity{
+			Id:        category.ID.String(),
+			CreatedAt: timestamppb.New(category.CreatedAt),
+			UpdatedAt: timestamppb.New(category.UpdatedAt),
+		},
+		Name:        category.Name,
+		Description: stringValue(category.Description),
+		ImageUrl:    stringValue(category.ImageURL),
+		SortOrder:   int32(category.SortOrder),
+		IsActive:    category.IsActive,
+	}
+	if category.ParentID != nil {
+		message.ParentId = category.ParentID.String()
+	}
+	return message
+}
+
+// toMoney converts an amount to minor units (kuruş for TRY)
+func toMoney(amount decimal.Decimal, currency string) *commonv1.Money {
+	return &commonv1.Money{
+		Currency: currency,
+		Amount:   amount.Shift(2).Round(0).IntPart(),
+	}
+}
+
+func fromMoney(money *commonv1.Money) decimal.Decimal {
+	return decimal.New(money.Amount, -2)
+}
+
+// toAttributes renders attribute values as strings; numbers and booleans use
+// their plain form and other values are JSON encoded
+func toAttributes(attributes models.Attributes) map[string]string {
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(attributes))
+	for key, value := range attributes {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case float64:
+			values[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				values[key] = fmt.Sprint(v)
+				continue
+			}
+			values[key] = string(data)
+		}
+	}
+	return values
+}
+
+// fromAttributes takes attribute values as strings; the category attribute
+// schema converts them to their declared type
+func fromAttributes(values map[string]string) models.Attributes {
+	attributes := make(models.Attributes, len(values))
+	for key, value := range values {
+		attributes[key] = value
+	}
+	return attributes
+}
+
+func imageURLs(media []*models.ProductMedia) []string {
+	var urls []string
+	for _, m := range media {
+		if m.Type == "image" {
+			urls = append(urls, m.URL)
+		}
+	}
+	return urls
+}
+
+// parseOptionalID parses an ID field where the empty string means unset
+func parseOptionalID(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// toPagination reports the page of a listing of total items
+func toPagination(page int, limit int, total int64) *commonv1.Pagination {
+	totalPages := int(total) / limit
+	if int(total)%limit > 0 {
+		totalPages++
+	}
+	return &commonv1.Pagination{
+		Page:       int32(page),
+		Limit:      int32(limit),
+		Total:      int32(total),
+		TotalPages: int32(totalPages),
+	}
+}
+
+// pageParams reads the requested page, defaulting to the first 20 items and
+// capping the page size at 100 like the HTTP API
+func pageParams(pagination *commonv1.Pagination) (int, int) {
+	page, limit := 1, 20
+	if pagination != nil {
+		if pagination.Page > 0 {
+			page = int(pagination.Page)
+		}
+		if pagination.Limit > 0 {
+			limit = int(pagination.Limit)
+		}
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return page, limit
+}
@@ -0,0 +1,542 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	catalogv1 "github.com/cebeuygun/platform/services/catalog/internal/pb/catalog/v1"
+	commonv1 "github.com/cebeuygun/platform/services/catalog/internal/pb/common/v1"
+	"github.com/cebeuygun/platform/services/catalog/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// CatalogServer serves catalog/v1/catalog.proto for internal callers such as
+// the order and pricing services
+type CatalogServer struct {
+	catalogv1.UnimplementedCatalogServiceServer
+
+	service   service.CatalogService
+	validator *validator.Validate
+}
+
+func NewCatalogServer(service service.CatalogService) *CatalogServer {
+	return &CatalogServer{
+		service:   service,
+		validator: validator.New(),
+	}
+}
+
+// CreateProduct creates a product with its variants. The contract has no
+// base price, so the cheapest variant price becomes the base price.
+func (s *CatalogServer) CreateProduct(ctx context.Context, req *catalogv1.CreateProductRequest) (*catalogv1.CreateProductResponse, error) {
+	categoryID, err := uuid.Parse(req.CategoryId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid category_id: %v", err)
+	}
+	sellerID, err := parseOptionalID(req.SellerId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid seller_id: %v", err)
+	}
+	if len(req.ImageUrls) > 0 {
+		return nil, status.Error(codes.InvalidArgument, "image_urls are not supported; upload media through the HTTP API")
+	}
+
+	create := &models.CreateProductRequest{
+		Name:              req.Name,
+		Description:       optionalString(req.Description),
+		CategoryID:        categoryID,
+		Tags:              req.Tags,
+		Attributes:        fromAttributes(req.Attributes),
+		IsExpressDelivery: req.IsExpressDelivery,
+		PreparationTime:   int(req.PreparationTimeMinutes),
+		SellerID:          sellerID,
+	}
+
+	for _, v := range req.Variants {
+		if v.Price == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "variant %q has no price", v.Name)
+		}
+		if len(v.ImageUrls) > 0 {
+			return nil, status.Error(codes.InvalidArgument, "image_urls are not supported; upload media through the HTTP API")
+		}
+
+		price := fromMoney(v.Price)
+		if create.Currency == "" || price.LessThan(create.BasePrice) {
+			create.BasePrice = price
+			create.Currency = v.Price.Currency
+		}
+
+		stock := int(v.InventoryQuantity)
+		create.Variants = append(create.Variants, &models.CreateVariantRequest{
+			Name:       v.Name,
+			SKU:        optionalString(v.Sku),
+			Price:      &price,
+			Stock:      &stock,
+			Attributes: fromAttributes(v.Attributes),
+		})
+	}
+	if len(create.Variants) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one variant with a price is required")
+	}
+	if !create.BasePrice.IsPositive() {
+		return nil, status.Error(codes.InvalidArgument, "variant prices must be positive")
+	}
+
+	// The decimal fields carry no validator-compatible rules, so they are checked above
+	if err := s.validator.StructExcept(create, "BasePrice", "TaxRate"); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
+	}
+	for _, variant := range create.Variants {
+		if err := s.validator.Struct(variant); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
+		}
+	}
+
+	product, err := s.service.CreateProduct(create, requestActor(ctx))
+	if err != nil {
+		return nil, statusError(err, "failed to create product")
+	}
+
+	product, err = s.service.GetProduct(product.ID)
+	if err != nil || product == nil {
+		return nil, statusError(err, "failed to get product")
+	}
+
+	return &catalogv1.CreateProductResponse{
+		Base:    okResponse("Product created successfully"),
+		Product: toProduct(product),
+	}, nil
+}
+
+func (s *CatalogServer) GetProduct(ctx context.Context, req *catalogv1.GetProductRequest) (*catalogv1.GetProductResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid product id: %v", err)
+	}
+
+	product, err := s.service.GetProduct(id)
+	if err != nil {
+		return nil, statusError(err, "failed to get product")
+	}
+	if product == nil {
+		return nil, status.Error(codes.NotFound, "product not found")
+	}
+
+	s.service.LocalizeProducts([]*models.Product{product}, s.requestLocales(ctx))
+
+	return &catalogv1.GetProductResponse{
+		Base:    okResponse("Product retrieved successfully"),
+		Product: toProduct(product),
+	}, nil
+}
+
+// UpdateProduct replaces the name, description, category, tags, attributes,
+// express delivery flag and preparation time of a product with those of the
+// given product. Proto3 cannot tell an empty field from an absent one, so
+// SKU, barcode, brand and base price are only changed when set, and the
+// status only when it is ACTIVE or DISCONTINUED. Variants are not changed.
+func (s *CatalogServer) UpdateProduct(ctx context.Context, req *catalogv1.UpdateProductRequest) (*catalogv1.UpdateProductResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid product id: %v", err)
+	}
+	if req.Product == nil {
+		return nil, status.Error(codes.InvalidArgument, "product is required")
+	}
+	p := req.Product
+
+	categoryID, err := uuid.Parse(p.CategoryId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid category_id: %v", err)
+	}
+
+	tags := p.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	preparationTime := int(p.PreparationTimeMinutes)
+	update := &models.UpdateProductRequest{
+		Name:              &p.Name,
+		Description:       &p.Description,
+		CategoryID:        &categoryID,
+		Tags:              tags,
+		Attributes:        fromAttributes(p.Attributes),
+		IsExpressDelivery: &p.IsExpressDelivery,
+		PreparationTime:   &preparationTime,
+		SKU:               optionalString(p.Sku),
+		Barcode:           optionalString(p.Barcode),
+		Brand:             optionalString(p.Brand),
+	}
+
+	switch p.Status {
+	case catalogv1.ProductStatus_PRODUCT_STATUS_UNSPECIFIED:
+	case catalogv1.ProductStatus_PRODUCT_STATUS_ACTIVE:
+		active := true
+		update.IsActive = &active
+	case catalogv1.ProductStatus_PRODUCT_STATUS_DISCONTINUED:
+		active := false
+		update.IsActive = &active
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "status %s is derived and cannot be set", p.Status)
+	}
+
+	if p.BasePrice != nil {
+		price := fromMoney(p.BasePrice)
+		if !price.IsPositive() {
+			return nil, status.Error(codes.InvalidArgument, "base_price must be positive")
+		}
+		update.BasePrice = &price
+		if p.BasePrice.Currency != "" {
+			update.Currency = &p.BasePrice.Currency
+		}
+	}
+
+	if err := s.validator.StructExcept(update, "BasePrice", "TaxRate"); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
+	}
+	if p.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	if err := s.service.UpdateProduct(id, update, requestActor(ctx)); err != nil {
+		return nil, statusError(err, "failed to update product")
+	}
+
+	product, err := s.service.GetProduct(id)
+	if err != nil || product == nil {
+		return nil, statusError(err, "failed to get product")
+	}
+
+	return &catalogv1.UpdateProductResponse{
+		Base:    okResponse("Product updated successfully"),
+		Product: toProduct(product),
+	}, nil
+}
+
+func (s *CatalogServer) DeleteProduct(ctx context.Context, req *catalogv1.DeleteProductRequest) (*catalogv1.DeleteProductResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid product id: %v", err)
+	}
+
+	if err := s.service.DeleteProduct(id, requestActor(ctx)); err != nil {
+		return nil, statusError(err, "failed to delete product")
+	}
+
+	return &catalogv1.DeleteProductResponse{
+		Base: okResponse("Product deleted successfully"),
+	}, nil
+}
+
+// ListProducts lists the live products of a seller and/or category. Only
+// ACTIVE and DISCONTINUED can be filtered on; the other statuses are derived.
+func (s *CatalogServer) ListProducts(ctx context.Context, req *catalogv1.ListProductsRequest) (*catalogv1.ListProductsResponse, error) {
+	search := &models.SearchRequest{SortBy: "created_at", SortOrder: "desc", IncludeTotal: true}
+	search.Page, search.Limit = pageParams(req.Pagination)
+
+	var err error
+	if search.SellerID, err = parseOptionalID(req.SellerId); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid seller_id: %v", err)
+	}
+	if search.CategoryID, err = parseOptionalID(req.CategoryId); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid category_id: %v", err)
+	}
+
+	switch req.Status {
+	case catalogv1.ProductStatus_PRODUCT_STATUS_UNSPECIFIED:
+	case catalogv1.ProductStatus_PRODUCT_STATUS_ACTIVE:
+		active := true
+		search.IsActive = &active
+	case catalogv1.ProductStatus_PRODUCT_STATUS_DISCONTINUED:
+		active := false
+		search.IsActive = &active
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "cannot filter on status %s", req.Status)
+	}
+
+	result, err := s.service.SearchProducts(search)
+	if err != nil {
+		return nil, statusError(err, "failed to list products")
+	}
+
+	return &catalogv1.ListProductsResponse{
+		Base:       okResponse("Products retrieved successfully"),
+		Products:   s.toProducts(ctx, result.Products),
+		Pagination: toPagination(result.Page, result.Limit, result.Total),
+	}, nil
+}
+
+// SearchProducts searches live products. The catalog has no store
+// locations, so the location of the request is not used.
+func (s *CatalogServer) SearchProducts(ctx context.Context, req *catalogv1.SearchProductsRequest) (*catalogv1.SearchProductsResponse, error) {
+	search := &models.SearchRequest{
+		Query:       req.Query,
+		Tags:        req.Tags,
+		ExpressOnly: req.ExpressDeliveryOnly,
+		SortBy:      "relevance",
+		SortOrder:   "desc",
+
+		IncludeTotal: true,
+	}
+	search.Page, search.Limit = pageParams(req.Pagination)
+
+	var err error
+	if search.CategoryID, err = parseOptionalID(req.CategoryId); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid category_id: %v", err)
+	}
+	if req.MinPrice != nil {
+		minPrice := fromMoney(req.MinPrice)
+		search.MinPrice = &minPrice
+	}
+	if req.MaxPrice != nil {
+		maxPrice := fromMoney(req.MaxPrice)
+		search.MaxPrice = &maxPrice
+	}
+	if req.Location != nil {
+		latitude, longitude := req.Location.Latitude, req.Location.Longitude
+		if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid location: %v, %v", latitude, longitude)
+		}
+		search.Latitude = &latitude
+		search.Longitude = &longitude
+	}
+
+	result, err := s.service.SearchProducts(search)
+	if err != nil {
+		return nil, statusError(err, "failed to search products")
+	}
+
+	return &catalogv1.SearchProductsResponse{
+		Base:       okResponse("Products retrieved successfully"),
+		Products:   s.toProducts(ctx, result.Products),
+		Pagination: toPagination(result.Page, result.Limit, result.Total),
+	}, nil
+}
+
+// BatchGetProducts returns products with their variants by ID in one call
+func (s *CatalogServer) BatchGetProducts(ctx context.Context, req *catalogv1.BatchGetProductsRequest) (*catalogv1.BatchGetProductsResponse, error) {
+	if len(req.Ids) > service.MaxBatchProducts {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d products can be requested at once", service.MaxBatchProducts)
+	}
+
+	ids := make([]uuid.UUID, len(req.Ids))
+	for i, value := range req.Ids {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid product id %q: %v", value, err)
+		}
+		ids[i] = id
+	}
+
+	products, err := s.service.GetProductsByIDs(ids)
+	if err != nil {
+		return nil, statusError(err, "failed to get products")
+	}
+
+	found := make(map[string]bool, len(products))
+	for _, product := range products {
+		found[product.ID.String()] = true
+	}
+
+	return &catalogv1.BatchGetProductsResponse{
+		Base:     okResponse("Products retrieved successfully"),
+		Products: s.toProducts(ctx, products),
+		NotFound: notFound(req.Ids, func(value string) bool { return found[uuid.MustParse(value).String()] }),
+	}, nil
+}
+
+// BatchGetProductsBySku returns products with their variants by SKU in one call
+func (s *CatalogServer) BatchGetProductsBySku(ctx context.Context, req *catalogv1.BatchGetProductsBySkuRequest) (*catalogv1.BatchGetProductsResponse, error) {
+	if len(req.Skus) > service.MaxBatchProducts {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d products can be requested at once", service.MaxBatchProducts)
+	}
+
+	products, err := s.service.GetProductsBySKUs(req.Skus)
+	if err != nil {
+		return nil, statusError(err, "failed to get products")
+	}
+
+	found := make(map[string]bool, len(products))
+	for _, product := range products {
+		found[stringValue(product.SKU)] = true
+	}
+
+	return &catalogv1.BatchGetProductsResponse{
+		Base:     okResponse("Products retrieved successfully"),
+		Products: s.toProducts(ctx, products),
+		NotFound: notFound(req.Skus, func(value string) bool { return found[value] }),
+	}, nil
+}
+
+func (s *CatalogServer) CreateCategory(ctx context.Context, req *catalogv1.CreateCategoryRequest) (*catalogv1.CreateCategoryResponse, error) {
+	parentID, err := parseOptionalID(req.ParentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid parent_id: %v", err)
+	}
+
+	create := &models.CreateCategoryRequest{
+		Name:        req.Name,
+		Description: optionalString(req.Description),
+		ParentID:    parentID,
+		ImageURL:    optionalString(req.ImageUrl),
+		SortOrder:   int(req.SortOrder),
+	}
+	if err := s.validator.Struct(create); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
+	}
+
+	category, err := s.service.CreateCategory(create)
+	if err != nil {
+		return nil, statusError(err, "failed to create category")
+	}
+
+	return &catalogv1.CreateCategoryResponse{
+		Base:     okResponse("Category created successfully"),
+		Category: toCategory(category),
+	}, nil
+}
+
+func (s *CatalogServer) GetCategory(ctx context.Context, req *catalogv1.GetCategoryRequest) (*catalogv1.GetCategoryResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid category id: %v", err)
+	}
+
+	category, err := s.service.GetCategory(id)
+	if err != nil {
+		return nil, statusError(err, "failed to get category")
+	}
+	if category == nil {
+		return nil, status.Error(codes.NotFound, "category not found")
+	}
+
+	s.service.LocalizeCategories([]*models.Category{category}, s.requestLocales(ctx))
+
+	return &catalogv1.GetCategoryResponse{
+		Base:     okResponse("Category retrieved successfully"),
+		Category: toCategory(category),
+	}, nil
+}
+
+// ListCategories lists the children of a category, or the root categories
+// when no parent is given
+func (s *CatalogServer) ListCategories(ctx context.Context, req *catalogv1.ListCategoriesRequest) (*catalogv1.ListCategoriesResponse, error) {
+	parentID, err := parseOptionalID(req.ParentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid parent_id: %v", err)
+	}
+
+	categories, err := s.service.GetCategories(parentID, req.OnlyActive)
+	if err != nil {
+		return nil, statusError(err, "failed to list categories")
+	}
+
+	page, limit := pageParams(req.Pagination)
+	total := len(categories)
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+	categories = categories[start:end]
+
+	s.service.LocalizeCategories(categories, s.requestLocales(ctx))
+
+	messages := make([]*catalogv1.Category, len(categories))
+	for i, category := range categories {
+		messages[i] = toCategory(category)
+	}
+
+	return &catalogv1.ListCategoriesResponse{
+		Base:       okResponse("Categories retrieved successfully"),
+		Categories: messages,
+		Pagination: toPagination(page, limit, int64(total)),
+	}, nil
+}
+
+// toProducts localizes products to the caller's locales and converts them
+func (s *CatalogServer) toProducts(ctx context.Context, products []*models.Product) []*catalogv1.Product {
+	s.service.LocalizeProducts(products, s.requestLocales(ctx))
+
+	messages := make([]*catalogv1.Product, len(products))
+	for i, product := range products {
+		messages[i] = toProduct(product)
+	}
+	return messages
+}
+
+// requestLocales negotiates the locales to read content in from the
+// accept-language metadata, the gRPC counterpart of the HTTP header
+func (s *CatalogServer) requestLocales(ctx context.Context) []string {
+	return s.service.NegotiateLocales(metadataValue(ctx, "accept-language"))
+}
+
+// requestActor identifies who makes a change, as set by the calling service
+// in the x-user-id metadata
+func requestActor(ctx context.Context) string {
+	return metadataValue(ctx, "x-user-id")
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// notFound lists the requested keys that found no product, without duplicates
+func notFound(keys []string, found func(key string) bool) []string {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if !found(key) && !seen[key] {
+			seen[key] = true
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+func okResponse(message string) *commonv1.BaseResponse {
+	return &commonv1.BaseResponse{
+		Success: true,
+		Message: message,
+		Code:    int32(codes.OK),
+	}
+}
+
+// statusError maps service errors to gRPC status errors the way the HTTP
+// handlers map them to status codes
+func statusError(err error, message string) error {
+	if err == nil {
+		return status.Error(codes.NotFound, "not found")
+	}
+
+	var attributeErr *service.AttributeValidationError
+	var foodErr *service.FoodInfoError
+	var barcodeErr *service.BarcodeError
+	var conflictErr *service.BarcodeConflictError
+	switch {
+	case err.Error() == "product not found", err.Error() == "category not found":
+		return status.Error(codes.NotFound, err.Error())
+	case errors.As(err, &attributeErr), errors.As(err, &foodErr), errors.As(err, &barcodeErr):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &conflictErr):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return status.Error(codes.Internal, fmt.Sprintf("%s: %v", message, err))
+	}
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/cebeuygun/platform/services/catalog/internal/models"
 	"github.com/cebeuygun/platform/services/catalog/internal/service"
@@ -75,7 +77,10 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 // @Tags categories
 // @Produce json
 // @Param id path string true "Category ID"
+// @Param Accept-Language header string false "Preferred locales, e.g. en-GB,en;q=0.8"
+// @Param If-None-Match header string false "ETag of a previously received response"
 // @Success 200 {object} models.APIResponse{data=models.Category}
+// @Success 304 "Not modified"
 // @Failure 400 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
@@ -110,11 +115,13 @@ func (h *CategoryHandler) GetCategory(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	h.service.LocalizeCategories([]*models.Category{category}, requestLocales(c, h.service))
+
+	respondConditional(c, models.APIResponse{
 		Success: true,
 		Message: "Category retrieved successfully",
 		Data:    category,
-	})
+	}, category.UpdatedAt)
 }
 
 // @Summary List categories
@@ -123,6 +130,7 @@ func (h *CategoryHandler) GetCategory(c *gin.Context) {
 // @Produce json
 // @Param parent_id query string false "Parent category ID"
 // @Param active_only query boolean false "Filter active categories only"
+// @Param Accept-Language header string false "Preferred locales, e.g. en-GB,en;q=0.8"
 // @Success 200 {object} models.APIResponse{data=[]models.Category}
 // @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
@@ -166,6 +174,8 @@ func (h *CategoryHandler) GetCategories(c *gin.Context) {
 		return
 	}
 
+	h.service.LocalizeCategories(categories, requestLocales(c, h.service))
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "Categories retrieved successfully",
@@ -174,26 +184,172 @@ func (h *CategoryHandler) GetCategories(c *gin.Context) {
 }
 
 // @Summary Get category tree
-// @Description Get hierarchical category tree
+// @Description Get hierarchical category tree with product counts rolled up from subcategories
 // @Tags categories
 // @Produce json
+// @Param root_id query string false "Only return the subtree below this category"
+// @Param Accept-Language header string false "Preferred locales, e.g. en-GB,en;q=0.8"
+// @Param If-None-Match header string false "ETag of a previously received response"
 // @Success 200 {object} models.APIResponse{data=[]models.Category}
+// @Success 304 "Not modified"
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /categories/tree [get]
 func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
-	categories, err := h.service.GetCategoryTree()
+	var rootID *uuid.UUID
+	if rootIDStr := c.Query("root_id"); rootIDStr != "" {
+		id, err := uuid.Parse(rootIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid root_id",
+				Error:   err.Error(),
+			})
+			return
+		}
+		rootID = &id
+	}
+
+	categories, err := h.service.GetCategoryTree(rootID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+		h.treeError(c, err, "Failed to get category tree")
+		return
+	}
+
+	h.service.LocalizeCategories(categories, requestLocales(c, h.service))
+
+	respondConditional(c, models.APIResponse{
+		Success: true,
+		Message: "Category tree retrieved successfully",
+		Data:    categories,
+	}, categoriesLastModified(categories))
+}
+
+// @Summary Get category path
+// @Description Get the breadcrumb of a category from its root down to the category itself
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param Accept-Language header string false "Preferred locales, e.g. en-GB,en;q=0.8"
+// @Success 200 {object} models.APIResponse{data=[]models.Category}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /categories/{id}/path [get]
+func (h *CategoryHandler) GetCategoryPath(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Failed to get category tree",
+			Message: "Invalid category ID",
 			Error:   err.Error(),
 		})
 		return
 	}
 
+	path, err := h.service.GetCategoryPath(id)
+	if err != nil {
+		h.treeError(c, err, "Failed to get category path")
+		return
+	}
+
+	h.service.LocalizeCategories(path, requestLocales(c, h.service))
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "Category tree retrieved successfully",
+		Message: "Category path retrieved successfully",
+		Data:    path,
+	})
+}
+
+// @Summary Move category
+// @Description Move a category and its subtree under another parent, or to the root when parent_id is null
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param move body models.MoveCategoryRequest true "Target parent and position"
+// @Success 200 {object} models.APIResponse{data=models.Category}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /categories/{id}/move [post]
+func (h *CategoryHandler) MoveCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	category, err := h.service.MoveCategory(id, &req)
+	if err != nil {
+		h.treeError(c, err, "Failed to move category")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Category moved successfully",
+		Data:    category,
+	})
+}
+
+// @Summary Reorder categories
+// @Description Set the order of the children of a parent in one request
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param order body models.ReorderCategoriesRequest true "Parent and ordered category IDs"
+// @Success 200 {object} models.APIResponse{data=[]models.Category}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /categories/reorder [put]
+func (h *CategoryHandler) ReorderCategories(c *gin.Context) {
+	var req models.ReorderCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	categories, err := h.service.ReorderCategories(&req)
+	if err != nil {
+		h.treeError(c, err, "Failed to reorder categories")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Categories reordered successfully",
 		Data:    categories,
 	})
 }
@@ -243,18 +399,7 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 
 	err = h.service.UpdateCategory(id, &req)
 	if err != nil {
-		if err.Error() == "category not found" {
-			c.JSON(http.StatusNotFound, models.APIResponse{
-				Success: false,
-				Message: "Category not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Message: "Failed to update category",
-			Error:   err.Error(),
-		})
+		h.treeError(c, err, "Failed to update category")
 		return
 	}
 
@@ -287,7 +432,7 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeleteCategory(id)
+	err = h.service.DeleteCategory(id, requestActor(c))
 	if err != nil {
 		if err.Error() == "category not found" {
 			c.JSON(http.StatusNotFound, models.APIResponse{
@@ -317,14 +462,412 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 	})
 }
 
+// @Summary Get category attribute schema
+// @Description Get the attributes of a category, including those inherited from its parents
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} models.APIResponse{data=[]models.CategoryAttribute}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /categories/{id}/attributes [get]
+func (h *CategoryHandler) GetCategoryAttributes(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	attributes, err := h.service.GetCategoryAttributes(id)
+	if err != nil {
+		h.attributeError(c, err, "Failed to get category attributes")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Category attributes retrieved successfully",
+		Data:    attributes,
+	})
+}
+
+// @Summary Create category attribute
+// @Description Add a typed attribute to a category. Subcategories inherit it.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param attribute body models.CategoryAttributeRequest true "Attribute definition"
+// @Success 201 {object} models.APIResponse{data=models.CategoryAttribute}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /categories/{id}/attributes [post]
+func (h *CategoryHandler) CreateCategoryAttribute(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.CategoryAttributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	attribute, err := h.service.CreateCategoryAttribute(id, &req)
+	if err != nil {
+		h.attributeError(c, err, "Failed to create category attribute")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Category attribute created successfully",
+		Data:    attribute,
+	})
+}
+
+// @Summary Update category attribute
+// @Description Replace the definition of a category attribute
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param attribute_id path string true "Attribute ID"
+// @Param attribute body models.CategoryAttributeRequest true "Attribute definition"
+// @Success 200 {object} models.APIResponse{data=models.CategoryAttribute}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /categories/{id}/attributes/{attribute_id} [put]
+func (h *CategoryHandler) UpdateCategoryAttribute(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	attributeIDStr := c.Param("attribute_id")
+	attributeID, err := uuid.Parse(attributeIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid attribute ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.CategoryAttributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	attribute, err := h.service.UpdateCategoryAttribute(id, attributeID, &req)
+	if err != nil {
+		h.attributeError(c, err, "Failed to update category attribute")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Category attribute updated successfully",
+		Data:    attribute,
+	})
+}
+
+// @Summary Delete category attribute
+// @Description Remove an attribute from a category schema. Existing product values are kept.
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param attribute_id path string true "Attribute ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /categories/{id}/attributes/{attribute_id} [delete]
+func (h *CategoryHandler) DeleteCategoryAttribute(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	attributeIDStr := c.Param("attribute_id")
+	attributeID, err := uuid.Parse(attributeIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid attribute ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	err = h.service.DeleteCategoryAttribute(id, attributeID)
+	if err != nil {
+		h.attributeError(c, err, "Failed to delete category attribute")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Category attribute deleted successfully",
+	})
+}
+
+// @Summary Get category translations
+// @Description List the translations of a category. The default locale's text is stored on the category itself.
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} models.APIResponse{data=[]models.CategoryTranslation}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /categories/{id}/translations [get]
+func (h *CategoryHandler) GetCategoryTranslations(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	translations, err := h.service.GetCategoryTranslations(id)
+	if err != nil {
+		translationError(c, err, "Failed to get translations")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Translations retrieved successfully",
+		Data:    translations,
+	})
+}
+
+// @Summary Set category translation
+// @Description Set the name and description of a category in a supported locale other than the default one
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param locale path string true "Locale, e.g. en"
+// @Param translation body models.TranslationRequest true "Translated text"
+// @Success 200 {object} models.APIResponse{data=models.CategoryTranslation}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /categories/{id}/translations/{locale} [put]
+func (h *CategoryHandler) SetCategoryTranslation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.TranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	translation, err := h.service.SetCategoryTranslation(id, c.Param("locale"), &req)
+	if err != nil {
+		translationError(c, err, "Failed to save translation")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Translation saved successfully",
+		Data:    translation,
+	})
+}
+
+// @Summary Delete category translation
+// @Description Remove the translation of a category in a locale; reads fall back to the next locale
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param locale path string true "Locale, e.g. en"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /categories/{id}/translations/{locale} [delete]
+func (h *CategoryHandler) DeleteCategoryTranslation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	err = h.service.DeleteCategoryTranslation(id, c.Param("locale"))
+	if err != nil {
+		translationError(c, err, "Failed to delete translation")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Translation deleted successfully",
+	})
+}
+
+// attributeError maps attribute schema errors onto HTTP responses
+// treeError maps category tree errors to responses
+func (h *CategoryHandler) treeError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "category not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Category not found",
+		})
+	case err.Error() == "parent category not found",
+		err.Error() == "category cannot be its own parent",
+		err.Error() == "category cannot be moved under its own subtree",
+		err.Error() == "categories must be distinct children of the same parent",
+		strings.HasSuffix(err.Error(), "is listed more than once"):
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid category tree change",
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
+
+func (h *CategoryHandler) attributeError(c *gin.Context, err error, message string) {
+	var attributeErr *service.AttributeValidationError
+	switch {
+	case err.Error() == "category not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Category not found",
+		})
+	case err.Error() == "attribute not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Attribute not found",
+		})
+	case errors.As(err, &attributeErr):
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid attribute definition",
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
+
 func (h *CategoryHandler) RegisterRoutes(r *gin.RouterGroup) {
 	categories := r.Group("/categories")
 	{
 		categories.POST("", h.CreateCategory)
 		categories.GET("", h.GetCategories)
 		categories.GET("/tree", h.GetCategoryTree)
+		categories.PUT("/reorder", h.ReorderCategories)
 		categories.GET("/:id", h.GetCategory)
 		categories.PUT("/:id", h.UpdateCategory)
+		categories.GET("/:id/path", h.GetCategoryPath)
+		categories.POST("/:id/move", h.MoveCategory)
 		categories.DELETE("/:id", h.DeleteCategory)
+		categories.GET("/:id/attributes", h.GetCategoryAttributes)
+		categories.POST("/:id/attributes", h.CreateCategoryAttribute)
+		categories.PUT("/:id/attributes/:attribute_id", h.UpdateCategoryAttribute)
+		categories.DELETE("/:id/attributes/:attribute_id", h.DeleteCategoryAttribute)
+		categories.GET("/:id/translations", h.GetCategoryTranslations)
+		categories.PUT("/:id/translations/:locale", h.SetCategoryTranslation)
+		categories.DELETE("/:id/translations/:locale", h.DeleteCategoryTranslation)
 	}
 }
\ No newline at end of file
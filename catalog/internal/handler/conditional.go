@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// respondConditional writes a successful read that clients can revalidate.
+// The ETag hashes the response body, so it changes with anything the client
+// would see, including the negotiated locale. A request whose If-None-Match
+// lists the current ETag gets 304 Not Modified without a body.
+func respondConditional(c *gin.Context, response models.APIResponse, lastModified time.Time) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to encode response",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatches compares an If-None-Match header with an ETag using the weak
+// comparison HTTP prescribes for it
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// productLastModified returns the latest change to a product and the
+// variants, media and seller listings loaded with it
+func productLastModified(product *models.Product) time.Time {
+	latest := product.UpdatedAt
+	for _, variant := range product.Variants {
+		if variant.UpdatedAt.After(latest) {
+			latest = variant.UpdatedAt
+		}
+	}
+	for _, media := range product.Media {
+		if media.UpdatedAt.After(latest) {
+			latest = media.UpdatedAt
+		}
+	}
+	for _, sellerProduct := range product.SellerData {
+		if sellerProduct.UpdatedAt.After(latest) {
+			latest = sellerProduct.UpdatedAt
+		}
+	}
+	return latest
+}
+
+func productsLastModified(products []*models.Product) time.Time {
+	var latest time.Time
+	for _, product := range products {
+		if updated := productLastModified(product); updated.After(latest) {
+			latest = updated
+		}
+	}
+	return latest
+}
+
+// categoriesLastModified returns the latest change to the categories and
+// their subcategories
+func categoriesLastModified(categories []*models.Category) time.Time {
+	var latest time.Time
+	for _, category := range categories {
+		if category.UpdatedAt.After(latest) {
+			latest = category.UpdatedAt
+		}
+		if updated := categoriesLastModified(category.Children); updated.After(latest) {
+			latest = updated
+		}
+	}
+	return latest
+}
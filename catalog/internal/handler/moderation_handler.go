@@ -0,0 +1,352 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/cebeuygun/platform/services/catalog/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type ModerationHandler struct {
+	service   service.CatalogService
+	validator *validator.Validate
+}
+
+func NewModerationHandler(service service.CatalogService) *ModerationHandler {
+	return &ModerationHandler{
+		service:   service,
+		validator: validator.New(),
+	}
+}
+
+// @Summary Get moderation queue
+// @Description List products by moderation status. Products waiting for review are listed oldest submission first.
+// @Tags moderation
+// @Produce json
+// @Param status query string false "Moderation status" Enums(DRAFT, PENDING_REVIEW, APPROVED, REJECTED) default(PENDING_REVIEW)
+// @Param seller_id query string false "Seller ID"
+// @Param check query string false "Only products flagged by this check" Enums(BANNED_WORDS, MISSING_IMAGES, PRICE_OUTLIER, POSSIBLE_DUPLICATE)
+// @Param page query integer false "Page number" default(1)
+// @Param limit query integer false "Items per page" default(20)
+// @Success 200 {object} models.APIResponse{data=models.ModerationQueueResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /moderation/queue [get]
+func (h *ModerationHandler) GetQueue(c *gin.Context) {
+	req := &models.ModerationQueueRequest{
+		Status: models.ProductStatus(c.DefaultQuery("status", string(models.ProductStatusPendingReview))),
+		Page:   1,
+		Limit:  20,
+	}
+
+	if sellerIDStr := c.Query("seller_id"); sellerIDStr != "" {
+		sellerID, err := uuid.Parse(sellerIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid seller_id",
+				Error:   err.Error(),
+			})
+			return
+		}
+		req.SellerID = &sellerID
+	}
+
+	if checkStr := c.Query("check"); checkStr != "" {
+		check := models.ModerationCheck(strings.ToUpper(checkStr))
+		req.Check = &check
+	}
+
+	// Parse pagination
+	if pageStr := c.Query("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid page number",
+			})
+			return
+		}
+		req.Page = page
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid limit (must be between 1 and 100)",
+			})
+			return
+		}
+		req.Limit = limit
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.service.GetModerationQueue(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to get moderation queue",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Moderation queue retrieved successfully",
+		Data:    response,
+	})
+}
+
+// @Summary Approve product
+// @Description Approve a product waiting for review, making it visible in search and listings
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} models.APIResponse{data=models.Product}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /moderation/products/{id}/approve [post]
+func (h *ModerationHandler) ApproveProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	product, err := h.service.ApproveProduct(id, requestActor(c))
+	if err != nil {
+		moderationError(c, err, "Failed to approve product")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Product approved successfully",
+		Data:    product,
+	})
+}
+
+// @Summary Reject product
+// @Description Reject a product waiting for review with a reason, or take down an approved product
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param rejection body models.RejectProductRequest true "Rejection reason"
+// @Success 200 {object} models.APIResponse{data=models.Product}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /moderation/products/{id}/reject [post]
+func (h *ModerationHandler) RejectProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.RejectProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	product, err := h.service.RejectProduct(id, &req, requestActor(c))
+	if err != nil {
+		moderationError(c, err, "Failed to reject product")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Product rejected successfully",
+		Data:    product,
+	})
+}
+
+// @Summary Find duplicate products
+// @Description List live products that likely sell the same item, matched by barcode, name and brand similarity and image hash, most likely first
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} models.APIResponse{data=[]models.DuplicateCandidate}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /moderation/products/{id}/duplicates [get]
+func (h *ModerationHandler) GetDuplicates(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	candidates, err := h.service.FindDuplicateProducts(id)
+	if err != nil {
+		moderationError(c, err, "Failed to find duplicate products")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Duplicate products retrieved successfully",
+		Data:    candidates,
+	})
+}
+
+// @Summary Merge duplicate products
+// @Description Fold duplicate products into this canonical product. Seller listings move to the matching product or variant, the seller that submitted a duplicate keeps its price and stock as a listing, and reviews and bundle references follow. The duplicates are moved to the trash and cannot be restored.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Canonical product ID"
+// @Param merge body models.MergeProductsRequest true "Duplicate products"
+// @Success 200 {object} models.APIResponse{data=models.ProductMergeResult}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /moderation/products/{id}/merge [post]
+func (h *ModerationHandler) MergeProducts(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.MergeProductsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := h.service.MergeProducts(id, &req, requestActor(c))
+	if err != nil {
+		moderationError(c, err, "Failed to merge products")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Products merged successfully",
+		Data:    result,
+	})
+}
+
+// @Summary Get rejection reasons
+// @Description List the reasons a moderator can reject a product for
+// @Tags moderation
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]string}
+// @Router /moderation/rejection-reasons [get]
+func (h *ModerationHandler) GetRejectionReasons(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Rejection reasons retrieved successfully",
+		Data:    models.RejectionReasons,
+	})
+}
+
+// moderationError maps moderation errors to responses
+func moderationError(c *gin.Context, err error, message string) {
+	var mergeErr *service.MergeError
+	switch {
+	case err.Error() == "product not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Product not found",
+		})
+	case strings.HasPrefix(err.Error(), "product cannot be"), strings.HasPrefix(err.Error(), "seller "):
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	case errors.As(err, &mergeErr):
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
+
+func (h *ModerationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	moderation := r.Group("/moderation")
+	{
+		moderation.GET("/queue", h.GetQueue)
+		moderation.GET("/rejection-reasons", h.GetRejectionReasons)
+		moderation.POST("/products/:id/approve", h.ApproveProduct)
+		moderation.POST("/products/:id/reject", h.RejectProduct)
+		moderation.GET("/products/:id/duplicates", h.GetDuplicates)
+		moderation.POST("/products/:id/merge", h.MergeProducts)
+	}
+}
@@ -1,15 +1,23 @@
 package handler
 
 import (
-	"io"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cebeuygun/platform/services/catalog/internal/models"
 	"github.com/cebeuygun/platform/services/catalog/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type ProductHandler struct {
@@ -32,6 +40,7 @@ func NewProductHandler(service service.CatalogService) *ProductHandler {
 // @Param product body models.CreateProductRequest true "Product data"
 // @Success 201 {object} models.APIResponse{data=models.Product}
 // @Failure 400 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /products [post]
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
@@ -54,8 +63,51 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.service.CreateProduct(&req)
+	product, err := h.service.CreateProduct(&req, requestActor(c))
 	if err != nil {
+		var attributeErr *service.AttributeValidationError
+		if errors.As(err, &attributeErr) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid product attributes",
+				Error:   err.Error(),
+			})
+			return
+		}
+		var foodErr *service.FoodInfoError
+		if errors.As(err, &foodErr) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid food information",
+				Error:   err.Error(),
+			})
+			return
+		}
+		if isAvailabilityError(err) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid availability window",
+				Error:   err.Error(),
+			})
+			return
+		}
+		var barcodeErr *service.BarcodeError
+		if errors.As(err, &barcodeErr) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid barcode",
+				Error:   err.Error(),
+			})
+			return
+		}
+		if isBarcodeConflict(err) {
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Message: "Barcode already in use",
+				Error:   err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Failed to create product",
@@ -76,7 +128,10 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 // @Tags products
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param Accept-Language header string false "Preferred locales, e.g. en-GB,en;q=0.8"
+// @Param If-None-Match header string false "ETag of a previously received response"
 // @Success 200 {object} models.APIResponse{data=models.Product}
+// @Success 304 "Not modified"
 // @Failure 400 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
@@ -111,11 +166,13 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	h.service.LocalizeProducts([]*models.Product{product}, requestLocales(c, h.service))
+
+	respondConditional(c, models.APIResponse{
 		Success: true,
 		Message: "Product retrieved successfully",
 		Data:    product,
-	})
+	}, productLastModified(product))
 }
 
 // @Summary Get product by SKU
@@ -123,7 +180,10 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 // @Tags products
 // @Produce json
 // @Param sku path string true "Product SKU"
+// @Param Accept-Language header string false "Preferred locales, e.g. en-GB,en;q=0.8"
+// @Param If-None-Match header string false "ETag of a previously received response"
 // @Success 200 {object} models.APIResponse{data=models.Product}
+// @Success 304 "Not modified"
 // @Failure 404 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /products/sku/{sku} [get]
@@ -148,19 +208,24 @@ func (h *ProductHandler) GetProductBySKU(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	h.service.LocalizeProducts([]*models.Product{product}, requestLocales(c, h.service))
+
+	respondConditional(c, models.APIResponse{
 		Success: true,
 		Message: "Product retrieved successfully",
 		Data:    product,
-	})
+	}, productLastModified(product))
 }
 
 // @Summary Get product by barcode
-// @Description Get a product by its barcode
+// @Description Get a product by its barcode or the barcode of one of its variants. GTINs match whatever length they are written at.
 // @Tags products
 // @Produce json
 // @Param barcode path string true "Product barcode"
+// @Param Accept-Language header string false "Preferred locales, e.g. en-GB,en;q=0.8"
+// @Param If-None-Match header string false "ETag of a previously received response"
 // @Success 200 {object} models.APIResponse{data=models.Product}
+// @Success 304 "Not modified"
 // @Failure 404 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /products/barcode/{barcode} [get]
@@ -185,15 +250,71 @@ func (h *ProductHandler) GetProductByBarcode(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	h.service.LocalizeProducts([]*models.Product{product}, requestLocales(c, h.service))
+
+	respondConditional(c, models.APIResponse{
 		Success: true,
 		Message: "Product retrieved successfully",
 		Data:    product,
+	}, productLastModified(product))
+}
+
+// @Summary Look up barcode
+// @Description Resolve a scanned barcode to the exact product, the variant when the barcode is a variant's, and the seller listings of that item. GTINs match whatever length they are written at.
+// @Tags products
+// @Produce json
+// @Param barcode path string true "Product or variant barcode"
+// @Param seller_id query string false "Only this seller's listing"
+// @Param Accept-Language header string false "Preferred locales, e.g. en-GB,en;q=0.8"
+// @Success 200 {object} models.APIResponse{data=models.BarcodeMatch}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/barcode/{barcode}/lookup [get]
+func (h *ProductHandler) LookupBarcode(c *gin.Context) {
+	var sellerID *uuid.UUID
+	if sellerIDStr := c.Query("seller_id"); sellerIDStr != "" {
+		id, err := uuid.Parse(sellerIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid seller_id",
+				Error:   err.Error(),
+			})
+			return
+		}
+		sellerID = &id
+	}
+
+	match, err := h.service.LookupBarcode(c.Param("barcode"), sellerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to look up barcode",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if match == nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Barcode not found",
+		})
+		return
+	}
+
+	h.service.LocalizeProducts([]*models.Product{match.Product}, requestLocales(c, h.service))
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Barcode resolved successfully",
+		Data:    match,
 	})
 }
 
 // @Summary Search products
-// @Description Search products with various filters
+// @Description Search products with various filters. The query is read with the live search settings: stopwords are dropped and every remaining word, or one of its synonyms, has to match.
 // @Tags products
 // @Produce json
 // @Param query query string false "Search query"
@@ -205,21 +326,52 @@ func (h *ProductHandler) GetProductByBarcode(c *gin.Context) {
 // @Param brand query string false "Brand"
 // @Param is_active query boolean false "Active products only"
 // @Param express_only query boolean false "Express delivery only"
+// @Param free_from query string false "Allergens (comma-separated) the product must neither contain nor possibly contain"
+// @Param dietary_labels query string false "Dietary labels (comma-separated) that must all apply"
+// @Param attr query object false "Attribute filters as attr[code]=value1,value2 or attr[code]=min..max"
+// @Param lat query number false "Shopper latitude; with lng, returns only products a seller delivers there, with their offers"
+// @Param lng query number false "Shopper longitude"
 // @Param page query integer false "Page number" default(1)
 // @Param limit query integer false "Items per page" default(20)
-// @Param sort_by query string false "Sort field" Enums(name, price, created_at, updated_at)
+// @Param sort_by query string false "Sort field; relevance applies the merchandising rules, then puts the newest first" Enums(relevance, name, price, rating, created_at, updated_at) default(relevance)
 // @Param sort_order query string false "Sort order" Enums(asc, desc)
+// @Param cursor query string false "next_cursor of the previous page; replaces page"
+// @Param include_total query boolean false "Count the results; approximate with a cursor (default true without a cursor, false with one)"
+// @Param Accept-Language header string false "Preferred locales, e.g. en-GB,en;q=0.8"
 // @Success 200 {object} models.APIResponse{data=models.SearchResponse}
 // @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /products/search [get]
 func (h *ProductHandler) SearchProducts(c *gin.Context) {
+	req, ok := parseSearchRequest(c, h.validator)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.SearchProducts(req)
+	if err != nil {
+		searchError(c, err, "Failed to search products")
+		return
+	}
+
+	h.service.LocalizeProducts(response.Products, requestLocales(c, h.service))
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Products retrieved successfully",
+		Data:    response,
+	})
+}
+
+// parseSearchRequest reads the search parameters of the query string. On
+// invalid parameters it responds with 400 and reports false.
+func parseSearchRequest(c *gin.Context, validate *validator.Validate) (*models.SearchRequest, bool) {
 	req := &models.SearchRequest{
 		Query:       c.Query("query"),
 		ExpressOnly: c.Query("express_only") == "true",
 		Page:        1,
 		Limit:       20,
-		SortBy:      "created_at",
+		SortBy:      "relevance",
 		SortOrder:   "desc",
 	}
 
@@ -232,7 +384,7 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 				Message: "Invalid category_id",
 				Error:   err.Error(),
 			})
-			return
+			return nil, false
 		}
 		req.CategoryID = &categoryID
 	}
@@ -245,7 +397,7 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 				Message: "Invalid seller_id",
 				Error:   err.Error(),
 			})
-			return
+			return nil, false
 		}
 		req.SellerID = &sellerID
 	}
@@ -259,7 +411,7 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 				Message: "Invalid min_price",
 				Error:   err.Error(),
 			})
-			return
+			return nil, false
 		}
 		// Convert to decimal
 		// req.MinPrice = &decimal.NewFromFloat(minPrice)
@@ -274,7 +426,7 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 				Message: "Invalid max_price",
 				Error:   err.Error(),
 			})
-			return
+			return nil, false
 		}
 		// Convert to decimal
 		// req.MaxPrice = &decimal.NewFromFloat(maxPrice)
@@ -303,11 +455,68 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 				Message: "Invalid is_active",
 				Error:   err.Error(),
 			})
-			return
+			return nil, false
 		}
 		req.IsActive = &isActive
 	}
 
+	// Parse food filters
+	if freeFromStr := c.Query("free_from"); freeFromStr != "" {
+		for _, allergen := range strings.Split(freeFromStr, ",") {
+			req.FreeFrom = append(req.FreeFrom, models.Allergen(strings.ToUpper(strings.TrimSpace(allergen))))
+		}
+	}
+
+	if labelsStr := c.Query("dietary_labels"); labelsStr != "" {
+		for _, label := range strings.Split(labelsStr, ",") {
+			req.DietaryLabels = append(req.DietaryLabels, models.DietaryLabel(strings.ToUpper(strings.TrimSpace(label))))
+		}
+	}
+
+	// Parse shopper location
+	latStr, lngStr := c.Query("lat"), c.Query("lng")
+	if (latStr == "") != (lngStr == "") {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "lat and lng must be given together",
+		})
+		return nil, false
+	}
+	if latStr != "" {
+		latitude, err := strconv.ParseFloat(latStr, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid lat",
+				Error:   err.Error(),
+			})
+			return nil, false
+		}
+		longitude, err := strconv.ParseFloat(lngStr, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid lng",
+				Error:   err.Error(),
+			})
+			return nil, false
+		}
+		req.Latitude = &latitude
+		req.Longitude = &longitude
+	}
+
+	// Parse attribute filters
+	attributeFilters, err := parseAttributeFilters(c.QueryMap("attr"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid attribute filter",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+	req.AttributeFilters = attributeFilters
+
 	// Parse pagination
 	if pageStr := c.Query("page"); pageStr != "" {
 		page, err := strconv.Atoi(pageStr)
@@ -316,7 +525,7 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 				Success: false,
 				Message: "Invalid page number",
 			})
-			return
+			return nil, false
 		}
 		req.Page = page
 	}
@@ -328,11 +537,36 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 				Success: false,
 				Message: "Invalid limit (must be between 1 and 100)",
 			})
-			return
+			return nil, false
 		}
 		req.Limit = limit
 	}
 
+	// A cursor continues the previous page and replaces page numbers
+	req.Cursor = c.Query("cursor")
+	if req.Cursor != "" && c.Query("page") != "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "page and cursor cannot be combined",
+		})
+		return nil, false
+	}
+
+	// Counting every page is what makes deep pages slow, so cursor pages skip it by default
+	req.IncludeTotal = req.Cursor == ""
+	if includeTotalStr := c.Query("include_total"); includeTotalStr != "" {
+		includeTotal, err := strconv.ParseBool(includeTotalStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid include_total",
+				Error:   err.Error(),
+			})
+			return nil, false
+		}
+		req.IncludeTotal = includeTotal
+	}
+
 	// Parse sorting
 	if sortBy := c.Query("sort_by"); sortBy != "" {
 		req.SortBy = sortBy
@@ -343,34 +577,93 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 	}
 
 	// Validate request
-	if err := h.validator.Struct(req); err != nil {
+	if err := validate.Struct(req); err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
 			Message: "Validation failed",
 			Error:   err.Error(),
 		})
-		return
+		return nil, false
 	}
 
-	response, err := h.service.SearchProducts(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+	return req, true
+}
+
+// searchError responds to a failed search
+func searchError(c *gin.Context, err error, message string) {
+	var attributeErr *service.AttributeValidationError
+	if errors.As(err, &attributeErr) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Failed to search products",
+			Message: "Invalid attribute filter",
 			Error:   err.Error(),
 		})
 		return
 	}
-
-	c.JSON(http.StatusOK, models.APIResponse{
-		Success: true,
-		Message: "Products retrieved successfully",
-		Data:    response,
+	var cursorErr *service.CursorError
+	if errors.As(err, &cursorErr) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid cursor",
+			Error:   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.APIResponse{
+		Success: false,
+		Message: message,
+		Error:   err.Error(),
 	})
 }
 
+// parseAttributeFilters reads attr[code]=value1,value2 and attr[code]=min..max
+// query parameters, where either bound of a range may be omitted
+func parseAttributeFilters(params map[string]string) ([]*models.AttributeFilter, error) {
+	codes := make([]string, 0, len(params))
+	for code := range params {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var filters []*models.AttributeFilter
+	for _, code := range codes {
+		raw := params[code]
+		filter := &models.AttributeFilter{Code: code}
+
+		if lower, upper, isRange := strings.Cut(raw, ".."); isRange {
+			if lower = strings.TrimSpace(lower); lower != "" {
+				min, err := decimal.NewFromString(lower)
+				if err != nil {
+					return nil, fmt.Errorf("invalid minimum for %s: %w", code, err)
+				}
+				filter.Min = &min
+			}
+			if upper = strings.TrimSpace(upper); upper != "" {
+				max, err := decimal.NewFromString(upper)
+				if err != nil {
+					return nil, fmt.Errorf("invalid maximum for %s: %w", code, err)
+				}
+				filter.Max = &max
+			}
+		} else {
+			for _, value := range strings.Split(raw, ",") {
+				if value = strings.TrimSpace(value); value != "" {
+					filter.Values = append(filter.Values, value)
+				}
+			}
+		}
+
+		if len(filter.Values) == 0 && filter.Min == nil && filter.Max == nil {
+			continue
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
 // @Summary Update product
-// @Description Update an existing product
+// @Description Update an existing product. A compare_at_price must be above the price and not above the lowest price of the 30 days before the price changed; 0 removes it.
 // @Tags products
 // @Accept json
 // @Produce json
@@ -379,6 +672,7 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 // @Success 200 {object} models.APIResponse
 // @Failure 400 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /products/{id} [put]
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
@@ -412,7 +706,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	err = h.service.UpdateProduct(id, &req)
+	err = h.service.UpdateProduct(id, &req, requestActor(c))
 	if err != nil {
 		if err.Error() == "product not found" {
 			c.JSON(http.StatusNotFound, models.APIResponse{
@@ -421,6 +715,50 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 			})
 			return
 		}
+		var attributeErr *service.AttributeValidationError
+		if errors.As(err, &attributeErr) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid product attributes",
+				Error:   err.Error(),
+			})
+			return
+		}
+		var foodErr *service.FoodInfoError
+		if errors.As(err, &foodErr) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid food information",
+				Error:   err.Error(),
+			})
+			return
+		}
+		var barcodeErr *service.BarcodeError
+		if errors.As(err, &barcodeErr) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid barcode",
+				Error:   err.Error(),
+			})
+			return
+		}
+		if isBarcodeConflict(err) {
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Message: "Barcode already in use",
+				Error:   err.Error(),
+			})
+			return
+		}
+		var priceErr *service.PriceError
+		if errors.As(err, &priceErr) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid compare-at price",
+				Error:   err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Failed to update product",
@@ -457,7 +795,7 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeleteProduct(id)
+	err = h.service.DeleteProduct(id, requestActor(c))
 	if err != nil {
 		if err.Error() == "product not found" {
 			c.JSON(http.StatusNotFound, models.APIResponse{
@@ -485,7 +823,10 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 // @Tags products
 // @Produce json
 // @Param limit query integer false "Number of products to return" default(20)
+// @Param Accept-Language header string false "Preferred locales, e.g. en-GB,en;q=0.8"
+// @Param If-None-Match header string false "ETag of a previously received response"
 // @Success 200 {object} models.APIResponse{data=[]models.Product}
+// @Success 304 "Not modified"
 // @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /products/featured [get]
@@ -513,10 +854,71 @@ func (h *ProductHandler) GetFeaturedProducts(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	h.service.LocalizeProducts(products, requestLocales(c, h.service))
+
+	respondConditional(c, models.APIResponse{
 		Success: true,
 		Message: "Featured products retrieved successfully",
 		Data:    products,
+	}, productsLastModified(products))
+}
+
+// @Summary Get product recommendations
+// @Description Get the products frequently bought together with a product and the products most similar to it by category, attributes and tags. Only available products in stock are recommended.
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param limit query integer false "Number of products in each list" default(10)
+// @Param Accept-Language header string false "Preferred locales, e.g. en-GB,en;q=0.8"
+// @Success 200 {object} models.APIResponse{data=models.Recommendations}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/recommendations [get]
+func (h *ProductHandler) GetRecommendations(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	limit := 10
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 50 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid limit (must be between 1 and 50)",
+			})
+			return
+		}
+	}
+
+	recommendations, err := h.service.GetRecommendations(id, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to get recommendations",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if recommendations == nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Product not found",
+		})
+		return
+	}
+
+	locales := requestLocales(c, h.service)
+	h.service.LocalizeProducts(recommendations.BoughtTogether, locales)
+	h.service.LocalizeProducts(recommendations.Similar, locales)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Recommendations retrieved successfully",
+		Data:    recommendations,
 	})
 }
 
@@ -587,76 +989,87 @@ func (h *ProductHandler) UploadMedia(c *gin.Context) {
 	})
 }
 
-// @Summary Bulk import products
-// @Description Import products from CSV file
+// @Summary Create a presigned media upload
+// @Description Get a presigned URL for uploading a media file directly to storage. Confirm the upload once the PUT has finished.
 // @Tags products
-// @Accept multipart/form-data
+// @Accept json
 // @Produce json
-// @Param seller_id formData string true "Seller ID"
-// @Param file formData file true "CSV file"
-// @Success 200 {object} models.APIResponse{data=models.BulkImportResponse}
+// @Param id path string true "Product ID"
+// @Param request body models.CreateMediaUploadRequest true "Upload details"
+// @Success 201 {object} models.APIResponse{data=models.PresignedUpload}
 // @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
-// @Router /products/bulk/import [post]
-func (h *ProductHandler) BulkImport(c *gin.Context) {
-	sellerIDStr := c.PostForm("seller_id")
-	sellerID, err := uuid.Parse(sellerIDStr)
+// @Router /products/{id}/media/uploads [post]
+func (h *ProductHandler) CreateMediaUpload(c *gin.Context) {
+	idStr := c.Param("id")
+	productID, err := uuid.Parse(idStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Invalid seller ID",
+			Message: "Invalid product ID",
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	file, _, err := c.Request.FormFile("file")
-	if err != nil {
+	var req models.CreateMediaUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Failed to get file from request",
+			Message: "Invalid request data",
 			Error:   err.Error(),
 		})
 		return
 	}
-	defer file.Close()
 
-	response, err := h.service.BulkImport(sellerID, file)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Failed to process bulk import",
+			Message: "Validation failed",
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	statusCode := http.StatusOK
-	if !response.Success {
-		statusCode = http.StatusBadRequest
+	upload, err := h.service.CreateMediaUpload(productID, &req)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Message: "Product not found",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create media upload",
+			Error:   err.Error(),
+		})
+		return
 	}
 
-	c.JSON(statusCode, models.APIResponse{
-		Success: response.Success,
-		Message: response.Message,
-		Data:    response,
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Media upload created successfully",
+		Data:    upload,
 	})
 }
 
-// @Summary Upsert seller product
-// @Description Create or update seller-specific product data
+// @Summary Confirm a presigned media upload
+// @Description Validate the uploaded file and attach it to the product
 // @Tags products
-// @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
-// @Param seller_id query string true "Seller ID"
-// @Param variant_id query string false "Variant ID"
-// @Param seller_product body models.SellerProductRequest true "Seller product data"
-// @Success 200 {object} models.APIResponse{data=models.SellerProduct}
+// @Param upload_id path string true "Upload ID"
+// @Success 201 {object} models.APIResponse{data=models.ProductMedia}
 // @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
-// @Router /products/{id}/sellers [post]
-func (h *ProductHandler) UpsertSellerProduct(c *gin.Context) {
+// @Router /products/{id}/media/uploads/{upload_id}/confirm [post]
+func (h *ProductHandler) ConfirmMediaUpload(c *gin.Context) {
 	idStr := c.Param("id")
 	productID, err := uuid.Parse(idStr)
 	if err != nil {
@@ -668,7 +1081,60 @@ func (h *ProductHandler) UpsertSellerProduct(c *gin.Context) {
 		return
 	}
 
-	sellerIDStr := c.Query("seller_id")
+	uploadIDStr := c.Param("upload_id")
+	uploadID, err := uuid.Parse(uploadIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid upload ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	media, err := h.service.ConfirmMediaUpload(productID, uploadID)
+	if err != nil {
+		if err.Error() == "media upload not found" {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Message: "Media upload not found",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Failed to confirm media upload",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Media uploaded successfully",
+		Data:    media,
+	})
+}
+
+// @Summary Bulk import products
+// @Description Queue an asynchronous import job from a CSV or XLSX file. Products are upserted by SKU or barcode.
+// @Tags products
+// @Accept multipart/form-data
+// @Produce json
+// @Param seller_id formData string true "Seller ID"
+// @Param file formData file true "CSV or XLSX file"
+// @Param format formData string false "File format (detected from the file name if omitted)" Enums(csv, xlsx)
+// @Param dry_run formData boolean false "Validate only, without writing products"
+// @Param match_by formData string false "Field used to match existing products" Enums(sku, barcode) default(sku)
+// @Param header_mapping formData string false "JSON object mapping import columns to file headers"
+// @Success 202 {object} models.APIResponse{data=models.ImportJob}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/bulk/import [post]
+func (h *ProductHandler) BulkImport(c *gin.Context) {
+	sellerIDStr := c.PostForm("seller_id")
 	sellerID, err := uuid.Parse(sellerIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
@@ -679,47 +1145,1423 @@ func (h *ProductHandler) UpsertSellerProduct(c *gin.Context) {
 		return
 	}
 
-	var variantID *uuid.UUID
-	if variantIDStr := c.Query("variant_id"); variantIDStr != "" {
-		id, err := uuid.Parse(variantIDStr)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Failed to get file from request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	req := &models.CreateImportJobRequest{
+		SellerID: sellerID,
+		FileName: filepath.Base(header.Filename),
+		Format:   strings.ToLower(c.PostForm("format")),
+		MatchBy:  c.DefaultPostForm("match_by", "sku"),
+	}
+
+	if req.Format == "" {
+		req.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+
+	if dryRunStr := c.PostForm("dry_run"); dryRunStr != "" {
+		req.DryRun, err = strconv.ParseBool(dryRunStr)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, models.APIResponse{
 				Success: false,
-				Message: "Invalid variant ID",
+				Message: "Invalid dry_run",
 				Error:   err.Error(),
 			})
 			return
 		}
-		variantID = &id
 	}
 
-	var req models.SellerProductRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if headerMapping := c.PostForm("header_mapping"); headerMapping != "" {
+		if err := json.Unmarshal([]byte(headerMapping), &req.HeaderMapping); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid header_mapping",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	if err := h.validator.Struct(req); err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Invalid request data",
+			Message: "Validation failed",
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	sellerProduct, err := h.service.UpsertSellerProduct(sellerID, productID, variantID, &req)
+	job, err := h.service.CreateImportJob(req, file, header.Size)
 	if err != nil {
+		var importErr *service.ImportError
+		if errors.As(err, &importErr) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid import",
+				Error:   err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
-			Message: "Failed to upsert seller product",
+			Message: "Failed to create import job",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Message: "Import job queued successfully",
+		Data:    job,
+	})
+}
+
+// @Summary Get import job
+// @Description Get the status and counters of a bulk import job
+// @Tags products
+// @Produce json
+// @Param job_id path string true "Import job ID"
+// @Success 200 {object} models.APIResponse{data=models.ImportJob}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/bulk/import/{job_id} [get]
+func (h *ProductHandler) GetImportJob(c *gin.Context) {
+	jobIDStr := c.Param("job_id")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid import job ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	job, err := h.service.GetImportJob(jobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to get import job",
 			Error:   err.Error(),
 		})
 		return
 	}
 
+	if job == nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Import job not found",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "Seller product updated successfully",
-		Data:    sellerProduct,
+		Message: "Import job retrieved successfully",
+		Data:    job,
+	})
+}
+
+// @Summary Get import job rows
+// @Description Get the per-row outcome of a bulk import job
+// @Tags products
+// @Produce json
+// @Param job_id path string true "Import job ID"
+// @Param status query string false "Row status" Enums(VALID, CREATED, UPDATED, FAILED)
+// @Param page query integer false "Page number" default(1)
+// @Param limit query integer false "Items per page" default(50)
+// @Success 200 {object} models.APIResponse{data=models.ImportJobRowsResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/bulk/import/{job_id}/rows [get]
+func (h *ProductHandler) GetImportJobRows(c *gin.Context) {
+	jobIDStr := c.Param("job_id")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid import job ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var status *models.ImportRowStatus
+	if statusStr := c.Query("status"); statusStr != "" {
+		rowStatus := models.ImportRowStatus(strings.ToUpper(statusStr))
+		switch rowStatus {
+		case models.ImportRowStatusValid, models.ImportRowStatusCreated, models.ImportRowStatusUpdated, models.ImportRowStatusFailed:
+			status = &rowStatus
+		default:
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid status",
+			})
+			return
+		}
+	}
+
+	page := 1
+	if pageStr := c.Query("page"); pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid page number",
+			})
+			return
+		}
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid limit (must be between 1 and 500)",
+			})
+			return
+		}
+	}
+
+	response, err := h.service.GetImportJobRows(jobID, status, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to get import job rows",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Import job rows retrieved successfully",
+		Data:    response,
 	})
 }
 
+// @Summary Export products
+// @Description Export a seller's or category's products with variants, seller overrides and media URLs. The file can be re-imported through the bulk import endpoint.
+// @Tags products
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param seller_id query string false "Seller ID"
+// @Param category_id query string false "Category ID (includes subcategories)"
+// @Param format query string false "File format" Enums(csv, xlsx) default(csv)
+// @Param include_inactive query boolean false "Include inactive products"
+// @Success 200 {file} file
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/export [get]
+func (h *ProductHandler) ExportProducts(c *gin.Context) {
+	req := &models.CatalogExportRequest{
+		Format: strings.ToLower(c.DefaultQuery("format", "csv")),
+	}
+
+	if sellerIDStr := c.Query("seller_id"); sellerIDStr != "" {
+		sellerID, err := uuid.Parse(sellerIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid seller_id",
+				Error:   err.Error(),
+			})
+			return
+		}
+		req.SellerID = &sellerID
+	}
+
+	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
+		categoryID, err := uuid.Parse(categoryIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid category_id",
+				Error:   err.Error(),
+			})
+			return
+		}
+		req.CategoryID = &categoryID
+	}
+
+	if req.SellerID == nil && req.CategoryID == nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "seller_id or category_id is required",
+		})
+		return
+	}
+
+	req.IncludeInactive = c.Query("include_inactive") == "true"
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Render into memory first so failures can still be reported as JSON
+	var buf bytes.Buffer
+	if err := h.service.ExportCatalog(req, &buf); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to export products",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	contentType := "text/csv"
+	if req.Format == "xlsx" {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	fileName := fmt.Sprintf("products-%s.%s", time.Now().UTC().Format("20060102"), req.Format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// @Summary Generate Google Merchant feed
+// @Description Regenerate the Google Merchant Center product feed and store it in object storage
+// @Tags products
+// @Produce json
+// @Param format query string false "Feed format" Enums(xml, tsv) default(xml)
+// @Success 200 {object} models.APIResponse{data=models.MerchantFeed}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/feeds/google-merchant [post]
+func (h *ProductHandler) GenerateMerchantFeed(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "xml"))
+	if format != "xml" && format != "tsv" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid format (must be xml or tsv)",
+		})
+		return
+	}
+
+	feed, err := h.service.GenerateMerchantFeed(format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate merchant feed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Merchant feed generated successfully",
+		Data:    feed,
+	})
+}
+
+// @Summary Upsert seller product
+// @Description Create or update seller-specific product data. A compare_at_price needs a price on an existing listing, above it and not above the lowest price of the 30 days before the price changed.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param seller_id query string true "Seller ID"
+// @Param variant_id query string false "Variant ID"
+// @Param seller_product body models.SellerProductRequest true "Seller product data"
+// @Success 200 {object} models.APIResponse{data=models.SellerProduct}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/sellers [post]
+func (h *ProductHandler) UpsertSellerProduct(c *gin.Context) {
+	idStr := c.Param("id")
+	productID, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	sellerIDStr := c.Query("seller_id")
+	sellerID, err := uuid.Parse(sellerIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid seller ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var variantID *uuid.UUID
+	if variantIDStr := c.Query("variant_id"); variantIDStr != "" {
+		id, err := uuid.Parse(variantIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid variant ID",
+				Error:   err.Error(),
+			})
+			return
+		}
+		variantID = &id
+	}
+
+	var req models.SellerProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	sellerProduct, err := h.service.UpsertSellerProduct(sellerID, productID, variantID, &req, requestActor(c))
+	if err != nil {
+		if isAvailabilityError(err) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid availability window",
+				Error:   err.Error(),
+			})
+			return
+		}
+		var priceErr *service.PriceError
+		if errors.As(err, &priceErr) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid compare-at price",
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to upsert seller product",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Seller product updated successfully",
+		Data:    sellerProduct,
+	})
+}
+
+// @Summary Get product revisions
+// @Description Get the change history of a product, its variants and seller overrides, newest first
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param entity_type query string false "Entity type" Enums(PRODUCT, VARIANT, SELLER_PRODUCT)
+// @Param entity_id query string false "Variant or seller override ID"
+// @Param page query integer false "Page number" default(1)
+// @Param limit query integer false "Items per page" default(50)
+// @Success 200 {object} models.APIResponse{data=models.RevisionListResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/revisions [get]
+func (h *ProductHandler) GetRevisions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	req := &models.RevisionListRequest{ProductID: id, Page: 1, Limit: 50}
+
+	if entityTypeStr := c.Query("entity_type"); entityTypeStr != "" {
+		entityType := models.RevisionEntityType(strings.ToUpper(entityTypeStr))
+		switch entityType {
+		case models.RevisionEntityProduct, models.RevisionEntityVariant, models.RevisionEntitySellerProduct:
+			req.EntityType = &entityType
+		default:
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid entity_type",
+			})
+			return
+		}
+	}
+
+	if entityIDStr := c.Query("entity_id"); entityIDStr != "" {
+		entityID, err := uuid.Parse(entityIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid entity_id",
+				Error:   err.Error(),
+			})
+			return
+		}
+		req.EntityID = &entityID
+	}
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		req.Page, err = strconv.Atoi(pageStr)
+		if err != nil || req.Page < 1 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid page number",
+			})
+			return
+		}
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		req.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || req.Limit < 1 || req.Limit > 500 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid limit (must be between 1 and 500)",
+			})
+			return
+		}
+	}
+
+	response, err := h.service.GetRevisions(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to get revisions",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Revisions retrieved successfully",
+		Data:    response,
+	})
+}
+
+// @Summary Get product revision
+// @Description Get a single revision with the entity snapshot
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param revision_id path string true "Revision ID"
+// @Success 200 {object} models.APIResponse{data=models.Revision}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/revisions/{revision_id} [get]
+func (h *ProductHandler) GetRevision(c *gin.Context) {
+	productID, revisionID, ok := parseRevisionParams(c)
+	if !ok {
+		return
+	}
+
+	revision, err := h.service.GetRevision(productID, revisionID)
+	if err != nil {
+		revisionError(c, err, "Failed to get revision")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Revision retrieved successfully",
+		Data:    revision,
+	})
+}
+
+// @Summary Compare product revisions
+// @Description Get the field-level diff between two revisions of the same entity
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param from query string true "Older revision ID"
+// @Param to query string true "Newer revision ID"
+// @Success 200 {object} models.APIResponse{data=models.RevisionComparison}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/revisions/compare [get]
+func (h *ProductHandler) CompareRevisions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	fromID, err := uuid.Parse(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid from revision ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	toID, err := uuid.Parse(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid to revision ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	comparison, err := h.service.CompareRevisions(id, fromID, toID)
+	if err != nil {
+		revisionError(c, err, "Failed to compare revisions")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Revisions compared successfully",
+		Data:    comparison,
+	})
+}
+
+// @Summary Restore product revision
+// @Description Restore a product, variant or seller override to the state recorded in a revision. Deleted products are taken out of the trash; other deleted entities are re-created.
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param revision_id path string true "Revision ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/revisions/{revision_id}/restore [post]
+func (h *ProductHandler) RestoreRevision(c *gin.Context) {
+	productID, revisionID, ok := parseRevisionParams(c)
+	if !ok {
+		return
+	}
+
+	restored, err := h.service.RestoreRevision(productID, revisionID, requestActor(c))
+	if err != nil {
+		revisionError(c, err, "Failed to restore revision")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Revision restored successfully",
+		Data:    restored,
+	})
+}
+
+// @Summary Get product price history
+// @Description Get the price changes of a product, its variants and seller listings, newest first. A null price is a variant or listing using the price it inherits.
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param entity_type query string false "Entity type" Enums(PRODUCT, VARIANT, SELLER_PRODUCT)
+// @Param entity_id query string false "Variant or seller listing ID"
+// @Param since query string false "Only changes at or after this time, RFC 3339"
+// @Param page query integer false "Page number" default(1)
+// @Param limit query integer false "Items per page" default(50)
+// @Success 200 {object} models.APIResponse{data=models.PriceHistoryResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/price-history [get]
+func (h *ProductHandler) GetPriceHistory(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	req := &models.PriceHistoryRequest{ProductID: id, Page: 1, Limit: 50}
+
+	if entityTypeStr := c.Query("entity_type"); entityTypeStr != "" {
+		entityType := models.RevisionEntityType(strings.ToUpper(entityTypeStr))
+		switch entityType {
+		case models.RevisionEntityProduct, models.RevisionEntityVariant, models.RevisionEntitySellerProduct:
+			req.EntityType = &entityType
+		default:
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid entity_type",
+			})
+			return
+		}
+	}
+
+	if entityIDStr := c.Query("entity_id"); entityIDStr != "" {
+		entityID, err := uuid.Parse(entityIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid entity_id",
+				Error:   err.Error(),
+			})
+			return
+		}
+		req.EntityID = &entityID
+	}
+
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid since",
+				Error:   err.Error(),
+			})
+			return
+		}
+		req.Since = &since
+	}
+
+	var err error
+	if pageStr := c.Query("page"); pageStr != "" {
+		req.Page, err = strconv.Atoi(pageStr)
+		if err != nil || req.Page < 1 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid page number",
+			})
+			return
+		}
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		req.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || req.Limit < 1 || req.Limit > 500 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid limit (must be between 1 and 500)",
+			})
+			return
+		}
+	}
+
+	response, err := h.service.GetPriceHistory(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to get price history",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Price history retrieved successfully",
+		Data:    response,
+	})
+}
+
+// @Summary Submit product for review
+// @Description Submit a draft or rejected seller product for moderation. Automatic checks run first; products containing banned words are rejected straight away.
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} models.APIResponse{data=models.Product}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/submit [post]
+func (h *ProductHandler) SubmitProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	product, err := h.service.SubmitProduct(id, requestActor(c))
+	if err != nil {
+		moderationError(c, err, "Failed to submit product")
+		return
+	}
+
+	message := "Product submitted for review"
+	if product.Status == models.ProductStatusRejected {
+		message = "Product rejected by automatic checks"
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: message,
+		Data:    product,
+	})
+}
+
+// @Summary Set product availability
+// @Description Replace a product's publish/unpublish times and weekly availability schedule. Omitted fields are cleared. Schedule times are in the catalog's time zone.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param availability body models.ProductAvailabilityRequest true "Availability window"
+// @Success 200 {object} models.APIResponse{data=models.Product}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/availability [put]
+func (h *ProductHandler) SetProductAvailability(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.ProductAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	product, err := h.service.SetProductAvailability(id, &req, requestActor(c))
+	if err != nil {
+		switch {
+		case err.Error() == "product not found":
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Message: "Product not found",
+			})
+		case isAvailabilityError(err):
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid availability window",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to update availability",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Availability updated successfully",
+		Data:    product,
+	})
+}
+
+// @Summary Get product bundle
+// @Description Get the components and choice groups of a bundle product with stock derived from its components
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} models.APIResponse{data=models.Bundle}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/bundle [get]
+func (h *ProductHandler) GetBundle(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	bundle, err := h.service.GetBundle(id)
+	if err != nil {
+		bundleError(c, err, "Failed to get bundle")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Bundle retrieved successfully",
+		Data:    bundle,
+	})
+}
+
+// @Summary Set product bundle
+// @Description Replace the composition of a product, turning it into a bundle. Components are always included; choice groups let the customer pick between min_select and max_select options.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param bundle body models.BundleRequest true "Bundle composition"
+// @Success 200 {object} models.APIResponse{data=models.Bundle}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/bundle [put]
+func (h *ProductHandler) SetBundle(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.BundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	bundle, err := h.service.SetBundle(id, &req)
+	if err != nil {
+		bundleError(c, err, "Failed to save bundle")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Bundle saved successfully",
+		Data:    bundle,
+	})
+}
+
+// @Summary Delete product bundle
+// @Description Remove the composition of a bundle, turning it back into a simple product
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/bundle [delete]
+func (h *ProductHandler) DeleteBundle(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.DeleteBundle(id); err != nil {
+		bundleError(c, err, "Failed to delete bundle")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Bundle deleted successfully",
+	})
+}
+
+// @Summary Resolve bundle selection
+// @Description Check the options chosen for a bundle against its choice groups and stock, and price them. Groups that are left out get their default options. The order service records the returned selection with the order line.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param selection body models.BundleSelectionRequest true "Chosen options per group"
+// @Success 200 {object} models.APIResponse{data=models.BundleSelection}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/bundle/selection [post]
+func (h *ProductHandler) ResolveBundleSelection(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.BundleSelectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	selection, err := h.service.ResolveBundleSelection(id, &req)
+	if err != nil {
+		bundleError(c, err, "Failed to resolve bundle selection")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Bundle selection resolved successfully",
+		Data:    selection,
+	})
+}
+
+// @Summary Get product translations
+// @Description List the translations of a product and its variants. The default locale's text is stored on the product itself.
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} models.APIResponse{data=models.ProductTranslations}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/translations [get]
+func (h *ProductHandler) GetProductTranslations(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	translations, err := h.service.GetProductTranslations(id)
+	if err != nil {
+		translationError(c, err, "Failed to get translations")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Translations retrieved successfully",
+		Data:    translations,
+	})
+}
+
+// @Summary Set product translation
+// @Description Set the name and description of a product in a supported locale other than the default one
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param locale path string true "Locale, e.g. en"
+// @Param translation body models.TranslationRequest true "Translated text"
+// @Success 200 {object} models.APIResponse{data=models.ProductTranslation}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/translations/{locale} [put]
+func (h *ProductHandler) SetProductTranslation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.TranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	translation, err := h.service.SetProductTranslation(id, c.Param("locale"), &req)
+	if err != nil {
+		translationError(c, err, "Failed to save translation")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Translation saved successfully",
+		Data:    translation,
+	})
+}
+
+// @Summary Delete product translation
+// @Description Remove the translation of a product in a locale; reads fall back to the next locale
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param locale path string true "Locale, e.g. en"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/translations/{locale} [delete]
+func (h *ProductHandler) DeleteProductTranslation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	err = h.service.DeleteProductTranslation(id, c.Param("locale"))
+	if err != nil {
+		translationError(c, err, "Failed to delete translation")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Translation deleted successfully",
+	})
+}
+
+// @Summary Set variant translation
+// @Description Set the name of a variant in a supported locale other than the default one
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variant_id path string true "Variant ID"
+// @Param locale path string true "Locale, e.g. en"
+// @Param translation body models.TranslationRequest true "Translated name; description is ignored"
+// @Success 200 {object} models.APIResponse{data=models.VariantTranslation}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/variants/{variant_id}/translations/{locale} [put]
+func (h *ProductHandler) SetVariantTranslation(c *gin.Context) {
+	id, variantID, ok := parseProductVariantIDs(c)
+	if !ok {
+		return
+	}
+
+	var req models.TranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	translation, err := h.service.SetVariantTranslation(id, variantID, c.Param("locale"), &req)
+	if err != nil {
+		translationError(c, err, "Failed to save translation")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Translation saved successfully",
+		Data:    translation,
+	})
+}
+
+// @Summary Delete variant translation
+// @Description Remove the translation of a variant in a locale
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variant_id path string true "Variant ID"
+// @Param locale path string true "Locale, e.g. en"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/variants/{variant_id}/translations/{locale} [delete]
+func (h *ProductHandler) DeleteVariantTranslation(c *gin.Context) {
+	id, variantID, ok := parseProductVariantIDs(c)
+	if !ok {
+		return
+	}
+
+	err := h.service.DeleteVariantTranslation(id, variantID, c.Param("locale"))
+	if err != nil {
+		translationError(c, err, "Failed to delete translation")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Translation deleted successfully",
+	})
+}
+
+// parseProductVariantIDs reads the product and variant IDs of a variant route,
+// responding with 400 when either is invalid
+func parseProductVariantIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	variantID, err := uuid.Parse(c.Param("variant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid variant ID",
+			Error:   err.Error(),
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return productID, variantID, true
+}
+
+// bundleError maps bundle errors to responses
+func bundleError(c *gin.Context, err error, message string) {
+	var bundleErr *service.BundleError
+	switch {
+	case err.Error() == "product not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Product not found",
+		})
+	case err.Error() == "bundle not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Product is not a bundle",
+		})
+	case errors.As(err, &bundleErr):
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
+
+// translationError maps translation errors to responses
+func translationError(c *gin.Context, err error, message string) {
+	var translationErr *service.TranslationError
+	switch {
+	case err.Error() == "product not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Product not found",
+		})
+	case err.Error() == "variant not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Variant not found",
+		})
+	case err.Error() == "category not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Category not found",
+		})
+	case err.Error() == "translation not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Translation not found",
+		})
+	case errors.As(err, &translationErr):
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
+
+// isAvailabilityError reports whether err rejects an availability window
+func isAvailabilityError(err error) bool {
+	return err.Error() == "unpublish_at must be after publish_at"
+}
+
+// isBarcodeConflict reports whether err rejects a barcode another product or
+// variant holds
+func isBarcodeConflict(err error) bool {
+	var conflictErr *service.BarcodeConflictError
+	return errors.As(err, &conflictErr)
+}
+
+func parseRevisionParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid product ID",
+			Error:   err.Error(),
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	revisionID, err := uuid.Parse(c.Param("revision_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid revision ID",
+			Error:   err.Error(),
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return productID, revisionID, true
+}
+
+// revisionError maps revision errors to responses
+func revisionError(c *gin.Context, err error, message string) {
+	var attributeErr *service.AttributeValidationError
+	var trashErr *service.TrashError
+	switch {
+	case err.Error() == "revision not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Revision not found",
+		})
+	case err.Error() == "revisions belong to different entities":
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Revisions cannot be compared",
+			Error:   err.Error(),
+		})
+	case errors.As(err, &attributeErr):
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Revision attributes do not match the category schema",
+			Error:   err.Error(),
+		})
+	case errors.As(err, &trashErr):
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: trashErr.Message,
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
+
+// requestLocales negotiates the locales to read content in from the
+// Accept-Language header and announces the preferred one in the response
+func requestLocales(c *gin.Context, s service.CatalogService) []string {
+	locales := s.NegotiateLocales(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", locales[0])
+	c.Header("Vary", "Accept-Language")
+	return locales
+}
+
+// requestActor identifies who makes a change, as set by the API gateway
+func requestActor(c *gin.Context) string {
+	return c.GetHeader("X-User-ID")
+}
+
 func (h *ProductHandler) RegisterRoutes(r *gin.RouterGroup) {
 	products := r.Group("/products")
 	{
@@ -728,11 +2570,35 @@ func (h *ProductHandler) RegisterRoutes(r *gin.RouterGroup) {
 		products.GET("/featured", h.GetFeaturedProducts)
 		products.GET("/sku/:sku", h.GetProductBySKU)
 		products.GET("/barcode/:barcode", h.GetProductByBarcode)
+		products.GET("/barcode/:barcode/lookup", h.LookupBarcode)
 		products.POST("/bulk/import", h.BulkImport)
+		products.GET("/bulk/import/:job_id", h.GetImportJob)
+		products.GET("/bulk/import/:job_id/rows", h.GetImportJobRows)
+		products.GET("/export", h.ExportProducts)
+		products.POST("/feeds/google-merchant", h.GenerateMerchantFeed)
 		products.GET("/:id", h.GetProduct)
 		products.PUT("/:id", h.UpdateProduct)
 		products.DELETE("/:id", h.DeleteProduct)
+		products.POST("/:id/submit", h.SubmitProduct)
+		products.PUT("/:id/availability", h.SetProductAvailability)
+		products.GET("/:id/recommendations", h.GetRecommendations)
+		products.GET("/:id/bundle", h.GetBundle)
+		products.PUT("/:id/bundle", h.SetBundle)
+		products.DELETE("/:id/bundle", h.DeleteBundle)
+		products.POST("/:id/bundle/selection", h.ResolveBundleSelection)
+		products.GET("/:id/translations", h.GetProductTranslations)
+		products.PUT("/:id/translations/:locale", h.SetProductTranslation)
+		products.DELETE("/:id/translations/:locale", h.DeleteProductTranslation)
+		products.PUT("/:id/variants/:variant_id/translations/:locale", h.SetVariantTranslation)
+		products.DELETE("/:id/variants/:variant_id/translations/:locale", h.DeleteVariantTranslation)
 		products.POST("/:id/media", h.UploadMedia)
+		products.POST("/:id/media/uploads", h.CreateMediaUpload)
+		products.POST("/:id/media/uploads/:upload_id/confirm", h.ConfirmMediaUpload)
 		products.POST("/:id/sellers", h.UpsertSellerProduct)
+		products.GET("/:id/revisions", h.GetRevisions)
+		products.GET("/:id/revisions/compare", h.CompareRevisions)
+		products.GET("/:id/revisions/:revision_id", h.GetRevision)
+		products.POST("/:id/revisions/:revision_id/restore", h.RestoreRevision)
+		products.GET("/:id/price-history", h.GetPriceHistory)
 	}
 }
\ No newline at end of file
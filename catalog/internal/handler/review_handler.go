@@ -0,0 +1,566 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/cebeuygun/platform/services/catalog/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type ReviewHandler struct {
+	service   service.CatalogService
+	validator *validator.Validate
+}
+
+func NewReviewHandler(service service.CatalogService) *ReviewHandler {
+	return &ReviewHandler{
+		service:   service,
+		validator: validator.New(),
+	}
+}
+
+// @Summary Get product reviews
+// @Description List the approved reviews of a product with its rating summary
+// @Tags reviews
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param rating query integer false "Only reviews with this star rating"
+// @Param with_photos query boolean false "Only reviews with photos"
+// @Param sort_by query string false "Sort order" Enums(newest, oldest, helpful, highest, lowest) default(newest)
+// @Param page query integer false "Page number" default(1)
+// @Param limit query integer false "Items per page" default(20)
+// @Success 200 {object} models.APIResponse{data=models.ReviewListResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/reviews [get]
+func (h *ReviewHandler) GetProductReviews(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	req := &models.ReviewListRequest{
+		ProductID: &productID,
+		Status:    models.ReviewStatusApproved,
+	}
+	h.listReviews(c, req)
+}
+
+// @Summary Create product review
+// @Description Review a product from one of the customer's delivered orders. Reviews are published once a moderator approves them.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param X-User-ID header string true "Customer ID"
+// @Param review body models.CreateReviewRequest true "Review"
+// @Success 201 {object} models.APIResponse{data=models.Review}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /products/{id}/reviews [post]
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	customerID, ok := requestCustomer(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateReviewRequest
+	if !bindRequest(c, h.validator, &req) {
+		return
+	}
+
+	review, err := h.service.CreateReview(productID, customerID, &req)
+	if err != nil {
+		reviewError(c, err, "Failed to create review")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Review created successfully",
+		Data:    review,
+	})
+}
+
+// @Summary Add review photo
+// @Description Attach a photo to the customer's review while it waits for moderation. Photo metadata, including location, is removed.
+// @Tags reviews
+// @Accept multipart/form-data
+// @Produce json
+// @Param review_id path string true "Review ID"
+// @Param X-User-ID header string true "Customer ID"
+// @Param file formData file true "Photo"
+// @Success 201 {object} models.APIResponse{data=models.Review}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /reviews/{review_id}/photos [post]
+func (h *ReviewHandler) AddReviewPhoto(c *gin.Context) {
+	reviewID, ok := parseIDParam(c, "review_id", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	customerID, ok := requestCustomer(c)
+	if !ok {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Failed to get file from request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	review, err := h.service.AddReviewPhoto(reviewID, customerID, file, header.Size, header.Header.Get("Content-Type"))
+	if err != nil {
+		reviewError(c, err, "Failed to add review photo")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Review photo added successfully",
+		Data:    review,
+	})
+}
+
+// @Summary Vote on review
+// @Description Record whether a review was helpful. Voting again replaces the earlier vote.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param review_id path string true "Review ID"
+// @Param X-User-ID header string true "Customer ID"
+// @Param vote body models.ReviewVoteRequest true "Vote"
+// @Success 200 {object} models.APIResponse{data=models.Review}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /reviews/{review_id}/votes [put]
+func (h *ReviewHandler) VoteReview(c *gin.Context) {
+	reviewID, ok := parseIDParam(c, "review_id", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	voterID, ok := requestCustomer(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReviewVoteRequest
+	if !bindRequest(c, h.validator, &req) {
+		return
+	}
+
+	review, err := h.service.VoteReview(reviewID, voterID, *req.Helpful)
+	if err != nil {
+		reviewError(c, err, "Failed to vote on review")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Review vote recorded successfully",
+		Data:    review,
+	})
+}
+
+// @Summary Delete review vote
+// @Description Withdraw the customer's helpfulness vote on a review
+// @Tags reviews
+// @Produce json
+// @Param review_id path string true "Review ID"
+// @Param X-User-ID header string true "Customer ID"
+// @Success 200 {object} models.APIResponse{data=models.Review}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /reviews/{review_id}/votes [delete]
+func (h *ReviewHandler) DeleteReviewVote(c *gin.Context) {
+	reviewID, ok := parseIDParam(c, "review_id", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	voterID, ok := requestCustomer(c)
+	if !ok {
+		return
+	}
+
+	review, err := h.service.DeleteReviewVote(reviewID, voterID)
+	if err != nil {
+		reviewError(c, err, "Failed to delete review vote")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Review vote deleted successfully",
+		Data:    review,
+	})
+}
+
+// @Summary Get seller reviews
+// @Description List the reviews of a seller's listings
+// @Tags sellers
+// @Produce json
+// @Param seller_id path string true "Seller ID"
+// @Param status query string false "Review status" Enums(PENDING, APPROVED, REJECTED) default(APPROVED)
+// @Param rating query integer false "Only reviews with this star rating"
+// @Param with_photos query boolean false "Only reviews with photos"
+// @Param sort_by query string false "Sort order" Enums(newest, oldest, helpful, highest, lowest) default(newest)
+// @Param page query integer false "Page number" default(1)
+// @Param limit query integer false "Items per page" default(20)
+// @Success 200 {object} models.APIResponse{data=models.ReviewListResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/reviews [get]
+func (h *ReviewHandler) GetSellerReviews(c *gin.Context) {
+	sellerID, ok := parseIDParam(c, "seller_id", "Invalid seller ID")
+	if !ok {
+		return
+	}
+
+	req := &models.ReviewListRequest{
+		SellerID: &sellerID,
+		Status:   models.ReviewStatus(strings.ToUpper(c.DefaultQuery("status", string(models.ReviewStatusApproved)))),
+	}
+	h.listReviews(c, req)
+}
+
+// @Summary Reply to review
+// @Description Publish the seller's reply to a review of one of their listings, replacing an earlier reply
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Param seller_id path string true "Seller ID"
+// @Param review_id path string true "Review ID"
+// @Param reply body models.ReviewReplyRequest true "Reply"
+// @Success 200 {object} models.APIResponse{data=models.Review}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/reviews/{review_id}/reply [put]
+func (h *ReviewHandler) ReplyToReview(c *gin.Context) {
+	sellerID, ok := parseIDParam(c, "seller_id", "Invalid seller ID")
+	if !ok {
+		return
+	}
+	reviewID, ok := parseIDParam(c, "review_id", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	var req models.ReviewReplyRequest
+	if !bindRequest(c, h.validator, &req) {
+		return
+	}
+
+	review, err := h.service.ReplyToReview(sellerID, reviewID, &req)
+	if err != nil {
+		reviewError(c, err, "Failed to reply to review")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Review reply saved successfully",
+		Data:    review,
+	})
+}
+
+// @Summary Get review moderation queue
+// @Description List reviews by moderation status. Pending reviews are listed oldest first.
+// @Tags moderation
+// @Produce json
+// @Param status query string false "Review status" Enums(PENDING, APPROVED, REJECTED) default(PENDING)
+// @Param product_id query string false "Product ID"
+// @Param rating query integer false "Only reviews with this star rating"
+// @Param with_photos query boolean false "Only reviews with photos"
+// @Param page query integer false "Page number" default(1)
+// @Param limit query integer false "Items per page" default(20)
+// @Success 200 {object} models.APIResponse{data=models.ReviewListResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /moderation/reviews [get]
+func (h *ReviewHandler) GetModerationQueue(c *gin.Context) {
+	req := &models.ReviewListRequest{
+		Status: models.ReviewStatus(strings.ToUpper(c.DefaultQuery("status", string(models.ReviewStatusPending)))),
+		SortBy: "oldest",
+	}
+
+	if productIDStr := c.Query("product_id"); productIDStr != "" {
+		productID, err := uuid.Parse(productIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid product_id",
+				Error:   err.Error(),
+			})
+			return
+		}
+		req.ProductID = &productID
+	}
+
+	h.listReviews(c, req)
+}
+
+// @Summary Approve review
+// @Description Publish a pending review and count it in the product's rating
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Review ID"
+// @Success 200 {object} models.APIResponse{data=models.Review}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /moderation/reviews/{id}/approve [post]
+func (h *ReviewHandler) ApproveReview(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	review, err := h.service.ApproveReview(id, requestActor(c))
+	if err != nil {
+		reviewError(c, err, "Failed to approve review")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Review approved successfully",
+		Data:    review,
+	})
+}
+
+// @Summary Reject review
+// @Description Reject a pending review, or take down an approved one
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Review ID"
+// @Param rejection body models.RejectReviewRequest true "Rejection note"
+// @Success 200 {object} models.APIResponse{data=models.Review}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /moderation/reviews/{id}/reject [post]
+func (h *ReviewHandler) RejectReview(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	var req models.RejectReviewRequest
+	if !bindRequest(c, h.validator, &req) {
+		return
+	}
+
+	review, err := h.service.RejectReview(id, &req, requestActor(c))
+	if err != nil {
+		reviewError(c, err, "Failed to reject review")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Review rejected successfully",
+		Data:    review,
+	})
+}
+
+// listReviews reads the shared listing parameters into req and answers with
+// the matching page of reviews
+func (h *ReviewHandler) listReviews(c *gin.Context, req *models.ReviewListRequest) {
+	req.Page = 1
+	req.Limit = 20
+	if req.SortBy == "" {
+		req.SortBy = "newest"
+	}
+	req.SortBy = c.DefaultQuery("sort_by", req.SortBy)
+
+	if ratingStr := c.Query("rating"); ratingStr != "" {
+		rating, err := strconv.Atoi(ratingStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid rating",
+				Error:   err.Error(),
+			})
+			return
+		}
+		req.Rating = &rating
+	}
+
+	if withPhotosStr := c.Query("with_photos"); withPhotosStr != "" {
+		withPhotos, err := strconv.ParseBool(withPhotosStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid with_photos",
+				Error:   err.Error(),
+			})
+			return
+		}
+		req.WithPhotos = withPhotos
+	}
+
+	// Parse pagination
+	if pageStr := c.Query("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid page number",
+			})
+			return
+		}
+		req.Page = page
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid limit (must be between 1 and 100)",
+			})
+			return
+		}
+		req.Limit = limit
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.service.ListReviews(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to get reviews",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Reviews retrieved successfully",
+		Data:    response,
+	})
+}
+
+// requestCustomer reads the customer ID from the X-User-ID header,
+// answering 401 when it is missing or malformed
+func requestCustomer(c *gin.Context) (uuid.UUID, bool) {
+	customerID, err := uuid.Parse(requestActor(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "A customer ID is required in the X-User-ID header",
+		})
+		return uuid.Nil, false
+	}
+	return customerID, true
+}
+
+// reviewError maps review errors to responses
+func reviewError(c *gin.Context, err error, message string) {
+	var reviewErr *service.ReviewError
+	switch {
+	case err.Error() == "review not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Review not found",
+		})
+	case err.Error() == "vote not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Vote not found",
+		})
+	case err.Error() == "order not eligible for review":
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	case err.Error() == "review already exists", strings.HasPrefix(err.Error(), "review cannot"):
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	case errors.As(err, &reviewErr),
+		strings.HasPrefix(err.Error(), "invalid image"),
+		strings.HasPrefix(err.Error(), "file type"),
+		strings.HasPrefix(err.Error(), "file size"):
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
+
+func (h *ReviewHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/products/:id/reviews", h.GetProductReviews)
+	r.POST("/products/:id/reviews", h.CreateReview)
+
+	reviews := r.Group("/reviews")
+	{
+		reviews.POST("/:review_id/photos", h.AddReviewPhoto)
+		reviews.PUT("/:review_id/votes", h.VoteReview)
+		reviews.DELETE("/:review_id/votes", h.DeleteReviewVote)
+	}
+
+	r.GET("/sellers/:seller_id/reviews", h.GetSellerReviews)
+	r.PUT("/sellers/:seller_id/reviews/:review_id/reply", h.ReplyToReview)
+
+	moderation := r.Group("/moderation/reviews")
+	{
+		moderation.GET("", h.GetModerationQueue)
+		moderation.POST("/:id/approve", h.ApproveReview)
+		moderation.POST("/:id/reject", h.RejectReview)
+	}
+}
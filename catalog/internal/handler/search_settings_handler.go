@@ -0,0 +1,386 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/cebeuygun/platform/services/catalog/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type SearchSettingsHandler struct {
+	service   service.CatalogService
+	validator *validator.Validate
+}
+
+func NewSearchSettingsHandler(service service.CatalogService) *SearchSettingsHandler {
+	return &SearchSettingsHandler{
+		service:   service,
+		validator: validator.New(),
+	}
+}
+
+// @Summary Get live search settings
+// @Description Get the synonyms, stopwords and merchandising rules searches currently apply
+// @Tags search-settings
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.SearchSettings}
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /search-settings [get]
+func (h *SearchSettingsHandler) GetLiveSettings(c *gin.Context) {
+	settings, err := h.service.GetLiveSearchSettings()
+	if err != nil {
+		searchSettingsError(c, err, "Failed to get search settings")
+		return
+	}
+	if settings == nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "No search settings have been published",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Search settings retrieved successfully",
+		Data:    settings,
+	})
+}
+
+// @Summary Get search settings versions
+// @Description List every version of the search settings, newest first: the draft, the live version and the retired ones
+// @Tags search-settings
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.SearchSettings}
+// @Failure 500 {object} models.APIResponse
+// @Router /search-settings/versions [get]
+func (h *SearchSettingsHandler) GetVersions(c *gin.Context) {
+	versions, err := h.service.GetSearchSettingsVersions()
+	if err != nil {
+		searchSettingsError(c, err, "Failed to get search settings versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Search settings versions retrieved successfully",
+		Data:    versions,
+	})
+}
+
+// @Summary Get search settings version
+// @Description Get one version of the search settings
+// @Tags search-settings
+// @Produce json
+// @Param version path integer true "Version"
+// @Success 200 {object} models.APIResponse{data=models.SearchSettings}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /search-settings/versions/{version} [get]
+func (h *SearchSettingsHandler) GetVersion(c *gin.Context) {
+	version, ok := parseVersionParam(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.service.GetSearchSettingsVersion(version)
+	if err != nil {
+		searchSettingsError(c, err, "Failed to get search settings")
+		return
+	}
+	if settings == nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Search settings version not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Search settings retrieved successfully",
+		Data:    settings,
+	})
+}
+
+// @Summary Restore search settings version
+// @Description Copy an earlier version into the draft, replacing it, so that it can be previewed and published again
+// @Tags search-settings
+// @Produce json
+// @Param version path integer true "Version"
+// @Success 200 {object} models.APIResponse{data=models.SearchSettings}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /search-settings/versions/{version}/restore [post]
+func (h *SearchSettingsHandler) RestoreVersion(c *gin.Context) {
+	version, ok := parseVersionParam(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.service.RestoreSearchSettings(version, requestActor(c))
+	if err != nil {
+		searchSettingsError(c, err, "Failed to restore search settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Search settings restored to the draft successfully",
+		Data:    settings,
+	})
+}
+
+// @Summary Get search settings draft
+// @Description Get the draft of the next search settings version
+// @Tags search-settings
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.SearchSettings}
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /search-settings/draft [get]
+func (h *SearchSettingsHandler) GetDraft(c *gin.Context) {
+	settings, err := h.service.GetSearchSettingsDraft()
+	if err != nil {
+		searchSettingsError(c, err, "Failed to get search settings draft")
+		return
+	}
+	if settings == nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Search settings draft not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Search settings draft retrieved successfully",
+		Data:    settings,
+	})
+}
+
+// @Summary Save search settings draft
+// @Description Replace the draft of the next search settings version, starting one when there is none. Terms are stored in lower case. Every rule needs a query, a category or both, and products or brands to move.
+// @Tags search-settings
+// @Accept json
+// @Produce json
+// @Param settings body models.SearchSettingsRequest true "Search settings"
+// @Success 200 {object} models.APIResponse{data=models.SearchSettings}
+// @Failure 400 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /search-settings/draft [put]
+func (h *SearchSettingsHandler) SaveDraft(c *gin.Context) {
+	var req models.SearchSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	settings, err := h.service.SaveSearchSettingsDraft(&req, requestActor(c))
+	if err != nil {
+		searchSettingsError(c, err, "Failed to save search settings draft")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Search settings draft saved successfully",
+		Data:    settings,
+	})
+}
+
+// @Summary Discard search settings draft
+// @Description Delete the draft of the next search settings version
+// @Tags search-settings
+// @Produce json
+// @Success 200 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /search-settings/draft [delete]
+func (h *SearchSettingsHandler) DiscardDraft(c *gin.Context) {
+	if err := h.service.DiscardSearchSettingsDraft(); err != nil {
+		searchSettingsError(c, err, "Failed to discard search settings draft")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Search settings draft discarded successfully",
+	})
+}
+
+// @Summary Publish search settings draft
+// @Description Make the draft the live search settings, retiring the version that was live
+// @Tags search-settings
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.SearchSettings}
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /search-settings/draft/publish [post]
+func (h *SearchSettingsHandler) PublishDraft(c *gin.Context) {
+	settings, err := h.service.PublishSearchSettings(requestActor(c))
+	if err != nil {
+		searchSettingsError(c, err, "Failed to publish search settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Search settings published successfully",
+		Data:    settings,
+	})
+}
+
+// @Summary Preview search settings
+// @Description Run a search with the draft, or another version, of the search settings, as it would run at a given time. Takes the parameters of the product search, and reports how the query was read and which merchandising rules applied.
+// @Tags search-settings
+// @Produce json
+// @Param version query integer false "Version to preview (default the draft)"
+// @Param at query string false "Time to preview campaigns at, RFC 3339 (default now)"
+// @Param query query string false "Search query"
+// @Param category_id query string false "Category ID"
+// @Param sort_by query string false "Sort field" Enums(relevance, name, price, rating, created_at, updated_at) default(relevance)
+// @Param page query integer false "Page number" default(1)
+// @Param limit query integer false "Items per page" default(20)
+// @Success 200 {object} models.APIResponse{data=models.SearchPreview}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /search-settings/preview [get]
+func (h *SearchSettingsHandler) Preview(c *gin.Context) {
+	var version *int
+	if versionStr := c.Query("version"); versionStr != "" {
+		parsed, err := strconv.Atoi(versionStr)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid version",
+			})
+			return
+		}
+		version = &parsed
+	}
+
+	at := time.Now()
+	if atStr := c.Query("at"); atStr != "" {
+		parsed, err := time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid at",
+				Error:   err.Error(),
+			})
+			return
+		}
+		at = parsed
+	}
+
+	req, ok := parseSearchRequest(c, h.validator)
+	if !ok {
+		return
+	}
+
+	preview, err := h.service.PreviewSearch(req, version, at)
+	if err != nil {
+		var settingsErr *service.SearchSettingsError
+		if errors.As(err, &settingsErr) {
+			searchSettingsError(c, err, "Failed to preview search settings")
+			return
+		}
+		searchError(c, err, "Failed to preview search settings")
+		return
+	}
+
+	h.service.LocalizeProducts(preview.Results.Products, requestLocales(c, h.service))
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Search settings previewed successfully",
+		Data:    preview,
+	})
+}
+
+// parseVersionParam reads the version path parameter, responding with 400
+// when it is not a version number
+func parseVersionParam(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid version",
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+// searchSettingsError maps search settings errors to status codes
+func searchSettingsError(c *gin.Context, err error, message string) {
+	var settingsErr *service.SearchSettingsError
+	isSettingsErr := errors.As(err, &settingsErr)
+	switch {
+	case isSettingsErr && settingsErr.NotFound, strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	case strings.HasSuffix(err.Error(), "changed concurrently"):
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	case isSettingsErr:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
+
+func (h *SearchSettingsHandler) RegisterRoutes(r *gin.RouterGroup) {
+	settings := r.Group("/search-settings")
+	{
+		settings.GET("", h.GetLiveSettings)
+		settings.GET("/versions", h.GetVersions)
+		settings.GET("/versions/:version", h.GetVersion)
+		settings.POST("/versions/:version/restore", h.RestoreVersion)
+		settings.GET("/draft", h.GetDraft)
+		settings.PUT("/draft", h.SaveDraft)
+		settings.DELETE("/draft", h.DiscardDraft)
+		settings.POST("/draft/publish", h.PublishDraft)
+		settings.GET("/preview", h.Preview)
+	}
+}
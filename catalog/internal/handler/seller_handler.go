@@ -0,0 +1,607 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/cebeuygun/platform/services/catalog/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type SellerHandler struct {
+	service   service.CatalogService
+	validator *validator.Validate
+}
+
+func NewSellerHandler(service service.CatalogService) *SellerHandler {
+	return &SellerHandler{
+		service:   service,
+		validator: validator.New(),
+	}
+}
+
+// @Summary Get low-stock report
+// @Description Get seller listings whose stock is at or below their MinStock threshold
+// @Tags sellers
+// @Produce json
+// @Param seller_id path string true "Seller ID"
+// @Success 200 {object} models.APIResponse{data=[]models.LowStockItem}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/low-stock [get]
+func (h *SellerHandler) GetLowStockReport(c *gin.Context) {
+	sellerIDStr := c.Param("seller_id")
+	sellerID, err := uuid.Parse(sellerIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid seller ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	items, err := h.service.GetLowStockReport(sellerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to get low stock report",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Low stock report retrieved successfully",
+		Data:    items,
+	})
+}
+
+// @Summary List seller products
+// @Description List a seller's product listings joined with product data
+// @Tags sellers
+// @Produce json
+// @Param seller_id path string true "Seller ID"
+// @Param is_visible query boolean false "Filter by visibility"
+// @Param is_available query boolean false "Filter by availability window"
+// @Param stock_state query string false "Filter by stock state" Enums(IN_STOCK, LOW, DEPLETED)
+// @Param category_id query string false "Category ID"
+// @Param page query integer false "Page number" default(1)
+// @Param limit query integer false "Items per page" default(20)
+// @Param sort_order query string false "Sort order by updated time" Enums(asc, desc)
+// @Success 200 {object} models.APIResponse{data=models.SellerProductListResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/products [get]
+func (h *SellerHandler) ListSellerProducts(c *gin.Context) {
+	req, ok := h.parseListRequest(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.ListSellerProducts(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to list seller products",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Seller products retrieved successfully",
+		Data:    response,
+	})
+}
+
+// @Summary Export seller products
+// @Description Export a seller's product listings as CSV using the same filters as the listing endpoint
+// @Tags sellers
+// @Produce text/csv
+// @Param seller_id path string true "Seller ID"
+// @Param is_visible query boolean false "Filter by visibility"
+// @Param is_available query boolean false "Filter by availability window"
+// @Param stock_state query string false "Filter by stock state" Enums(IN_STOCK, LOW, DEPLETED)
+// @Param category_id query string false "Category ID"
+// @Param sort_order query string false "Sort order by updated time" Enums(asc, desc)
+// @Success 200 {file} file
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/products/export [get]
+func (h *SellerHandler) ExportSellerProducts(c *gin.Context) {
+	req, ok := h.parseListRequest(c)
+	if !ok {
+		return
+	}
+
+	w := &csvExportWriter{
+		c:        c,
+		fileName: fmt.Sprintf("seller-products-%s-%s.csv", req.SellerID, time.Now().UTC().Format("20060102")),
+	}
+	if err := h.service.ExportSellerProducts(req, w); err != nil {
+		if w.started {
+			// The status is sent, so the client only sees a truncated file
+			log.Printf("Seller products export of seller %s failed after it started: %v", req.SellerID, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to export seller products",
+			Error:   err.Error(),
+		})
+	}
+}
+
+// csvExportWriter streams an export to the response, sending the CSV headers
+// with its first bytes so that an export failing before then can still
+// answer with an error
+type csvExportWriter struct {
+	c        *gin.Context
+	fileName string
+	started  bool
+}
+
+func (w *csvExportWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", w.fileName))
+		w.c.Header("Content-Type", "text/csv")
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+func (h *SellerHandler) parseListRequest(c *gin.Context) (*models.SellerProductListRequest, bool) {
+	sellerIDStr := c.Param("seller_id")
+	sellerID, err := uuid.Parse(sellerIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid seller ID",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+
+	req := &models.SellerProductListRequest{
+		SellerID:  sellerID,
+		Page:      1,
+		Limit:     20,
+		SortOrder: "desc",
+	}
+
+	if isVisibleStr := c.Query("is_visible"); isVisibleStr != "" {
+		isVisible, err := strconv.ParseBool(isVisibleStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid is_visible",
+				Error:   err.Error(),
+			})
+			return nil, false
+		}
+		req.IsVisible = &isVisible
+	}
+
+	if isAvailableStr := c.Query("is_available"); isAvailableStr != "" {
+		isAvailable, err := strconv.ParseBool(isAvailableStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid is_available",
+				Error:   err.Error(),
+			})
+			return nil, false
+		}
+		req.IsAvailable = &isAvailable
+	}
+
+	if stockStateStr := c.Query("stock_state"); stockStateStr != "" {
+		stockState := models.StockState(stockStateStr)
+		req.StockState = &stockState
+	}
+
+	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
+		categoryID, err := uuid.Parse(categoryIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid category_id",
+				Error:   err.Error(),
+			})
+			return nil, false
+		}
+		req.CategoryID = &categoryID
+	}
+
+	// Parse pagination
+	if pageStr := c.Query("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid page number",
+			})
+			return nil, false
+		}
+		req.Page = page
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid limit (must be between 1 and 100)",
+			})
+			return nil, false
+		}
+		req.Limit = limit
+	}
+
+	if sortOrder := c.Query("sort_order"); sortOrder != "" {
+		req.SortOrder = sortOrder
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+
+	return req, true
+}
+
+// @Summary List seller locations
+// @Description List a seller's store locations with their delivery zones
+// @Tags sellers
+// @Produce json
+// @Param seller_id path string true "Seller ID"
+// @Success 200 {object} models.APIResponse{data=[]models.SellerLocation}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/locations [get]
+func (h *SellerHandler) GetSellerLocations(c *gin.Context) {
+	sellerID, ok := parseIDParam(c, "seller_id", "Invalid seller ID")
+	if !ok {
+		return
+	}
+
+	locations, err := h.service.GetSellerLocations(sellerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to get seller locations",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Seller locations retrieved successfully",
+		Data:    locations,
+	})
+}
+
+// @Summary Create seller location
+// @Description Add a store location a seller fulfils orders from
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Param seller_id path string true "Seller ID"
+// @Param location body models.SellerLocationRequest true "Location"
+// @Success 201 {object} models.APIResponse{data=models.SellerLocation}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/locations [post]
+func (h *SellerHandler) CreateSellerLocation(c *gin.Context) {
+	sellerID, ok := parseIDParam(c, "seller_id", "Invalid seller ID")
+	if !ok {
+		return
+	}
+
+	var req models.SellerLocationRequest
+	if !bindRequest(c, h.validator, &req) {
+		return
+	}
+
+	location, err := h.service.CreateSellerLocation(sellerID, &req)
+	if err != nil {
+		locationError(c, err, "Failed to create seller location")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Seller location created successfully",
+		Data:    location,
+	})
+}
+
+// @Summary Update seller location
+// @Description Replace a seller location; its delivery zones are kept
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Param seller_id path string true "Seller ID"
+// @Param location_id path string true "Location ID"
+// @Param location body models.SellerLocationRequest true "Location"
+// @Success 200 {object} models.APIResponse{data=models.SellerLocation}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/locations/{location_id} [put]
+func (h *SellerHandler) UpdateSellerLocation(c *gin.Context) {
+	sellerID, locationID, ok := parseLocationParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.SellerLocationRequest
+	if !bindRequest(c, h.validator, &req) {
+		return
+	}
+
+	location, err := h.service.UpdateSellerLocation(sellerID, locationID, &req)
+	if err != nil {
+		locationError(c, err, "Failed to update seller location")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Seller location updated successfully",
+		Data:    location,
+	})
+}
+
+// @Summary Delete seller location
+// @Description Delete a seller location together with its delivery zones
+// @Tags sellers
+// @Produce json
+// @Param seller_id path string true "Seller ID"
+// @Param location_id path string true "Location ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/locations/{location_id} [delete]
+func (h *SellerHandler) DeleteSellerLocation(c *gin.Context) {
+	sellerID, locationID, ok := parseLocationParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSellerLocation(sellerID, locationID); err != nil {
+		locationError(c, err, "Failed to delete seller location")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Seller location deleted successfully",
+	})
+}
+
+// @Summary Create delivery zone
+// @Description Add an area a seller location delivers to: a radius around the location or a polygon
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Param seller_id path string true "Seller ID"
+// @Param location_id path string true "Location ID"
+// @Param zone body models.DeliveryZoneRequest true "Delivery zone"
+// @Success 201 {object} models.APIResponse{data=models.DeliveryZone}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/locations/{location_id}/zones [post]
+func (h *SellerHandler) CreateDeliveryZone(c *gin.Context) {
+	sellerID, locationID, ok := parseLocationParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.DeliveryZoneRequest
+	if !bindRequest(c, h.validator, &req) {
+		return
+	}
+
+	zone, err := h.service.CreateDeliveryZone(sellerID, locationID, &req)
+	if err != nil {
+		locationError(c, err, "Failed to create delivery zone")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Delivery zone created successfully",
+		Data:    zone,
+	})
+}
+
+// @Summary Update delivery zone
+// @Description Replace a delivery zone of a seller location
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Param seller_id path string true "Seller ID"
+// @Param location_id path string true "Location ID"
+// @Param zone_id path string true "Zone ID"
+// @Param zone body models.DeliveryZoneRequest true "Delivery zone"
+// @Success 200 {object} models.APIResponse{data=models.DeliveryZone}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/locations/{location_id}/zones/{zone_id} [put]
+func (h *SellerHandler) UpdateDeliveryZone(c *gin.Context) {
+	sellerID, locationID, ok := parseLocationParams(c)
+	if !ok {
+		return
+	}
+	zoneID, ok := parseIDParam(c, "zone_id", "Invalid zone ID")
+	if !ok {
+		return
+	}
+
+	var req models.DeliveryZoneRequest
+	if !bindRequest(c, h.validator, &req) {
+		return
+	}
+
+	zone, err := h.service.UpdateDeliveryZone(sellerID, locationID, zoneID, &req)
+	if err != nil {
+		locationError(c, err, "Failed to update delivery zone")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Delivery zone updated successfully",
+		Data:    zone,
+	})
+}
+
+// @Summary Delete delivery zone
+// @Description Delete a delivery zone of a seller location
+// @Tags sellers
+// @Produce json
+// @Param seller_id path string true "Seller ID"
+// @Param location_id path string true "Location ID"
+// @Param zone_id path string true "Zone ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /sellers/{seller_id}/locations/{location_id}/zones/{zone_id} [delete]
+func (h *SellerHandler) DeleteDeliveryZone(c *gin.Context) {
+	sellerID, locationID, ok := parseLocationParams(c)
+	if !ok {
+		return
+	}
+	zoneID, ok := parseIDParam(c, "zone_id", "Invalid zone ID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteDeliveryZone(sellerID, locationID, zoneID); err != nil {
+		locationError(c, err, "Failed to delete delivery zone")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Delivery zone deleted successfully",
+	})
+}
+
+// bindRequest reads and validates a JSON request body, answering 400 when
+// it cannot be used
+func bindRequest(c *gin.Context, validate *validator.Validate, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return false
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return false
+	}
+
+	return true
+}
+
+// parseIDParam reads a UUID path parameter, answering 400 with message when
+// it is malformed
+func parseIDParam(c *gin.Context, name string, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func parseLocationParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	sellerID, ok := parseIDParam(c, "seller_id", "Invalid seller ID")
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	locationID, ok := parseIDParam(c, "location_id", "Invalid location ID")
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return sellerID, locationID, true
+}
+
+func locationError(c *gin.Context, err error, message string) {
+	var locationErr *service.LocationError
+	switch {
+	case err.Error() == "seller location not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Seller location not found",
+		})
+	case err.Error() == "delivery zone not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Delivery zone not found",
+		})
+	case errors.As(err, &locationErr):
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
+
+func (h *SellerHandler) RegisterRoutes(r *gin.RouterGroup) {
+	sellers := r.Group("/sellers")
+	{
+		sellers.GET("/:seller_id/products", h.ListSellerProducts)
+		sellers.GET("/:seller_id/products/export", h.ExportSellerProducts)
+		sellers.GET("/:seller_id/low-stock", h.GetLowStockReport)
+
+		// Store locations and delivery zones
+		sellers.GET("/:seller_id/locations", h.GetSellerLocations)
+		sellers.POST("/:seller_id/locations", h.CreateSellerLocation)
+		sellers.PUT("/:seller_id/locations/:location_id", h.UpdateSellerLocation)
+		sellers.DELETE("/:seller_id/locations/:location_id", h.DeleteSellerLocation)
+		sellers.POST("/:seller_id/locations/:location_id/zones", h.CreateDeliveryZone)
+		sellers.PUT("/:seller_id/locations/:location_id/zones/:zone_id", h.UpdateDeliveryZone)
+		sellers.DELETE("/:seller_id/locations/:location_id/zones/:zone_id", h.DeleteDeliveryZone)
+	}
+}
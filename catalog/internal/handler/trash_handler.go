@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/cebeuygun/platform/services/catalog/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type TrashHandler struct {
+	service service.CatalogService
+}
+
+func NewTrashHandler(service service.CatalogService) *TrashHandler {
+	return &TrashHandler{
+		service: service,
+	}
+}
+
+// @Summary Get deleted products
+// @Description List the products in the trash, most recently deleted first. They can be restored until the retention purge removes them.
+// @Tags trash
+// @Produce json
+// @Param page query integer false "Page number" default(1)
+// @Param limit query integer false "Items per page" default(20)
+// @Success 200 {object} models.APIResponse{data=models.DeletedProductListResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /trash/products [get]
+func (h *TrashHandler) GetDeletedProducts(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid page number",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid limit (must be between 1 and 100)",
+		})
+		return
+	}
+
+	response, err := h.service.GetDeletedProducts(page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to get deleted products",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Deleted products retrieved successfully",
+		Data:    response,
+	})
+}
+
+// @Summary Restore deleted product
+// @Description Take a product out of the trash. Its category has to be restored first.
+// @Tags trash
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} models.APIResponse{data=models.Product}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /trash/products/{id}/restore [post]
+func (h *TrashHandler) RestoreProduct(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	product, err := h.service.RestoreProduct(id, requestActor(c))
+	if err != nil {
+		trashError(c, err, "Failed to restore product")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Product restored successfully",
+		Data:    product,
+	})
+}
+
+// @Summary Get deleted categories
+// @Description List the categories in the trash, most recently deleted first
+// @Tags trash
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.Category}
+// @Failure 500 {object} models.APIResponse
+// @Router /trash/categories [get]
+func (h *TrashHandler) GetDeletedCategories(c *gin.Context) {
+	categories, err := h.service.GetDeletedCategories()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to get deleted categories",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Deleted categories retrieved successfully",
+		Data:    categories,
+	})
+}
+
+// @Summary Restore deleted category
+// @Description Take a category out of the trash. Its parent has to be restored first.
+// @Tags trash
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} models.APIResponse{data=models.Category}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /trash/categories/{id}/restore [post]
+func (h *TrashHandler) RestoreCategory(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid category ID")
+	if !ok {
+		return
+	}
+
+	category, err := h.service.RestoreCategory(id)
+	if err != nil {
+		trashError(c, err, "Failed to restore category")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Category restored successfully",
+		Data:    category,
+	})
+}
+
+// trashError maps restore errors onto HTTP responses
+func trashError(c *gin.Context, err error, message string) {
+	var trashErr *service.TrashError
+	switch {
+	case err.Error() == "product not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Deleted product not found",
+		})
+	case err.Error() == "category not found":
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Deleted category not found",
+		})
+	case errors.As(err, &trashErr):
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+}
+
+func (h *TrashHandler) RegisterRoutes(r *gin.RouterGroup) {
+	trash := r.Group("/trash")
+	{
+		trash.GET("/products", h.GetDeletedProducts)
+		trash.POST("/products/:id/restore", h.RestoreProduct)
+		trash.GET("/categories", h.GetDeletedCategories)
+		trash.POST("/categories/:id/restore", h.RestoreCategory)
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
@@ -15,12 +18,73 @@ type Category struct {
 	ImageURL    *string    `json:"image_url,omitempty" db:"image_url"`
 	SortOrder   int        `json:"sort_order" db:"sort_order"`
 	IsActive    bool       `json:"is_active" db:"is_active"`
+	Path        string     `json:"path" db:"path"`
+	Depth       int        `json:"depth" db:"depth"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	DeletedBy   *string    `json:"deleted_by,omitempty" db:"deleted_by"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 	
 	// Computed fields
 	Children    []*Category `json:"children,omitempty" db:"-"`
 	ProductCount int        `json:"product_count,omitempty" db:"-"`
+	Locale      string      `json:"locale,omitempty" db:"-"` // locale of Name when read with Accept-Language
+}
+
+// CategoryAttribute defines a typed attribute of the products in a category.
+// Attributes are inherited by subcategories, which may redefine a code.
+type CategoryAttribute struct {
+	ID           uuid.UUID        `json:"id" db:"id"`
+	CategoryID   uuid.UUID        `json:"category_id" db:"category_id"`
+	Code         string           `json:"code" db:"code"`
+	Name         string           `json:"name" db:"name"`
+	Type         AttributeType    `json:"type" db:"type"`
+	Unit         *string          `json:"unit,omitempty" db:"unit"`
+	Options      []string         `json:"options,omitempty" db:"options"`
+	MinValue     *decimal.Decimal `json:"min_value,omitempty" db:"min_value"`
+	MaxValue     *decimal.Decimal `json:"max_value,omitempty" db:"max_value"`
+	IsRequired   bool             `json:"is_required" db:"is_required"`
+	IsVariant    bool             `json:"is_variant" db:"is_variant"`
+	IsFilterable bool             `json:"is_filterable" db:"is_filterable"`
+	SortOrder    int              `json:"sort_order" db:"sort_order"`
+	CreatedAt    time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at" db:"updated_at"`
+
+	// Computed fields
+	Inherited bool `json:"inherited" db:"-"`
+}
+
+// Attribute Type Enum
+type AttributeType string
+
+const (
+	AttributeTypeEnum    AttributeType = "ENUM"
+	AttributeTypeNumber  AttributeType = "NUMBER"
+	AttributeTypeBoolean AttributeType = "BOOLEAN"
+)
+
+// Attributes holds product or variant attribute values, stored as JSONB
+type Attributes map[string]interface{}
+
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *Attributes) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into Attributes", src)
+	}
 }
 
 // Product represents a product in the catalog
@@ -33,6 +97,8 @@ type Product struct {
 	SKU                 *string         `json:"sku,omitempty" db:"sku"`
 	Barcode             *string         `json:"barcode,omitempty" db:"barcode"`
 	BasePrice           decimal.Decimal `json:"base_price" db:"base_price" validate:"required,gt=0"`
+	CompareAtPrice      *decimal.Decimal `json:"compare_at_price,omitempty" db:"compare_at_price"` // crossed-out price, only while it is a real discount
+	ReferencePrice      *decimal.Decimal `json:"reference_price,omitempty" db:"reference_price"` // lowest price of the 30 days before the price changed
 	Currency            string          `json:"currency" db:"currency" validate:"required,len=3"`
 	TaxRate             decimal.Decimal `json:"tax_rate" db:"tax_rate" validate:"gte=0,lte=100"`
 	BaseStock           int             `json:"base_stock" db:"base_stock" validate:"gte=0"`
@@ -41,10 +107,29 @@ type Product struct {
 	Weight              *decimal.Decimal `json:"weight,omitempty" db:"weight"`
 	Dimensions          *string         `json:"dimensions,omitempty" db:"dimensions"`
 	Tags                []string        `json:"tags,omitempty" db:"tags"`
-	Attributes          map[string]interface{} `json:"attributes,omitempty" db:"attributes"`
+	Attributes          Attributes             `json:"attributes,omitempty" db:"attributes"`
 	IsActive            bool            `json:"is_active" db:"is_active"`
 	IsExpressDelivery   bool            `json:"is_express_delivery" db:"is_express_delivery"`
 	PreparationTime     int             `json:"preparation_time" db:"preparation_time"` // in minutes
+	Status              ProductStatus    `json:"status" db:"status"`
+	SubmittedBy         *uuid.UUID       `json:"submitted_by,omitempty" db:"submitted_by"` // seller that submitted the product
+	SubmittedAt         *time.Time       `json:"submitted_at,omitempty" db:"submitted_at"`
+	ReviewedBy          *string          `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt          *time.Time       `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	RejectionReason     *RejectionReason `json:"rejection_reason,omitempty" db:"rejection_reason"`
+	RejectionNote       *string          `json:"rejection_note,omitempty" db:"rejection_note"`
+	ModerationFlags     ModerationFlags  `json:"moderation_flags,omitempty" db:"moderation_flags"`
+	PublishAt            *time.Time           `json:"publish_at,omitempty" db:"publish_at"`
+	UnpublishAt          *time.Time           `json:"unpublish_at,omitempty" db:"unpublish_at"`
+	AvailabilitySchedule AvailabilitySchedule `json:"availability_schedule,omitempty" db:"availability_schedule"`
+	IsAvailable          bool                 `json:"is_available" db:"is_available"` // maintained by the availability scheduler
+	ProductType          ProductType          `json:"product_type" db:"product_type"`
+	FoodInfo             *FoodInfo            `json:"food_info,omitempty" db:"food_info"`
+	RatingAverage        decimal.Decimal      `json:"rating_average" db:"rating_average"` // of approved reviews, maintained by review moderation
+	RatingCount          int                  `json:"rating_count" db:"rating_count"`
+	DeletedAt            *time.Time           `json:"deleted_at,omitempty" db:"deleted_at"` // set while the product waits in the trash for restore or purge
+	DeletedBy            *string              `json:"deleted_by,omitempty" db:"deleted_by"`
+	MergedInto           *uuid.UUID           `json:"merged_into,omitempty" db:"merged_into"` // canonical product a duplicate was merged into
 	CreatedAt           time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt           time.Time       `json:"updated_at" db:"updated_at"`
 	
@@ -53,6 +138,305 @@ type Product struct {
 	Variants    []*ProductVariant `json:"variants,omitempty" db:"-"`
 	Media       []*ProductMedia   `json:"media,omitempty" db:"-"`
 	SellerData  []*SellerProduct  `json:"seller_data,omitempty" db:"-"`
+	Bundle      *Bundle           `json:"bundle,omitempty" db:"-"`
+	Offers      []*SellerOffer    `json:"offers,omitempty" db:"-"` // set by location-aware searches
+	Locale      string            `json:"locale,omitempty" db:"-"` // locale of Name when read with Accept-Language
+}
+
+// Product Type Enum
+type ProductType string
+
+const (
+	ProductTypeSimple ProductType = "SIMPLE"
+	ProductTypeBundle ProductType = "BUNDLE"
+)
+
+// Allergen Enum: the 14 allergens that must be declared under EU Regulation
+// 1169/2011 and the Turkish Food Codex labelling regulation
+type Allergen string
+
+const (
+	AllergenGluten      Allergen = "GLUTEN"
+	AllergenCrustaceans Allergen = "CRUSTACEANS"
+	AllergenEggs        Allergen = "EGGS"
+	AllergenFish        Allergen = "FISH"
+	AllergenPeanuts     Allergen = "PEANUTS"
+	AllergenSoybeans    Allergen = "SOYBEANS"
+	AllergenMilk        Allergen = "MILK"
+	AllergenNuts        Allergen = "NUTS"
+	AllergenCelery      Allergen = "CELERY"
+	AllergenMustard     Allergen = "MUSTARD"
+	AllergenSesame      Allergen = "SESAME"
+	AllergenSulphites   Allergen = "SULPHITES"
+	AllergenLupin       Allergen = "LUPIN"
+	AllergenMolluscs    Allergen = "MOLLUSCS"
+)
+
+// Dietary Label Enum
+type DietaryLabel string
+
+const (
+	DietaryLabelVegan       DietaryLabel = "VEGAN"
+	DietaryLabelVegetarian  DietaryLabel = "VEGETARIAN"
+	DietaryLabelGlutenFree  DietaryLabel = "GLUTEN_FREE"
+	DietaryLabelLactoseFree DietaryLabel = "LACTOSE_FREE"
+	DietaryLabelHalal       DietaryLabel = "HALAL"
+	DietaryLabelKosher      DietaryLabel = "KOSHER"
+	DietaryLabelOrganic     DietaryLabel = "ORGANIC"
+	DietaryLabelSugarFree   DietaryLabel = "SUGAR_FREE"
+)
+
+// FoodInfo holds the food labelling of a product, stored as JSONB.
+// MayContain lists allergens present only as possible traces.
+type FoodInfo struct {
+	Allergens     []Allergen      `json:"allergens" validate:"omitempty,dive,oneof=GLUTEN CRUSTACEANS EGGS FISH PEANUTS SOYBEANS MILK NUTS CELERY MUSTARD SESAME SULPHITES LUPIN MOLLUSCS"`
+	MayContain    []Allergen      `json:"may_contain" validate:"omitempty,dive,oneof=GLUTEN CRUSTACEANS EGGS FISH PEANUTS SOYBEANS MILK NUTS CELERY MUSTARD SESAME SULPHITES LUPIN MOLLUSCS"`
+	DietaryLabels []DietaryLabel  `json:"dietary_labels" validate:"omitempty,dive,oneof=VEGAN VEGETARIAN GLUTEN_FREE LACTOSE_FREE HALAL KOSHER ORGANIC SUGAR_FREE"`
+	Ingredients   []string        `json:"ingredients,omitempty" validate:"omitempty,max=200,dive,required,max=200"`
+	Nutrition     *NutritionFacts `json:"nutrition,omitempty" validate:"omitempty"`
+}
+
+func (f FoodInfo) Value() (driver.Value, error) {
+	// Empty food information is stored as none, so that food filters skip the product
+	if len(f.Allergens) == 0 && len(f.MayContain) == 0 && len(f.DietaryLabels) == 0 && len(f.Ingredients) == 0 && f.Nutrition == nil {
+		return nil, nil
+	}
+	return json.Marshal(f)
+}
+
+func (f *FoodInfo) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*f = FoodInfo{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	default:
+		return fmt.Errorf("cannot scan %T into FoodInfo", src)
+	}
+}
+
+// NutritionFacts is the nutrition declaration of a food product. Values are
+// given per 100 g (or 100 ml) and optionally per serving.
+type NutritionFacts struct {
+	Unit        string           `json:"unit" validate:"required,oneof=g ml"`
+	ServingSize *decimal.Decimal `json:"serving_size,omitempty"` // in Unit
+	Per100      *NutritionValues `json:"per_100" validate:"required"`
+	PerServing  *NutritionValues `json:"per_serving,omitempty"`
+}
+
+// NutritionValues are the mandatory nutrients of the declaration, in grams
+// except for energy
+type NutritionValues struct {
+	EnergyKJ      *decimal.Decimal `json:"energy_kj,omitempty"`
+	EnergyKcal    *decimal.Decimal `json:"energy_kcal,omitempty"`
+	Fat           *decimal.Decimal `json:"fat,omitempty"`
+	SaturatedFat  *decimal.Decimal `json:"saturated_fat,omitempty"`
+	Carbohydrates *decimal.Decimal `json:"carbohydrates,omitempty"`
+	Sugars        *decimal.Decimal `json:"sugars,omitempty"`
+	Fibre         *decimal.Decimal `json:"fibre,omitempty"`
+	Protein       *decimal.Decimal `json:"protein,omitempty"`
+	Salt          *decimal.Decimal `json:"salt,omitempty"`
+}
+
+// Bundle is the composition of a bundle product: components that are always
+// included and choice groups the customer picks options from
+type Bundle struct {
+	ProductID    uuid.UUID            `json:"product_id"`
+	Components   []*BundleComponent   `json:"components"`
+	ChoiceGroups []*BundleChoiceGroup `json:"choice_groups"`
+	Stock        int                  `json:"stock"` // derived from the components and required groups
+}
+
+// BundleComponent is a product or variant included in every bundle
+type BundleComponent struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	BundleID  uuid.UUID  `json:"bundle_id" db:"bundle_id"`
+	ProductID uuid.UUID  `json:"product_id" db:"product_id"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty" db:"variant_id"`
+	Quantity  int        `json:"quantity" db:"quantity"`
+	SortOrder int        `json:"sort_order" db:"sort_order"`
+	
+	// Computed fields
+	Name  string `json:"name" db:"-"`
+	Stock int    `json:"stock" db:"-"`
+}
+
+// BundleChoiceGroup is a set of options of which between MinSelect and
+// MaxSelect are chosen. Groups with a MinSelect above zero are required.
+type BundleChoiceGroup struct {
+	ID        uuid.UUID             `json:"id" db:"id"`
+	BundleID  uuid.UUID             `json:"bundle_id" db:"bundle_id"`
+	Name      string                `json:"name" db:"name"`
+	MinSelect int                   `json:"min_select" db:"min_select"`
+	MaxSelect int                   `json:"max_select" db:"max_select"`
+	SortOrder int                   `json:"sort_order" db:"sort_order"`
+	Options   []*BundleChoiceOption `json:"options" db:"-"`
+}
+
+// BundleChoiceOption is a product or variant that can be picked in a choice
+// group, changing the bundle price by PriceDelta
+type BundleChoiceOption struct {
+	ID         uuid.UUID       `json:"id" db:"id"`
+	GroupID    uuid.UUID       `json:"group_id" db:"group_id"`
+	ProductID  uuid.UUID       `json:"product_id" db:"product_id"`
+	VariantID  *uuid.UUID      `json:"variant_id,omitempty" db:"variant_id"`
+	Quantity   int             `json:"quantity" db:"quantity"`
+	PriceDelta decimal.Decimal `json:"price_delta" db:"price_delta"`
+	IsDefault  bool            `json:"is_default" db:"is_default"`
+	SortOrder  int             `json:"sort_order" db:"sort_order"`
+	
+	// Computed fields
+	Name  string `json:"name" db:"-"`
+	Stock int    `json:"stock" db:"-"`
+}
+
+// BundleSelection is a validated and priced set of choices for a bundle. The
+// order service stores it with the order line so the choices can be replayed.
+type BundleSelection struct {
+	ProductID  uuid.UUID                `json:"product_id"`
+	Name       string                   `json:"name"`
+	BasePrice  decimal.Decimal          `json:"base_price"`
+	Currency   string                   `json:"currency"`
+	Components []*BundleComponent       `json:"components"`
+	Options    []*SelectedBundleOption  `json:"options"`
+	TotalPrice decimal.Decimal          `json:"total_price"`
+}
+
+// SelectedBundleOption is an option chosen in a BundleSelection
+type SelectedBundleOption struct {
+	GroupID    uuid.UUID       `json:"group_id"`
+	GroupName  string          `json:"group_name"`
+	OptionID   uuid.UUID       `json:"option_id"`
+	ProductID  uuid.UUID       `json:"product_id"`
+	VariantID  *uuid.UUID      `json:"variant_id,omitempty"`
+	Name       string          `json:"name"`
+	Quantity   int             `json:"quantity"`
+	PriceDelta decimal.Decimal `json:"price_delta"`
+}
+
+// Product Status Enum
+type ProductStatus string
+
+const (
+	ProductStatusDraft         ProductStatus = "DRAFT"
+	ProductStatusPendingReview ProductStatus = "PENDING_REVIEW"
+	ProductStatusApproved      ProductStatus = "APPROVED"
+	ProductStatusRejected      ProductStatus = "REJECTED"
+)
+
+// Rejection Reason Enum
+type RejectionReason string
+
+const (
+	RejectionReasonBannedContent         RejectionReason = "BANNED_CONTENT"
+	RejectionReasonMissingImages         RejectionReason = "MISSING_IMAGES"
+	RejectionReasonPriceOutlier          RejectionReason = "PRICE_OUTLIER"
+	RejectionReasonWrongCategory         RejectionReason = "WRONG_CATEGORY"
+	RejectionReasonInaccurateInformation RejectionReason = "INACCURATE_INFORMATION"
+	RejectionReasonProhibitedProduct     RejectionReason = "PROHIBITED_PRODUCT"
+	RejectionReasonDuplicate             RejectionReason = "DUPLICATE"
+	RejectionReasonOther                 RejectionReason = "OTHER"
+)
+
+// RejectionReasons lists the reasons a moderator can reject a product for
+var RejectionReasons = []RejectionReason{
+	RejectionReasonBannedContent,
+	RejectionReasonMissingImages,
+	RejectionReasonPriceOutlier,
+	RejectionReasonWrongCategory,
+	RejectionReasonInaccurateInformation,
+	RejectionReasonProhibitedProduct,
+	RejectionReasonDuplicate,
+	RejectionReasonOther,
+}
+
+// Moderation Check Enum
+type ModerationCheck string
+
+const (
+	ModerationCheckBannedWords   ModerationCheck = "BANNED_WORDS"
+	ModerationCheckMissingImages ModerationCheck = "MISSING_IMAGES"
+	ModerationCheckPriceOutlier  ModerationCheck = "PRICE_OUTLIER"
+	ModerationCheckDuplicate     ModerationCheck = "POSSIBLE_DUPLICATE"
+)
+
+// ModerationFlag is a finding of an automatic moderation check
+type ModerationFlag struct {
+	Check   ModerationCheck `json:"check"`
+	Message string          `json:"message"`
+}
+
+// ModerationFlags holds the findings of the automatic checks, stored as JSONB
+type ModerationFlags []*ModerationFlag
+
+func (f ModerationFlags) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return json.Marshal(f)
+}
+
+func (f *ModerationFlags) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	default:
+		return fmt.Errorf("cannot scan %T into ModerationFlags", src)
+	}
+}
+
+// Weekday Enum
+type Weekday string
+
+const (
+	WeekdayMonday    Weekday = "MON"
+	WeekdayTuesday   Weekday = "TUE"
+	WeekdayWednesday Weekday = "WED"
+	WeekdayThursday  Weekday = "THU"
+	WeekdayFriday    Weekday = "FRI"
+	WeekdaySaturday  Weekday = "SAT"
+	WeekdaySunday    Weekday = "SUN"
+)
+
+// AvailabilityWindow is a weekly time range in the catalog's time zone. A
+// window that ends at or before its start runs past midnight into the next day.
+type AvailabilityWindow struct {
+	Days  []Weekday `json:"days" validate:"required,min=1,dive,oneof=MON TUE WED THU FRI SAT SUN"`
+	Start string    `json:"start" validate:"required,datetime=15:04"`
+	End   string    `json:"end" validate:"required,datetime=15:04"`
+}
+
+// AvailabilitySchedule lists the weekly windows an item can be ordered in,
+// stored as JSONB. An empty schedule means available all week.
+type AvailabilitySchedule []*AvailabilityWindow
+
+func (a AvailabilitySchedule) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *AvailabilitySchedule) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into AvailabilitySchedule", src)
+	}
 }
 
 // ProductVariant represents a variant of a product
@@ -63,10 +447,12 @@ type ProductVariant struct {
 	SKU         *string         `json:"sku,omitempty" db:"sku"`
 	Barcode     *string         `json:"barcode,omitempty" db:"barcode"`
 	Price       *decimal.Decimal `json:"price,omitempty" db:"price"`
+	CompareAtPrice *decimal.Decimal `json:"compare_at_price,omitempty" db:"compare_at_price"` // needs a price of the variant's own
+	ReferencePrice *decimal.Decimal `json:"reference_price,omitempty" db:"reference_price"`
 	Stock       *int            `json:"stock,omitempty" db:"stock"`
 	Weight      *decimal.Decimal `json:"weight,omitempty" db:"weight"`
 	Dimensions  *string         `json:"dimensions,omitempty" db:"dimensions"`
-	Attributes  map[string]interface{} `json:"attributes,omitempty" db:"attributes"`
+	Attributes  Attributes             `json:"attributes,omitempty" db:"attributes"`
 	IsActive    bool            `json:"is_active" db:"is_active"`
 	SortOrder   int             `json:"sort_order" db:"sort_order"`
 	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
@@ -76,6 +462,43 @@ type ProductVariant struct {
 	Media       []*ProductMedia   `json:"media,omitempty" db:"-"`
 }
 
+// ProductTranslation holds the text of a product in a locale other than the
+// default one, which is stored on the product itself
+type ProductTranslation struct {
+	ProductID   uuid.UUID `json:"product_id" db:"product_id"`
+	Locale      string    `json:"locale" db:"locale"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// VariantTranslation holds the name of a variant in a locale other than the default one
+type VariantTranslation struct {
+	VariantID uuid.UUID `json:"variant_id" db:"variant_id"`
+	Locale    string    `json:"locale" db:"locale"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CategoryTranslation holds the text of a category in a locale other than the default one
+type CategoryTranslation struct {
+	CategoryID  uuid.UUID `json:"category_id" db:"category_id"`
+	Locale      string    `json:"locale" db:"locale"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ProductTranslations lists the translations of a product and its variants
+type ProductTranslations struct {
+	DefaultLocale string                `json:"default_locale"`
+	Product       []*ProductTranslation `json:"product"`
+	Variants      []*VariantTranslation `json:"variants"`
+}
+
 // ProductMedia represents media files associated with products
 type ProductMedia struct {
 	ID          uuid.UUID  `json:"id" db:"id"`
@@ -89,10 +512,63 @@ type ProductMedia struct {
 	AltText     *string    `json:"alt_text,omitempty" db:"alt_text"`
 	SortOrder   int        `json:"sort_order" db:"sort_order"`
 	IsActive    bool       `json:"is_active" db:"is_active"`
+	ContentHash *string    `json:"content_hash,omitempty" db:"content_hash"`
+	PerceptualHash *int64  `json:"-" db:"perceptual_hash"` // difference hash of the processed image, for duplicate detection
+	Width       *int       `json:"width,omitempty" db:"width"`
+	Height      *int       `json:"height,omitempty" db:"height"`
+	Derivatives *MediaDerivatives     `json:"derivatives,omitempty" db:"derivatives"`
+	ProcessingStatus MediaProcessingStatus `json:"processing_status" db:"processing_status"`
+	ProcessingError  *string              `json:"processing_error,omitempty" db:"processing_error"`
+	RawObjectName    *string              `json:"-" db:"raw_object_name"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// Media Processing Status Enum
+type MediaProcessingStatus string
+
+const (
+	MediaProcessingStatusPending    MediaProcessingStatus = "PENDING"
+	MediaProcessingStatusProcessing MediaProcessingStatus = "PROCESSING"
+	MediaProcessingStatusReady      MediaProcessingStatus = "READY"
+	MediaProcessingStatusFailed     MediaProcessingStatus = "FAILED"
+)
+
+// MediaDerivatives holds the URLs of the resized renditions of an image
+type MediaDerivatives struct {
+	Thumbnail string `json:"thumbnail,omitempty"`
+	Medium    string `json:"medium,omitempty"`
+	Large     string `json:"large,omitempty"`
+	WebP      string `json:"webp,omitempty"`
+}
+
+// MediaUpload tracks a presigned direct upload until it is confirmed
+type MediaUpload struct {
+	ID           uuid.UUID         `json:"id" db:"id"`
+	ProductID    uuid.UUID         `json:"product_id" db:"product_id"`
+	VariantID    *uuid.UUID        `json:"variant_id,omitempty" db:"variant_id"`
+	ObjectName   string            `json:"-" db:"object_name"`
+	FileName     string            `json:"file_name" db:"file_name"`
+	ContentType  string            `json:"content_type" db:"content_type"`
+	FileSize     int64             `json:"file_size" db:"file_size"`
+	Status       MediaUploadStatus `json:"status" db:"status"`
+	MediaID      *uuid.UUID        `json:"media_id,omitempty" db:"media_id"`
+	ErrorMessage *string           `json:"error_message,omitempty" db:"error_message"`
+	ExpiresAt    time.Time         `json:"expires_at" db:"expires_at"`
+	CreatedAt    time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// Media Upload Status Enum
+type MediaUploadStatus string
+
+const (
+	MediaUploadStatusPending   MediaUploadStatus = "PENDING"
+	MediaUploadStatusConfirmed MediaUploadStatus = "CONFIRMED"
+	MediaUploadStatusFailed    MediaUploadStatus = "FAILED"
+	MediaUploadStatusExpired   MediaUploadStatus = "EXPIRED"
+)
+
 // SellerProduct represents seller-specific product data (overrides)
 type SellerProduct struct {
 	ID              uuid.UUID        `json:"id" db:"id"`
@@ -101,15 +577,152 @@ type SellerProduct struct {
 	VariantID       *uuid.UUID       `json:"variant_id,omitempty" db:"variant_id"`
 	SellerSKU       *string          `json:"seller_sku,omitempty" db:"seller_sku"`
 	Price           *decimal.Decimal `json:"price,omitempty" db:"price"`
+	CompareAtPrice  *decimal.Decimal `json:"compare_at_price,omitempty" db:"compare_at_price"` // needs a price of the listing's own
+	ReferencePrice  *decimal.Decimal `json:"reference_price,omitempty" db:"reference_price"`
 	Stock           *int             `json:"stock,omitempty" db:"stock"`
 	MinStock        *int             `json:"min_stock,omitempty" db:"min_stock"`
 	MaxStock        *int             `json:"max_stock,omitempty" db:"max_stock"`
 	IsActive        bool             `json:"is_active" db:"is_active"`
 	IsVisible       bool             `json:"is_visible" db:"is_visible"`
+	HiddenByStock   bool             `json:"hidden_by_stock" db:"hidden_by_stock"`
+	PublishAt            *time.Time           `json:"publish_at,omitempty" db:"publish_at"`
+	UnpublishAt          *time.Time           `json:"unpublish_at,omitempty" db:"unpublish_at"`
+	AvailabilitySchedule AvailabilitySchedule `json:"availability_schedule,omitempty" db:"availability_schedule"`
+	IsAvailable          bool                 `json:"is_available" db:"is_available"` // maintained by the availability scheduler
 	PreparationTime *int             `json:"preparation_time,omitempty" db:"preparation_time"`
 	Notes           *string          `json:"notes,omitempty" db:"notes"`
 	CreatedAt       time.Time        `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time        `json:"updated_at" db:"updated_at"`
+	
+	// Computed fields
+	Product      *Product         `json:"product,omitempty" db:"-"`
+	VariantName  *string          `json:"variant_name,omitempty" db:"-"`
+	VariantPrice *decimal.Decimal `json:"variant_price,omitempty" db:"-"` // used when the listing has no price
+	VariantStock *int             `json:"variant_stock,omitempty" db:"-"` // used when the listing has no stock
+	StockState   StockState       `json:"stock_state,omitempty" db:"-"`
+}
+
+// Stock State Enum
+type StockState string
+
+const (
+	StockStateInStock  StockState = "IN_STOCK"
+	StockStateLow      StockState = "LOW"
+	StockStateDepleted StockState = "DEPLETED"
+)
+
+// StockStateFor classifies a stock level against its MinStock threshold
+func StockStateFor(stock, minStock int) StockState {
+	switch {
+	case stock <= 0:
+		return StockStateDepleted
+	case stock <= minStock:
+		return StockStateLow
+	default:
+		return StockStateInStock
+	}
+}
+
+// LowStockItem represents a seller listing at or below its MinStock threshold
+type LowStockItem struct {
+	SellerProductID uuid.UUID  `json:"seller_product_id" db:"seller_product_id"`
+	ProductID       uuid.UUID  `json:"product_id" db:"product_id"`
+	VariantID       *uuid.UUID `json:"variant_id,omitempty" db:"variant_id"`
+	ProductName     string     `json:"product_name" db:"product_name"`
+	VariantName     *string    `json:"variant_name,omitempty" db:"variant_name"`
+	SellerSKU       *string    `json:"seller_sku,omitempty" db:"seller_sku"`
+	Stock           int        `json:"stock" db:"stock"`
+	MinStock        int        `json:"min_stock" db:"min_stock"`
+	MaxStock        *int       `json:"max_stock,omitempty" db:"max_stock"`
+	State           StockState `json:"state" db:"-"`
+	IsVisible       bool       `json:"is_visible" db:"is_visible"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// SellerLocation is a store a seller fulfils orders from
+type SellerLocation struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	SellerID  uuid.UUID `json:"seller_id" db:"seller_id"`
+	Name      string    `json:"name" db:"name"`
+	Address   *string   `json:"address,omitempty" db:"address"`
+	Latitude  float64   `json:"latitude" db:"latitude"`
+	Longitude float64   `json:"longitude" db:"longitude"`
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// Computed fields
+	Zones []*DeliveryZone `json:"zones" db:"-"`
+}
+
+// Delivery Zone Type Enum
+type DeliveryZoneType string
+
+const (
+	DeliveryZoneTypeRadius  DeliveryZoneType = "RADIUS"  // within RadiusKm of the location
+	DeliveryZoneTypePolygon DeliveryZoneType = "POLYGON" // inside Polygon
+)
+
+// GeoPoint is a WGS 84 coordinate
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude" validate:"gte=-90,lte=90"`
+	Longitude float64 `json:"longitude" validate:"gte=-180,lte=180"`
+}
+
+// GeoPolygon is the outline of an area as its vertices in order. The ring
+// closes by itself, so the first vertex need not be repeated at the end.
+type GeoPolygon []GeoPoint
+
+func (p GeoPolygon) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
+
+func (p *GeoPolygon) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return fmt.Errorf("cannot scan %T into GeoPolygon", src)
+	}
+}
+
+// DeliveryZone is an area a seller location delivers to
+type DeliveryZone struct {
+	ID         uuid.UUID        `json:"id" db:"id"`
+	LocationID uuid.UUID        `json:"location_id" db:"location_id"`
+	Name       string           `json:"name" db:"name"`
+	Type       DeliveryZoneType `json:"type" db:"zone_type"`
+	RadiusKm   *float64         `json:"radius_km,omitempty" db:"radius_km"`
+	Polygon    GeoPolygon       `json:"polygon,omitempty" db:"polygon"`
+	IsActive   bool             `json:"is_active" db:"is_active"`
+	CreatedAt  time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// SellerOffer is a seller listing that delivers to the shopper's location.
+// Price, Stock and PreparationTime are the seller's overrides where set and
+// the variant's or product's values otherwise.
+type SellerOffer struct {
+	SellerProductID uuid.UUID       `json:"seller_product_id"`
+	SellerID        uuid.UUID       `json:"seller_id"`
+	ProductID       uuid.UUID       `json:"product_id"`
+	VariantID       *uuid.UUID      `json:"variant_id,omitempty"`
+	VariantName     *string         `json:"variant_name,omitempty"`
+	Price           decimal.Decimal `json:"price"`
+	Currency        string          `json:"currency"`
+	Stock           int             `json:"stock"`
+	PreparationTime int             `json:"preparation_time"` // in minutes
+	LocationID      uuid.UUID       `json:"location_id"`      // nearest location serving the shopper
+	LocationName    string          `json:"location_name"`
+	DistanceKm      float64         `json:"distance_km"`
 }
 
 // DTOs for API requests/responses
@@ -131,6 +744,71 @@ type UpdateCategoryRequest struct {
 	IsActive    *bool      `json:"is_active,omitempty"`
 }
 
+// MoveCategoryRequest moves a category with its subtree; a nil parent moves it to the root
+type MoveCategoryRequest struct {
+	ParentID  *uuid.UUID `json:"parent_id"`
+	SortOrder *int       `json:"sort_order,omitempty"`
+}
+
+// ReorderCategoriesRequest sets the order of the children of a parent; a nil parent reorders the roots.
+// Siblings that are not listed keep their relative order after the listed ones.
+type ReorderCategoriesRequest struct {
+	ParentID    *uuid.UUID  `json:"parent_id"`
+	CategoryIDs []uuid.UUID `json:"category_ids" validate:"required,min=1,max=500"`
+}
+
+// BundleRequest replaces the composition of a bundle product
+type BundleRequest struct {
+	Components   []*BundleComponentRequest   `json:"components" validate:"omitempty,max=50,dive"`
+	ChoiceGroups []*BundleChoiceGroupRequest `json:"choice_groups" validate:"omitempty,max=20,dive"`
+}
+
+type BundleComponentRequest struct {
+	ProductID uuid.UUID  `json:"product_id" validate:"required"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty"`
+	Quantity  int        `json:"quantity" validate:"required,min=1,max=100"`
+}
+
+type BundleChoiceGroupRequest struct {
+	Name      string                       `json:"name" validate:"required,min=1,max=100"`
+	MinSelect int                          `json:"min_select" validate:"gte=0"`
+	MaxSelect int                          `json:"max_select" validate:"required,min=1,gtefield=MinSelect"`
+	Options   []*BundleChoiceOptionRequest `json:"options" validate:"required,min=1,max=50,dive"`
+}
+
+type BundleChoiceOptionRequest struct {
+	ProductID  uuid.UUID       `json:"product_id" validate:"required"`
+	VariantID  *uuid.UUID      `json:"variant_id,omitempty"`
+	Quantity   int             `json:"quantity" validate:"required,min=1,max=100"`
+	PriceDelta decimal.Decimal `json:"price_delta"`
+	IsDefault  bool            `json:"is_default"`
+}
+
+// BundleSelectionRequest lists the options chosen per choice group. Groups
+// that are left out get their default options.
+type BundleSelectionRequest struct {
+	Choices []*BundleChoice `json:"choices" validate:"omitempty,dive"`
+}
+
+type BundleChoice struct {
+	GroupID   uuid.UUID   `json:"group_id" validate:"required"`
+	OptionIDs []uuid.UUID `json:"option_ids"`
+}
+
+type CategoryAttributeRequest struct {
+	Code         string           `json:"code" validate:"required,min=1,max=50"`
+	Name         string           `json:"name" validate:"required,min=1,max=100"`
+	Type         AttributeType    `json:"type" validate:"required,oneof=ENUM NUMBER BOOLEAN"`
+	Unit         *string          `json:"unit,omitempty" validate:"omitempty,max=20"`
+	Options      []string         `json:"options,omitempty"`
+	MinValue     *decimal.Decimal `json:"min_value,omitempty"`
+	MaxValue     *decimal.Decimal `json:"max_value,omitempty"`
+	IsRequired   bool             `json:"is_required"`
+	IsVariant    bool             `json:"is_variant"`
+	IsFilterable bool             `json:"is_filterable"`
+	SortOrder    int              `json:"sort_order"`
+}
+
 type CreateProductRequest struct {
 	Name                string                 `json:"name" validate:"required,min=2,max=200"`
 	Description         *string                `json:"description,omitempty"`
@@ -147,10 +825,21 @@ type CreateProductRequest struct {
 	Weight              *decimal.Decimal       `json:"weight,omitempty"`
 	Dimensions          *string                `json:"dimensions,omitempty"`
 	Tags                []string               `json:"tags,omitempty"`
-	Attributes          map[string]interface{} `json:"attributes,omitempty"`
+	Attributes          Attributes             `json:"attributes,omitempty"`
 	IsExpressDelivery   bool                   `json:"is_express_delivery"`
 	PreparationTime     int                    `json:"preparation_time"`
 	Variants            []*CreateVariantRequest `json:"variants,omitempty"`
+	
+	// New products start as drafts and go live once a moderator approves them
+	SellerID            *uuid.UUID             `json:"seller_id,omitempty"`
+	SubmitForReview     bool                   `json:"submit_for_review"`
+	
+	// Availability window; the product is hidden outside it
+	PublishAt            *time.Time           `json:"publish_at,omitempty"`
+	UnpublishAt          *time.Time           `json:"unpublish_at,omitempty"`
+	AvailabilitySchedule AvailabilitySchedule `json:"availability_schedule,omitempty" validate:"omitempty,dive"`
+	
+	FoodInfo             *FoodInfo            `json:"food_info,omitempty" validate:"omitempty"`
 }
 
 type CreateVariantRequest struct {
@@ -158,10 +847,11 @@ type CreateVariantRequest struct {
 	SKU        *string                `json:"sku,omitempty"`
 	Barcode    *string                `json:"barcode,omitempty"`
 	Price      *decimal.Decimal       `json:"price,omitempty"`
+	CompareAtPrice *decimal.Decimal   `json:"-"` // import column for variants that exist; new ones have no price history
 	Stock      *int                   `json:"stock,omitempty"`
 	Weight     *decimal.Decimal       `json:"weight,omitempty"`
 	Dimensions *string                `json:"dimensions,omitempty"`
-	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Attributes Attributes             `json:"attributes,omitempty"`
 	SortOrder  int                    `json:"sort_order"`
 }
 
@@ -173,6 +863,7 @@ type UpdateProductRequest struct {
 	SKU                 *string                `json:"sku,omitempty"`
 	Barcode             *string                `json:"barcode,omitempty"`
 	BasePrice           *decimal.Decimal       `json:"base_price,omitempty" validate:"omitempty,gt=0"`
+	CompareAtPrice      *decimal.Decimal       `json:"compare_at_price,omitempty"` // 0 removes it
 	Currency            *string                `json:"currency,omitempty" validate:"omitempty,len=3"`
 	TaxRate             *decimal.Decimal       `json:"tax_rate,omitempty" validate:"omitempty,gte=0,lte=100"`
 	BaseStock           *int                   `json:"base_stock,omitempty" validate:"omitempty,gte=0"`
@@ -181,15 +872,17 @@ type UpdateProductRequest struct {
 	Weight              *decimal.Decimal       `json:"weight,omitempty"`
 	Dimensions          *string                `json:"dimensions,omitempty"`
 	Tags                []string               `json:"tags,omitempty"`
-	Attributes          map[string]interface{} `json:"attributes,omitempty"`
+	Attributes          Attributes             `json:"attributes,omitempty"`
 	IsActive            *bool                  `json:"is_active,omitempty"`
 	IsExpressDelivery   *bool                  `json:"is_express_delivery,omitempty"`
 	PreparationTime     *int                   `json:"preparation_time,omitempty"`
+	FoodInfo            *FoodInfo              `json:"food_info,omitempty" validate:"omitempty"`
 }
 
 type SellerProductRequest struct {
 	SellerSKU       *string          `json:"seller_sku,omitempty"`
 	Price           *decimal.Decimal `json:"price,omitempty"`
+	CompareAtPrice  *decimal.Decimal `json:"compare_at_price,omitempty"`
 	Stock           *int             `json:"stock,omitempty"`
 	MinStock        *int             `json:"min_stock,omitempty"`
 	MaxStock        *int             `json:"max_stock,omitempty"`
@@ -197,26 +890,181 @@ type SellerProductRequest struct {
 	IsVisible       *bool            `json:"is_visible,omitempty"`
 	PreparationTime *int             `json:"preparation_time,omitempty"`
 	Notes           *string          `json:"notes,omitempty"`
+	PublishAt            *time.Time           `json:"publish_at,omitempty"`
+	UnpublishAt          *time.Time           `json:"unpublish_at,omitempty"`
+	AvailabilitySchedule AvailabilitySchedule `json:"availability_schedule,omitempty" validate:"omitempty,dive"`
+}
+
+// SellerLocationRequest creates or replaces a seller location. IsActive
+// defaults to true.
+type SellerLocationRequest struct {
+	Name      string   `json:"name" validate:"required,min=2,max=100"`
+	Address   *string  `json:"address,omitempty" validate:"omitempty,max=500"`
+	Latitude  *float64 `json:"latitude" validate:"required,gte=-90,lte=90"`
+	Longitude *float64 `json:"longitude" validate:"required,gte=-180,lte=180"`
+	IsActive  *bool    `json:"is_active,omitempty"`
+}
+
+// DeliveryZoneRequest creates or replaces a delivery zone. Radius zones take
+// RadiusKm, polygon zones take Polygon. IsActive defaults to true.
+type DeliveryZoneRequest struct {
+	Name     string           `json:"name" validate:"required,min=2,max=100"`
+	Type     DeliveryZoneType `json:"type" validate:"required,oneof=RADIUS POLYGON"`
+	RadiusKm *float64         `json:"radius_km,omitempty" validate:"omitempty,gt=0,lte=100"`
+	Polygon  GeoPolygon       `json:"polygon,omitempty" validate:"omitempty,max=500,dive"`
+	IsActive *bool            `json:"is_active,omitempty"`
+}
+
+// ProductAvailabilityRequest replaces a product's availability window.
+// Omitted fields are cleared.
+type ProductAvailabilityRequest struct {
+	PublishAt            *time.Time           `json:"publish_at,omitempty"`
+	UnpublishAt          *time.Time           `json:"unpublish_at,omitempty"`
+	AvailabilitySchedule AvailabilitySchedule `json:"availability_schedule,omitempty" validate:"omitempty,dive"`
+}
+
+// TranslationRequest sets the text of a product, variant or category in one
+// locale. Variants only take a name.
+type TranslationRequest struct {
+	Name        string  `json:"name" validate:"required,min=1,max=200"`
+	Description *string `json:"description,omitempty"`
+}
+
+type RejectProductRequest struct {
+	Reason RejectionReason `json:"reason" validate:"required,oneof=BANNED_CONTENT MISSING_IMAGES PRICE_OUTLIER WRONG_CATEGORY INACCURATE_INFORMATION PROHIBITED_PRODUCT DUPLICATE OTHER"`
+	Note   *string         `json:"note,omitempty" validate:"omitempty,max=1000"`
+}
+
+type ModerationQueueRequest struct {
+	Status   ProductStatus    `validate:"required,oneof=DRAFT PENDING_REVIEW APPROVED REJECTED"`
+	SellerID *uuid.UUID
+	Check    *ModerationCheck `validate:"omitempty,oneof=BANNED_WORDS MISSING_IMAGES PRICE_OUTLIER POSSIBLE_DUPLICATE"`
+	Page     int
+	Limit    int
+}
+
+type ModerationQueueResponse struct {
+	Products   []*Product `json:"products"`
+	Total      int64      `json:"total"`
+	Page       int        `json:"page"`
+	Limit      int        `json:"limit"`
+	TotalPages int        `json:"total_pages"`
+}
+
+// Import Job Status Enum
+type ImportJobStatus string
+
+const (
+	ImportJobStatusPending   ImportJobStatus = "PENDING"
+	ImportJobStatusRunning   ImportJobStatus = "RUNNING"
+	ImportJobStatusCompleted ImportJobStatus = "COMPLETED"
+	ImportJobStatusFailed    ImportJobStatus = "FAILED"
+)
+
+// Import Row Status Enum
+type ImportRowStatus string
+
+const (
+	ImportRowStatusValid   ImportRowStatus = "VALID"
+	ImportRowStatusCreated ImportRowStatus = "CREATED"
+	ImportRowStatusUpdated ImportRowStatus = "UPDATED"
+	ImportRowStatusFailed  ImportRowStatus = "FAILED"
+)
+
+// Import Row Action Enum
+type ImportRowAction string
+
+const (
+	ImportRowActionCreate ImportRowAction = "CREATE"
+	ImportRowActionUpdate ImportRowAction = "UPDATE"
+)
+
+// ImportJob represents an asynchronous bulk product import
+type ImportJob struct {
+	ID            uuid.UUID         `json:"id" db:"id"`
+	SellerID      uuid.UUID         `json:"seller_id" db:"seller_id"`
+	Status        ImportJobStatus   `json:"status" db:"status"`
+	Format        string            `json:"format" db:"format"`
+	DryRun        bool              `json:"dry_run" db:"dry_run"`
+	MatchBy       string            `json:"match_by" db:"match_by"`
+	HeaderMapping map[string]string `json:"header_mapping,omitempty" db:"header_mapping"`
+	FileName      string            `json:"file_name" db:"file_name"`
+	ObjectName    string            `json:"-" db:"object_name"`
+	TotalRows     int               `json:"total_rows" db:"total_rows"`
+	ProcessedRows int               `json:"processed_rows" db:"processed_rows"`
+	CreatedCount  int               `json:"created_count" db:"created_count"`
+	UpdatedCount  int               `json:"updated_count" db:"updated_count"`
+	ErrorCount    int               `json:"error_count" db:"error_count"`
+	ErrorMessage  *string           `json:"error_message,omitempty" db:"error_message"`
+	StartedAt     *time.Time        `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt   *time.Time        `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// ImportJobRow records the outcome of a single row of an import job
+type ImportJobRow struct {
+	ID        uuid.UUID        `json:"id" db:"id"`
+	JobID     uuid.UUID        `json:"job_id" db:"job_id"`
+	RowNumber int              `json:"row_number" db:"row_number"`
+	Status    ImportRowStatus  `json:"status" db:"status"`
+	Action    *ImportRowAction `json:"action,omitempty" db:"action"`
+	ProductID *uuid.UUID       `json:"product_id,omitempty" db:"product_id"`
+	VariantID *uuid.UUID       `json:"variant_id,omitempty" db:"variant_id"`
+	MatchKey  *string          `json:"match_key,omitempty" db:"match_key"`
+	Field     *string          `json:"field,omitempty" db:"field"`
+	Message   *string          `json:"message,omitempty" db:"message"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+}
+
+type CreateImportJobRequest struct {
+	SellerID      uuid.UUID         `json:"seller_id" validate:"required"`
+	FileName      string            `json:"file_name" validate:"required"`
+	Format        string            `json:"format" validate:"required,oneof=csv xlsx"`
+	DryRun        bool              `json:"dry_run"`
+	MatchBy       string            `json:"match_by" validate:"required,oneof=sku barcode"`
+	HeaderMapping map[string]string `json:"header_mapping,omitempty"`
+}
+
+type ImportJobRowsResponse struct {
+	Rows       []*ImportJobRow `json:"rows"`
+	Total      int64           `json:"total"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	TotalPages int             `json:"total_pages"`
+}
+
+type CreateMediaUploadRequest struct {
+	VariantID   *uuid.UUID `json:"variant_id,omitempty"`
+	FileName    string     `json:"file_name" validate:"required"`
+	ContentType string     `json:"content_type" validate:"required"`
+	FileSize    int64      `json:"file_size" validate:"required,gt=0"`
 }
 
-type BulkImportRequest struct {
-	SellerID uuid.UUID `json:"seller_id" validate:"required"`
-	Data     []byte    `json:"data" validate:"required"`
+// PresignedUpload tells the client where and how to upload a media file
+type PresignedUpload struct {
+	Upload    *MediaUpload      `json:"upload"`
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
 }
 
-type BulkImportResponse struct {
-	Success      bool                    `json:"success"`
-	Message      string                  `json:"message"`
-	ProcessedCount int                   `json:"processed_count"`
-	SuccessCount   int                   `json:"success_count"`
-	ErrorCount     int                   `json:"error_count"`
-	Errors         []BulkImportError     `json:"errors,omitempty"`
+// CatalogExportRequest selects the products included in a catalog export
+type CatalogExportRequest struct {
+	SellerID        *uuid.UUID `json:"seller_id,omitempty"`
+	CategoryID      *uuid.UUID `json:"category_id,omitempty"`
+	Format          string     `json:"format" validate:"required,oneof=csv xlsx"`
+	IncludeInactive bool       `json:"include_inactive"`
 }
 
-type BulkImportError struct {
-	Row     int    `json:"row"`
-	Field   string `json:"field"`
-	Message string `json:"message"`
+// MerchantFeed describes a generated Google Merchant Center product feed
+type MerchantFeed struct {
+	Format       string    `json:"format"`
+	URL          string    `json:"url"`
+	ItemCount    int       `json:"item_count"`
+	SkippedCount int       `json:"skipped_count"`
+	GeneratedAt  time.Time `json:"generated_at"`
 }
 
 type SearchRequest struct {
@@ -229,18 +1077,572 @@ type SearchRequest struct {
 	Brand       *string    `json:"brand,omitempty"`
 	IsActive    *bool      `json:"is_active,omitempty"`
 	ExpressOnly bool       `json:"express_only"`
+	Latitude    *float64   `json:"latitude,omitempty" validate:"omitempty,gte=-90,lte=90"` // with Longitude, limits results to sellers delivering there
+	Longitude   *float64   `json:"longitude,omitempty" validate:"omitempty,gte=-180,lte=180"`
+	AttributeFilters []*AttributeFilter `json:"attribute_filters,omitempty"`
+	FreeFrom      []Allergen     `json:"free_from,omitempty" validate:"omitempty,dive,oneof=GLUTEN CRUSTACEANS EGGS FISH PEANUTS SOYBEANS MILK NUTS CELERY MUSTARD SESAME SULPHITES LUPIN MOLLUSCS"` // excludes products containing or possibly containing these
+	DietaryLabels []DietaryLabel `json:"dietary_labels,omitempty" validate:"omitempty,dive,oneof=VEGAN VEGETARIAN GLUTEN_FREE LACTOSE_FREE HALAL KOSHER ORGANIC SUGAR_FREE"` // all must apply
 	Page        int        `json:"page" validate:"min=1"`
 	Limit       int        `json:"limit" validate:"min=1,max=100"`
-	SortBy      string     `json:"sort_by" validate:"oneof=name price created_at updated_at"`
+	SortBy      string     `json:"sort_by" validate:"oneof=relevance name price rating created_at updated_at"` // relevance applies merchandising rules, then newest first
 	SortOrder   string     `json:"sort_order" validate:"oneof=asc desc"`
+	Cursor       string        `json:"cursor,omitempty"` // next_cursor of the previous page; replaces Page
+	IncludeTotal bool          `json:"include_total"`
+	After        *SearchCursor `json:"-"` // decoded Cursor
+	QueryTerms   [][]string    `json:"-"` // Query after stopwords and synonyms: each group must match one of its terms
+	Ranking      []*RankTier   `json:"-"` // merchandising tiers of a relevance sort
+}
+
+// SearchCursor is the position of the last product of a page in the sort
+// order of a search: the value of the sort column and the ID that breaks ties
+type SearchCursor struct {
+	SortBy    string    `json:"s"`
+	SortOrder string    `json:"o"`
+	Value     string    `json:"v"`
+	Rank      int       `json:"r,omitempty"` // merchandising tier of a relevance sort
+	ID        uuid.UUID `json:"id"`
+}
+
+// RankTier places the products it matches, by ID or by brand, ahead of the
+// rest of a relevance sort when Rank is negative and behind it when positive.
+// Lower ranks come first.
+type RankTier struct {
+	Rank       int
+	ProductIDs []uuid.UUID
+	Brands     []string // lower case
+}
+
+type SellerProductListRequest struct {
+	SellerID   uuid.UUID   `json:"seller_id" validate:"required"`
+	IsVisible  *bool       `json:"is_visible,omitempty"`
+	IsAvailable *bool      `json:"is_available,omitempty"`
+	StockState *StockState `json:"stock_state,omitempty" validate:"omitempty,oneof=IN_STOCK LOW DEPLETED"`
+	CategoryID *uuid.UUID  `json:"category_id,omitempty"`
+	Page       int         `json:"page" validate:"min=1"`
+	Limit      int         `json:"limit" validate:"min=1,max=100"`
+	SortOrder  string      `json:"sort_order" validate:"oneof=asc desc"`
+}
+
+type SellerProductListResponse struct {
+	Items      []*SellerProduct `json:"items"`
+	Total      int64            `json:"total"`
+	Page       int              `json:"page"`
+	Limit      int              `json:"limit"`
+	TotalPages int              `json:"total_pages"`
 }
 
 type SearchResponse struct {
 	Products    []*Product `json:"products"`
 	Total       int64      `json:"total"`
-	Page        int        `json:"page"`
+	TotalApproximate bool  `json:"total_approximate,omitempty"` // Total is the planner's estimate
+	Page        int        `json:"page,omitempty"`
 	Limit       int        `json:"limit"`
 	TotalPages  int        `json:"total_pages"`
+	NextCursor  string     `json:"next_cursor,omitempty"` // empty on the last page
+	Facets      []*AttributeFacet `json:"facets,omitempty"`
+}
+
+// AttributeFilter restricts a search to products whose attribute, on the
+// product or on one of its variants, has one of Values or lies within Min-Max
+type AttributeFilter struct {
+	Code   string           `json:"code"`
+	Values []string         `json:"values,omitempty"`
+	Min    *decimal.Decimal `json:"min,omitempty"`
+	Max    *decimal.Decimal `json:"max,omitempty"`
+}
+
+// AttributeFacet summarizes the values of a filterable attribute in search results
+type AttributeFacet struct {
+	Code   string           `json:"code"`
+	Name   string           `json:"name"`
+	Type   AttributeType    `json:"type"`
+	Unit   *string          `json:"unit,omitempty"`
+	Values []*FacetValue    `json:"values,omitempty"`
+	Min    *decimal.Decimal `json:"min,omitempty"`
+	Max    *decimal.Decimal `json:"max,omitempty"`
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// StockAlertEvent is published when stock crosses below MinStock or hits zero
+type StockAlertEvent struct {
+	EventType string     `json:"event_type"`
+	ProductID uuid.UUID  `json:"product_id"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty"`
+	SellerID  *uuid.UUID `json:"seller_id,omitempty"`
+	Stock     int        `json:"stock"`
+	MinStock  int        `json:"min_stock"`
+	State     StockState `json:"state"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// Revision Entity Type Enum
+type RevisionEntityType string
+
+const (
+	RevisionEntityProduct       RevisionEntityType = "PRODUCT"
+	RevisionEntityVariant       RevisionEntityType = "VARIANT"
+	RevisionEntitySellerProduct RevisionEntityType = "SELLER_PRODUCT"
+)
+
+// Revision Action Enum
+type RevisionAction string
+
+const (
+	RevisionActionCreate  RevisionAction = "CREATE"
+	RevisionActionUpdate  RevisionAction = "UPDATE"
+	RevisionActionDelete  RevisionAction = "DELETE"
+	RevisionActionRestore RevisionAction = "RESTORE"
+)
+
+// FieldChange is the value of a field before and after a change
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// FieldChanges is a field-level diff keyed by JSON field name, stored as JSONB
+type FieldChanges map[string]*FieldChange
+
+func (c FieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *FieldChanges) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("cannot scan %T into FieldChanges", src)
+	}
+}
+
+// Revision is an append-only record of a change to a product, variant or
+// seller override. Snapshot holds the entity's state after the change, or
+// before it for deletions.
+type Revision struct {
+	ID           uuid.UUID          `json:"id" db:"id"`
+	EntityType   RevisionEntityType `json:"entity_type" db:"entity_type"`
+	EntityID     uuid.UUID          `json:"entity_id" db:"entity_id"`
+	ProductID    uuid.UUID          `json:"product_id" db:"product_id"`
+	Revision     int                `json:"revision" db:"revision"`
+	Action       RevisionAction     `json:"action" db:"action"`
+	Actor        string             `json:"actor" db:"actor"`
+	Changes      FieldChanges       `json:"changes,omitempty" db:"changes"`
+	Snapshot     json.RawMessage    `json:"snapshot,omitempty" db:"snapshot"`
+	RestoredFrom *uuid.UUID         `json:"restored_from,omitempty" db:"restored_from"`
+	CreatedAt    time.Time          `json:"created_at" db:"created_at"`
+}
+
+type RevisionListRequest struct {
+	ProductID  uuid.UUID
+	EntityType *RevisionEntityType
+	EntityID   *uuid.UUID
+	Page       int
+	Limit      int
+}
+
+type RevisionListResponse struct {
+	Revisions  []*Revision `json:"revisions"`
+	Total      int64       `json:"total"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	TotalPages int         `json:"total_pages"`
+}
+
+// DeletedProductListResponse is a page of the product trash
+type DeletedProductListResponse struct {
+	Products   []*Product `json:"products"`
+	Total      int64      `json:"total"`
+	Page       int        `json:"page"`
+	Limit      int        `json:"limit"`
+	TotalPages int        `json:"total_pages"`
+}
+
+// RevisionComparison is the diff between two revisions of the same entity
+type RevisionComparison struct {
+	From    *Revision    `json:"from"`
+	To      *Revision    `json:"to"`
+	Changes FieldChanges `json:"changes"`
+}
+
+// Review Status Enum
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "PENDING"
+	ReviewStatusApproved ReviewStatus = "APPROVED"
+	ReviewStatusRejected ReviewStatus = "REJECTED"
+)
+
+// Review is a customer's rating and review of a product from a delivered order
+type Review struct {
+	ID              uuid.UUID    `json:"id" db:"id"`
+	ProductID       uuid.UUID    `json:"product_id" db:"product_id"`
+	VariantID       *uuid.UUID   `json:"variant_id,omitempty" db:"variant_id"`
+	OrderID         uuid.UUID    `json:"order_id" db:"order_id"`
+	CustomerID      uuid.UUID    `json:"customer_id" db:"customer_id"`
+	SellerID        uuid.UUID    `json:"seller_id" db:"seller_id"`
+	Rating          int          `json:"rating" db:"rating"`
+	Title           *string      `json:"title,omitempty" db:"title"`
+	Body            *string      `json:"body,omitempty" db:"body"`
+	Photos          ReviewPhotos `json:"photos" db:"photos"`
+	Status          ReviewStatus `json:"status" db:"status"`
+	ModeratedBy     *string      `json:"moderated_by,omitempty" db:"moderated_by"`
+	ModeratedAt     *time.Time   `json:"moderated_at,omitempty" db:"moderated_at"`
+	ModerationNote  *string      `json:"moderation_note,omitempty" db:"moderation_note"`
+	SellerReply     *string      `json:"seller_reply,omitempty" db:"seller_reply"`
+	SellerRepliedAt *time.Time   `json:"seller_replied_at,omitempty" db:"seller_replied_at"`
+	HelpfulCount    int          `json:"helpful_count" db:"helpful_count"`
+	NotHelpfulCount int          `json:"not_helpful_count" db:"not_helpful_count"`
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// ReviewPhoto is a photo attached to a review, re-encoded without its metadata
+type ReviewPhoto struct {
+	ID           uuid.UUID `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+}
+
+type ReviewPhotos []*ReviewPhoto
+
+func (p ReviewPhotos) Value() (driver.Value, error) {
+	if p == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(p)
+}
+
+func (p *ReviewPhotos) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*p = ReviewPhotos{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return fmt.Errorf("cannot scan %T into ReviewPhotos", src)
+	}
+}
+
+// ReviewEligibility is an order item delivered to a customer, who may review
+// the product once for the order
+type ReviewEligibility struct {
+	OrderID     uuid.UUID  `json:"order_id" db:"order_id"`
+	ProductID   uuid.UUID  `json:"product_id" db:"product_id"`
+	VariantID   *uuid.UUID `json:"variant_id,omitempty" db:"variant_id"`
+	CustomerID  uuid.UUID  `json:"customer_id" db:"customer_id"`
+	SellerID    uuid.UUID  `json:"seller_id" db:"seller_id"`
+	DeliveredAt time.Time  `json:"delivered_at" db:"delivered_at"`
+}
+
+type CreateReviewRequest struct {
+	OrderID uuid.UUID `json:"order_id" validate:"required"`
+	Rating  int       `json:"rating" validate:"required,min=1,max=5"`
+	Title   *string   `json:"title,omitempty" validate:"omitempty,max=150"`
+	Body    *string   `json:"body,omitempty" validate:"omitempty,max=5000"`
+}
+
+type ReviewReplyRequest struct {
+	Reply string `json:"reply" validate:"required,min=2,max=2000"`
+}
+
+type ReviewVoteRequest struct {
+	Helpful *bool `json:"helpful" validate:"required"`
+}
+
+type RejectReviewRequest struct {
+	Note *string `json:"note,omitempty" validate:"omitempty,max=1000"`
+}
+
+type ReviewListRequest struct {
+	ProductID  *uuid.UUID
+	SellerID   *uuid.UUID
+	Status     ReviewStatus `validate:"required,oneof=PENDING APPROVED REJECTED"`
+	Rating     *int         `validate:"omitempty,min=1,max=5"`
+	WithPhotos bool
+	SortBy     string `validate:"oneof=newest oldest helpful highest lowest"`
+	Page       int
+	Limit      int
+}
+
+type ReviewListResponse struct {
+	Reviews    []*Review      `json:"reviews"`
+	Summary    *RatingSummary `json:"summary,omitempty"` // set when listing a product's reviews
+	Total      int64          `json:"total"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+	TotalPages int            `json:"total_pages"`
+}
+
+// RatingSummary aggregates the approved reviews of a product
+type RatingSummary struct {
+	Average      decimal.Decimal `json:"average"`
+	Count        int             `json:"count"`
+	Distribution map[int]int     `json:"distribution"` // number of reviews per star rating
+}
+
+// DuplicateReason is a signal that made the matcher consider two products the same
+type DuplicateReason string
+
+const (
+	DuplicateReasonBarcode DuplicateReason = "BARCODE"
+	DuplicateReasonName    DuplicateReason = "NAME"
+	DuplicateReasonBrand   DuplicateReason = "BRAND"
+	DuplicateReasonImage   DuplicateReason = "IMAGE"
+)
+
+// DuplicateCandidate is a product that likely sells the same item as another
+type DuplicateCandidate struct {
+	Product *Product          `json:"product"`
+	Score   float64           `json:"score"` // 0 to 1, 1 for matching barcodes
+	Reasons []DuplicateReason `json:"reasons"`
+}
+
+type MergeProductsRequest struct {
+	DuplicateIDs []uuid.UUID `json:"duplicate_ids" validate:"required,min=1,max=20,dive,required"`
+}
+
+// ProductMerge is the plan for folding duplicate products into a canonical
+// one, carried out in a single transaction
+type ProductMerge struct {
+	CanonicalID   uuid.UUID
+	DuplicateIDs  []uuid.UUID
+	VariantMap    map[uuid.UUID]uuid.UUID // duplicate variant to canonical variant
+	MovedOffers   []*SellerProduct        // seller listings of the duplicates, already pointing at the canonical product
+	CreatedOffers []*SellerProduct        // the submitting sellers' own prices and stock, as listings
+	Actor         string
+}
+
+type ProductMergeResult struct {
+	Product       *Product         `json:"product"`
+	MergedIDs     []uuid.UUID      `json:"merged_ids"`
+	MovedOffers   []*SellerProduct `json:"moved_offers"`
+	CreatedOffers []*SellerProduct `json:"created_offers"`
+	SkippedOffers []uuid.UUID      `json:"skipped_offers"` // listings left on a duplicate because the seller already lists the same item
+	MovedReviews  int64            `json:"moved_reviews"`
+}
+
+// BarcodeOwner is the live product, or variant of one, that holds a barcode
+type BarcodeOwner struct {
+	Barcode   string
+	ProductID uuid.UUID
+	VariantID *uuid.UUID
+}
+
+// BarcodeMatch is what a scanned barcode resolves to: the product, the
+// variant when the barcode is a variant's, and the seller listings of exactly
+// that item
+type BarcodeMatch struct {
+	Product *Product         `json:"product"`
+	Variant *ProductVariant  `json:"variant,omitempty"`
+	Offers  []*SellerProduct `json:"offers"`
+}
+
+// Search Settings Status Enum
+type SearchSettingsStatus string
+
+const (
+	SearchSettingsStatusDraft   SearchSettingsStatus = "DRAFT"
+	SearchSettingsStatusLive    SearchSettingsStatus = "LIVE"
+	SearchSettingsStatusRetired SearchSettingsStatus = "RETIRED"
+)
+
+// SearchSettings is a version of the search tuning. Only the LIVE version
+// applies to shoppers; the DRAFT is where admins prepare the next one.
+type SearchSettings struct {
+	Version     int                  `json:"version"`
+	Status      SearchSettingsStatus `json:"status"`
+	Synonyms    SynonymSets          `json:"synonyms"`
+	Stopwords   []string             `json:"stopwords"`
+	Rules       MerchandisingRules   `json:"rules"`
+	CreatedBy   string               `json:"created_by"`
+	PublishedBy *string              `json:"published_by,omitempty"`
+	PublishedAt *time.Time           `json:"published_at,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+// SynonymSet lists terms shoppers use for the same thing; searching for any
+// of them finds products named with the others. Terms may span several words.
+type SynonymSet struct {
+	Terms []string `json:"terms" validate:"required,min=2,max=50,dive,required,max=100"`
+}
+
+// SynonymSets holds the synonym sets of a search settings version, stored as JSONB
+type SynonymSets []SynonymSet
+
+func (s SynonymSets) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *SynonymSets) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("cannot scan %T into SynonymSets", src)
+	}
+}
+
+// Merchandising Action Enum
+type MerchandisingAction string
+
+const (
+	MerchandisingActionPin   MerchandisingAction = "PIN"   // to the top, in the order the products are listed
+	MerchandisingActionBoost MerchandisingAction = "BOOST" // ahead of unranked results
+	MerchandisingActionBury  MerchandisingAction = "BURY"  // behind unranked results
+)
+
+// MerchandisingRule moves the products it names, or the products of its
+// brands, in relevance-sorted searches for Query, in CategoryID, or for Query
+// in CategoryID. A rule with StartsAt or EndsAt only applies during that
+// window, so campaigns can be scheduled ahead.
+type MerchandisingRule struct {
+	Name       string              `json:"name" validate:"required,max=255"`
+	Action     MerchandisingAction `json:"action" validate:"required,oneof=PIN BOOST BURY"`
+	Query      *string             `json:"query,omitempty" validate:"omitempty,max=255"`
+	CategoryID *uuid.UUID          `json:"category_id,omitempty"`
+	ProductIDs []uuid.UUID         `json:"product_ids,omitempty" validate:"omitempty,max=50"`
+	Brands     []string            `json:"brands,omitempty" validate:"omitempty,max=50,dive,required,max=255"`
+	StartsAt   *time.Time          `json:"starts_at,omitempty"`
+	EndsAt     *time.Time          `json:"ends_at,omitempty"`
+}
+
+// MerchandisingRules holds the rules of a search settings version in the
+// order they were given, stored as JSONB
+type MerchandisingRules []MerchandisingRule
+
+func (r MerchandisingRules) Value() (driver.Value, error) {
+	if r == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(r)
+}
+
+func (r *MerchandisingRules) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return fmt.Errorf("cannot scan %T into MerchandisingRules", src)
+	}
+}
+
+// SearchSettingsRequest replaces the search settings draft
+type SearchSettingsRequest struct {
+	Synonyms  []SynonymSet        `json:"synonyms" validate:"omitempty,max=1000,dive"`
+	Stopwords []string            `json:"stopwords" validate:"omitempty,max=500,dive,required,max=100"`
+	Rules     []MerchandisingRule `json:"rules" validate:"omitempty,max=200,dive"`
+}
+
+// SearchPreview is a search run with a search settings version that need not
+// be live, along with how the version read the query
+type SearchPreview struct {
+	Version int                 `json:"version"`
+	Terms   [][]string          `json:"terms"` // each group matches one of its terms
+	Rules   []MerchandisingRule `json:"rules"` // the rules that applied
+	Results *SearchResponse     `json:"results"`
+}
+
+// Recommendations are the products shown alongside a product, all of them
+// available and in stock
+type Recommendations struct {
+	ProductID      uuid.UUID  `json:"product_id"`
+	BoughtTogether []*Product `json:"bought_together"` // most often ordered with the product first
+	Similar        []*Product `json:"similar"`         // by category, attributes and tags, most similar first
+}
+
+// PriceChange is a price a product, variant or seller listing took. Price is
+// nil while a variant or listing used the price it inherits.
+type PriceChange struct {
+	ID         int64              `json:"id" db:"id"`
+	EntityType RevisionEntityType `json:"entity_type" db:"entity_type"`
+	EntityID   uuid.UUID          `json:"entity_id" db:"entity_id"`
+	ProductID  uuid.UUID          `json:"product_id" db:"product_id"`
+	VariantID  *uuid.UUID         `json:"variant_id,omitempty" db:"variant_id"`
+	SellerID   *uuid.UUID         `json:"seller_id,omitempty" db:"seller_id"`
+	Price      *decimal.Decimal   `json:"price" db:"price"`
+	ChangedAt  time.Time          `json:"changed_at" db:"changed_at"`
+}
+
+type PriceHistoryRequest struct {
+	ProductID  uuid.UUID
+	EntityType *RevisionEntityType
+	EntityID   *uuid.UUID
+	Since      *time.Time
+	Page       int
+	Limit      int
+}
+
+type PriceHistoryResponse struct {
+	Changes    []*PriceChange `json:"changes"`
+	Total      int64          `json:"total"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+	TotalPages int            `json:"total_pages"`
+}
+
+// OrderDeliveredEvent is the part of the order service's order.delivered
+// event that makes order items reviewable
+type OrderDeliveredEvent struct {
+	OrderID    uuid.UUID        `json:"order_id"`
+	CustomerID uuid.UUID        `json:"customer_id"`
+	SellerID   uuid.UUID        `json:"seller_id"`
+	Status     string           `json:"status"`
+	Items      []OrderEventItem `json:"items"`
+	Timestamp  time.Time        `json:"timestamp"`
+}
+
+// OrderCreatedEvent is the part of the order service's order.created event
+// that marks products as ordered, keeping them from the retention purge
+type OrderCreatedEvent struct {
+	OrderID   uuid.UUID        `json:"order_id"`
+	Items     []OrderEventItem `json:"items"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+// ProductChangeEvent is the part of this service's own product events that
+// drops the cached product on every instance
+type ProductChangeEvent struct {
+	Action    string    `json:"action"`
+	ProductID uuid.UUID `json:"product_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type OrderEventItem struct {
+	ProductID uuid.UUID  `json:"product_id"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty"`
 }
 
 type APIResponse struct {
@@ -261,14 +1663,30 @@ type ProductDocument struct {
 	SKU               string          `json:"sku"`
 	Barcode           string          `json:"barcode"`
 	BasePrice         decimal.Decimal `json:"base_price"`
+	CompareAtPrice    *decimal.Decimal `json:"compare_at_price,omitempty"`
+	ReferencePrice    *decimal.Decimal `json:"reference_price,omitempty"`
 	Currency          string          `json:"currency"`
 	TaxRate           decimal.Decimal `json:"tax_rate"`
 	BaseStock         int             `json:"base_stock"`
 	Tags              []string        `json:"tags"`
-	Attributes        map[string]interface{} `json:"attributes"`
+	Attributes        Attributes             `json:"attributes"`
 	IsActive          bool            `json:"is_active"`
 	IsExpressDelivery bool            `json:"is_express_delivery"`
 	PreparationTime   int             `json:"preparation_time"`
+	IsAvailable       bool            `json:"is_available"`
+	ProductType       ProductType     `json:"product_type"`
+	RatingAverage     float64         `json:"rating_average"` // a number, so that the index sorts it numerically
+	RatingCount       int             `json:"rating_count"`
+	Allergens         []Allergen      `json:"allergens,omitempty"`
+	MayContain        []Allergen      `json:"may_contain,omitempty"`
+	DietaryLabels     []DietaryLabel  `json:"dietary_labels,omitempty"`
+	Ingredients       []string        `json:"ingredients,omitempty"`
+	PublishAt         *time.Time      `json:"publish_at,omitempty"`
+	UnpublishAt       *time.Time      `json:"unpublish_at,omitempty"`
+	// Text per locale, indexed as one field per language (names.tr, names.en, ...)
+	Names             map[string]string `json:"names"`
+	Descriptions      map[string]string `json:"descriptions"`
+	CategoryNames     map[string]string `json:"category_names"`
 	CreatedAt         time.Time       `json:"created_at"`
 	UpdatedAt         time.Time       `json:"updated_at"`
 }
\ No newline at end of file
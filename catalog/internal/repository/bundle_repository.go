@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+type BundleRepository interface {
+	GetBundle(productID uuid.UUID) (*models.Bundle, error)
+	ReplaceBundle(bundle *models.Bundle) error
+	DeleteBundle(productID uuid.UUID) error
+	GetBundleIDsContaining(productID uuid.UUID) ([]uuid.UUID, error)
+}
+
+type bundleRepository struct {
+	db *sql.DB
+}
+
+func NewBundleRepository(db *sql.DB) BundleRepository {
+	return &bundleRepository{db: db}
+}
+
+// bundleItemName and bundleItemStock describe the product or variant a
+// component or option refers to. Inactive and deleted items count as out of stock.
+const (
+	bundleItemName  = `p.name || COALESCE(' - ' || pv.name, '')`
+	bundleItemStock = `CASE WHEN p.is_active AND p.deleted_at IS NULL AND COALESCE(pv.is_active, true)
+		                    THEN GREATEST(COALESCE(pv.stock, p.base_stock), 0) ELSE 0 END`
+)
+
+// GetBundle returns the components and choice groups of a bundle with the
+// names and stock of the items they refer to
+func (r *bundleRepository) GetBundle(productID uuid.UUID) (*models.Bundle, error) {
+	bundle := &models.Bundle{
+		ProductID:    productID,
+		Components:   []*models.BundleComponent{},
+		ChoiceGroups: []*models.BundleChoiceGroup{},
+	}
+
+	componentQuery := fmt.Sprintf(`
+		SELECT bc.id, bc.bundle_id, bc.product_id, bc.variant_id, bc.quantity, bc.sort_order, %s, %s
+		FROM bundle_components bc
+		INNER JOIN products p ON bc.product_id = p.id
+		LEFT JOIN product_variants pv ON bc.variant_id = pv.id
+		WHERE bc.bundle_id = $1
+		ORDER BY bc.sort_order`, bundleItemName, bundleItemStock)
+
+	rows, err := r.db.Query(componentQuery, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		component := &models.BundleComponent{}
+		err := rows.Scan(
+			&component.ID,
+			&component.BundleID,
+			&component.ProductID,
+			&component.VariantID,
+			&component.Quantity,
+			&component.SortOrder,
+			&component.Name,
+			&component.Stock,
+		)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Components = append(bundle.Components, component)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	groupRows, err := r.db.Query(`
+		SELECT id, bundle_id, name, min_select, max_select, sort_order
+		FROM bundle_choice_groups
+		WHERE bundle_id = $1
+		ORDER BY sort_order`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer groupRows.Close()
+
+	groups := make(map[uuid.UUID]*models.BundleChoiceGroup)
+	for groupRows.Next() {
+		group := &models.BundleChoiceGroup{Options: []*models.BundleChoiceOption{}}
+		err := groupRows.Scan(
+			&group.ID,
+			&group.BundleID,
+			&group.Name,
+			&group.MinSelect,
+			&group.MaxSelect,
+			&group.SortOrder,
+		)
+		if err != nil {
+			return nil, err
+		}
+		bundle.ChoiceGroups = append(bundle.ChoiceGroups, group)
+		groups[group.ID] = group
+	}
+	if err := groupRows.Err(); err != nil {
+		return nil, err
+	}
+
+	optionQuery := fmt.Sprintf(`
+		SELECT o.id, o.group_id, o.product_id, o.variant_id, o.quantity, o.price_delta, o.is_default, o.sort_order,
+		       %s, %s
+		FROM bundle_choice_options o
+		INNER JOIN bundle_choice_groups g ON o.group_id = g.id
+		INNER JOIN products p ON o.product_id = p.id
+		LEFT JOIN product_variants pv ON o.variant_id = pv.id
+		WHERE g.bundle_id = $1
+		ORDER BY o.sort_order`, bundleItemName, bundleItemStock)
+
+	optionRows, err := r.db.Query(optionQuery, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer optionRows.Close()
+
+	for optionRows.Next() {
+		option := &models.BundleChoiceOption{}
+		err := optionRows.Scan(
+			&option.ID,
+			&option.GroupID,
+			&option.ProductID,
+			&option.VariantID,
+			&option.Quantity,
+			&option.PriceDelta,
+			&option.IsDefault,
+			&option.SortOrder,
+			&option.Name,
+			&option.Stock,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if group, ok := groups[option.GroupID]; ok {
+			group.Options = append(group.Options, option)
+		}
+	}
+
+	return bundle, optionRows.Err()
+}
+
+// ReplaceBundle swaps the composition of a bundle for the given one and marks
+// the product as a bundle
+func (r *bundleRepository) ReplaceBundle(bundle *models.Bundle) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE products SET product_type = $2 WHERE id = $1", bundle.ProductID, models.ProductTypeBundle)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("product not found")
+	}
+
+	// Options go with their groups through ON DELETE CASCADE
+	if _, err := tx.Exec("DELETE FROM bundle_components WHERE bundle_id = $1", bundle.ProductID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM bundle_choice_groups WHERE bundle_id = $1", bundle.ProductID); err != nil {
+		return err
+	}
+
+	for _, component := range bundle.Components {
+		_, err := tx.Exec(`
+			INSERT INTO bundle_components (id, bundle_id, product_id, variant_id, quantity, sort_order)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			component.ID,
+			component.BundleID,
+			component.ProductID,
+			component.VariantID,
+			component.Quantity,
+			component.SortOrder,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, group := range bundle.ChoiceGroups {
+		_, err := tx.Exec(`
+			INSERT INTO bundle_choice_groups (id, bundle_id, name, min_select, max_select, sort_order)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			group.ID,
+			group.BundleID,
+			group.Name,
+			group.MinSelect,
+			group.MaxSelect,
+			group.SortOrder,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, option := range group.Options {
+			_, err := tx.Exec(`
+				INSERT INTO bundle_choice_options (id, group_id, product_id, variant_id, quantity, price_delta,
+				                                   is_default, sort_order)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+				option.ID,
+				option.GroupID,
+				option.ProductID,
+				option.VariantID,
+				option.Quantity,
+				option.PriceDelta,
+				option.IsDefault,
+				option.SortOrder,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteBundle removes the composition of a bundle and turns it back into a
+// simple product
+func (r *bundleRepository) DeleteBundle(productID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE products SET product_type = $2 WHERE id = $1", productID, models.ProductTypeSimple)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("product not found")
+	}
+
+	if _, err := tx.Exec("DELETE FROM bundle_components WHERE bundle_id = $1", productID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM bundle_choice_groups WHERE bundle_id = $1", productID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetBundleIDsContaining returns the bundles that use a product as a
+// component or option
+func (r *bundleRepository) GetBundleIDsContaining(productID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT bundle_id FROM bundle_components WHERE product_id = $1
+		UNION
+		SELECT g.bundle_id
+		FROM bundle_choice_options o
+		INNER JOIN bundle_choice_groups g ON o.group_id = g.id
+		WHERE o.product_id = $1`
+
+	rows, err := r.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bundleIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		bundleIDs = append(bundleIDs, id)
+	}
+
+	return bundleIDs, rows.Err()
+}
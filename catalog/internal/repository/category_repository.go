@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/cebeuygun/platform/services/catalog/internal/models"
 	"github.com/google/uuid"
@@ -14,10 +15,27 @@ type CategoryRepository interface {
 	Create(category *models.Category) error
 	GetByID(id uuid.UUID) (*models.Category, error)
 	GetAll(parentID *uuid.UUID, activeOnly bool) ([]*models.Category, error)
-	GetTree() ([]*models.Category, error)
+	GetTree(rootID *uuid.UUID) ([]*models.Category, error)
+	GetPath(id uuid.UUID) ([]*models.Category, error)
 	Update(id uuid.UUID, updates *models.UpdateCategoryRequest) error
-	Delete(id uuid.UUID) error
+	Move(id uuid.UUID, parentID *uuid.UUID, sortOrder *int) error
+	Reorder(parentID *uuid.UUID, categoryIDs []uuid.UUID) error
+	Delete(id uuid.UUID, actor string) error
 	GetProductCount(categoryID uuid.UUID) (int, error)
+	GetProductCounts(categoryIDs []uuid.UUID) (map[uuid.UUID]int, error)
+
+	// Attribute schema operations
+	CreateAttribute(attribute *models.CategoryAttribute) error
+	GetAttribute(id uuid.UUID) (*models.CategoryAttribute, error)
+	GetEffectiveAttributes(categoryID uuid.UUID) ([]*models.CategoryAttribute, error)
+	UpdateAttribute(attribute *models.CategoryAttribute) error
+	DeleteAttribute(id uuid.UUID) error
+
+	// Trash operations
+	GetDeletedByID(id uuid.UUID) (*models.Category, error)
+	GetDeleted() ([]*models.Category, error)
+	Undelete(id uuid.UUID) error
+	PurgeDeleted(before time.Time) (int64, error)
 }
 
 type categoryRepository struct {
@@ -28,11 +46,17 @@ func NewCategoryRepository(db *sql.DB) CategoryRepository {
 	return &categoryRepository{db: db}
 }
 
+const categoryColumns = `id, name, description, parent_id, image_url, sort_order, is_active, path, depth, deleted_at, deleted_by,
+		created_at, updated_at`
+
+// errCategoryCycle is returned when a category would become its own ancestor
+var errCategoryCycle = fmt.Errorf("category cannot be moved under its own subtree")
+
 func (r *categoryRepository) Create(category *models.Category) error {
 	query := `
 		INSERT INTO categories (id, name, description, parent_id, image_url, sort_order, is_active)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING created_at, updated_at`
+		RETURNING path, depth, created_at, updated_at`
 	
 	return r.db.QueryRow(
 		query,
@@ -43,27 +67,15 @@ func (r *categoryRepository) Create(category *models.Category) error {
 		category.ImageURL,
 		category.SortOrder,
 		category.IsActive,
-	).Scan(&category.CreatedAt, &category.UpdatedAt)
+	).Scan(&category.Path, &category.Depth, &category.CreatedAt, &category.UpdatedAt)
 }
 
 func (r *categoryRepository) GetByID(id uuid.UUID) (*models.Category, error) {
-	category := &models.Category{}
-	query := `
-		SELECT id, name, description, parent_id, image_url, sort_order, is_active, created_at, updated_at
-		FROM categories WHERE id = $1`
-	
-	err := r.db.QueryRow(query, id).Scan(
-		&category.ID,
-		&category.Name,
-		&category.Description,
-		&category.ParentID,
-		&category.ImageURL,
-		&category.SortOrder,
-		&category.IsActive,
-		&category.CreatedAt,
-		&category.UpdatedAt,
-	)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM categories WHERE id = $1 AND deleted_at IS NULL`, categoryColumns)
 	
+	category, err := scanCategory(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -72,7 +84,7 @@ func (r *categoryRepository) GetByID(id uuid.UUID) (*models.Category, error) {
 }
 
 func (r *categoryRepository) GetAll(parentID *uuid.UUID, activeOnly bool) ([]*models.Category, error) {
-	var conditions []string
+	conditions := []string{"deleted_at IS NULL"}
 	var args []interface{}
 	argIndex := 1
 
@@ -90,65 +102,45 @@ func (r *categoryRepository) GetAll(parentID *uuid.UUID, activeOnly bool) ([]*mo
 		argIndex++
 	}
 
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = "WHERE " + strings.Join(conditions, " AND ")
-	}
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
 
 	query := fmt.Sprintf(`
-		SELECT id, name, description, parent_id, image_url, sort_order, is_active, created_at, updated_at
+		SELECT %s
 		FROM categories %s
-		ORDER BY sort_order, name`, whereClause)
+		ORDER BY sort_order, name`, categoryColumns, whereClause)
 	
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	
-	var categories []*models.Category
-	for rows.Next() {
-		category := &models.Category{}
-		err := rows.Scan(
-			&category.ID,
-			&category.Name,
-			&category.Description,
-			&category.ParentID,
-			&category.ImageURL,
-			&category.SortOrder,
-			&category.IsActive,
-			&category.CreatedAt,
-			&category.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		categories = append(categories, category)
-	}
-	
-	return categories, rows.Err()
+	return r.queryCategories(query, args...)
 }
 
-func (r *categoryRepository) GetTree() ([]*models.Category, error) {
-	query := `
-		WITH RECURSIVE category_tree AS (
-			-- Base case: root categories
-			SELECT id, name, description, parent_id, image_url, sort_order, is_active, created_at, updated_at, 0 as level
-			FROM categories
-			WHERE parent_id IS NULL
-			
-			UNION ALL
-			
-			-- Recursive case: child categories
-			SELECT c.id, c.name, c.description, c.parent_id, c.image_url, c.sort_order, c.is_active, c.created_at, c.updated_at, ct.level + 1
-			FROM categories c
-			INNER JOIN category_tree ct ON c.parent_id = ct.id
-		)
-		SELECT id, name, description, parent_id, image_url, sort_order, is_active, created_at, updated_at
-		FROM category_tree
-		ORDER BY level, sort_order, name`
+// GetTree returns the category forest, or the subtree below rootID, with
+// product counts rolled up from the descendants
+func (r *categoryRepository) GetTree(rootID *uuid.UUID) ([]*models.Category, error) {
+	conditions := []string{"c.deleted_at IS NULL"}
+	var args []interface{}
+
+	if rootID != nil {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM categories root
+			WHERE root.id = $1 AND (c.path = root.path OR c.path LIKE root.path || '/%'))`)
+		args = append(args, *rootID)
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	query := fmt.Sprintf(`
+		SELECT c.id, c.name, c.description, c.parent_id, c.image_url, c.sort_order, c.is_active, c.path, c.depth,
+		       c.created_at, c.updated_at, COALESCE(pc.product_count, 0)
+		FROM categories c
+		LEFT JOIN (
+			SELECT p.category_id, COUNT(*) AS product_count
+			FROM products p
+			WHERE p.is_active = true AND p.status = 'APPROVED' AND p.deleted_at IS NULL AND %s
+			GROUP BY p.category_id
+		) pc ON pc.category_id = c.id
+		%s
+		ORDER BY c.depth, c.sort_order, c.name`, productAvailableCondition, whereClause)
 	
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -167,8 +159,11 @@ func (r *categoryRepository) GetTree() ([]*models.Category, error) {
 			&category.ImageURL,
 			&category.SortOrder,
 			&category.IsActive,
+			&category.Path,
+			&category.Depth,
 			&category.CreatedAt,
 			&category.UpdatedAt,
+			&category.ProductCount,
 		)
 		if err != nil {
 			return nil, err
@@ -176,7 +171,7 @@ func (r *categoryRepository) GetTree() ([]*models.Category, error) {
 		
 		categoryMap[category.ID] = category
 		
-		if category.ParentID == nil {
+		if category.ParentID == nil || (rootID != nil && category.ID == *rootID) {
 			categories = append(categories, category)
 		} else {
 			if parent, exists := categoryMap[*category.ParentID]; exists {
@@ -184,8 +179,26 @@ func (r *categoryRepository) GetTree() ([]*models.Category, error) {
 			}
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	
-	return categories, rows.Err()
+	for _, category := range categories {
+		rollUpProductCount(category)
+	}
+	
+	return categories, nil
+}
+
+// GetPath returns the ancestors of a category from the root down to the category itself
+func (r *categoryRepository) GetPath(id uuid.UUID) ([]*models.Category, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM categories
+		WHERE id = ANY(string_to_array((SELECT path FROM categories WHERE id = $1 AND deleted_at IS NULL), '/')::uuid[])
+		ORDER BY depth`, categoryColumns)
+	
+	return r.queryCategories(query, id)
 }
 
 func (r *categoryRepository) Update(id uuid.UUID, updates *models.UpdateCategoryRequest) error {
@@ -233,12 +246,12 @@ func (r *categoryRepository) Update(id uuid.UUID, updates *models.UpdateCategory
 		return fmt.Errorf("no fields to update")
 	}
 
-	query := fmt.Sprintf("UPDATE categories SET %s WHERE id = $%d", strings.Join(setParts, ", "), argIndex)
+	query := fmt.Sprintf("UPDATE categories SET %s WHERE id = $%d AND deleted_at IS NULL", strings.Join(setParts, ", "), argIndex)
 	args = append(args, id)
 
 	result, err := r.db.Exec(query, args...)
 	if err != nil {
-		return err
+		return categoryTreeError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -253,10 +266,100 @@ func (r *categoryRepository) Update(id uuid.UUID, updates *models.UpdateCategory
 	return nil
 }
 
-func (r *categoryRepository) Delete(id uuid.UUID) error {
+// Move re-parents a category; the database trigger rewrites the paths of the
+// whole subtree and rejects cycles. Without a sort order the category is
+// placed after its new siblings.
+func (r *categoryRepository) Move(id uuid.UUID, parentID *uuid.UUID, sortOrder *int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Serialize concurrent moves so two of them cannot form a cycle together
+	if _, err := tx.Exec("LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+
+	position := 0
+	if sortOrder != nil {
+		position = *sortOrder
+	} else {
+		err = tx.QueryRow(`
+			SELECT COALESCE(MAX(sort_order) + 1, 0)
+			FROM categories
+			WHERE parent_id IS NOT DISTINCT FROM $1 AND id <> $2 AND deleted_at IS NULL`, parentID, id).Scan(&position)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.Exec("UPDATE categories SET parent_id = $2, sort_order = $3 WHERE id = $1 AND deleted_at IS NULL", id, parentID, position)
+	if err != nil {
+		return categoryTreeError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("category not found")
+	}
+
+	return tx.Commit()
+}
+
+// Reorder assigns sort orders to the children of a parent in the given order.
+// Siblings that are not listed keep their relative order after the listed ones.
+func (r *categoryRepository) Reorder(parentID *uuid.UUID, categoryIDs []uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE categories c
+		SET sort_order = $3 + s.position
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY sort_order, name) - 1 AS position
+			FROM categories
+			WHERE parent_id IS NOT DISTINCT FROM $1 AND id <> ALL($2::uuid[]) AND deleted_at IS NULL
+		) s
+		WHERE c.id = s.id`, parentID, pq.Array(categoryIDs), len(categoryIDs))
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE categories c
+		SET sort_order = o.position - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, position)
+		WHERE c.id = o.id AND c.parent_id IS NOT DISTINCT FROM $1 AND c.deleted_at IS NULL`, parentID, pq.Array(categoryIDs))
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected != int64(len(categoryIDs)) {
+		return fmt.Errorf("categories must be distinct children of the same parent")
+	}
+
+	return tx.Commit()
+}
+
+// Delete moves a category to the trash. Only categories without live
+// subcategories or products can be deleted.
+func (r *categoryRepository) Delete(id uuid.UUID, actor string) error {
 	// Check if category has children
 	var childCount int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM categories WHERE parent_id = $1", id).Scan(&childCount)
+	err := r.db.QueryRow("SELECT COUNT(*) FROM categories WHERE parent_id = $1 AND deleted_at IS NULL", id).Scan(&childCount)
 	if err != nil {
 		return err
 	}
@@ -267,7 +370,7 @@ func (r *categoryRepository) Delete(id uuid.UUID) error {
 
 	// Check if category has products
 	var productCount int
-	err = r.db.QueryRow("SELECT COUNT(*) FROM products WHERE category_id = $1", id).Scan(&productCount)
+	err = r.db.QueryRow("SELECT COUNT(*) FROM products WHERE category_id = $1 AND deleted_at IS NULL", id).Scan(&productCount)
 	if err != nil {
 		return err
 	}
@@ -276,7 +379,7 @@ func (r *categoryRepository) Delete(id uuid.UUID) error {
 		return fmt.Errorf("cannot delete category with products")
 	}
 
-	result, err := r.db.Exec("DELETE FROM categories WHERE id = $1", id)
+	result, err := r.db.Exec("UPDATE categories SET deleted_at = NOW(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL", id, actor)
 	if err != nil {
 		return err
 	}
@@ -294,18 +397,346 @@ func (r *categoryRepository) Delete(id uuid.UUID) error {
 }
 
 func (r *categoryRepository) GetProductCount(categoryID uuid.UUID) (int, error) {
-	var count int
+	counts, err := r.GetProductCounts([]uuid.UUID{categoryID})
+	if err != nil {
+		return 0, err
+	}
+
+	return counts[categoryID], nil
+}
+
+// GetProductCounts returns the number of active, approved products in each category's subtree
+func (r *categoryRepository) GetProductCounts(categoryIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := make(map[uuid.UUID]int)
+	if len(categoryIDs) == 0 {
+		return counts, nil
+	}
+
 	query := `
-		WITH RECURSIVE category_tree AS (
-			SELECT id FROM categories WHERE id = $1
-			UNION ALL
-			SELECT c.id FROM categories c
-			INNER JOIN category_tree ct ON c.parent_id = ct.id
-		)
-		SELECT COUNT(*) FROM products p
-		INNER JOIN category_tree ct ON p.category_id = ct.id
-		WHERE p.is_active = true`
+		SELECT c.id, COUNT(p.id)
+		FROM categories c
+		INNER JOIN categories d ON d.path = c.path OR d.path LIKE c.path || '/%'
+		INNER JOIN products p ON p.category_id = d.id AND p.is_active = true AND p.status = 'APPROVED'
+		                     AND p.deleted_at IS NULL AND ` + productAvailableCondition + `
+		WHERE c.id = ANY($1::uuid[])
+		GROUP BY c.id`
+	
+	rows, err := r.db.Query(query, pq.Array(categoryIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	for rows.Next() {
+		var id uuid.UUID
+		var count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+	
+	return counts, rows.Err()
+}
+
+func (r *categoryRepository) queryCategories(query string, args ...interface{}) ([]*models.Category, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 	
-	err := r.db.QueryRow(query, categoryID).Scan(&count)
-	return count, err
-}
\ No newline at end of file
+	var categories []*models.Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	
+	return categories, rows.Err()
+}
+
+func scanCategory(row rowScanner) (*models.Category, error) {
+	category := &models.Category{}
+	err := row.Scan(
+		&category.ID,
+		&category.Name,
+		&category.Description,
+		&category.ParentID,
+		&category.ImageURL,
+		&category.SortOrder,
+		&category.IsActive,
+		&category.Path,
+		&category.Depth,
+		&category.DeletedAt,
+		&category.DeletedBy,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// rollUpProductCount adds the product counts of the children to each node
+func rollUpProductCount(category *models.Category) int {
+	for _, child := range category.Children {
+		category.ProductCount += rollUpProductCount(child)
+	}
+
+	return category.ProductCount
+}
+
+// categoryTreeError translates the errors raised by the category path trigger
+func categoryTreeError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code {
+		case "23514":
+			return errCategoryCycle
+		case "23503":
+			return fmt.Errorf("parent category not found")
+		}
+	}
+
+	return err
+}
+
+const categoryAttributeColumns = `ca.id, ca.category_id, ca.code, ca.name, ca.type, ca.unit, ca.options, ca.min_value,
+		       ca.max_value, ca.is_required, ca.is_variant, ca.is_filterable, ca.sort_order, ca.created_at, ca.updated_at`
+
+func (r *categoryRepository) CreateAttribute(attribute *models.CategoryAttribute) error {
+	query := `
+		INSERT INTO category_attributes (id, category_id, code, name, type, unit, options, min_value, max_value,
+		                                 is_required, is_variant, is_filterable, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRow(
+		query,
+		attribute.ID,
+		attribute.CategoryID,
+		attribute.Code,
+		attribute.Name,
+		attribute.Type,
+		attribute.Unit,
+		pq.Array(attribute.Options),
+		attribute.MinValue,
+		attribute.MaxValue,
+		attribute.IsRequired,
+		attribute.IsVariant,
+		attribute.IsFilterable,
+		attribute.SortOrder,
+	).Scan(&attribute.CreatedAt, &attribute.UpdatedAt)
+}
+
+func (r *categoryRepository) GetAttribute(id uuid.UUID) (*models.CategoryAttribute, error) {
+	query := fmt.Sprintf(`
+		SELECT %s, 0
+		FROM category_attributes ca WHERE ca.id = $1`, categoryAttributeColumns)
+
+	attribute, err := scanCategoryAttribute(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return attribute, err
+}
+
+// GetEffectiveAttributes returns the attribute schema of a category: its own
+// attributes plus those inherited from its ancestors. When a code is defined
+// at several levels the definition closest to the category wins.
+func (r *categoryRepository) GetEffectiveAttributes(categoryID uuid.UUID) ([]*models.CategoryAttribute, error) {
+	query := fmt.Sprintf(`
+		WITH ancestors AS (
+			SELECT a.id, c.depth - a.depth AS depth
+			FROM categories c
+			INNER JOIN categories a ON a.id = ANY(string_to_array(c.path, '/')::uuid[])
+			WHERE c.id = $1
+		),
+		effective AS (
+			SELECT DISTINCT ON (ca.code) %s, a.depth
+			FROM category_attributes ca
+			INNER JOIN ancestors a ON ca.category_id = a.id
+			ORDER BY ca.code, a.depth
+		)
+		SELECT * FROM effective
+		ORDER BY sort_order, name`, categoryAttributeColumns)
+
+	rows, err := r.db.Query(query, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attributes []*models.CategoryAttribute
+	for rows.Next() {
+		attribute, err := scanCategoryAttribute(rows)
+		if err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, attribute)
+	}
+
+	return attributes, rows.Err()
+}
+
+func (r *categoryRepository) UpdateAttribute(attribute *models.CategoryAttribute) error {
+	query := `
+		UPDATE category_attributes
+		SET code = $2, name = $3, type = $4, unit = $5, options = $6, min_value = $7, max_value = $8,
+		    is_required = $9, is_variant = $10, is_filterable = $11, sort_order = $12
+		WHERE id = $1
+		RETURNING updated_at`
+
+	err := r.db.QueryRow(
+		query,
+		attribute.ID,
+		attribute.Code,
+		attribute.Name,
+		attribute.Type,
+		attribute.Unit,
+		pq.Array(attribute.Options),
+		attribute.MinValue,
+		attribute.MaxValue,
+		attribute.IsRequired,
+		attribute.IsVariant,
+		attribute.IsFilterable,
+		attribute.SortOrder,
+	).Scan(&attribute.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("attribute not found")
+	}
+
+	return err
+}
+
+func (r *categoryRepository) DeleteAttribute(id uuid.UUID) error {
+	result, err := r.db.Exec("DELETE FROM category_attributes WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("attribute not found")
+	}
+
+	return nil
+}
+
+func scanCategoryAttribute(row rowScanner) (*models.CategoryAttribute, error) {
+	attribute := &models.CategoryAttribute{}
+	var depth int
+
+	err := row.Scan(
+		&attribute.ID,
+		&attribute.CategoryID,
+		&attribute.Code,
+		&attribute.Name,
+		&attribute.Type,
+		&attribute.Unit,
+		pq.Array(&attribute.Options),
+		&attribute.MinValue,
+		&attribute.MaxValue,
+		&attribute.IsRequired,
+		&attribute.IsVariant,
+		&attribute.IsFilterable,
+		&attribute.SortOrder,
+		&attribute.CreatedAt,
+		&attribute.UpdatedAt,
+		&depth,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	attribute.Inherited = depth > 0
+	return attribute, nil
+}
+
+// GetDeletedByID returns a category in the trash, or nil when the category
+// does not exist or is not deleted
+func (r *categoryRepository) GetDeletedByID(id uuid.UUID) (*models.Category, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM categories WHERE id = $1 AND deleted_at IS NOT NULL`, categoryColumns)
+
+	category, err := scanCategory(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return category, err
+}
+
+// GetDeleted returns the categories in the trash, most recently deleted first
+func (r *categoryRepository) GetDeleted() ([]*models.Category, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM categories
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, name`, categoryColumns)
+
+	return r.queryCategories(query)
+}
+
+// Undelete takes a category out of the trash. Its parent must not be deleted.
+func (r *categoryRepository) Undelete(id uuid.UUID) error {
+	query := `
+		UPDATE categories c
+		SET deleted_at = NULL, deleted_by = NULL
+		WHERE c.id = $1 AND c.deleted_at IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM categories parent WHERE parent.id = c.parent_id AND parent.deleted_at IS NOT NULL)`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("category not found")
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently removes the categories deleted before the given
+// time that no longer have subcategories or products, deleted or not. Emptied
+// parents are removed in the same call.
+func (r *categoryRepository) PurgeDeleted(before time.Time) (int64, error) {
+	query := `
+		DELETE FROM categories c
+		WHERE c.deleted_at < $1
+		  AND NOT EXISTS (SELECT 1 FROM categories child WHERE child.parent_id = c.id)
+		  AND NOT EXISTS (SELECT 1 FROM products p WHERE p.category_id = c.id)`
+
+	var purged int64
+	for {
+		result, err := r.db.Exec(query, before)
+		if err != nil {
+			return purged, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return purged, err
+		}
+		if rowsAffected == 0 {
+			return purged, nil
+		}
+		purged += rowsAffected
+	}
+}
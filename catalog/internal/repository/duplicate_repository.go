@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type DuplicateRepository interface {
+	// FindByGTIN returns the products whose barcode, or one of whose variants'
+	// barcodes, has one of the given GTIN keys
+	FindByGTIN(keys []string, excludeID uuid.UUID) ([]uuid.UUID, error)
+	// FindBySimilarName returns the products with the most similar names
+	// by trigram similarity, most similar first
+	FindBySimilarName(name string, excludeID uuid.UUID, limit int) ([]uuid.UUID, error)
+	// FindBySimilarImage returns the perceptual hashes of other products'
+	// images that share a band with one of the given hashes
+	FindBySimilarImage(hashes []int64, excludeID uuid.UUID) (map[uuid.UUID][]int64, error)
+
+	// Merge folds the duplicates into the canonical product and returns how
+	// many reviews moved
+	Merge(merge *models.ProductMerge) (int64, error)
+}
+
+type duplicateRepository struct {
+	db *sql.DB
+}
+
+func NewDuplicateRepository(db *sql.DB) DuplicateRepository {
+	return &duplicateRepository{db: db}
+}
+
+// duplicateCandidateCondition limits the matcher to live products p that can
+// be merged: bundles are built from other products and rejected products are
+// not going anywhere
+const duplicateCandidateCondition = `p.deleted_at IS NULL AND p.product_type = 'SIMPLE' AND p.status <> 'REJECTED'`
+
+// duplicateImageCandidates caps the image hashes a single lookup returns;
+// one-byte bands collide often, so popular bands would otherwise flood it
+const duplicateImageCandidates = 500
+
+func (r *duplicateRepository) FindByGTIN(keys []string, excludeID uuid.UUID) ([]uuid.UUID, error) {
+	if len(keys) == 0 {
+		return []uuid.UUID{}, nil
+	}
+
+	query := `
+		SELECT p.id FROM products p
+		WHERE gtin_key(p.barcode) = ANY($1::text[]) AND p.id <> $2 AND ` + duplicateCandidateCondition + `
+		UNION
+		SELECT p.id FROM product_variants v
+		INNER JOIN products p ON v.product_id = p.id
+		WHERE gtin_key(v.barcode) = ANY($1::text[]) AND p.id <> $2 AND ` + duplicateCandidateCondition
+
+	return r.queryIDs(query, pq.Array(keys), excludeID)
+}
+
+func (r *duplicateRepository) FindBySimilarName(name string, excludeID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT p.id FROM products p
+		WHERE p.name % $1 AND p.id <> $2 AND ` + duplicateCandidateCondition + `
+		ORDER BY similarity(p.name, $1) DESC, p.id
+		LIMIT $3`
+
+	return r.queryIDs(query, name, excludeID, limit)
+}
+
+func (r *duplicateRepository) FindBySimilarImage(hashes []int64, excludeID uuid.UUID) (map[uuid.UUID][]int64, error) {
+	candidates := make(map[uuid.UUID][]int64)
+	if len(hashes) == 0 {
+		return candidates, nil
+	}
+
+	query := `
+		SELECT m.product_id, m.perceptual_hash
+		FROM product_media m
+		INNER JOIN products p ON m.product_id = p.id
+		WHERE m.perceptual_hash_bands && (
+			SELECT array_agg(band) FROM unnest($1::bigint[]) AS hash, unnest(perceptual_hash_bands(hash)) AS band
+		)
+		  AND m.is_active = true AND m.product_id <> $2 AND ` + duplicateCandidateCondition + `
+		LIMIT $3`
+
+	rows, err := r.db.Query(query, pq.Array(hashes), excludeID, duplicateImageCandidates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID uuid.UUID
+		var hash int64
+		if err := rows.Scan(&productID, &hash); err != nil {
+			return nil, err
+		}
+		candidates[productID] = append(candidates[productID], hash)
+	}
+
+	return candidates, rows.Err()
+}
+
+func (r *duplicateRepository) queryIDs(query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// Merge moves the seller listings, reviews and bundle references of the
+// duplicates to the canonical product and moves the duplicates to the trash.
+// Skipped listings, reviews of an order that already reviewed the canonical
+// product and references to variants without a counterpart stay with the
+// duplicate.
+func (r *duplicateRepository) Merge(merge *models.ProductMerge) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	productIDs := append([]uuid.UUID{merge.CanonicalID}, merge.DuplicateIDs...)
+	var locked int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT id FROM products WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL FOR UPDATE
+		) live`, pq.Array(productIDs)).Scan(&locked)
+	if err != nil {
+		return 0, err
+	}
+	if locked != len(productIDs) {
+		return 0, fmt.Errorf("product not found")
+	}
+
+	var fromIDs, toIDs []uuid.UUID
+	for from, to := range merge.VariantMap {
+		fromIDs = append(fromIDs, from)
+		toIDs = append(toIDs, to)
+	}
+	// mappedVariant translates the variant_id column of the updated row
+	const mappedVariant = `(SELECT m.to_id FROM unnest($3::uuid[], $4::uuid[]) AS m(from_id, to_id) WHERE m.from_id = variant_id)`
+
+	for _, sp := range merge.MovedOffers {
+		_, err := tx.Exec("UPDATE seller_products SET product_id = $2, variant_id = $3 WHERE id = $1", sp.ID, sp.ProductID, sp.VariantID)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("seller %s already lists the product", sp.SellerID)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, sp := range merge.CreatedOffers {
+		_, err := tx.Exec(`
+			INSERT INTO seller_products (id, seller_id, product_id, variant_id, seller_sku, price, stock,
+			                            min_stock, max_stock, is_active, is_visible, hidden_by_stock, preparation_time, notes,
+			                            publish_at, unpublish_at, availability_schedule, is_available)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`,
+			sp.ID, sp.SellerID, sp.ProductID, sp.VariantID, sp.SellerSKU, sp.Price, sp.Stock,
+			sp.MinStock, sp.MaxStock, sp.IsActive, sp.IsVisible, sp.HiddenByStock, sp.PreparationTime, sp.Notes,
+			sp.PublishAt, sp.UnpublishAt, sp.AvailabilitySchedule, sp.IsAvailable)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("seller %s already uses the SKU of a merged product", sp.SellerID)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var movedReviews int64
+	for _, duplicateID := range merge.DuplicateIDs {
+		// One at a time, so that two duplicates reviewed in the same order do
+		// not both move
+		result, err := tx.Exec(`
+			UPDATE product_reviews r
+			SET product_id = $1, variant_id = `+mappedVariant+`
+			WHERE r.product_id = $2
+			  AND NOT EXISTS (SELECT 1 FROM product_reviews c WHERE c.product_id = $1 AND c.order_id = r.order_id)`,
+			merge.CanonicalID, duplicateID, pq.Array(fromIDs), pq.Array(toIDs))
+		if err != nil {
+			return 0, err
+		}
+		moved, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		movedReviews += moved
+
+		_, err = tx.Exec(`
+			UPDATE review_eligibilities e
+			SET product_id = $1, variant_id = `+mappedVariant+`
+			WHERE e.product_id = $2
+			  AND NOT EXISTS (SELECT 1 FROM review_eligibilities c WHERE c.product_id = $1 AND c.order_id = e.order_id)`,
+			merge.CanonicalID, duplicateID, pq.Array(fromIDs), pq.Array(toIDs))
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`
+			UPDATE bundle_components b
+			SET product_id = $1, variant_id = `+mappedVariant+`
+			WHERE b.product_id = $2 AND b.bundle_id <> $1 AND (b.variant_id IS NULL OR b.variant_id = ANY($3::uuid[]))`,
+			merge.CanonicalID, duplicateID, pq.Array(fromIDs), pq.Array(toIDs))
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`
+			UPDATE bundle_choice_options o
+			SET product_id = $1, variant_id = `+mappedVariant+`
+			WHERE o.product_id = $2 AND (o.variant_id IS NULL OR o.variant_id = ANY($3::uuid[]))
+			  AND NOT EXISTS (SELECT 1 FROM bundle_choice_groups g WHERE g.id = o.group_id AND g.bundle_id = $1)`,
+			merge.CanonicalID, duplicateID, pq.Array(fromIDs), pq.Array(toIDs))
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`
+			UPDATE products SET deleted_at = NOW(), deleted_by = $3, merged_into = $1
+			WHERE id = $2`, merge.CanonicalID, duplicateID, merge.Actor)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return movedReviews, nil
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+type ImportJobRepository interface {
+	Create(job *models.ImportJob) error
+	GetByID(id uuid.UUID) (*models.ImportJob, error)
+	ClaimNext(lease time.Duration) (*models.ImportJob, error)
+	ResumePoint(job *models.ImportJob) (int, error)
+	UpdateProgress(job *models.ImportJob) error
+	Complete(job *models.ImportJob) error
+	Fail(id uuid.UUID, message string) error
+	AddRow(row *models.ImportJobRow) error
+	GetRows(jobID uuid.UUID, status *models.ImportRowStatus, limit int, offset int) ([]*models.ImportJobRow, int64, error)
+}
+
+type importJobRepository struct {
+	db *sql.DB
+}
+
+func NewImportJobRepository(db *sql.DB) ImportJobRepository {
+	return &importJobRepository{db: db}
+}
+
+const importJobColumns = `id, seller_id, status, format, dry_run, match_by, header_mapping, file_name, object_name,
+		       total_rows, processed_rows, created_count, updated_count, error_count, error_message,
+		       started_at, completed_at, created_at, updated_at`
+
+func (r *importJobRepository) Create(job *models.ImportJob) error {
+	headerMappingJSON, err := json.Marshal(job.HeaderMapping)
+	if err != nil {
+		return fmt.Errorf("failed to serialize header mapping: %w", err)
+	}
+
+	query := `
+		INSERT INTO import_jobs (id, seller_id, status, format, dry_run, match_by, header_mapping, file_name, object_name)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRow(
+		query,
+		job.ID,
+		job.SellerID,
+		job.Status,
+		job.Format,
+		job.DryRun,
+		job.MatchBy,
+		headerMappingJSON,
+		job.FileName,
+		job.ObjectName,
+	).Scan(&job.CreatedAt, &job.UpdatedAt)
+}
+
+func (r *importJobRepository) GetByID(id uuid.UUID) (*models.ImportJob, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM import_jobs WHERE id = $1`, importJobColumns)
+
+	job, err := scanImportJob(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return job, err
+}
+
+// ClaimNext atomically moves the oldest pending job to RUNNING so that
+// concurrent catalog instances never process the same job twice. A RUNNING
+// job whose progress has not been updated for longer than the lease is
+// claimed again, since the worker running it has stopped; a lease of 0
+// never reclaims jobs.
+func (r *importJobRepository) ClaimNext(lease time.Duration) (*models.ImportJob, error) {
+	query := fmt.Sprintf(`
+		UPDATE import_jobs
+		SET status = $1, started_at = COALESCE(started_at, NOW())
+		WHERE id = (
+			SELECT id FROM import_jobs
+			WHERE status = $2
+			   OR (status = $1 AND $3::float8 > 0 AND updated_at < NOW() - $3::float8 * INTERVAL '1 second')
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %s`, importJobColumns)
+
+	job, err := scanImportJob(r.db.QueryRow(query, models.ImportJobStatusRunning, models.ImportJobStatusPending, lease.Seconds()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return job, err
+}
+
+// ResumePoint restores the counters of a job from the rows it has recorded
+// and returns the number of the last one, 0 for a job that has not started
+func (r *importJobRepository) ResumePoint(job *models.ImportJob) (int, error) {
+	query := `
+		SELECT COALESCE(MAX(row_number), 0), COUNT(*),
+		       COUNT(*) FILTER (WHERE status = $2 OR (status = $3 AND action IS DISTINCT FROM $4)),
+		       COUNT(*) FILTER (WHERE status = $5 OR (status = $3 AND action = $4)),
+		       COUNT(*) FILTER (WHERE status = $6)
+		FROM import_job_rows
+		WHERE job_id = $1`
+
+	var lastRow, rows int
+	err := r.db.QueryRow(
+		query,
+		job.ID,
+		models.ImportRowStatusCreated,
+		models.ImportRowStatusValid,
+		models.ImportRowActionUpdate,
+		models.ImportRowStatusUpdated,
+		models.ImportRowStatusFailed,
+	).Scan(&lastRow, &rows, &job.CreatedCount, &job.UpdatedCount, &job.ErrorCount)
+	if err != nil {
+		return 0, err
+	}
+
+	job.TotalRows = rows
+	job.ProcessedRows = rows
+	return lastRow, nil
+}
+
+func (r *importJobRepository) UpdateProgress(job *models.ImportJob) error {
+	query := `
+		UPDATE import_jobs
+		SET total_rows = $2, processed_rows = $3, created_count = $4, updated_count = $5, error_count = $6
+		WHERE id = $1`
+
+	_, err := r.db.Exec(
+		query,
+		job.ID,
+		job.TotalRows,
+		job.ProcessedRows,
+		job.CreatedCount,
+		job.UpdatedCount,
+		job.ErrorCount,
+	)
+	return err
+}
+
+func (r *importJobRepository) Complete(job *models.ImportJob) error {
+	query := `
+		UPDATE import_jobs
+		SET status = $2, total_rows = $3, processed_rows = $4, created_count = $5, updated_count = $6,
+		    error_count = $7, completed_at = NOW()
+		WHERE id = $1
+		RETURNING completed_at`
+
+	job.Status = models.ImportJobStatusCompleted
+	return r.db.QueryRow(
+		query,
+		job.ID,
+		job.Status,
+		job.TotalRows,
+		job.ProcessedRows,
+		job.CreatedCount,
+		job.UpdatedCount,
+		job.ErrorCount,
+	).Scan(&job.CompletedAt)
+}
+
+func (r *importJobRepository) Fail(id uuid.UUID, message string) error {
+	query := `
+		UPDATE import_jobs
+		SET status = $2, error_message = $3, completed_at = NOW()
+		WHERE id = $1`
+
+	result, err := r.db.Exec(query, id, models.ImportJobStatusFailed, message)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("import job not found")
+	}
+
+	return nil
+}
+
+// AddRow records a row. Recording the same row again, as a retry after an
+// error may, keeps the first record.
+func (r *importJobRepository) AddRow(row *models.ImportJobRow) error {
+	query := `
+		INSERT INTO import_job_rows (id, job_id, row_number, status, action, product_id, variant_id, match_key, field, message)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE SET id = EXCLUDED.id
+		RETURNING created_at`
+
+	return r.db.QueryRow(
+		query,
+		row.ID,
+		row.JobID,
+		row.RowNumber,
+		row.Status,
+		row.Action,
+		row.ProductID,
+		row.VariantID,
+		row.MatchKey,
+		row.Field,
+		row.Message,
+	).Scan(&row.CreatedAt)
+}
+
+func (r *importJobRepository) GetRows(jobID uuid.UUID, status *models.ImportRowStatus, limit int, offset int) ([]*models.ImportJobRow, int64, error) {
+	whereClause := "WHERE job_id = $1"
+	args := []interface{}{jobID}
+	if status != nil {
+		whereClause += " AND status = $2"
+		args = append(args, *status)
+	}
+
+	// Count query
+	var total int64
+	err := r.db.QueryRow("SELECT COUNT(*) FROM import_job_rows "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, job_id, row_number, status, action, product_id, variant_id, match_key, field, message, created_at
+		FROM import_job_rows %s
+		ORDER BY row_number
+		LIMIT $%d OFFSET $%d`, whereClause, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var jobRows []*models.ImportJobRow
+	for rows.Next() {
+		row := &models.ImportJobRow{}
+		err := rows.Scan(
+			&row.ID,
+			&row.JobID,
+			&row.RowNumber,
+			&row.Status,
+			&row.Action,
+			&row.ProductID,
+			&row.VariantID,
+			&row.MatchKey,
+			&row.Field,
+			&row.Message,
+			&row.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		jobRows = append(jobRows, row)
+	}
+
+	return jobRows, total, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanImportJob(row rowScanner) (*models.ImportJob, error) {
+	job := &models.ImportJob{}
+	var headerMappingJSON []byte
+
+	err := row.Scan(
+		&job.ID,
+		&job.SellerID,
+		&job.Status,
+		&job.Format,
+		&job.DryRun,
+		&job.MatchBy,
+		&headerMappingJSON,
+		&job.FileName,
+		&job.ObjectName,
+		&job.TotalRows,
+		&job.ProcessedRows,
+		&job.CreatedCount,
+		&job.UpdatedCount,
+		&job.ErrorCount,
+		&job.ErrorMessage,
+		&job.StartedAt,
+		&job.CompletedAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deserialize header mapping
+	if len(headerMappingJSON) > 0 {
+		if err := json.Unmarshal(headerMappingJSON, &job.HeaderMapping); err != nil {
+			return nil, fmt.Errorf("failed to deserialize header mapping: %w", err)
+		}
+	}
+
+	return job, nil
+}
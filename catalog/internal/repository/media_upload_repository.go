@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+type MediaUploadRepository interface {
+	Create(upload *models.MediaUpload) error
+	GetByID(id uuid.UUID) (*models.MediaUpload, error)
+	MarkConfirmed(id uuid.UUID, mediaID uuid.UUID) error
+	MarkFailed(id uuid.UUID, message string) error
+	ExpirePending(limit int) ([]*models.MediaUpload, error)
+}
+
+type mediaUploadRepository struct {
+	db *sql.DB
+}
+
+func NewMediaUploadRepository(db *sql.DB) MediaUploadRepository {
+	return &mediaUploadRepository{db: db}
+}
+
+const mediaUploadColumns = `id, product_id, variant_id, object_name, file_name, content_type, file_size,
+		       status, media_id, error_message, expires_at, created_at, updated_at`
+
+func (r *mediaUploadRepository) Create(upload *models.MediaUpload) error {
+	query := `
+		INSERT INTO media_uploads (id, product_id, variant_id, object_name, file_name, content_type, file_size, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRow(
+		query,
+		upload.ID,
+		upload.ProductID,
+		upload.VariantID,
+		upload.ObjectName,
+		upload.FileName,
+		upload.ContentType,
+		upload.FileSize,
+		upload.Status,
+		upload.ExpiresAt,
+	).Scan(&upload.CreatedAt, &upload.UpdatedAt)
+}
+
+func (r *mediaUploadRepository) GetByID(id uuid.UUID) (*models.MediaUpload, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM media_uploads WHERE id = $1`, mediaUploadColumns)
+
+	upload, err := scanMediaUpload(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return upload, err
+}
+
+func (r *mediaUploadRepository) MarkConfirmed(id uuid.UUID, mediaID uuid.UUID) error {
+	query := `
+		UPDATE media_uploads
+		SET status = $2, media_id = $3
+		WHERE id = $1`
+
+	return r.exec(query, id, models.MediaUploadStatusConfirmed, mediaID)
+}
+
+func (r *mediaUploadRepository) MarkFailed(id uuid.UUID, message string) error {
+	query := `
+		UPDATE media_uploads
+		SET status = $2, error_message = $3
+		WHERE id = $1`
+
+	return r.exec(query, id, models.MediaUploadStatusFailed, message)
+}
+
+// ExpirePending marks pending uploads past their expiry as EXPIRED and
+// returns them so their stored objects can be removed
+func (r *mediaUploadRepository) ExpirePending(limit int) ([]*models.MediaUpload, error) {
+	query := fmt.Sprintf(`
+		UPDATE media_uploads
+		SET status = $1
+		WHERE id IN (
+			SELECT id FROM media_uploads
+			WHERE status = $2 AND expires_at <= NOW()
+			ORDER BY expires_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %s`, mediaUploadColumns)
+
+	rows, err := r.db.Query(query, models.MediaUploadStatusExpired, models.MediaUploadStatusPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uploads []*models.MediaUpload
+	for rows.Next() {
+		upload, err := scanMediaUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, rows.Err()
+}
+
+func (r *mediaUploadRepository) exec(query string, args ...interface{}) error {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("media upload not found")
+	}
+
+	return nil
+}
+
+func scanMediaUpload(row rowScanner) (*models.MediaUpload, error) {
+	upload := &models.MediaUpload{}
+	err := row.Scan(
+		&upload.ID,
+		&upload.ProductID,
+		&upload.VariantID,
+		&upload.ObjectName,
+		&upload.FileName,
+		&upload.ContentType,
+		&upload.FileSize,
+		&upload.Status,
+		&upload.MediaID,
+		&upload.ErrorMessage,
+		&upload.ExpiresAt,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
@@ -2,12 +2,15 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/cebeuygun/platform/services/catalog/internal/models"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
 )
 
 type ProductRepository interface {
@@ -15,22 +18,70 @@ type ProductRepository interface {
 	GetByID(id uuid.UUID) (*models.Product, error)
 	GetBySKU(sku string) (*models.Product, error)
 	GetByBarcode(barcode string) (*models.Product, error)
-	Search(req *models.SearchRequest) ([]*models.Product, int64, error)
+	FindBarcodeOwners(barcodes []string) ([]*models.BarcodeOwner, error)
+	Search(req *models.SearchRequest) ([]*models.Product, bool, error)
+	CountSearch(req *models.SearchRequest) (int64, error)
+	EstimateSearchCount(req *models.SearchRequest) (int64, error)
+	GetAttributeFacets(req *models.SearchRequest, attributes []*models.CategoryAttribute) ([]*models.AttributeFacet, error)
 	Update(id uuid.UUID, updates *models.UpdateProductRequest) error
-	Delete(id uuid.UUID) error
+	Restore(product *models.Product) error
+	Delete(id uuid.UUID, actor string) error
 	GetVariants(productID uuid.UUID) ([]*models.ProductVariant, error)
+	GetVariant(id uuid.UUID) (*models.ProductVariant, error)
 	GetMedia(productID uuid.UUID, variantID *uuid.UUID) ([]*models.ProductMedia, error)
+	GetAllMedia(productID uuid.UUID) ([]*models.ProductMedia, error)
+	GetMediaByHash(contentHash string) ([]*models.ProductMedia, error)
+	GetUnhashedMedia(after uuid.UUID, limit int) ([]*models.ProductMedia, error)
+	SetPerceptualHash(media *models.ProductMedia, hash int64) error
+	ClaimPendingMedia() (*models.ProductMedia, error)
+	CompleteMediaProcessing(media *models.ProductMedia) error
+	FailMediaProcessing(id uuid.UUID, message string) error
 	GetSellerData(productID uuid.UUID, sellerID *uuid.UUID) ([]*models.SellerProduct, error)
 	CreateVariant(variant *models.ProductVariant) error
 	UpdateVariant(id uuid.UUID, variant *models.ProductVariant) error
 	DeleteVariant(id uuid.UUID) error
 	CreateMedia(media *models.ProductMedia) error
 	UpdateMedia(id uuid.UUID, media *models.ProductMedia) error
-	DeleteMedia(id uuid.UUID) error
+	DeleteMedia(id uuid.UUID) (uuid.UUID, error)
+	GetSellerProduct(id uuid.UUID) (*models.SellerProduct, error)
 	UpsertSellerProduct(sellerProduct *models.SellerProduct) error
 	DeleteSellerProduct(id uuid.UUID) error
+	GetLowStockBySeller(sellerID uuid.UUID) ([]*models.LowStockItem, error)
+	GetBySeller(req *models.SellerProductListRequest) ([]*models.SellerProduct, int64, error)
+	PublishStockAlerts(limit int, publish func([]*models.StockAlertEvent) error) (int, error)
+	EachBySeller(req *models.SellerProductListRequest, fn func(*models.SellerProduct) error) error
 	GetFeatured(limit int) ([]*models.Product, error)
-	GetByCategory(categoryID uuid.UUID, limit int, offset int) ([]*models.Product, int64, error)
+	GetForExport(sellerID *uuid.UUID, categoryID *uuid.UUID, activeOnly bool) ([]*models.Product, error)
+
+	// Batch lookups
+	GetByIDs(ids []uuid.UUID) ([]*models.Product, error)
+	GetBySKUs(skus []string) ([]*models.Product, error)
+	GetVariantsByProductIDs(productIDs []uuid.UUID) ([]*models.ProductVariant, error)
+	GetMediaByProductIDs(productIDs []uuid.UUID) ([]*models.ProductMedia, error)
+
+	// Moderation operations
+	UpdateModeration(product *models.Product) error
+	GetModerationQueue(req *models.ModerationQueueRequest) ([]*models.Product, int64, error)
+	GetCategoryPriceMedian(categoryID uuid.UUID) (decimal.Decimal, int, error)
+
+	// Availability operations
+	UpdateAvailability(product *models.Product) error
+	GetScheduledProducts() ([]*models.Product, error)
+	GetNextPublishBoundary() (*time.Time, error)
+	GetScheduledSellerProducts() ([]*models.SellerProduct, error)
+	SetAvailability(id uuid.UUID, available bool) (bool, error)
+	SetSellerProductAvailability(id uuid.UUID, available bool) (bool, error)
+
+	// Trash operations
+	GetDeletedByID(id uuid.UUID) (*models.Product, error)
+	GetDeleted(page int, limit int) ([]*models.Product, int64, error)
+	Undelete(id uuid.UUID) error
+	RecordOrderedProducts(productIDs []uuid.UUID, orderedAt time.Time) error
+	OrderedProductsBackfilled() (bool, error)
+	PurgeDeleted(before time.Time, limit int) ([]uuid.UUID, error)
+
+	// Price history
+	GetPriceHistory(req *models.PriceHistoryRequest) ([]*models.PriceChange, int64, error)
 }
 
 type productRepository struct {
@@ -45,11 +96,14 @@ func (r *productRepository) Create(product *models.Product) error {
 	query := `
 		INSERT INTO products (id, name, description, category_id, brand, sku, barcode, base_price, currency, 
 		                     tax_rate, base_stock, min_stock, max_stock, weight, dimensions, tags, attributes, 
-		                     is_active, is_express_delivery, preparation_time)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		                     is_active, is_express_delivery, preparation_time, status, submitted_by, submitted_at,
+		                     moderation_flags, publish_at, unpublish_at, availability_schedule, is_available,
+		                     product_type, food_info)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+		        $21, $22, $23, $24, $25, $26, $27, $28, $29, $30)
 		RETURNING created_at, updated_at`
 	
-	return r.db.QueryRow(
+	err := r.db.QueryRow(
 		query,
 		product.ID,
 		product.Name,
@@ -71,7 +125,18 @@ func (r *productRepository) Create(product *models.Product) error {
 		product.IsActive,
 		product.IsExpressDelivery,
 		product.PreparationTime,
+		product.Status,
+		product.SubmittedBy,
+		product.SubmittedAt,
+		product.ModerationFlags,
+		product.PublishAt,
+		product.UnpublishAt,
+		product.AvailabilitySchedule,
+		product.IsAvailable,
+		product.ProductType,
+		product.FoodInfo,
 	).Scan(&product.CreatedAt, &product.UpdatedAt)
+	return barcodeConflict(err)
 }
 
 func (r *productRepository) GetByID(id uuid.UUID) (*models.Product, error) {
@@ -80,10 +145,12 @@ func (r *productRepository) GetByID(id uuid.UUID) (*models.Product, error) {
 		SELECT p.id, p.name, p.description, p.category_id, p.brand, p.sku, p.barcode, p.base_price, p.currency,
 		       p.tax_rate, p.base_stock, p.min_stock, p.max_stock, p.weight, p.dimensions, p.tags, p.attributes,
 		       p.is_active, p.is_express_delivery, p.preparation_time, p.created_at, p.updated_at,
-		       c.name as category_name
+		       p.status, p.submitted_by, p.submitted_at, p.reviewed_by, p.reviewed_at, p.rejection_reason,
+		       p.rejection_note, p.moderation_flags, p.publish_at, p.unpublish_at, p.availability_schedule,
+		       p.is_available, p.product_type, p.food_info, p.rating_average, p.rating_count, ` + productPriceColumns + `, c.name as category_name
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.id = $1`
+		WHERE p.id = $1 AND p.deleted_at IS NULL`
 	
 	var categoryName sql.NullString
 	err := r.db.QueryRow(query, id).Scan(
@@ -109,6 +176,24 @@ func (r *productRepository) GetByID(id uuid.UUID) (*models.Product, error) {
 		&product.PreparationTime,
 		&product.CreatedAt,
 		&product.UpdatedAt,
+		&product.Status,
+		&product.SubmittedBy,
+		&product.SubmittedAt,
+		&product.ReviewedBy,
+		&product.ReviewedAt,
+		&product.RejectionReason,
+		&product.RejectionNote,
+		&product.ModerationFlags,
+		&product.PublishAt,
+		&product.UnpublishAt,
+		&product.AvailabilitySchedule,
+		&product.IsAvailable,
+		&product.ProductType,
+		&product.FoodInfo,
+		&product.RatingAverage,
+		&product.RatingCount,
+		&product.CompareAtPrice,
+		&product.ReferencePrice,
 		&categoryName,
 	)
 	
@@ -136,8 +221,11 @@ func (r *productRepository) GetBySKU(sku string) (*models.Product, error) {
 	query := `
 		SELECT id, name, description, category_id, brand, sku, barcode, base_price, currency,
 		       tax_rate, base_stock, min_stock, max_stock, weight, dimensions, tags, attributes,
-		       is_active, is_express_delivery, preparation_time, created_at, updated_at
-		FROM products WHERE sku = $1`
+		       is_active, is_express_delivery, preparation_time, created_at, updated_at,
+		       status, submitted_by, submitted_at, reviewed_by, reviewed_at, rejection_reason,
+		       rejection_note, moderation_flags, publish_at, unpublish_at, availability_schedule, is_available,
+		       product_type, food_info, rating_average, rating_count, ` + productPriceColumns + `
+		FROM products p WHERE sku = $1 AND deleted_at IS NULL`
 	
 	err := r.db.QueryRow(query, sku).Scan(
 		&product.ID,
@@ -162,6 +250,24 @@ func (r *productRepository) GetBySKU(sku string) (*models.Product, error) {
 		&product.PreparationTime,
 		&product.CreatedAt,
 		&product.UpdatedAt,
+		&product.Status,
+		&product.SubmittedBy,
+		&product.SubmittedAt,
+		&product.ReviewedBy,
+		&product.ReviewedAt,
+		&product.RejectionReason,
+		&product.RejectionNote,
+		&product.ModerationFlags,
+		&product.PublishAt,
+		&product.UnpublishAt,
+		&product.AvailabilitySchedule,
+		&product.IsAvailable,
+		&product.ProductType,
+		&product.FoodInfo,
+		&product.RatingAverage,
+		&product.RatingCount,
+		&product.CompareAtPrice,
+		&product.ReferencePrice,
 	)
 	
 	if err == sql.ErrNoRows {
@@ -176,8 +282,13 @@ func (r *productRepository) GetByBarcode(barcode string) (*models.Product, error
 	query := `
 		SELECT id, name, description, category_id, brand, sku, barcode, base_price, currency,
 		       tax_rate, base_stock, min_stock, max_stock, weight, dimensions, tags, attributes,
-		       is_active, is_express_delivery, preparation_time, created_at, updated_at
-		FROM products WHERE barcode = $1`
+		       is_active, is_express_delivery, preparation_time, created_at, updated_at,
+		       status, submitted_by, submitted_at, reviewed_by, reviewed_at, rejection_reason,
+		       rejection_note, moderation_flags, publish_at, unpublish_at, availability_schedule, is_available,
+		       product_type, food_info, rating_average, rating_count, ` + productPriceColumns + `
+		FROM products p WHERE (barcode = $1 OR gtin_key(barcode) = gtin_key($1)) AND deleted_at IS NULL
+		ORDER BY barcode = $1 DESC
+		LIMIT 1`
 	
 	err := r.db.QueryRow(query, barcode).Scan(
 		&product.ID,
@@ -202,6 +313,24 @@ func (r *productRepository) GetByBarcode(barcode string) (*models.Product, error
 		&product.PreparationTime,
 		&product.CreatedAt,
 		&product.UpdatedAt,
+		&product.Status,
+		&product.SubmittedBy,
+		&product.SubmittedAt,
+		&product.ReviewedBy,
+		&product.ReviewedAt,
+		&product.RejectionReason,
+		&product.RejectionNote,
+		&product.ModerationFlags,
+		&product.PublishAt,
+		&product.UnpublishAt,
+		&product.AvailabilitySchedule,
+		&product.IsAvailable,
+		&product.ProductType,
+		&product.FoodInfo,
+		&product.RatingAverage,
+		&product.RatingCount,
+		&product.CompareAtPrice,
+		&product.ReferencePrice,
 	)
 	
 	if err == sql.ErrNoRows {
@@ -211,22 +340,57 @@ func (r *productRepository) GetByBarcode(barcode string) (*models.Product, error
 	return product, err
 }
 
-func (r *productRepository) Search(req *models.SearchRequest) ([]*models.Product, int64, error) {
-	var conditions []string
+// productPublishedCondition limits a query to products inside their publish/unpublish window
+const productPublishedCondition = "(p.publish_at IS NULL OR p.publish_at <= NOW()) AND (p.unpublish_at IS NULL OR p.unpublish_at > NOW())"
+
+// productAvailableCondition also applies the weekly schedule, which the
+// availability scheduler evaluates into is_available. The publish window is
+// checked here directly so that launches do not wait for the next tick.
+const productAvailableCondition = "(p.availability_schedule IS NULL OR p.is_available = true) AND " + productPublishedCondition
+
+// productPriceColumns select the compare-at price of products p, which reads
+// show only while it is a real discount, and their reference price
+const productPriceColumns = "valid_compare_at_price(p.compare_at_price, p.base_price, p.reference_price), p.reference_price"
+
+// variantPriceColumns and sellerProductPriceColumns do the same for
+// variants v and seller listings sp
+const variantPriceColumns = "valid_compare_at_price(v.compare_at_price, v.price, v.reference_price), v.reference_price"
+const sellerProductPriceColumns = "valid_compare_at_price(sp.compare_at_price, sp.price, sp.reference_price), sp.reference_price"
+
+// queryCondition matches products whose text is ILIKE pattern, an argument
+// or ANY of an array argument. Shoppers may search in any language the
+// product is translated to.
+func queryCondition(pattern string) string {
+	return fmt.Sprintf(`(p.name ILIKE %[1]s OR p.description ILIKE %[1]s OR EXISTS (
+			SELECT 1 FROM product_translations pt
+			WHERE pt.product_id = p.id AND (pt.name ILIKE %[1]s OR pt.description ILIKE %[1]s)))`, pattern)
+}
+
+// searchConditions builds the WHERE conditions of a product search. The
+// filter on skipAttribute is left out so that its facet counts the values
+// the other filters allow.
+func searchConditions(req *models.SearchRequest, skipAttribute string) ([]string, []interface{}, int) {
+	// Only approved products inside their availability window are visible to shoppers
+	conditions := []string{"p.deleted_at IS NULL", "p.status = 'APPROVED'", productAvailableCondition}
 	var args []interface{}
 	argIndex := 1
 
-	// Base query
-	baseQuery := `
-		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.id`
-
 	// Build WHERE conditions
-	if req.Query != "" {
-		conditions = append(conditions, fmt.Sprintf("(p.name ILIKE $%d OR p.description ILIKE $%d)", argIndex, argIndex+1))
-		searchTerm := "%" + req.Query + "%"
-		args = append(args, searchTerm, searchTerm)
-		argIndex += 2
+	if len(req.QueryTerms) > 0 {
+		// Every word of the query, or one of its synonyms, has to appear
+		for _, group := range req.QueryTerms {
+			patterns := make([]string, len(group))
+			for i, term := range group {
+				patterns[i] = "%" + term + "%"
+			}
+			conditions = append(conditions, queryCondition(fmt.Sprintf("ANY($%d)", argIndex)))
+			args = append(args, pq.Array(patterns))
+			argIndex++
+		}
+	} else if req.Query != "" {
+		conditions = append(conditions, queryCondition(fmt.Sprintf("$%d", argIndex)))
+		args = append(args, "%"+req.Query+"%")
+		argIndex++
 	}
 
 	if req.CategoryID != nil {
@@ -269,55 +433,198 @@ func (r *productRepository) Search(req *models.SearchRequest) ([]*models.Product
 		conditions = append(conditions, "p.is_express_delivery = true")
 	}
 
+	// Location-aware searches only show products some seller delivers to the shopper
+	if req.Latitude != nil && req.Longitude != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM seller_products sp WHERE sp.product_id = p.id AND %s AND %s)",
+			sellerProductAvailableCondition, sellerServesCondition(argIndex)))
+		args = append(args, pointEWKT(models.GeoPoint{Latitude: *req.Latitude, Longitude: *req.Longitude}))
+		argIndex++
+	}
+
+	// Products without food information cannot be vouched for, so food filters exclude them
+	if len(req.FreeFrom) > 0 {
+		conditions = append(conditions, fmt.Sprintf(
+			"p.food_info IS NOT NULL AND NOT (p.food_info->'allergens' ?| $%[1]d::text[] OR p.food_info->'may_contain' ?| $%[1]d::text[])", argIndex))
+		args = append(args, pq.Array(req.FreeFrom))
+		argIndex++
+	}
+
+	if len(req.DietaryLabels) > 0 {
+		labels, _ := json.Marshal(req.DietaryLabels)
+		conditions = append(conditions, fmt.Sprintf("p.food_info->'dietary_labels' @> $%d::jsonb", argIndex))
+		args = append(args, string(labels))
+		argIndex++
+	}
+
+	for _, filter := range req.AttributeFilters {
+		if filter.Code == skipAttribute {
+			continue
+		}
+
+		keyArg := argIndex
+		args = append(args, filter.Code)
+		argIndex++
+
+		var valuesArg, minArg, maxArg int
+		if len(filter.Values) > 0 {
+			valuesArg = argIndex
+			args = append(args, pq.Array(filter.Values))
+			argIndex++
+		}
+		if filter.Min != nil {
+			minArg = argIndex
+			args = append(args, *filter.Min)
+			argIndex++
+		}
+		if filter.Max != nil {
+			maxArg = argIndex
+			args = append(args, *filter.Max)
+			argIndex++
+		}
+
+		match := func(alias string) string {
+			if valuesArg > 0 {
+				return fmt.Sprintf("%s.attributes->>$%d::text = ANY($%d)", alias, keyArg, valuesArg)
+			}
+			bounds := []string{fmt.Sprintf("jsonb_typeof(%s.attributes->$%d::text) = 'number'", alias, keyArg)}
+			if minArg > 0 {
+				bounds = append(bounds, fmt.Sprintf("(%s.attributes->>$%d::text)::numeric >= $%d", alias, keyArg, minArg))
+			}
+			if maxArg > 0 {
+				bounds = append(bounds, fmt.Sprintf("(%s.attributes->>$%d::text)::numeric <= $%d", alias, keyArg, maxArg))
+			}
+			return strings.Join(bounds, " AND ")
+		}
+
+		// A product matches when the product itself or one of its active variants has the value
+		conditions = append(conditions, fmt.Sprintf(
+			"((%s) OR EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_id = p.id AND pv.is_active = true AND %s))",
+			match("p"), match("pv")))
+	}
+
+	return conditions, args, argIndex
+}
+
+// searchOrder returns the sort column of a search and whether it is walked
+// in descending order. The product ID breaks ties so that every product has
+// a fixed position for keyset pagination.
+func searchOrder(req *models.SearchRequest) (string, bool) {
+	// Relevance puts the newest products first within each merchandising tier
+	if req.SortBy == "" || req.SortBy == "relevance" {
+		return "p.created_at", true
+	}
+
+	descending := req.SortOrder == "desc"
+	switch req.SortBy {
+	case "name":
+		return "p.name", descending
+	case "price":
+		return "p.base_price", descending
+	case "rating":
+		return "p.rating_average", descending
+	case "updated_at":
+		return "p.updated_at", descending
+	default:
+		return "p.created_at", descending
+	}
+}
+
+// searchRank builds the merchandising tier of products p in a relevance
+// sort, taking its arguments from argIndex. The first tier a product matches
+// is its tier; products matching none are in tier 0.
+func searchRank(req *models.SearchRequest, argIndex int) (string, []interface{}, int) {
+	if req.SortBy != "relevance" || len(req.Ranking) == 0 {
+		return "", nil, argIndex
+	}
+
+	var args []interface{}
+	cases := make([]string, len(req.Ranking))
+	for i, tier := range req.Ranking {
+		cases[i] = fmt.Sprintf("WHEN p.id = ANY($%d::uuid[]) OR lower(p.brand) = ANY($%d::text[]) THEN %d", argIndex, argIndex+1, tier.Rank)
+		args = append(args, pq.Array(tier.ProductIDs), pq.Array(tier.Brands))
+		argIndex += 2
+	}
+
+	return "(CASE " + strings.Join(cases, " ") + " ELSE 0 END)", args, argIndex
+}
+
+// searchWhere builds the WHERE clause of a product search
+func searchWhere(req *models.SearchRequest) (string, []interface{}, int) {
+	conditions, args, argIndex := searchConditions(req, "")
+
 	whereClause := ""
 	if len(conditions) > 0 {
 		whereClause = "WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	// Count query
-	countQuery := fmt.Sprintf("SELECT COUNT(*) %s %s", baseQuery, whereClause)
-	var total int64
-	err := r.db.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, err
-	}
+	return whereClause, args, argIndex
+}
 
-	// Build ORDER BY clause
-	orderBy := "p.created_at DESC"
-	if req.SortBy != "" {
-		direction := "ASC"
-		if req.SortOrder == "desc" {
-			direction = "DESC"
+// Search returns a page of the products matching a search: the products
+// after req.After when it is set, the page req.Page otherwise. It reports
+// whether more products follow the page.
+func (r *productRepository) Search(req *models.SearchRequest) ([]*models.Product, bool, error) {
+	whereClause, args, argIndex := searchWhere(req)
+
+	rank, rankArgs, argIndex := searchRank(req, argIndex)
+	args = append(args, rankArgs...)
+
+	column, descending := searchOrder(req)
+	direction, comparison := "ASC", ">"
+	if descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	ordering := fmt.Sprintf("%s %s, p.id %s", column, direction, direction)
+	if rank != "" {
+		ordering = rank + " ASC, " + ordering
+	}
+
+	pagination := ""
+	if req.After != nil {
+		cast := map[string]string{
+			"p.name":           "text",
+			"p.base_price":     "numeric",
+			"p.rating_average": "numeric",
+			"p.created_at":     "timestamptz",
+			"p.updated_at":     "timestamptz",
+		}[column]
+		keyset := fmt.Sprintf("(%s, p.id) %s ($%d::%s, $%d::uuid)", column, comparison, argIndex, cast, argIndex+1)
+		args = append(args, req.After.Value, req.After.ID)
+		argIndex += 2
+		if rank != "" {
+			// Later tiers, or later within the tier of the last product
+			keyset = fmt.Sprintf("(%[1]s > $%[2]d OR (%[1]s = $%[2]d AND %[3]s))", rank, argIndex, keyset)
+			args = append(args, req.After.Rank)
+			argIndex++
 		}
-		
-		switch req.SortBy {
-		case "name":
-			orderBy = fmt.Sprintf("p.name %s", direction)
-		case "price":
-			orderBy = fmt.Sprintf("p.base_price %s", direction)
-		case "created_at":
-			orderBy = fmt.Sprintf("p.created_at %s", direction)
-		case "updated_at":
-			orderBy = fmt.Sprintf("p.updated_at %s", direction)
+		if whereClause == "" {
+			whereClause = "WHERE " + keyset
+		} else {
+			whereClause += " AND " + keyset
 		}
+	} else {
+		pagination = fmt.Sprintf("OFFSET %d", (req.Page-1)*req.Limit)
 	}
 
-	// Main query with pagination
-	offset := (req.Page - 1) * req.Limit
+	// One extra row tells whether another page follows
 	selectQuery := fmt.Sprintf(`
 		SELECT p.id, p.name, p.description, p.category_id, p.brand, p.sku, p.barcode, p.base_price, p.currency,
 		       p.tax_rate, p.base_stock, p.min_stock, p.max_stock, p.weight, p.dimensions, p.tags, p.attributes,
 		       p.is_active, p.is_express_delivery, p.preparation_time, p.created_at, p.updated_at,
-		       c.name as category_name
-		%s %s
+		       p.rating_average, p.rating_count, ` + productPriceColumns + `, c.name as category_name
+		FROM products p
+		LEFT JOIN categories c ON p.category_id = c.id
+		%s
 		ORDER BY %s
-		LIMIT $%d OFFSET $%d`, baseQuery, whereClause, orderBy, argIndex, argIndex+1)
-	
-	args = append(args, req.Limit, offset)
+		LIMIT $%d %s`, whereClause, ordering, argIndex, pagination)
+
+	args = append(args, req.Limit+1)
 
 	rows, err := r.db.Query(selectQuery, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, false, err
 	}
 	defer rows.Close()
 
@@ -349,10 +656,14 @@ func (r *productRepository) Search(req *models.SearchRequest) ([]*models.Product
 			&product.PreparationTime,
 			&product.CreatedAt,
 			&product.UpdatedAt,
+			&product.RatingAverage,
+			&product.RatingCount,
+			&product.CompareAtPrice,
+			&product.ReferencePrice,
 			&categoryName,
 		)
 		if err != nil {
-			return nil, 0, err
+			return nil, false, err
 		}
 
 		// Load category if exists
@@ -365,8 +676,132 @@ func (r *productRepository) Search(req *models.SearchRequest) ([]*models.Product
 
 		products = append(products, product)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
 
-	return products, total, rows.Err()
+	hasMore := len(products) > req.Limit
+	if hasMore {
+		products = products[:req.Limit]
+	}
+
+	return products, hasMore, nil
+}
+
+// CountSearch counts the products matching a search exactly
+func (r *productRepository) CountSearch(req *models.SearchRequest) (int64, error) {
+	whereClause, args, _ := searchWhere(req)
+
+	var total int64
+	err := r.db.QueryRow("SELECT COUNT(*) FROM products p "+whereClause, args...).Scan(&total)
+	return total, err
+}
+
+// EstimateSearchCount returns the query planner's estimate of the number of
+// products matching a search, which costs no more than planning the query
+func (r *productRepository) EstimateSearchCount(req *models.SearchRequest) (int64, error) {
+	whereClause, args, _ := searchWhere(req)
+
+	var plan []byte
+	err := r.db.QueryRow("EXPLAIN (FORMAT JSON) SELECT 1 FROM products p "+whereClause, args...).Scan(&plan)
+	if err != nil {
+		return 0, err
+	}
+
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil {
+		return 0, fmt.Errorf("failed to parse query plan: %w", err)
+	}
+	if len(explained) == 0 {
+		return 0, fmt.Errorf("empty query plan")
+	}
+
+	return int64(explained[0].Plan.Rows), nil
+}
+
+// GetAttributeFacets counts the values of each attribute among the products
+// matching a search. Number attributes report their value range instead.
+func (r *productRepository) GetAttributeFacets(req *models.SearchRequest, attributes []*models.CategoryAttribute) ([]*models.AttributeFacet, error) {
+	var facets []*models.AttributeFacet
+
+	for _, attribute := range attributes {
+		conditions, args, argIndex := searchConditions(req, attribute.Code)
+		args = append(args, attribute.Code)
+
+		// Values of the attribute on each product and its active variants
+		fromClause := fmt.Sprintf(`
+			FROM products p
+			CROSS JOIN LATERAL (
+				SELECT p.attributes->$%[1]d::text AS value
+				UNION
+				SELECT pv.attributes->$%[1]d::text FROM product_variants pv
+				WHERE pv.product_id = p.id AND pv.is_active = true
+			) v`, argIndex)
+
+		facet := &models.AttributeFacet{
+			Code: attribute.Code,
+			Name: attribute.Name,
+			Type: attribute.Type,
+			Unit: attribute.Unit,
+		}
+
+		if attribute.Type == models.AttributeTypeNumber {
+			conditions = append(conditions, "jsonb_typeof(v.value) = 'number'")
+			query := fmt.Sprintf(`
+				SELECT MIN((v.value #>> '{}')::numeric), MAX((v.value #>> '{}')::numeric)
+				%s
+				WHERE %s`, fromClause, strings.Join(conditions, " AND "))
+
+			var min, max decimal.NullDecimal
+			if err := r.db.QueryRow(query, args...).Scan(&min, &max); err != nil {
+				return nil, err
+			}
+			if !min.Valid {
+				continue
+			}
+			facet.Min = &min.Decimal
+			facet.Max = &max.Decimal
+			facets = append(facets, facet)
+			continue
+		}
+
+		conditions = append(conditions, "jsonb_typeof(v.value) IN ('string', 'boolean')")
+		query := fmt.Sprintf(`
+			SELECT v.value #>> '{}', COUNT(DISTINCT p.id)
+			%s
+			WHERE %s
+			GROUP BY 1
+			ORDER BY 2 DESC, 1`, fromClause, strings.Join(conditions, " AND "))
+
+		rows, err := r.db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			value := &models.FacetValue{}
+			if err := rows.Scan(&value.Value, &value.Count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			facet.Values = append(facet.Values, value)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(facet.Values) > 0 {
+			facets = append(facets, facet)
+		}
+	}
+
+	return facets, nil
 }
 
 func (r *productRepository) Update(id uuid.UUID, updates *models.UpdateProductRequest) error {
@@ -416,6 +851,17 @@ func (r *productRepository) Update(id uuid.UUID, updates *models.UpdateProductRe
 		argIndex++
 	}
 
+	// A compare-at price of zero removes it
+	if updates.CompareAtPrice != nil {
+		setParts = append(setParts, fmt.Sprintf("compare_at_price = $%d", argIndex))
+		if updates.CompareAtPrice.IsZero() {
+			args = append(args, nil)
+		} else {
+			args = append(args, *updates.CompareAtPrice)
+		}
+		argIndex++
+	}
+
 	if updates.Currency != nil {
 		setParts = append(setParts, fmt.Sprintf("currency = $%d", argIndex))
 		args = append(args, *updates.Currency)
@@ -470,6 +916,12 @@ func (r *productRepository) Update(id uuid.UUID, updates *models.UpdateProductRe
 		argIndex++
 	}
 
+	if updates.FoodInfo != nil {
+		setParts = append(setParts, fmt.Sprintf("food_info = $%d", argIndex))
+		args = append(args, updates.FoodInfo)
+		argIndex++
+	}
+
 	if updates.IsActive != nil {
 		setParts = append(setParts, fmt.Sprintf("is_active = $%d", argIndex))
 		args = append(args, *updates.IsActive)
@@ -492,14 +944,68 @@ func (r *productRepository) Update(id uuid.UUID, updates *models.UpdateProductRe
 		return fmt.Errorf("no fields to update")
 	}
 
-	query := fmt.Sprintf("UPDATE products SET %s WHERE id = $%d", strings.Join(setParts, ", "), argIndex)
+	query := fmt.Sprintf("UPDATE products SET %s WHERE id = $%d AND deleted_at IS NULL", strings.Join(setParts, ", "), argIndex)
 	args = append(args, id)
 
 	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return compareAtPriceRejected(barcodeConflict(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
+	if rowsAffected == 0 {
+		return fmt.Errorf("product not found")
+	}
+
+	return nil
+}
+
+// Restore overwrites every editable column of a product with the given values
+func (r *productRepository) Restore(product *models.Product) error {
+	query := `
+		UPDATE products
+		SET name = $2, description = $3, category_id = $4, brand = $5, sku = $6, barcode = $7, base_price = $8,
+		    currency = $9, tax_rate = $10, base_stock = $11, min_stock = $12, max_stock = $13, weight = $14,
+		    dimensions = $15, tags = $16, attributes = $17, is_active = $18, is_express_delivery = $19,
+		    preparation_time = $20, publish_at = $21, unpublish_at = $22, availability_schedule = $23,
+		    food_info = $24
+		WHERE id = $1`
+	
+	result, err := r.db.Exec(
+		query,
+		product.ID,
+		product.Name,
+		product.Description,
+		product.CategoryID,
+		product.Brand,
+		product.SKU,
+		product.Barcode,
+		product.BasePrice,
+		product.Currency,
+		product.TaxRate,
+		product.BaseStock,
+		product.MinStock,
+		product.MaxStock,
+		product.Weight,
+		product.Dimensions,
+		pq.Array(product.Tags),
+		product.Attributes,
+		product.IsActive,
+		product.IsExpressDelivery,
+		product.PreparationTime,
+		product.PublishAt,
+		product.UnpublishAt,
+		product.AvailabilitySchedule,
+		product.FoodInfo,
+	)
+	if err != nil {
+		return barcodeConflict(err)
+	}
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return err
@@ -512,8 +1018,10 @@ func (r *productRepository) Update(id uuid.UUID, updates *models.UpdateProductRe
 	return nil
 }
 
-func (r *productRepository) Delete(id uuid.UUID) error {
-	result, err := r.db.Exec("DELETE FROM products WHERE id = $1", id)
+// Delete moves a product to the trash. Its rows are kept, so that orders and
+// revisions referring to it stay resolvable, until PurgeDeleted removes them.
+func (r *productRepository) Delete(id uuid.UUID, actor string) error {
+	result, err := r.db.Exec("UPDATE products SET deleted_at = NOW(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL", id, actor)
 	if err != nil {
 		return err
 	}
@@ -532,9 +1040,9 @@ func (r *productRepository) Delete(id uuid.UUID) error {
 
 func (r *productRepository) GetVariants(productID uuid.UUID) ([]*models.ProductVariant, error) {
 	query := `
-		SELECT id, product_id, name, sku, barcode, price, stock, weight, dimensions, attributes,
-		       is_active, sort_order, created_at, updated_at
-		FROM product_variants
+		SELECT id, product_id, name, sku, barcode, price, ` + variantPriceColumns + `, stock, weight,
+		       dimensions, attributes, is_active, sort_order, created_at, updated_at
+		FROM product_variants v
 		WHERE product_id = $1 AND is_active = true
 		ORDER BY sort_order, name`
 	
@@ -554,6 +1062,8 @@ func (r *productRepository) GetVariants(productID uuid.UUID) ([]*models.ProductV
 			&variant.SKU,
 			&variant.Barcode,
 			&variant.Price,
+			&variant.CompareAtPrice,
+			&variant.ReferencePrice,
 			&variant.Stock,
 			&variant.Weight,
 			&variant.Dimensions,
@@ -572,28 +1082,181 @@ func (r *productRepository) GetVariants(productID uuid.UUID) ([]*models.ProductV
 	return variants, rows.Err()
 }
 
+// GetVariant returns a variant by ID, including inactive variants
+func (r *productRepository) GetVariant(id uuid.UUID) (*models.ProductVariant, error) {
+	variant := &models.ProductVariant{}
+	query := `
+		SELECT id, product_id, name, sku, barcode, price, ` + variantPriceColumns + `, stock, weight,
+		       dimensions, attributes, is_active, sort_order, created_at, updated_at
+		FROM product_variants v
+		WHERE id = $1`
+	
+	err := r.db.QueryRow(query, id).Scan(
+		&variant.ID,
+		&variant.ProductID,
+		&variant.Name,
+		&variant.SKU,
+		&variant.Barcode,
+		&variant.Price,
+		&variant.CompareAtPrice,
+		&variant.ReferencePrice,
+		&variant.Stock,
+		&variant.Weight,
+		&variant.Dimensions,
+		&variant.Attributes,
+		&variant.IsActive,
+		&variant.SortOrder,
+		&variant.CreatedAt,
+		&variant.UpdatedAt,
+	)
+	
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	
+	if err != nil {
+		return nil, err
+	}
+	
+	return variant, nil
+}
+
 func (r *productRepository) GetMedia(productID uuid.UUID, variantID *uuid.UUID) ([]*models.ProductMedia, error) {
 	var query string
 	var args []interface{}
 	
 	if variantID != nil {
-		query = `
-			SELECT id, product_id, variant_id, type, url, file_name, file_size, mime_type, alt_text,
-			       sort_order, is_active, created_at, updated_at
+		query = fmt.Sprintf(`
+			SELECT %s
 			FROM product_media
 			WHERE product_id = $1 AND variant_id = $2 AND is_active = true
-			ORDER BY sort_order`
+			ORDER BY sort_order`, mediaColumns)
 		args = []interface{}{productID, *variantID}
 	} else {
-		query = `
-			SELECT id, product_id, variant_id, type, url, file_name, file_size, mime_type, alt_text,
-			       sort_order, is_active, created_at, updated_at
+		query = fmt.Sprintf(`
+			SELECT %s
 			FROM product_media
 			WHERE product_id = $1 AND variant_id IS NULL AND is_active = true
-			ORDER BY sort_order`
+			ORDER BY sort_order`, mediaColumns)
 		args = []interface{}{productID}
 	}
 	
+	return r.queryMedia(query, args...)
+}
+
+// GetAllMedia returns the active media of a product including its variants' media
+func (r *productRepository) GetAllMedia(productID uuid.UUID) ([]*models.ProductMedia, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM product_media
+		WHERE product_id = $1 AND is_active = true
+		ORDER BY sort_order`, mediaColumns)
+	
+	return r.queryMedia(query, productID)
+}
+
+// GetMediaByHash returns media with the given content hash, processed media first
+func (r *productRepository) GetMediaByHash(contentHash string) ([]*models.ProductMedia, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM product_media
+		WHERE content_hash = $1 AND is_active = true AND processing_status <> $2
+		ORDER BY processing_status = $3 DESC, created_at`, mediaColumns)
+	
+	return r.queryMedia(query, contentHash, models.MediaProcessingStatusFailed, models.MediaProcessingStatusReady)
+}
+
+// GetUnhashedMedia returns processed images without a perceptual hash in ID
+// order, starting after the given ID
+func (r *productRepository) GetUnhashedMedia(after uuid.UUID, limit int) ([]*models.ProductMedia, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM product_media
+		WHERE id > $1 AND type = 'image' AND processing_status = $2 AND perceptual_hash IS NULL
+		ORDER BY id
+		LIMIT $3`, mediaColumns)
+
+	return r.queryMedia(query, after, models.MediaProcessingStatusReady, limit)
+}
+
+// SetPerceptualHash stores the perceptual hash of an image and of every
+// other upload of the same file
+func (r *productRepository) SetPerceptualHash(media *models.ProductMedia, hash int64) error {
+	query := `
+		UPDATE product_media
+		SET perceptual_hash = $3
+		WHERE id = $1 OR (content_hash = $2 AND perceptual_hash IS NULL)`
+
+	_, err := r.db.Exec(query, media.ID, media.ContentHash, hash)
+	return err
+}
+
+// ClaimPendingMedia atomically moves the oldest pending media to PROCESSING so
+// that concurrent catalog instances never process the same upload twice
+func (r *productRepository) ClaimPendingMedia() (*models.ProductMedia, error) {
+	query := fmt.Sprintf(`
+		UPDATE product_media
+		SET processing_status = $1
+		WHERE id = (
+			SELECT id FROM product_media
+			WHERE processing_status = $2
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %s`, mediaColumns)
+	
+	media, err := scanMedia(r.db.QueryRow(query, models.MediaProcessingStatusProcessing, models.MediaProcessingStatusPending))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	
+	return media, err
+}
+
+func (r *productRepository) CompleteMediaProcessing(media *models.ProductMedia) error {
+	derivativesJSON, err := json.Marshal(media.Derivatives)
+	if err != nil {
+		return fmt.Errorf("failed to serialize derivatives: %w", err)
+	}
+
+	query := `
+		UPDATE product_media
+		SET url = $2, file_size = $3, mime_type = $4, width = $5, height = $6, derivatives = $7,
+		    processing_status = $8, processing_error = NULL, raw_object_name = NULL, perceptual_hash = $9
+		WHERE id = $1`
+	
+	media.ProcessingStatus = models.MediaProcessingStatusReady
+	_, err = r.db.Exec(
+		query,
+		media.ID,
+		media.URL,
+		media.FileSize,
+		media.MimeType,
+		media.Width,
+		media.Height,
+		derivativesJSON,
+		media.ProcessingStatus,
+		media.PerceptualHash,
+	)
+	return err
+}
+
+func (r *productRepository) FailMediaProcessing(id uuid.UUID, message string) error {
+	query := `
+		UPDATE product_media
+		SET processing_status = $2, processing_error = $3
+		WHERE id = $1`
+	
+	_, err := r.db.Exec(query, id, models.MediaProcessingStatusFailed, message)
+	return err
+}
+
+const mediaColumns = `id, product_id, variant_id, type, url, file_name, file_size, mime_type, alt_text,
+		       sort_order, is_active, content_hash, width, height, derivatives, processing_status,
+		       processing_error, raw_object_name, perceptual_hash, created_at, updated_at`
+
+func (r *productRepository) queryMedia(query string, args ...interface{}) ([]*models.ProductMedia, error) {
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
@@ -602,22 +1265,7 @@ func (r *productRepository) GetMedia(productID uuid.UUID, variantID *uuid.UUID)
 	
 	var media []*models.ProductMedia
 	for rows.Next() {
-		m := &models.ProductMedia{}
-		err := rows.Scan(
-			&m.ID,
-			&m.ProductID,
-			&m.VariantID,
-			&m.Type,
-			&m.URL,
-			&m.FileName,
-			&m.FileSize,
-			&m.MimeType,
-			&m.AltText,
-			&m.SortOrder,
-			&m.IsActive,
-			&m.CreatedAt,
-			&m.UpdatedAt,
-		)
+		m, err := scanMedia(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -627,6 +1275,48 @@ func (r *productRepository) GetMedia(productID uuid.UUID, variantID *uuid.UUID)
 	return media, rows.Err()
 }
 
+func scanMedia(row rowScanner) (*models.ProductMedia, error) {
+	m := &models.ProductMedia{}
+	var derivativesJSON []byte
+	
+	err := row.Scan(
+		&m.ID,
+		&m.ProductID,
+		&m.VariantID,
+		&m.Type,
+		&m.URL,
+		&m.FileName,
+		&m.FileSize,
+		&m.MimeType,
+		&m.AltText,
+		&m.SortOrder,
+		&m.IsActive,
+		&m.ContentHash,
+		&m.Width,
+		&m.Height,
+		&derivativesJSON,
+		&m.ProcessingStatus,
+		&m.ProcessingError,
+		&m.RawObjectName,
+		&m.PerceptualHash,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Deserialize derivatives
+	if len(derivativesJSON) > 0 && string(derivativesJSON) != "null" {
+		m.Derivatives = &models.MediaDerivatives{}
+		if err := json.Unmarshal(derivativesJSON, m.Derivatives); err != nil {
+			return nil, fmt.Errorf("failed to deserialize derivatives: %w", err)
+		}
+	}
+	
+	return m, nil
+}
+
 func (r *productRepository) GetSellerData(productID uuid.UUID, sellerID *uuid.UUID) ([]*models.SellerProduct, error) {
 	var query string
 	var args []interface{}
@@ -634,15 +1324,17 @@ func (r *productRepository) GetSellerData(productID uuid.UUID, sellerID *uuid.UU
 	if sellerID != nil {
 		query = `
 			SELECT id, seller_id, product_id, variant_id, seller_sku, price, stock, min_stock, max_stock,
-			       is_active, is_visible, preparation_time, notes, created_at, updated_at
-			FROM seller_products
+			       is_active, is_visible, hidden_by_stock, preparation_time, notes, created_at, updated_at,
+			       publish_at, unpublish_at, availability_schedule, is_available, ` + sellerProductPriceColumns + `
+			FROM seller_products sp
 			WHERE product_id = $1 AND seller_id = $2`
 		args = []interface{}{productID, *sellerID}
 	} else {
 		query = `
 			SELECT id, seller_id, product_id, variant_id, seller_sku, price, stock, min_stock, max_stock,
-			       is_active, is_visible, preparation_time, notes, created_at, updated_at
-			FROM seller_products
+			       is_active, is_visible, hidden_by_stock, preparation_time, notes, created_at, updated_at,
+			       publish_at, unpublish_at, availability_schedule, is_available, ` + sellerProductPriceColumns + `
+			FROM seller_products sp
 			WHERE product_id = $1`
 		args = []interface{}{productID}
 	}
@@ -668,10 +1360,17 @@ func (r *productRepository) GetSellerData(productID uuid.UUID, sellerID *uuid.UU
 			&sp.MaxStock,
 			&sp.IsActive,
 			&sp.IsVisible,
+			&sp.HiddenByStock,
 			&sp.PreparationTime,
 			&sp.Notes,
 			&sp.CreatedAt,
 			&sp.UpdatedAt,
+			&sp.PublishAt,
+			&sp.UnpublishAt,
+			&sp.AvailabilitySchedule,
+			&sp.IsAvailable,
+			&sp.CompareAtPrice,
+			&sp.ReferencePrice,
 		)
 		if err != nil {
 			return nil, err
@@ -689,7 +1388,7 @@ func (r *productRepository) CreateVariant(variant *models.ProductVariant) error
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING created_at, updated_at`
 	
-	return r.db.QueryRow(
+	err := r.db.QueryRow(
 		query,
 		variant.ID,
 		variant.ProductID,
@@ -704,13 +1403,14 @@ func (r *productRepository) CreateVariant(variant *models.ProductVariant) error
 		variant.IsActive,
 		variant.SortOrder,
 	).Scan(&variant.CreatedAt, &variant.UpdatedAt)
+	return barcodeConflict(err)
 }
 
 func (r *productRepository) UpdateVariant(id uuid.UUID, variant *models.ProductVariant) error {
 	query := `
 		UPDATE product_variants 
 		SET name = $2, sku = $3, barcode = $4, price = $5, stock = $6, weight = $7, dimensions = $8,
-		    attributes = $9, is_active = $10, sort_order = $11
+		    attributes = $9, is_active = $10, sort_order = $11, compare_at_price = $12
 		WHERE id = $1`
 	
 	result, err := r.db.Exec(
@@ -726,9 +1426,10 @@ func (r *productRepository) UpdateVariant(id uuid.UUID, variant *models.ProductV
 		variant.Attributes,
 		variant.IsActive,
 		variant.SortOrder,
+		variant.CompareAtPrice,
 	)
 	if err != nil {
-		return err
+		return compareAtPriceRejected(barcodeConflict(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -762,10 +1463,20 @@ func (r *productRepository) DeleteVariant(id uuid.UUID) error {
 }
 
 func (r *productRepository) CreateMedia(media *models.ProductMedia) error {
+	var derivativesJSON []byte
+	if media.Derivatives != nil {
+		var err error
+		derivativesJSON, err = json.Marshal(media.Derivatives)
+		if err != nil {
+			return fmt.Errorf("failed to serialize derivatives: %w", err)
+		}
+	}
+
 	query := `
 		INSERT INTO product_media (id, product_id, variant_id, type, url, file_name, file_size, mime_type,
-		                          alt_text, sort_order, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		                          alt_text, sort_order, is_active, content_hash, width, height, derivatives,
+		                          processing_status, raw_object_name, perceptual_hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		RETURNING created_at, updated_at`
 	
 	return r.db.QueryRow(
@@ -781,6 +1492,13 @@ func (r *productRepository) CreateMedia(media *models.ProductMedia) error {
 		media.AltText,
 		media.SortOrder,
 		media.IsActive,
+		media.ContentHash,
+		media.Width,
+		media.Height,
+		derivativesJSON,
+		media.ProcessingStatus,
+		media.RawObjectName,
+		media.PerceptualHash,
 	).Scan(&media.CreatedAt, &media.UpdatedAt)
 }
 
@@ -819,44 +1537,95 @@ func (r *productRepository) UpdateMedia(id uuid.UUID, media *models.ProductMedia
 	return nil
 }
 
-func (r *productRepository) DeleteMedia(id uuid.UUID) error {
-	result, err := r.db.Exec("DELETE FROM product_media WHERE id = $1", id)
-	if err != nil {
-		return err
+// DeleteMedia deletes a media item and returns the product it belonged to
+func (r *productRepository) DeleteMedia(id uuid.UUID) (uuid.UUID, error) {
+	var productID uuid.UUID
+	err := r.db.QueryRow("DELETE FROM product_media WHERE id = $1 RETURNING product_id", id).Scan(&productID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("media not found")
 	}
-
-	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("media not found")
+		return uuid.Nil, err
 	}
 
-	return nil
+	return productID, nil
 }
 
-func (r *productRepository) UpsertSellerProduct(sellerProduct *models.SellerProduct) error {
+func (r *productRepository) GetSellerProduct(id uuid.UUID) (*models.SellerProduct, error) {
+	sp := &models.SellerProduct{}
 	query := `
-		INSERT INTO seller_products (id, seller_id, product_id, variant_id, seller_sku, price, stock,
-		                            min_stock, max_stock, is_active, is_visible, preparation_time, notes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-		ON CONFLICT (seller_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::UUID))
-		DO UPDATE SET
-			seller_sku = EXCLUDED.seller_sku,
-			price = EXCLUDED.price,
-			stock = EXCLUDED.stock,
-			min_stock = EXCLUDED.min_stock,
-			max_stock = EXCLUDED.max_stock,
-			is_active = EXCLUDED.is_active,
-			is_visible = EXCLUDED.is_visible,
-			preparation_time = EXCLUDED.preparation_time,
+		SELECT id, seller_id, product_id, variant_id, seller_sku, price, stock, min_stock, max_stock,
+		       is_active, is_visible, hidden_by_stock, preparation_time, notes, created_at, updated_at,
+		       publish_at, unpublish_at, availability_schedule, is_available, ` + sellerProductPriceColumns + `
+		FROM seller_products sp
+		WHERE id = $1`
+	
+	err := r.db.QueryRow(query, id).Scan(
+		&sp.ID,
+		&sp.SellerID,
+		&sp.ProductID,
+		&sp.VariantID,
+		&sp.SellerSKU,
+		&sp.Price,
+		&sp.Stock,
+		&sp.MinStock,
+		&sp.MaxStock,
+		&sp.IsActive,
+		&sp.IsVisible,
+		&sp.HiddenByStock,
+		&sp.PreparationTime,
+		&sp.Notes,
+		&sp.CreatedAt,
+		&sp.UpdatedAt,
+		&sp.PublishAt,
+		&sp.UnpublishAt,
+		&sp.AvailabilitySchedule,
+		&sp.IsAvailable,
+		&sp.CompareAtPrice,
+		&sp.ReferencePrice,
+	)
+	
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	
+	if err != nil {
+		return nil, err
+	}
+	
+	return sp, nil
+}
+
+// UpsertSellerProduct creates or replaces a seller listing. The compare-at
+// price only applies to a listing that exists: a new one has no earlier
+// price to compare with.
+func (r *productRepository) UpsertSellerProduct(sellerProduct *models.SellerProduct) error {
+	query := `
+		INSERT INTO seller_products (id, seller_id, product_id, variant_id, seller_sku, price, stock,
+		                            min_stock, max_stock, is_active, is_visible, hidden_by_stock, preparation_time, notes,
+		                            publish_at, unpublish_at, availability_schedule, is_available)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		ON CONFLICT (seller_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::UUID))
+		DO UPDATE SET
+			seller_sku = EXCLUDED.seller_sku,
+			price = EXCLUDED.price,
+			compare_at_price = $19,
+			stock = EXCLUDED.stock,
+			min_stock = EXCLUDED.min_stock,
+			max_stock = EXCLUDED.max_stock,
+			is_active = EXCLUDED.is_active,
+			is_visible = EXCLUDED.is_visible,
+			hidden_by_stock = EXCLUDED.hidden_by_stock,
+			preparation_time = EXCLUDED.preparation_time,
 			notes = EXCLUDED.notes,
+			publish_at = EXCLUDED.publish_at,
+			unpublish_at = EXCLUDED.unpublish_at,
+			availability_schedule = EXCLUDED.availability_schedule,
+			is_available = EXCLUDED.is_available,
 			updated_at = NOW()
 		RETURNING created_at, updated_at`
 	
-	return r.db.QueryRow(
+	err := r.db.QueryRow(
 		query,
 		sellerProduct.ID,
 		sellerProduct.SellerID,
@@ -869,9 +1638,16 @@ func (r *productRepository) UpsertSellerProduct(sellerProduct *models.SellerProd
 		sellerProduct.MaxStock,
 		sellerProduct.IsActive,
 		sellerProduct.IsVisible,
+		sellerProduct.HiddenByStock,
 		sellerProduct.PreparationTime,
 		sellerProduct.Notes,
+		sellerProduct.PublishAt,
+		sellerProduct.UnpublishAt,
+		sellerProduct.AvailabilitySchedule,
+		sellerProduct.IsAvailable,
+		sellerProduct.CompareAtPrice,
 	).Scan(&sellerProduct.CreatedAt, &sellerProduct.UpdatedAt)
+	return compareAtPriceRejected(err)
 }
 
 func (r *productRepository) DeleteSellerProduct(id uuid.UUID) error {
@@ -892,15 +1668,333 @@ func (r *productRepository) DeleteSellerProduct(id uuid.UUID) error {
 	return nil
 }
 
+func (r *productRepository) GetLowStockBySeller(sellerID uuid.UUID) ([]*models.LowStockItem, error) {
+	query := `
+		SELECT sp.id, sp.product_id, sp.variant_id, p.name, pv.name, sp.seller_sku, sp.stock,
+		       COALESCE(sp.min_stock, p.min_stock), sp.max_stock, sp.is_visible, sp.updated_at
+		FROM seller_products sp
+		INNER JOIN products p ON sp.product_id = p.id AND p.deleted_at IS NULL
+		LEFT JOIN product_variants pv ON sp.variant_id = pv.id
+		WHERE sp.seller_id = $1 AND sp.is_active = true AND sp.stock IS NOT NULL
+		  AND sp.stock <= COALESCE(sp.min_stock, p.min_stock)
+		ORDER BY sp.stock, p.name`
+	
+	rows, err := r.db.Query(query, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var items []*models.LowStockItem
+	for rows.Next() {
+		item := &models.LowStockItem{}
+		err := rows.Scan(
+			&item.SellerProductID,
+			&item.ProductID,
+			&item.VariantID,
+			&item.ProductName,
+			&item.VariantName,
+			&item.SellerSKU,
+			&item.Stock,
+			&item.MinStock,
+			&item.MaxStock,
+			&item.IsVisible,
+			&item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		item.State = models.StockStateFor(item.Stock, item.MinStock)
+		items = append(items, item)
+	}
+	
+	return items, rows.Err()
+}
+
+// PublishStockAlerts hands up to limit of the oldest pending stock alerts to
+// publish and deletes them once it succeeds. The alerts stay locked while
+// they are published, so other instances skip them.
+func (r *productRepository) PublishStockAlerts(limit int, publish func([]*models.StockAlertEvent) error) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, product_id, variant_id, seller_id, stock, min_stock, state, created_at
+		FROM stock_alerts
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []int64
+	var alerts []*models.StockAlertEvent
+	for rows.Next() {
+		var id int64
+		alert := &models.StockAlertEvent{}
+		err := rows.Scan(
+			&id,
+			&alert.ProductID,
+			&alert.VariantID,
+			&alert.SellerID,
+			&alert.Stock,
+			&alert.MinStock,
+			&alert.State,
+			&alert.Timestamp,
+		)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		alerts = append(alerts, alert)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(alerts) == 0 {
+		return 0, nil
+	}
+
+	if err := publish(alerts); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM stock_alerts WHERE id = ANY($1)", pq.Array(ids)); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(alerts), nil
+}
+
+// sellerProductsFrom joins listings with their product, variant and category
+const sellerProductsFrom = `
+		FROM seller_products sp
+		INNER JOIN products p ON sp.product_id = p.id
+		LEFT JOIN product_variants pv ON sp.variant_id = pv.id
+		LEFT JOIN categories c ON p.category_id = c.id`
+
+// GetBySeller lists a page of a seller's listings joined with product data
+func (r *productRepository) GetBySeller(req *models.SellerProductListRequest) ([]*models.SellerProduct, int64, error) {
+	whereClause, args := sellerProductConditions(req)
+
+	// Count query
+	countQuery := fmt.Sprintf("SELECT COUNT(*) %s %s", sellerProductsFrom, whereClause)
+	var total int64
+	err := r.db.QueryRow(countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var sellerProducts []*models.SellerProduct
+	err = r.scanBySeller(req, whereClause, args, func(sp *models.SellerProduct) error {
+		sellerProducts = append(sellerProducts, sp)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return sellerProducts, total, nil
+}
+
+// EachBySeller hands every listing matching the filters to fn in list order,
+// ignoring pagination. Rows are read from the database as fn consumes them,
+// so exports never hold all of them.
+func (r *productRepository) EachBySeller(req *models.SellerProductListRequest, fn func(*models.SellerProduct) error) error {
+	whereClause, args := sellerProductConditions(req)
+
+	all := *req
+	all.Limit = 0
+	return r.scanBySeller(&all, whereClause, args, fn)
+}
+
+// sellerProductConditions builds the WHERE clause of the seller listing filters
+func sellerProductConditions(req *models.SellerProductListRequest) (string, []interface{}) {
+	conditions := []string{"sp.seller_id = $1", "p.deleted_at IS NULL"}
+	args := []interface{}{req.SellerID}
+	argIndex := 2
+
+	// Build WHERE conditions
+	if req.IsVisible != nil {
+		conditions = append(conditions, fmt.Sprintf("sp.is_visible = $%d", argIndex))
+		args = append(args, *req.IsVisible)
+		argIndex++
+	}
+
+	if req.IsAvailable != nil {
+		conditions = append(conditions, fmt.Sprintf("sp.is_available = $%d", argIndex))
+		args = append(args, *req.IsAvailable)
+		argIndex++
+	}
+
+	if req.CategoryID != nil {
+		conditions = append(conditions, fmt.Sprintf("p.category_id = $%d", argIndex))
+		args = append(args, *req.CategoryID)
+		argIndex++
+	}
+
+	if req.StockState != nil {
+		effectiveStock := "COALESCE(sp.stock, pv.stock, p.base_stock)"
+		effectiveMinStock := "COALESCE(sp.min_stock, p.min_stock)"
+		switch *req.StockState {
+		case models.StockStateDepleted:
+			conditions = append(conditions, fmt.Sprintf("%s <= 0", effectiveStock))
+		case models.StockStateLow:
+			conditions = append(conditions, fmt.Sprintf("%s > 0 AND %s <= %s", effectiveStock, effectiveStock, effectiveMinStock))
+		case models.StockStateInStock:
+			conditions = append(conditions, fmt.Sprintf("%s > %s", effectiveStock, effectiveMinStock))
+		}
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	return whereClause, args
+}
+
+// scanBySeller reads the listings matching whereClause, a page of them when
+// req has a Limit, and hands each to fn
+func (r *productRepository) scanBySeller(req *models.SellerProductListRequest, whereClause string, args []interface{}, fn func(*models.SellerProduct) error) error {
+	argIndex := len(args) + 1
+
+	direction := "DESC"
+	if req.SortOrder == "asc" {
+		direction = "ASC"
+	}
+
+	paginationClause := ""
+	if req.Limit > 0 {
+		offset := (req.Page - 1) * req.Limit
+		paginationClause = fmt.Sprintf("LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+		args = append(args, req.Limit, offset)
+	}
+
+	selectQuery := fmt.Sprintf(`
+		SELECT sp.id, sp.seller_id, sp.product_id, sp.variant_id, sp.seller_sku, sp.price, sp.stock, sp.min_stock,
+		       sp.max_stock, sp.is_active, sp.is_visible, sp.hidden_by_stock, sp.preparation_time, sp.notes,
+		       sp.created_at, sp.updated_at, sp.publish_at, sp.unpublish_at, sp.availability_schedule,
+		       sp.is_available, ` + sellerProductPriceColumns + `, pv.name, pv.price, pv.stock,
+		       p.id, p.name, p.description, p.category_id, p.brand, p.sku, p.barcode, p.base_price, p.currency,
+		       p.tax_rate, p.base_stock, p.min_stock, p.max_stock, p.weight, p.dimensions, p.tags, p.attributes,
+		       p.is_active, p.is_express_delivery, p.preparation_time, p.created_at, p.updated_at,
+		       p.rating_average, p.rating_count, ` + productPriceColumns + `, c.name as category_name
+		%s %s
+		ORDER BY sp.updated_at %s, sp.id
+		%s`, sellerProductsFrom, whereClause, direction, paginationClause)
+
+	rows, err := r.db.Query(selectQuery, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sp := &models.SellerProduct{}
+		product := &models.Product{}
+		var categoryName sql.NullString
+
+		err := rows.Scan(
+			&sp.ID,
+			&sp.SellerID,
+			&sp.ProductID,
+			&sp.VariantID,
+			&sp.SellerSKU,
+			&sp.Price,
+			&sp.Stock,
+			&sp.MinStock,
+			&sp.MaxStock,
+			&sp.IsActive,
+			&sp.IsVisible,
+			&sp.HiddenByStock,
+			&sp.PreparationTime,
+			&sp.Notes,
+			&sp.CreatedAt,
+			&sp.UpdatedAt,
+			&sp.PublishAt,
+			&sp.UnpublishAt,
+			&sp.AvailabilitySchedule,
+			&sp.IsAvailable,
+			&sp.CompareAtPrice,
+			&sp.ReferencePrice,
+			&sp.VariantName,
+			&sp.VariantPrice,
+			&sp.VariantStock,
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.CategoryID,
+			&product.Brand,
+			&product.SKU,
+			&product.Barcode,
+			&product.BasePrice,
+			&product.Currency,
+			&product.TaxRate,
+			&product.BaseStock,
+			&product.MinStock,
+			&product.MaxStock,
+			&product.Weight,
+			&product.Dimensions,
+			pq.Array(&product.Tags),
+			&product.Attributes,
+			&product.IsActive,
+			&product.IsExpressDelivery,
+			&product.PreparationTime,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+			&product.RatingAverage,
+			&product.RatingCount,
+			&product.CompareAtPrice,
+			&product.ReferencePrice,
+			&categoryName,
+		)
+		if err != nil {
+			return err
+		}
+
+		// Load category if exists
+		if categoryName.Valid {
+			product.Category = &models.Category{
+				ID:   product.CategoryID,
+				Name: categoryName.String,
+			}
+		}
+
+		stock, minStock := product.BaseStock, product.MinStock
+		if sp.Stock != nil {
+			stock = *sp.Stock
+		} else if sp.VariantStock != nil {
+			stock = *sp.VariantStock
+		}
+		if sp.MinStock != nil {
+			minStock = *sp.MinStock
+		}
+		sp.StockState = models.StockStateFor(stock, minStock)
+		sp.Product = product
+
+		if err := fn(sp); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 func (r *productRepository) GetFeatured(limit int) ([]*models.Product, error) {
 	query := `
 		SELECT p.id, p.name, p.description, p.category_id, p.brand, p.sku, p.barcode, p.base_price, p.currency,
 		       p.tax_rate, p.base_stock, p.min_stock, p.max_stock, p.weight, p.dimensions, p.tags, p.attributes,
 		       p.is_active, p.is_express_delivery, p.preparation_time, p.created_at, p.updated_at,
-		       c.name as category_name
+		       p.rating_average, p.rating_count, ` + productPriceColumns + `, c.name as category_name
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.is_active = true
+		WHERE p.is_active = true AND p.status = 'APPROVED' AND p.deleted_at IS NULL AND ` + productAvailableCondition + `
 		ORDER BY p.created_at DESC
 		LIMIT $1`
 	
@@ -938,6 +2032,10 @@ func (r *productRepository) GetFeatured(limit int) ([]*models.Product, error) {
 			&product.PreparationTime,
 			&product.CreatedAt,
 			&product.UpdatedAt,
+			&product.RatingAverage,
+			&product.RatingCount,
+			&product.CompareAtPrice,
+			&product.ReferencePrice,
 			&categoryName,
 		)
 		if err != nil {
@@ -958,34 +2056,48 @@ func (r *productRepository) GetFeatured(limit int) ([]*models.Product, error) {
 	return products, rows.Err()
 }
 
-func (r *productRepository) GetByCategory(categoryID uuid.UUID, limit int, offset int) ([]*models.Product, int64, error) {
-	// Count query
-	countQuery := `
-		SELECT COUNT(*)
-		FROM products p
-		WHERE p.category_id = $1 AND p.is_active = true`
-	
-	var total int64
-	err := r.db.QueryRow(countQuery, categoryID).Scan(&total)
-	if err != nil {
-		return nil, 0, err
+// GetForExport returns the products listed by a seller and/or filed under a
+// category subtree, without pagination
+func (r *productRepository) GetForExport(sellerID *uuid.UUID, categoryID *uuid.UUID, activeOnly bool) ([]*models.Product, error) {
+	conditions := []string{"p.deleted_at IS NULL"}
+	var args []interface{}
+	argIndex := 1
+
+	if activeOnly {
+		// Feeds are generated periodically, so weekly schedules are left to the storefront
+		conditions = append(conditions, "p.is_active = true", "p.status = 'APPROVED'", productPublishedCondition)
 	}
 
-	// Main query
-	query := `
+	if sellerID != nil {
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM seller_products sp WHERE sp.product_id = p.id AND sp.seller_id = $%d)", argIndex))
+		args = append(args, *sellerID)
+		argIndex++
+	}
+
+	if categoryID != nil {
+		conditions = append(conditions, fmt.Sprintf(`p.category_id IN (
+			SELECT sub.id FROM categories root
+			JOIN categories sub ON sub.path = root.path OR sub.path LIKE root.path || '/%%'
+			WHERE root.id = $%d)`, argIndex))
+		args = append(args, *categoryID)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	query := fmt.Sprintf(`
 		SELECT p.id, p.name, p.description, p.category_id, p.brand, p.sku, p.barcode, p.base_price, p.currency,
 		       p.tax_rate, p.base_stock, p.min_stock, p.max_stock, p.weight, p.dimensions, p.tags, p.attributes,
 		       p.is_active, p.is_express_delivery, p.preparation_time, p.created_at, p.updated_at,
-		       c.name as category_name
+		       p.food_info, p.rating_average, p.rating_count, ` + productPriceColumns + `, c.name as category_name
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.category_id = $1 AND p.is_active = true
-		ORDER BY p.created_at DESC
-		LIMIT $2 OFFSET $3`
-	
-	rows, err := r.db.Query(query, categoryID, limit, offset)
+		%s
+		ORDER BY p.name, p.id`, whereClause)
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -993,7 +2105,7 @@ func (r *productRepository) GetByCategory(categoryID uuid.UUID, limit int, offse
 	for rows.Next() {
 		product := &models.Product{}
 		var categoryName sql.NullString
-		
+
 		err := rows.Scan(
 			&product.ID,
 			&product.Name,
@@ -1017,13 +2129,17 @@ func (r *productRepository) GetByCategory(categoryID uuid.UUID, limit int, offse
 			&product.PreparationTime,
 			&product.CreatedAt,
 			&product.UpdatedAt,
+			&product.FoodInfo,
+			&product.RatingAverage,
+			&product.RatingCount,
+			&product.CompareAtPrice,
+			&product.ReferencePrice,
 			&categoryName,
 		)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 
-		// Load category if exists
 		if categoryName.Valid {
 			product.Category = &models.Category{
 				ID:   product.CategoryID,
@@ -1034,5 +2150,719 @@ func (r *productRepository) GetByCategory(categoryID uuid.UUID, limit int, offse
 		products = append(products, product)
 	}
 
-	return products, total, rows.Err()
-}
\ No newline at end of file
+	return products, rows.Err()
+}
+
+// GetByIDs returns the products with the given IDs in no particular order;
+// IDs without a product are skipped
+func (r *productRepository) GetByIDs(ids []uuid.UUID) ([]*models.Product, error) {
+	if len(ids) == 0 {
+		return []*models.Product{}, nil
+	}
+	return r.getMany("p.id = ANY($1::uuid[]) AND p.deleted_at IS NULL", pq.Array(ids))
+}
+
+// GetBySKUs returns the products with the given SKUs in no particular order;
+// SKUs without a product are skipped
+func (r *productRepository) GetBySKUs(skus []string) ([]*models.Product, error) {
+	if len(skus) == 0 {
+		return []*models.Product{}, nil
+	}
+	return r.getMany("p.sku = ANY($1::text[]) AND p.deleted_at IS NULL", pq.Array(skus))
+}
+
+// getMany returns the products selected by clause, the part of the query
+// after WHERE. Deleted products are only returned when clause asks for them.
+func (r *productRepository) getMany(clause string, args ...interface{}) ([]*models.Product, error) {
+	query := fmt.Sprintf(`
+		SELECT p.id, p.name, p.description, p.category_id, p.brand, p.sku, p.barcode, p.base_price, p.currency,
+		       p.tax_rate, p.base_stock, p.min_stock, p.max_stock, p.weight, p.dimensions, p.tags, p.attributes,
+		       p.is_active, p.is_express_delivery, p.preparation_time, p.created_at, p.updated_at,
+		       p.status, p.submitted_by, p.submitted_at, p.reviewed_by, p.reviewed_at, p.rejection_reason,
+		       p.rejection_note, p.moderation_flags, p.publish_at, p.unpublish_at, p.availability_schedule,
+		       p.is_available, p.product_type, p.food_info, p.rating_average, p.rating_count, ` + productPriceColumns + `, p.deleted_at,
+		       p.deleted_by, p.merged_into, c.name as category_name
+		FROM products p
+		LEFT JOIN categories c ON p.category_id = c.id
+		WHERE %s`, clause)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []*models.Product{}
+	for rows.Next() {
+		product := &models.Product{}
+		var categoryName sql.NullString
+
+		err := rows.Scan(
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.CategoryID,
+			&product.Brand,
+			&product.SKU,
+			&product.Barcode,
+			&product.BasePrice,
+			&product.Currency,
+			&product.TaxRate,
+			&product.BaseStock,
+			&product.MinStock,
+			&product.MaxStock,
+			&product.Weight,
+			&product.Dimensions,
+			pq.Array(&product.Tags),
+			&product.Attributes,
+			&product.IsActive,
+			&product.IsExpressDelivery,
+			&product.PreparationTime,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+			&product.Status,
+			&product.SubmittedBy,
+			&product.SubmittedAt,
+			&product.ReviewedBy,
+			&product.ReviewedAt,
+			&product.RejectionReason,
+			&product.RejectionNote,
+			&product.ModerationFlags,
+			&product.PublishAt,
+			&product.UnpublishAt,
+			&product.AvailabilitySchedule,
+			&product.IsAvailable,
+			&product.ProductType,
+			&product.FoodInfo,
+			&product.RatingAverage,
+			&product.RatingCount,
+			&product.CompareAtPrice,
+			&product.ReferencePrice,
+			&product.DeletedAt,
+			&product.DeletedBy,
+			&product.MergedInto,
+			&categoryName,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if categoryName.Valid {
+			product.Category = &models.Category{
+				ID:   product.CategoryID,
+				Name: categoryName.String,
+			}
+		}
+
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// GetVariantsByProductIDs returns the active variants of the given products
+func (r *productRepository) GetVariantsByProductIDs(productIDs []uuid.UUID) ([]*models.ProductVariant, error) {
+	variants := []*models.ProductVariant{}
+	if len(productIDs) == 0 {
+		return variants, nil
+	}
+
+	query := `
+		SELECT id, product_id, name, sku, barcode, price, ` + variantPriceColumns + `, stock, weight,
+		       dimensions, attributes, is_active, sort_order, created_at, updated_at
+		FROM product_variants v
+		WHERE product_id = ANY($1::uuid[]) AND is_active = true
+		ORDER BY product_id, sort_order, name`
+
+	rows, err := r.db.Query(query, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		variant := &models.ProductVariant{}
+		err := rows.Scan(
+			&variant.ID,
+			&variant.ProductID,
+			&variant.Name,
+			&variant.SKU,
+			&variant.Barcode,
+			&variant.Price,
+			&variant.CompareAtPrice,
+			&variant.ReferencePrice,
+			&variant.Stock,
+			&variant.Weight,
+			&variant.Dimensions,
+			&variant.Attributes,
+			&variant.IsActive,
+			&variant.SortOrder,
+			&variant.CreatedAt,
+			&variant.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+
+	return variants, rows.Err()
+}
+
+// GetMediaByProductIDs returns the active media of the given products,
+// including their variants' media
+func (r *productRepository) GetMediaByProductIDs(productIDs []uuid.UUID) ([]*models.ProductMedia, error) {
+	if len(productIDs) == 0 {
+		return []*models.ProductMedia{}, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM product_media
+		WHERE product_id = ANY($1::uuid[]) AND is_active = true
+		ORDER BY product_id, sort_order`, mediaColumns)
+
+	return r.queryMedia(query, pq.Array(productIDs))
+}
+
+// UpdateModeration stores the moderation state of a product
+func (r *productRepository) UpdateModeration(product *models.Product) error {
+	query := `
+		UPDATE products
+		SET status = $2, submitted_at = $3, reviewed_by = $4, reviewed_at = $5, rejection_reason = $6,
+		    rejection_note = $7, moderation_flags = $8
+		WHERE id = $1`
+
+	result, err := r.db.Exec(
+		query,
+		product.ID,
+		product.Status,
+		product.SubmittedAt,
+		product.ReviewedBy,
+		product.ReviewedAt,
+		product.RejectionReason,
+		product.RejectionNote,
+		product.ModerationFlags,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("product not found")
+	}
+
+	return nil
+}
+
+// GetModerationQueue lists products by moderation status. Products waiting
+// for review come oldest submission first, the others most recently changed first.
+func (r *productRepository) GetModerationQueue(req *models.ModerationQueueRequest) ([]*models.Product, int64, error) {
+	conditions := []string{"p.status = $1", "p.deleted_at IS NULL"}
+	args := []interface{}{req.Status}
+	argIndex := 2
+
+	if req.SellerID != nil {
+		conditions = append(conditions, fmt.Sprintf("p.submitted_by = $%d", argIndex))
+		args = append(args, *req.SellerID)
+		argIndex++
+	}
+
+	if req.Check != nil {
+		conditions = append(conditions, fmt.Sprintf("p.moderation_flags @> jsonb_build_array(jsonb_build_object('check', $%d::text))", argIndex))
+		args = append(args, string(*req.Check))
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	// Count query
+	var total int64
+	err := r.db.QueryRow("SELECT COUNT(*) FROM products p "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	orderBy := "p.updated_at DESC"
+	if req.Status == models.ProductStatusPendingReview {
+		orderBy = "p.submitted_at ASC NULLS LAST, p.created_at ASC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT p.id, p.name, p.description, p.category_id, p.brand, p.sku, p.barcode, p.base_price, p.currency,
+		       p.tax_rate, p.base_stock, p.min_stock, p.max_stock, p.weight, p.dimensions, p.tags, p.attributes,
+		       p.is_active, p.is_express_delivery, p.preparation_time, p.created_at, p.updated_at,
+		       p.status, p.submitted_by, p.submitted_at, p.reviewed_by, p.reviewed_at, p.rejection_reason,
+		       p.rejection_note, p.moderation_flags
+		FROM products p %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`, whereClause, orderBy, argIndex, argIndex+1)
+	args = append(args, req.Limit, (req.Page-1)*req.Limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var products []*models.Product
+	for rows.Next() {
+		product := &models.Product{}
+		err := rows.Scan(
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.CategoryID,
+			&product.Brand,
+			&product.SKU,
+			&product.Barcode,
+			&product.BasePrice,
+			&product.Currency,
+			&product.TaxRate,
+			&product.BaseStock,
+			&product.MinStock,
+			&product.MaxStock,
+			&product.Weight,
+			&product.Dimensions,
+			pq.Array(&product.Tags),
+			&product.Attributes,
+			&product.IsActive,
+			&product.IsExpressDelivery,
+			&product.PreparationTime,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+			&product.Status,
+			&product.SubmittedBy,
+			&product.SubmittedAt,
+			&product.ReviewedBy,
+			&product.ReviewedAt,
+			&product.RejectionReason,
+			&product.RejectionNote,
+			&product.ModerationFlags,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		products = append(products, product)
+	}
+
+	return products, total, rows.Err()
+}
+
+// GetCategoryPriceMedian returns the median base price of the approved,
+// active products in a category and the number of products it is based on
+func (r *productRepository) GetCategoryPriceMedian(categoryID uuid.UUID) (decimal.Decimal, int, error) {
+	query := `
+		SELECT COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY base_price), 0), COUNT(*)
+		FROM products
+		WHERE category_id = $1 AND status = $2 AND is_active = true AND deleted_at IS NULL`
+
+	var median decimal.Decimal
+	var count int
+	err := r.db.QueryRow(query, categoryID, models.ProductStatusApproved).Scan(&median, &count)
+	return median, count, err
+}
+
+// UpdateAvailability stores the availability window of a product
+func (r *productRepository) UpdateAvailability(product *models.Product) error {
+	query := `
+		UPDATE products
+		SET publish_at = $2, unpublish_at = $3, availability_schedule = $4, is_available = $5
+		WHERE id = $1`
+
+	result, err := r.db.Exec(
+		query,
+		product.ID,
+		product.PublishAt,
+		product.UnpublishAt,
+		product.AvailabilitySchedule,
+		product.IsAvailable,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("product not found")
+	}
+
+	return nil
+}
+
+// GetScheduledProducts returns the availability fields of products that have
+// a window, or that are marked unavailable and may need to be shown again
+func (r *productRepository) GetScheduledProducts() ([]*models.Product, error) {
+	query := `
+		SELECT id, publish_at, unpublish_at, availability_schedule, is_available
+		FROM products
+		WHERE (publish_at IS NOT NULL OR unpublish_at IS NOT NULL OR availability_schedule IS NOT NULL
+		   OR is_available = false) AND deleted_at IS NULL`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*models.Product
+	for rows.Next() {
+		product := &models.Product{}
+		err := rows.Scan(
+			&product.ID,
+			&product.PublishAt,
+			&product.UnpublishAt,
+			&product.AvailabilitySchedule,
+			&product.IsAvailable,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// GetNextPublishBoundary returns the next publish_at or unpublish_at of a
+// live product, or nil when none is ahead
+func (r *productRepository) GetNextPublishBoundary() (*time.Time, error) {
+	query := `
+		SELECT MIN(boundary) FROM (
+			SELECT publish_at AS boundary FROM products WHERE publish_at > NOW() AND deleted_at IS NULL
+			UNION ALL
+			SELECT unpublish_at FROM products WHERE unpublish_at > NOW() AND deleted_at IS NULL
+		) boundaries`
+
+	var boundary *time.Time
+	if err := r.db.QueryRow(query).Scan(&boundary); err != nil {
+		return nil, err
+	}
+	return boundary, nil
+}
+
+// GetScheduledSellerProducts is GetScheduledProducts for seller listings
+func (r *productRepository) GetScheduledSellerProducts() ([]*models.SellerProduct, error) {
+	query := `
+		SELECT id, product_id, publish_at, unpublish_at, availability_schedule, is_available
+		FROM seller_products
+		WHERE publish_at IS NOT NULL OR unpublish_at IS NOT NULL OR availability_schedule IS NOT NULL
+		   OR is_available = false`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sellerProducts []*models.SellerProduct
+	for rows.Next() {
+		sp := &models.SellerProduct{}
+		err := rows.Scan(
+			&sp.ID,
+			&sp.ProductID,
+			&sp.PublishAt,
+			&sp.UnpublishAt,
+			&sp.AvailabilitySchedule,
+			&sp.IsAvailable,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sellerProducts = append(sellerProducts, sp)
+	}
+
+	return sellerProducts, rows.Err()
+}
+
+// SetAvailability records the result of evaluating a product's availability
+// window. It reports whether this call changed it, so that of the instances
+// evaluating the same window only one announces the change; a row another
+// instance is flipping is skipped.
+func (r *productRepository) SetAvailability(id uuid.UUID, available bool) (bool, error) {
+	query := `
+		UPDATE products SET is_available = $2
+		WHERE id = (
+			SELECT id FROM products
+			WHERE id = $1 AND is_available <> $2
+			FOR UPDATE SKIP LOCKED
+		)`
+
+	return r.claimAvailability(query, id, available)
+}
+
+// SetSellerProductAvailability is SetAvailability for seller listings
+func (r *productRepository) SetSellerProductAvailability(id uuid.UUID, available bool) (bool, error) {
+	query := `
+		UPDATE seller_products SET is_available = $2
+		WHERE id = (
+			SELECT id FROM seller_products
+			WHERE id = $1 AND is_available <> $2
+			FOR UPDATE SKIP LOCKED
+		)`
+
+	return r.claimAvailability(query, id, available)
+}
+
+func (r *productRepository) claimAvailability(query string, id uuid.UUID, available bool) (bool, error) {
+	result, err := r.db.Exec(query, id, available)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetDeletedByID returns a product in the trash, or nil when the product does
+// not exist or is not deleted
+func (r *productRepository) GetDeletedByID(id uuid.UUID) (*models.Product, error) {
+	products, err := r.getMany("p.id = $1 AND p.deleted_at IS NOT NULL", id)
+	if err != nil || len(products) == 0 {
+		return nil, err
+	}
+	return products[0], nil
+}
+
+// GetDeleted returns a page of the trash, most recently deleted first
+func (r *productRepository) GetDeleted(page int, limit int) ([]*models.Product, int64, error) {
+	var total int64
+	err := r.db.QueryRow("SELECT COUNT(*) FROM products WHERE deleted_at IS NOT NULL").Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	products, err := r.getMany("p.deleted_at IS NOT NULL ORDER BY p.deleted_at DESC, p.id LIMIT $1 OFFSET $2", limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return products, total, nil
+}
+
+// Undelete takes a product out of the trash
+func (r *productRepository) Undelete(id uuid.UUID) error {
+	result, err := r.db.Exec("UPDATE products SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return fmt.Errorf("product SKU or barcode is used by another product")
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("product not found")
+	}
+
+	return nil
+}
+
+// RecordOrderedProducts remembers that the products appeared in an order, which
+// keeps them from being purged
+func (r *productRepository) RecordOrderedProducts(productIDs []uuid.UUID, orderedAt time.Time) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO ordered_products (product_id, first_ordered_at, last_ordered_at)
+		SELECT DISTINCT id, $2::timestamptz, $2::timestamptz FROM unnest($1::uuid[]) AS id
+		ON CONFLICT (product_id) DO UPDATE
+		SET first_ordered_at = LEAST(ordered_products.first_ordered_at, EXCLUDED.first_ordered_at),
+		    last_ordered_at = GREATEST(ordered_products.last_ordered_at, EXCLUDED.last_ordered_at)`
+
+	_, err := r.db.Exec(query, pq.Array(productIDs), orderedAt)
+	return err
+}
+
+// OrderedProductsBackfilled reports whether ordered_products has been
+// backfilled from the order service's order items
+func (r *productRepository) OrderedProductsBackfilled() (bool, error) {
+	var backfilled bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM ordered_products_backfill)`).Scan(&backfilled)
+	return backfilled, err
+}
+
+// PurgeDeleted permanently removes up to limit products deleted before the
+// given time. Products that appeared in an order, as recorded from order
+// events or delivered items, are kept in the trash for good, and bundle
+// components stay until their bundles drop them.
+func (r *productRepository) PurgeDeleted(before time.Time, limit int) ([]uuid.UUID, error) {
+	query := `
+		DELETE FROM products
+		WHERE id IN (
+			SELECT p.id FROM products p
+			WHERE p.deleted_at < $1
+			  AND NOT EXISTS (SELECT 1 FROM ordered_products o WHERE o.product_id = p.id)
+			  AND NOT EXISTS (SELECT 1 FROM review_eligibilities e WHERE e.product_id = p.id)
+			  AND NOT EXISTS (SELECT 1 FROM bundle_components b WHERE b.product_id = p.id)
+			  AND NOT EXISTS (SELECT 1 FROM bundle_choice_options b WHERE b.product_id = p.id)
+			ORDER BY p.deleted_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`
+
+	rows, err := r.db.Query(query, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// FindBarcodeOwners returns the live products and variants whose barcode is
+// one of the given barcodes or, for GTINs, the same GTIN written at another
+// length. Exact matches come first.
+func (r *productRepository) FindBarcodeOwners(barcodes []string) ([]*models.BarcodeOwner, error) {
+	owners := []*models.BarcodeOwner{}
+	if len(barcodes) == 0 {
+		return owners, nil
+	}
+
+	query := `
+		WITH wanted AS (
+			SELECT b AS barcode, gtin_key(b) AS key FROM unnest($1::text[]) AS b
+		)
+		SELECT barcode, product_id, variant_id FROM (
+			SELECT p.barcode, p.id AS product_id, NULL::uuid AS variant_id, p.barcode = ANY($1::text[]) AS exact
+			FROM products p
+			WHERE (p.barcode = ANY($1::text[]) OR gtin_key(p.barcode) IN (SELECT key FROM wanted))
+			  AND p.deleted_at IS NULL
+			UNION ALL
+			SELECT v.barcode, v.product_id, v.id, v.barcode = ANY($1::text[])
+			FROM product_variants v
+			INNER JOIN products p ON v.product_id = p.id
+			WHERE (v.barcode = ANY($1::text[]) OR gtin_key(v.barcode) IN (SELECT key FROM wanted))
+			  AND p.deleted_at IS NULL
+		) owners
+		ORDER BY exact DESC, variant_id NULLS FIRST`
+
+	rows, err := r.db.Query(query, pq.Array(barcodes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		owner := &models.BarcodeOwner{}
+		if err := rows.Scan(&owner.Barcode, &owner.ProductID, &owner.VariantID); err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+
+	return owners, rows.Err()
+}
+
+func (r *productRepository) GetPriceHistory(req *models.PriceHistoryRequest) ([]*models.PriceChange, int64, error) {
+	conditions := []string{"product_id = $1"}
+	args := []interface{}{req.ProductID}
+	argIndex := 2
+
+	if req.EntityType != nil {
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", argIndex))
+		args = append(args, *req.EntityType)
+		argIndex++
+	}
+
+	if req.EntityID != nil {
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", argIndex))
+		args = append(args, *req.EntityID)
+		argIndex++
+	}
+
+	if req.Since != nil {
+		conditions = append(conditions, fmt.Sprintf("changed_at >= $%d", argIndex))
+		args = append(args, *req.Since)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.QueryRow("SELECT COUNT(*) FROM price_history "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, entity_type, entity_id, product_id, variant_id, seller_id, price, changed_at
+		FROM price_history %s
+		ORDER BY changed_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, whereClause, argIndex, argIndex+1)
+	args = append(args, req.Limit, (req.Page-1)*req.Limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	changes := []*models.PriceChange{}
+	for rows.Next() {
+		change := &models.PriceChange{}
+		err := rows.Scan(
+			&change.ID,
+			&change.EntityType,
+			&change.EntityID,
+			&change.ProductID,
+			&change.VariantID,
+			&change.SellerID,
+			&change.Price,
+			&change.ChangedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, total, rows.Err()
+}
+
+// compareAtPriceRejected turns the error of the trigger that checks
+// compare-at prices against the price history into the error the service
+// reports for it
+func compareAtPriceRejected(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Constraint == "compare_at_price_valid" {
+		return fmt.Errorf("%s", pqErr.Message)
+	}
+	return err
+}
+
+// barcodeConflict turns the error of the trigger that keeps a GTIN on one
+// product or variant into the error the service reports for it
+func barcodeConflict(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Constraint == "barcode_gtin_unique" {
+		return fmt.Errorf("%s", pqErr.Message)
+	}
+	return err
+}
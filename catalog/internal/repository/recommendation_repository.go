@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type RecommendationRepository interface {
+	// RecordOrder counts the products of an order as bought together. An
+	// order that has been recorded before is not counted again.
+	RecordOrder(orderID uuid.UUID, productIDs []uuid.UUID, orderedAt time.Time) error
+
+	// FindBoughtTogether returns the recommendable products bought with a
+	// product in at least minOrders orders, most often first
+	FindBoughtTogether(productID uuid.UUID, minOrders int, limit int) ([]uuid.UUID, error)
+	// FindSimilarCandidates returns recommendable products in the category of
+	// a product or sharing one of its tags, those in the category and sharing
+	// the most tags first
+	FindSimilarCandidates(product *models.Product, limit int) ([]uuid.UUID, error)
+}
+
+type recommendationRepository struct {
+	db *sql.DB
+}
+
+func NewRecommendationRepository(db *sql.DB) RecommendationRepository {
+	return &recommendationRepository{db: db}
+}
+
+// recommendableCondition limits a query to products p shoppers can buy now:
+// live, active and available, with stock of their own, on an active variant
+// or on a buyable seller listing
+const recommendableCondition = `p.deleted_at IS NULL AND p.status = 'APPROVED' AND p.is_active = true AND ` + productAvailableCondition + `
+	AND (p.base_stock > 0
+		OR EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_id = p.id AND pv.is_active = true AND pv.stock > 0)
+		OR EXISTS (SELECT 1 FROM seller_products sp WHERE sp.product_id = p.id AND sp.stock > 0 AND ` + sellerProductAvailableCondition + `))`
+
+func (r *recommendationRepository) RecordOrder(orderID uuid.UUID, productIDs []uuid.UUID, orderedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO co_purchase_orders (order_id, ordered_at) VALUES ($1, $2)
+		ON CONFLICT (order_id) DO NOTHING`, orderID, orderedAt)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return nil
+	}
+
+	// Products the catalog does not know are left out
+	_, err = tx.Exec(`
+		WITH items AS (
+			SELECT DISTINCT p.id FROM products p WHERE p.id = ANY($1::uuid[])
+		)
+		INSERT INTO co_purchases (product_id, related_id, order_count, last_ordered_at)
+		SELECT a.id, b.id, 1, $2 FROM items a CROSS JOIN items b WHERE a.id <> b.id
+		ON CONFLICT (product_id, related_id) DO UPDATE
+		SET order_count = co_purchases.order_count + 1,
+		    last_ordered_at = GREATEST(co_purchases.last_ordered_at, EXCLUDED.last_ordered_at)`,
+		pq.Array(productIDs), orderedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *recommendationRepository) FindBoughtTogether(productID uuid.UUID, minOrders int, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT p.id FROM co_purchases cp
+		INNER JOIN products p ON cp.related_id = p.id
+		WHERE cp.product_id = $1 AND cp.order_count >= $2 AND ` + recommendableCondition + `
+		ORDER BY cp.order_count DESC, cp.last_ordered_at DESC, p.id
+		LIMIT $3`
+
+	return r.queryIDs(query, productID, minOrders, limit)
+}
+
+func (r *recommendationRepository) FindSimilarCandidates(product *models.Product, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT p.id FROM products p
+		WHERE p.id <> $1 AND (p.category_id = $2 OR p.tags && $3::text[]) AND ` + recommendableCondition + `
+		ORDER BY p.category_id = $2 DESC,
+		         cardinality(ARRAY(SELECT unnest(p.tags) INTERSECT SELECT unnest($3::text[]))) DESC,
+		         p.rating_average DESC, p.created_at DESC
+		LIMIT $4`
+
+	tags := product.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return r.queryIDs(query, product.ID, product.CategoryID, pq.Array(tags), limit)
+}
+
+func (r *recommendationRepository) queryIDs(query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+type ReviewRepository interface {
+	UpsertEligibility(eligibility *models.ReviewEligibility) error
+	GetEligibility(orderID uuid.UUID, productID uuid.UUID) (*models.ReviewEligibility, error)
+
+	Create(review *models.Review) error
+	GetByID(id uuid.UUID) (*models.Review, error)
+	List(req *models.ReviewListRequest) ([]*models.Review, int64, error)
+	UpdateModeration(review *models.Review) error
+	// AddPhoto appends a photo to a pending review holding fewer than limit photos
+	AddPhoto(review *models.Review, photo *models.ReviewPhoto, limit int) error
+	UpdateReply(review *models.Review) error
+
+	// Vote records or changes a helpfulness vote and returns the review's new counts
+	Vote(reviewID uuid.UUID, voterID uuid.UUID, helpful bool) (int, int, error)
+	// DeleteVote withdraws a helpfulness vote and returns the review's new counts
+	DeleteVote(reviewID uuid.UUID, voterID uuid.UUID) (int, int, error)
+
+	GetRatingSummary(productID uuid.UUID) (*models.RatingSummary, error)
+	// RefreshProductRating recomputes the rating aggregates stored on a product
+	RefreshProductRating(productID uuid.UUID) error
+}
+
+type reviewRepository struct {
+	db *sql.DB
+}
+
+func NewReviewRepository(db *sql.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+// UpsertEligibility records a delivered order item. Redelivered events leave
+// the first record in place, and items of deleted products or variants are
+// recorded without them rather than failing.
+func (r *reviewRepository) UpsertEligibility(eligibility *models.ReviewEligibility) error {
+	query := `
+		INSERT INTO review_eligibilities (order_id, product_id, variant_id, customer_id, seller_id, delivered_at)
+		SELECT $1, $2, (SELECT id FROM product_variants WHERE id = $3), $4, $5, $6
+		WHERE EXISTS (SELECT 1 FROM products WHERE id = $2)
+		ON CONFLICT (order_id, product_id) DO NOTHING`
+
+	_, err := r.db.Exec(
+		query,
+		eligibility.OrderID,
+		eligibility.ProductID,
+		eligibility.VariantID,
+		eligibility.CustomerID,
+		eligibility.SellerID,
+		eligibility.DeliveredAt,
+	)
+	return err
+}
+
+func (r *reviewRepository) GetEligibility(orderID uuid.UUID, productID uuid.UUID) (*models.ReviewEligibility, error) {
+	query := `
+		SELECT order_id, product_id, variant_id, customer_id, seller_id, delivered_at
+		FROM review_eligibilities
+		WHERE order_id = $1 AND product_id = $2`
+
+	eligibility := &models.ReviewEligibility{}
+	err := r.db.QueryRow(query, orderID, productID).Scan(
+		&eligibility.OrderID,
+		&eligibility.ProductID,
+		&eligibility.VariantID,
+		&eligibility.CustomerID,
+		&eligibility.SellerID,
+		&eligibility.DeliveredAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return eligibility, err
+}
+
+const reviewColumns = `id, product_id, variant_id, order_id, customer_id, seller_id, rating, title, body, photos, status,
+		       moderated_by, moderated_at, moderation_note, seller_reply, seller_replied_at, helpful_count,
+		       not_helpful_count, created_at, updated_at`
+
+func (r *reviewRepository) Create(review *models.Review) error {
+	query := `
+		INSERT INTO product_reviews (id, product_id, variant_id, order_id, customer_id, seller_id, rating, title,
+		                             body, photos, status, moderated_by, moderated_at, moderation_note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRow(
+		query,
+		review.ID,
+		review.ProductID,
+		review.VariantID,
+		review.OrderID,
+		review.CustomerID,
+		review.SellerID,
+		review.Rating,
+		review.Title,
+		review.Body,
+		review.Photos,
+		review.Status,
+		review.ModeratedBy,
+		review.ModeratedAt,
+		review.ModerationNote,
+	).Scan(&review.CreatedAt, &review.UpdatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return fmt.Errorf("review already exists")
+	}
+
+	return err
+}
+
+func (r *reviewRepository) GetByID(id uuid.UUID) (*models.Review, error) {
+	query := "SELECT " + reviewColumns + " FROM product_reviews WHERE id = $1"
+
+	review, err := scanReview(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return review, err
+}
+
+// List returns a page of reviews in the requested order, with the number of
+// reviews matching the request
+func (r *reviewRepository) List(req *models.ReviewListRequest) ([]*models.Review, int64, error) {
+	conditions := []string{"status = $1"}
+	args := []interface{}{req.Status}
+	argIndex := 2
+
+	if req.ProductID != nil {
+		conditions = append(conditions, fmt.Sprintf("product_id = $%d", argIndex))
+		args = append(args, *req.ProductID)
+		argIndex++
+	}
+
+	if req.SellerID != nil {
+		conditions = append(conditions, fmt.Sprintf("seller_id = $%d", argIndex))
+		args = append(args, *req.SellerID)
+		argIndex++
+	}
+
+	if req.Rating != nil {
+		conditions = append(conditions, fmt.Sprintf("rating = $%d", argIndex))
+		args = append(args, *req.Rating)
+		argIndex++
+	}
+
+	if req.WithPhotos {
+		conditions = append(conditions, "jsonb_array_length(photos) > 0")
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.QueryRow("SELECT COUNT(*) FROM product_reviews "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	orderBy := map[string]string{
+		"oldest":  "created_at ASC, id ASC",
+		"helpful": "helpful_count DESC, created_at DESC, id DESC",
+		"highest": "rating DESC, created_at DESC, id DESC",
+		"lowest":  "rating ASC, created_at DESC, id DESC",
+	}[req.SortBy]
+	if orderBy == "" {
+		orderBy = "created_at DESC, id DESC"
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM product_reviews %s ORDER BY %s LIMIT $%d OFFSET $%d",
+		reviewColumns, whereClause, orderBy, argIndex, argIndex+1)
+	args = append(args, req.Limit, (req.Page-1)*req.Limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reviews := []*models.Review{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, total, rows.Err()
+}
+
+func scanReview(row rowScanner) (*models.Review, error) {
+	review := &models.Review{}
+	err := row.Scan(
+		&review.ID,
+		&review.ProductID,
+		&review.VariantID,
+		&review.OrderID,
+		&review.CustomerID,
+		&review.SellerID,
+		&review.Rating,
+		&review.Title,
+		&review.Body,
+		&review.Photos,
+		&review.Status,
+		&review.ModeratedBy,
+		&review.ModeratedAt,
+		&review.ModerationNote,
+		&review.SellerReply,
+		&review.SellerRepliedAt,
+		&review.HelpfulCount,
+		&review.NotHelpfulCount,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// UpdateModeration stores the moderation state of a review
+func (r *reviewRepository) UpdateModeration(review *models.Review) error {
+	query := `
+		UPDATE product_reviews
+		SET status = $2, moderated_by = $3, moderated_at = $4, moderation_note = $5
+		WHERE id = $1
+		RETURNING updated_at`
+
+	err := r.db.QueryRow(
+		query,
+		review.ID,
+		review.Status,
+		review.ModeratedBy,
+		review.ModeratedAt,
+		review.ModerationNote,
+	).Scan(&review.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("review not found")
+	}
+
+	return err
+}
+
+func (r *reviewRepository) AddPhoto(review *models.Review, photo *models.ReviewPhoto, limit int) error {
+	query := `
+		UPDATE product_reviews
+		SET photos = photos || $2::jsonb
+		WHERE id = $1 AND status = 'PENDING' AND jsonb_array_length(photos) < $3
+		RETURNING photos, updated_at`
+
+	err := r.db.QueryRow(query, review.ID, models.ReviewPhotos{photo}, limit).Scan(&review.Photos, &review.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("review cannot take more photos")
+	}
+
+	return err
+}
+
+// UpdateReply stores the seller's reply to a review
+func (r *reviewRepository) UpdateReply(review *models.Review) error {
+	query := `
+		UPDATE product_reviews
+		SET seller_reply = $2, seller_replied_at = $3
+		WHERE id = $1
+		RETURNING updated_at`
+
+	err := r.db.QueryRow(query, review.ID, review.SellerReply, review.SellerRepliedAt).Scan(&review.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("review not found")
+	}
+
+	return err
+}
+
+func (r *reviewRepository) Vote(reviewID uuid.UUID, voterID uuid.UUID, helpful bool) (int, int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO review_votes (review_id, voter_id, helpful)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (review_id, voter_id) DO UPDATE
+		SET helpful = EXCLUDED.helpful, updated_at = NOW()`
+
+	if _, err := tx.Exec(query, reviewID, voterID, helpful); err != nil {
+		return 0, 0, err
+	}
+
+	helpfulCount, notHelpfulCount, err := countVotes(tx, reviewID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return helpfulCount, notHelpfulCount, tx.Commit()
+}
+
+func (r *reviewRepository) DeleteVote(reviewID uuid.UUID, voterID uuid.UUID) (int, int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM review_votes WHERE review_id = $1 AND voter_id = $2", reviewID, voterID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+	if rowsAffected == 0 {
+		return 0, 0, fmt.Errorf("vote not found")
+	}
+
+	helpfulCount, notHelpfulCount, err := countVotes(tx, reviewID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return helpfulCount, notHelpfulCount, tx.Commit()
+}
+
+// countVotes stores the vote counts of a review on the review. The row lock
+// taken by the update orders concurrent votes on the same review.
+func countVotes(tx *sql.Tx, reviewID uuid.UUID) (int, int, error) {
+	query := `
+		UPDATE product_reviews r
+		SET helpful_count = v.helpful, not_helpful_count = v.not_helpful
+		FROM (
+			SELECT COUNT(*) FILTER (WHERE helpful) AS helpful, COUNT(*) FILTER (WHERE NOT helpful) AS not_helpful
+			FROM review_votes WHERE review_id = $1
+		) v
+		WHERE r.id = $1
+		RETURNING r.helpful_count, r.not_helpful_count`
+
+	var helpfulCount, notHelpfulCount int
+	err := tx.QueryRow(query, reviewID).Scan(&helpfulCount, &notHelpfulCount)
+	if err == sql.ErrNoRows {
+		return 0, 0, fmt.Errorf("review not found")
+	}
+
+	return helpfulCount, notHelpfulCount, err
+}
+
+// GetRatingSummary aggregates the approved reviews of a product
+func (r *reviewRepository) GetRatingSummary(productID uuid.UUID) (*models.RatingSummary, error) {
+	query := `
+		SELECT rating, COUNT(*)
+		FROM product_reviews
+		WHERE product_id = $1 AND status = 'APPROVED'
+		GROUP BY rating`
+
+	rows, err := r.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := &models.RatingSummary{Distribution: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+	sum := 0
+	for rows.Next() {
+		var rating, count int
+		if err := rows.Scan(&rating, &count); err != nil {
+			return nil, err
+		}
+		summary.Distribution[rating] = count
+		summary.Count += count
+		sum += rating * count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if summary.Count > 0 {
+		summary.Average = decimal.NewFromInt(int64(sum)).Div(decimal.NewFromInt(int64(summary.Count))).Round(2)
+	}
+
+	return summary, nil
+}
+
+func (r *reviewRepository) RefreshProductRating(productID uuid.UUID) error {
+	query := `
+		UPDATE products p
+		SET rating_average = a.average, rating_count = a.count
+		FROM (
+			SELECT COALESCE(ROUND(AVG(rating), 2), 0) AS average, COUNT(*) AS count
+			FROM product_reviews
+			WHERE product_id = $1 AND status = 'APPROVED'
+		) a
+		WHERE p.id = $1 AND (p.rating_average <> a.average OR p.rating_count <> a.count)`
+
+	_, err := r.db.Exec(query, productID)
+	return err
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+type RevisionRepository interface {
+	Create(revision *models.Revision) error
+	GetByID(id uuid.UUID) (*models.Revision, error)
+	List(req *models.RevisionListRequest) ([]*models.Revision, int64, error)
+}
+
+type revisionRepository struct {
+	db *sql.DB
+}
+
+func NewRevisionRepository(db *sql.DB) RevisionRepository {
+	return &revisionRepository{db: db}
+}
+
+const revisionColumns = `id, entity_type, entity_id, product_id, revision, action, actor, changes, snapshot,
+		       restored_from, created_at`
+
+// Create appends a revision, numbering it after the entity's latest revision
+func (r *revisionRepository) Create(revision *models.Revision) error {
+	query := `
+		INSERT INTO revisions (id, entity_type, entity_id, product_id, revision, action, actor, changes, snapshot, restored_from)
+		SELECT $1::uuid, $2::varchar, $3::uuid, $4::uuid, COALESCE(MAX(revision), 0) + 1, $5::varchar, $6::varchar,
+		       $7::jsonb, $8::jsonb, $9::uuid
+		FROM revisions
+		WHERE entity_type = $2 AND entity_id = $3
+		RETURNING revision, created_at`
+
+	return r.db.QueryRow(
+		query,
+		revision.ID,
+		revision.EntityType,
+		revision.EntityID,
+		revision.ProductID,
+		revision.Action,
+		revision.Actor,
+		revision.Changes,
+		[]byte(revision.Snapshot),
+		revision.RestoredFrom,
+	).Scan(&revision.Revision, &revision.CreatedAt)
+}
+
+func (r *revisionRepository) GetByID(id uuid.UUID) (*models.Revision, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM revisions WHERE id = $1`, revisionColumns)
+
+	revision, err := scanRevision(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return revision, err
+}
+
+// List returns the revisions of a product and its variants and seller
+// overrides, newest first
+func (r *revisionRepository) List(req *models.RevisionListRequest) ([]*models.Revision, int64, error) {
+	conditions := []string{"product_id = $1"}
+	args := []interface{}{req.ProductID}
+	argIndex := 2
+
+	if req.EntityType != nil {
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", argIndex))
+		args = append(args, *req.EntityType)
+		argIndex++
+	}
+
+	if req.EntityID != nil {
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", argIndex))
+		args = append(args, *req.EntityID)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	// Count query
+	var total int64
+	err := r.db.QueryRow("SELECT COUNT(*) FROM revisions "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM revisions %s
+		ORDER BY created_at DESC, revision DESC
+		LIMIT $%d OFFSET $%d`, revisionColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, req.Limit, (req.Page-1)*req.Limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var revisions []*models.Revision
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, total, rows.Err()
+}
+
+func scanRevision(row rowScanner) (*models.Revision, error) {
+	revision := &models.Revision{}
+	var snapshot []byte
+	err := row.Scan(
+		&revision.ID,
+		&revision.EntityType,
+		&revision.EntityID,
+		&revision.ProductID,
+		&revision.Revision,
+		&revision.Action,
+		&revision.Actor,
+		&revision.Changes,
+		&snapshot,
+		&revision.RestoredFrom,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	revision.Snapshot = snapshot
+
+	return revision, nil
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/lib/pq"
+)
+
+type SearchSettingsRepository interface {
+	GetLive() (*models.SearchSettings, error)
+	GetDraft() (*models.SearchSettings, error)
+	GetByVersion(version int) (*models.SearchSettings, error)
+	// List returns every version, newest first
+	List() ([]*models.SearchSettings, error)
+
+	// SaveDraft replaces the content of the draft, starting a new version
+	// when there is no draft
+	SaveDraft(settings *models.SearchSettings) error
+	DeleteDraft() error
+	// PublishDraft makes the draft live and retires the live version
+	PublishDraft(actor string) (*models.SearchSettings, error)
+}
+
+type searchSettingsRepository struct {
+	db *sql.DB
+}
+
+func NewSearchSettingsRepository(db *sql.DB) SearchSettingsRepository {
+	return &searchSettingsRepository{db: db}
+}
+
+const searchSettingsColumns = "version, status, synonyms, stopwords, rules, created_by, published_by, published_at, created_at, updated_at"
+
+func (r *searchSettingsRepository) GetLive() (*models.SearchSettings, error) {
+	return r.getOne("SELECT "+searchSettingsColumns+" FROM search_settings WHERE status = $1", models.SearchSettingsStatusLive)
+}
+
+func (r *searchSettingsRepository) GetDraft() (*models.SearchSettings, error) {
+	return r.getOne("SELECT "+searchSettingsColumns+" FROM search_settings WHERE status = $1", models.SearchSettingsStatusDraft)
+}
+
+func (r *searchSettingsRepository) GetByVersion(version int) (*models.SearchSettings, error) {
+	return r.getOne("SELECT "+searchSettingsColumns+" FROM search_settings WHERE version = $1", version)
+}
+
+func (r *searchSettingsRepository) getOne(query string, arg interface{}) (*models.SearchSettings, error) {
+	settings, err := scanSearchSettings(r.db.QueryRow(query, arg))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return settings, err
+}
+
+func (r *searchSettingsRepository) List() ([]*models.SearchSettings, error) {
+	rows, err := r.db.Query("SELECT " + searchSettingsColumns + " FROM search_settings ORDER BY version DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*models.SearchSettings{}
+	for rows.Next() {
+		settings, err := scanSearchSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, settings)
+	}
+	return versions, rows.Err()
+}
+
+func (r *searchSettingsRepository) SaveDraft(settings *models.SearchSettings) error {
+	query := `
+		INSERT INTO search_settings (version, status, synonyms, stopwords, rules, created_by)
+		VALUES ((SELECT COALESCE(MAX(version), 0) + 1 FROM search_settings), 'DRAFT', $1, $2, $3, $4)
+		ON CONFLICT (status) WHERE status = 'DRAFT' DO UPDATE
+		SET synonyms = EXCLUDED.synonyms, stopwords = EXCLUDED.stopwords, rules = EXCLUDED.rules
+		RETURNING ` + searchSettingsColumns
+
+	saved, err := scanSearchSettings(r.db.QueryRow(
+		query,
+		settings.Synonyms,
+		pq.Array(settings.Stopwords),
+		settings.Rules,
+		settings.CreatedBy,
+	))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("search settings draft was changed concurrently")
+		}
+		return err
+	}
+
+	*settings = *saved
+	return nil
+}
+
+func (r *searchSettingsRepository) DeleteDraft() error {
+	result, err := r.db.Exec("DELETE FROM search_settings WHERE status = 'DRAFT'")
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("search settings draft not found")
+	}
+	return nil
+}
+
+func (r *searchSettingsRepository) PublishDraft(actor string) (*models.SearchSettings, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.QueryRow("SELECT version FROM search_settings WHERE status = 'DRAFT' FOR UPDATE").Scan(&version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("UPDATE search_settings SET status = 'RETIRED' WHERE status = 'LIVE'"); err != nil {
+		return nil, err
+	}
+
+	settings, err := scanSearchSettings(tx.QueryRow(`
+		UPDATE search_settings SET status = 'LIVE', published_by = $2, published_at = NOW()
+		WHERE version = $1
+		RETURNING `+searchSettingsColumns, version, actor))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func scanSearchSettings(row rowScanner) (*models.SearchSettings, error) {
+	settings := &models.SearchSettings{}
+	err := row.Scan(
+		&settings.Version,
+		&settings.Status,
+		&settings.Synonyms,
+		pq.Array(&settings.Stopwords),
+		&settings.Rules,
+		&settings.CreatedBy,
+		&settings.PublishedBy,
+		&settings.PublishedAt,
+		&settings.CreatedAt,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
@@ -0,0 +1,389 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type SellerLocationRepository interface {
+	CreateLocation(location *models.SellerLocation) error
+	GetLocation(id uuid.UUID) (*models.SellerLocation, error)
+	GetLocationsBySeller(sellerID uuid.UUID) ([]*models.SellerLocation, error)
+	UpdateLocation(location *models.SellerLocation) error
+	DeleteLocation(id uuid.UUID) error
+
+	CreateZone(zone *models.DeliveryZone) error
+	GetZone(id uuid.UUID) (*models.DeliveryZone, error)
+	GetZonesByLocationIDs(locationIDs []uuid.UUID) ([]*models.DeliveryZone, error)
+	UpdateZone(zone *models.DeliveryZone) error
+	DeleteZone(id uuid.UUID) error
+
+	// GetOffers returns the listings of the given products from sellers
+	// delivering to point, cheapest first within each product
+	GetOffers(productIDs []uuid.UUID, point models.GeoPoint) ([]*models.SellerOffer, error)
+}
+
+type sellerLocationRepository struct {
+	db *sql.DB
+}
+
+func NewSellerLocationRepository(db *sql.DB) SellerLocationRepository {
+	return &sellerLocationRepository{db: db}
+}
+
+// sellerProductAvailableCondition limits a query to seller listings shoppers
+// can buy from: active, visible, inside their availability window and, for
+// variant listings, of an active variant
+const sellerProductAvailableCondition = `sp.is_active = true AND sp.is_visible = true
+	AND (sp.availability_schedule IS NULL OR sp.is_available = true)
+	AND (sp.publish_at IS NULL OR sp.publish_at <= NOW()) AND (sp.unpublish_at IS NULL OR sp.unpublish_at > NOW())
+	AND (sp.variant_id IS NULL OR EXISTS (SELECT 1 FROM product_variants av WHERE av.id = sp.variant_id AND av.is_active = true))`
+
+// zoneCoversCondition matches delivery zones z of locations sl that cover
+// the point in argument pointArg
+func zoneCoversCondition(pointArg int) string {
+	return fmt.Sprintf(`sl.is_active = true AND z.is_active = true AND (
+		(z.zone_type = 'RADIUS' AND ST_DWithin(ST_Point(sl.longitude, sl.latitude)::geography, $%[1]d::geography, z.radius_km * 1000))
+		OR (z.zone_type = 'POLYGON' AND ST_Covers(z.area, $%[1]d::geography)))`, pointArg)
+}
+
+// sellerServesCondition matches seller listings sp whose seller delivers to
+// the point in argument pointArg
+func sellerServesCondition(pointArg int) string {
+	return fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM seller_locations sl
+		JOIN seller_delivery_zones z ON z.location_id = sl.id
+		WHERE sl.seller_id = sp.seller_id AND %s)`, zoneCoversCondition(pointArg))
+}
+
+// pointEWKT formats a point as the extended WKT PostGIS reads as geography
+func pointEWKT(point models.GeoPoint) string {
+	return fmt.Sprintf("SRID=4326;POINT(%s %s)", formatCoordinate(point.Longitude), formatCoordinate(point.Latitude))
+}
+
+// polygonEWKT formats a polygon as extended WKT, closing its ring. A nil
+// polygon has no WKT.
+func polygonEWKT(polygon models.GeoPolygon) sql.NullString {
+	if len(polygon) == 0 {
+		return sql.NullString{}
+	}
+
+	ring := append(models.GeoPolygon{}, polygon...)
+	if ring[0] != ring[len(ring)-1] {
+		ring = append(ring, ring[0])
+	}
+
+	vertices := make([]string, len(ring))
+	for i, vertex := range ring {
+		vertices[i] = formatCoordinate(vertex.Longitude) + " " + formatCoordinate(vertex.Latitude)
+	}
+
+	return sql.NullString{String: "SRID=4326;POLYGON((" + strings.Join(vertices, ", ") + "))", Valid: true}
+}
+
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+const locationColumns = "id, seller_id, name, address, latitude, longitude, is_active, created_at, updated_at"
+
+func (r *sellerLocationRepository) CreateLocation(location *models.SellerLocation) error {
+	query := `
+		INSERT INTO seller_locations (id, seller_id, name, address, latitude, longitude, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRow(
+		query,
+		location.ID,
+		location.SellerID,
+		location.Name,
+		location.Address,
+		location.Latitude,
+		location.Longitude,
+		location.IsActive,
+	).Scan(&location.CreatedAt, &location.UpdatedAt)
+}
+
+func (r *sellerLocationRepository) GetLocation(id uuid.UUID) (*models.SellerLocation, error) {
+	query := "SELECT " + locationColumns + " FROM seller_locations WHERE id = $1"
+
+	location, err := scanLocation(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return location, err
+}
+
+// GetLocationsBySeller returns a seller's locations in the order they were
+// added, without their zones
+func (r *sellerLocationRepository) GetLocationsBySeller(sellerID uuid.UUID) ([]*models.SellerLocation, error) {
+	query := "SELECT " + locationColumns + " FROM seller_locations WHERE seller_id = $1 ORDER BY created_at, id"
+
+	rows, err := r.db.Query(query, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []*models.SellerLocation{}
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, rows.Err()
+}
+
+func scanLocation(row rowScanner) (*models.SellerLocation, error) {
+	location := &models.SellerLocation{}
+	err := row.Scan(
+		&location.ID,
+		&location.SellerID,
+		&location.Name,
+		&location.Address,
+		&location.Latitude,
+		&location.Longitude,
+		&location.IsActive,
+		&location.CreatedAt,
+		&location.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+func (r *sellerLocationRepository) UpdateLocation(location *models.SellerLocation) error {
+	query := `
+		UPDATE seller_locations
+		SET name = $2, address = $3, latitude = $4, longitude = $5, is_active = $6
+		WHERE id = $1
+		RETURNING updated_at`
+
+	err := r.db.QueryRow(
+		query,
+		location.ID,
+		location.Name,
+		location.Address,
+		location.Latitude,
+		location.Longitude,
+		location.IsActive,
+	).Scan(&location.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("seller location not found")
+	}
+
+	return err
+}
+
+// DeleteLocation deletes a location together with its delivery zones
+func (r *sellerLocationRepository) DeleteLocation(id uuid.UUID) error {
+	result, err := r.db.Exec("DELETE FROM seller_locations WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("seller location not found")
+	}
+
+	return nil
+}
+
+const zoneColumns = "id, location_id, name, zone_type, radius_km, polygon, is_active, created_at, updated_at"
+
+func (r *sellerLocationRepository) CreateZone(zone *models.DeliveryZone) error {
+	query := `
+		INSERT INTO seller_delivery_zones (id, location_id, name, zone_type, radius_km, polygon, area, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, ST_GeogFromText($7), $8)
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRow(
+		query,
+		zone.ID,
+		zone.LocationID,
+		zone.Name,
+		zone.Type,
+		zone.RadiusKm,
+		zone.Polygon,
+		polygonEWKT(zone.Polygon),
+		zone.IsActive,
+	).Scan(&zone.CreatedAt, &zone.UpdatedAt)
+}
+
+func (r *sellerLocationRepository) GetZone(id uuid.UUID) (*models.DeliveryZone, error) {
+	query := "SELECT " + zoneColumns + " FROM seller_delivery_zones WHERE id = $1"
+
+	zone, err := scanZone(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return zone, err
+}
+
+// GetZonesByLocationIDs returns the delivery zones of the given locations
+func (r *sellerLocationRepository) GetZonesByLocationIDs(locationIDs []uuid.UUID) ([]*models.DeliveryZone, error) {
+	zones := []*models.DeliveryZone{}
+	if len(locationIDs) == 0 {
+		return zones, nil
+	}
+
+	query := "SELECT " + zoneColumns + ` FROM seller_delivery_zones
+		WHERE location_id = ANY($1::uuid[])
+		ORDER BY location_id, created_at, id`
+
+	rows, err := r.db.Query(query, pq.Array(locationIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		zone, err := scanZone(rows)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+	}
+
+	return zones, rows.Err()
+}
+
+func scanZone(row rowScanner) (*models.DeliveryZone, error) {
+	zone := &models.DeliveryZone{}
+	err := row.Scan(
+		&zone.ID,
+		&zone.LocationID,
+		&zone.Name,
+		&zone.Type,
+		&zone.RadiusKm,
+		&zone.Polygon,
+		&zone.IsActive,
+		&zone.CreatedAt,
+		&zone.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+func (r *sellerLocationRepository) UpdateZone(zone *models.DeliveryZone) error {
+	query := `
+		UPDATE seller_delivery_zones
+		SET name = $2, zone_type = $3, radius_km = $4, polygon = $5, area = ST_GeogFromText($6), is_active = $7
+		WHERE id = $1
+		RETURNING updated_at`
+
+	err := r.db.QueryRow(
+		query,
+		zone.ID,
+		zone.Name,
+		zone.Type,
+		zone.RadiusKm,
+		zone.Polygon,
+		polygonEWKT(zone.Polygon),
+		zone.IsActive,
+	).Scan(&zone.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("delivery zone not found")
+	}
+
+	return err
+}
+
+func (r *sellerLocationRepository) DeleteZone(id uuid.UUID) error {
+	result, err := r.db.Exec("DELETE FROM seller_delivery_zones WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("delivery zone not found")
+	}
+
+	return nil
+}
+
+// GetOffers reports each listing from the seller location nearest to point
+// among those delivering there
+func (r *sellerLocationRepository) GetOffers(productIDs []uuid.UUID, point models.GeoPoint) ([]*models.SellerOffer, error) {
+	offers := []*models.SellerOffer{}
+	if len(productIDs) == 0 {
+		return offers, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT sp.id, sp.seller_id, sp.product_id, sp.variant_id, pv.name,
+		       COALESCE(sp.price, pv.price, p.base_price) AS price, p.currency,
+		       COALESCE(sp.stock, pv.stock, p.base_stock),
+		       COALESCE(sp.preparation_time, p.preparation_time),
+		       nearest.id, nearest.name, nearest.distance_km
+		FROM seller_products sp
+		JOIN products p ON p.id = sp.product_id
+		LEFT JOIN product_variants pv ON pv.id = sp.variant_id
+		CROSS JOIN LATERAL (
+			SELECT sl.id, sl.name,
+			       ST_Distance(ST_Point(sl.longitude, sl.latitude)::geography, $2::geography) / 1000 AS distance_km
+			FROM seller_locations sl
+			JOIN seller_delivery_zones z ON z.location_id = sl.id
+			WHERE sl.seller_id = sp.seller_id AND %s
+			ORDER BY distance_km
+			LIMIT 1
+		) nearest
+		WHERE sp.product_id = ANY($1::uuid[]) AND %s
+		ORDER BY sp.product_id, price, nearest.distance_km, sp.id`,
+		zoneCoversCondition(2), sellerProductAvailableCondition)
+
+	rows, err := r.db.Query(query, pq.Array(productIDs), pointEWKT(point))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		offer := &models.SellerOffer{}
+		err := rows.Scan(
+			&offer.SellerProductID,
+			&offer.SellerID,
+			&offer.ProductID,
+			&offer.VariantID,
+			&offer.VariantName,
+			&offer.Price,
+			&offer.Currency,
+			&offer.Stock,
+			&offer.PreparationTime,
+			&offer.LocationID,
+			&offer.LocationName,
+			&offer.DistanceKm,
+		)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, offer)
+	}
+
+	return offers, rows.Err()
+}
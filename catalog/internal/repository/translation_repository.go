@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type TranslationRepository interface {
+	GetProductTranslations(productIDs []uuid.UUID) ([]*models.ProductTranslation, error)
+	UpsertProductTranslation(translation *models.ProductTranslation) error
+	DeleteProductTranslation(productID uuid.UUID, locale string) error
+
+	GetVariantTranslations(variantIDs []uuid.UUID) ([]*models.VariantTranslation, error)
+	UpsertVariantTranslation(translation *models.VariantTranslation) error
+	DeleteVariantTranslation(variantID uuid.UUID, locale string) error
+
+	GetCategoryTranslations(categoryIDs []uuid.UUID) ([]*models.CategoryTranslation, error)
+	UpsertCategoryTranslation(translation *models.CategoryTranslation) error
+	DeleteCategoryTranslation(categoryID uuid.UUID, locale string) error
+}
+
+type translationRepository struct {
+	db *sql.DB
+}
+
+func NewTranslationRepository(db *sql.DB) TranslationRepository {
+	return &translationRepository{db: db}
+}
+
+// GetProductTranslations returns every translation of the given products
+func (r *translationRepository) GetProductTranslations(productIDs []uuid.UUID) ([]*models.ProductTranslation, error) {
+	translations := []*models.ProductTranslation{}
+	if len(productIDs) == 0 {
+		return translations, nil
+	}
+
+	query := `
+		SELECT product_id, locale, name, description, created_at, updated_at
+		FROM product_translations
+		WHERE product_id = ANY($1::uuid[])
+		ORDER BY product_id, locale`
+
+	rows, err := r.db.Query(query, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		translation := &models.ProductTranslation{}
+		err := rows.Scan(
+			&translation.ProductID,
+			&translation.Locale,
+			&translation.Name,
+			&translation.Description,
+			&translation.CreatedAt,
+			&translation.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		translations = append(translations, translation)
+	}
+
+	return translations, rows.Err()
+}
+
+func (r *translationRepository) UpsertProductTranslation(translation *models.ProductTranslation) error {
+	query := `
+		INSERT INTO product_translations (product_id, locale, name, description)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (product_id, locale) DO UPDATE
+		SET name = EXCLUDED.name, description = EXCLUDED.description, updated_at = NOW()
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRow(
+		query,
+		translation.ProductID,
+		translation.Locale,
+		translation.Name,
+		translation.Description,
+	).Scan(&translation.CreatedAt, &translation.UpdatedAt)
+}
+
+func (r *translationRepository) DeleteProductTranslation(productID uuid.UUID, locale string) error {
+	return r.deleteTranslation("DELETE FROM product_translations WHERE product_id = $1 AND locale = $2", productID, locale)
+}
+
+// GetVariantTranslations returns every translation of the given variants
+func (r *translationRepository) GetVariantTranslations(variantIDs []uuid.UUID) ([]*models.VariantTranslation, error) {
+	translations := []*models.VariantTranslation{}
+	if len(variantIDs) == 0 {
+		return translations, nil
+	}
+
+	query := `
+		SELECT variant_id, locale, name, created_at, updated_at
+		FROM variant_translations
+		WHERE variant_id = ANY($1::uuid[])
+		ORDER BY variant_id, locale`
+
+	rows, err := r.db.Query(query, pq.Array(variantIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		translation := &models.VariantTranslation{}
+		err := rows.Scan(
+			&translation.VariantID,
+			&translation.Locale,
+			&translation.Name,
+			&translation.CreatedAt,
+			&translation.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		translations = append(translations, translation)
+	}
+
+	return translations, rows.Err()
+}
+
+func (r *translationRepository) UpsertVariantTranslation(translation *models.VariantTranslation) error {
+	query := `
+		INSERT INTO variant_translations (variant_id, locale, name)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (variant_id, locale) DO UPDATE
+		SET name = EXCLUDED.name, updated_at = NOW()
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRow(
+		query,
+		translation.VariantID,
+		translation.Locale,
+		translation.Name,
+	).Scan(&translation.CreatedAt, &translation.UpdatedAt)
+}
+
+func (r *translationRepository) DeleteVariantTranslation(variantID uuid.UUID, locale string) error {
+	return r.deleteTranslation("DELETE FROM variant_translations WHERE variant_id = $1 AND locale = $2", variantID, locale)
+}
+
+// GetCategoryTranslations returns every translation of the given categories
+func (r *translationRepository) GetCategoryTranslations(categoryIDs []uuid.UUID) ([]*models.CategoryTranslation, error) {
+	translations := []*models.CategoryTranslation{}
+	if len(categoryIDs) == 0 {
+		return translations, nil
+	}
+
+	query := `
+		SELECT category_id, locale, name, description, created_at, updated_at
+		FROM category_translations
+		WHERE category_id = ANY($1::uuid[])
+		ORDER BY category_id, locale`
+
+	rows, err := r.db.Query(query, pq.Array(categoryIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		translation := &models.CategoryTranslation{}
+		err := rows.Scan(
+			&translation.CategoryID,
+			&translation.Locale,
+			&translation.Name,
+			&translation.Description,
+			&translation.CreatedAt,
+			&translation.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		translations = append(translations, translation)
+	}
+
+	return translations, rows.Err()
+}
+
+func (r *translationRepository) UpsertCategoryTranslation(translation *models.CategoryTranslation) error {
+	query := `
+		INSERT INTO category_translations (category_id, locale, name, description)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (category_id, locale) DO UPDATE
+		SET name = EXCLUDED.name, description = EXCLUDED.description, updated_at = NOW()
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRow(
+		query,
+		translation.CategoryID,
+		translation.Locale,
+		translation.Name,
+		translation.Description,
+	).Scan(&translation.CreatedAt, &translation.UpdatedAt)
+}
+
+func (r *translationRepository) DeleteCategoryTranslation(categoryID uuid.UUID, locale string) error {
+	return r.deleteTranslation("DELETE FROM category_translations WHERE category_id = $1 AND locale = $2", categoryID, locale)
+}
+
+func (r *translationRepository) deleteTranslation(query string, id uuid.UUID, locale string) error {
+	result, err := r.db.Exec(query, id, locale)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("translation not found")
+	}
+
+	return nil
+}
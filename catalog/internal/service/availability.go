@@ -0,0 +1,235 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+// scheduleWeekdays maps schedule days to time weekdays
+var scheduleWeekdays = map[models.Weekday]time.Weekday{
+	models.WeekdayMonday:    time.Monday,
+	models.WeekdayTuesday:   time.Tuesday,
+	models.WeekdayWednesday: time.Wednesday,
+	models.WeekdayThursday:  time.Thursday,
+	models.WeekdayFriday:    time.Friday,
+	models.WeekdaySaturday:  time.Saturday,
+	models.WeekdaySunday:    time.Sunday,
+}
+
+// Availability operations
+func (s *catalogService) SetProductAvailability(id uuid.UUID, req *models.ProductAvailabilityRequest, actor string) (*models.Product, error) {
+	if err := validateAvailability(req.PublishAt, req.UnpublishAt); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	previous := *product
+	product.PublishAt = req.PublishAt
+	product.UnpublishAt = req.UnpublishAt
+	product.AvailabilitySchedule = normalizeSchedule(req.AvailabilitySchedule)
+	product.IsAvailable = s.availableAt(product.PublishAt, product.UnpublishAt, product.AvailabilitySchedule, time.Now())
+
+	err = s.productRepo.UpdateAvailability(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update availability: %w", err)
+	}
+
+	s.recordRevision(models.RevisionEntityProduct, product.ID, product.ID, models.RevisionActionUpdate, actor, &previous, product, nil)
+	s.refreshProduct(product.ID)
+
+	return product, nil
+}
+
+// StartAvailabilityScheduler re-evaluates availability windows on every tick,
+// flipping is_available on products and seller listings that crossed a
+// boundary and reindexing them. Every instance runs it; each flip is claimed
+// by one of them.
+func (s *catalogService) StartAvailabilityScheduler() {
+	if s.config.AvailabilityCheckInterval <= 0 {
+		log.Println("Availability scheduler disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.config.AvailabilityCheckInterval)
+	defer ticker.Stop()
+
+	log.Println("Starting availability scheduler...")
+
+	s.applyAvailability()
+	for range ticker.C {
+		s.applyAvailability()
+	}
+}
+
+func (s *catalogService) applyAvailability() {
+	now := time.Now()
+
+	products, err := s.productRepo.GetScheduledProducts()
+	if err != nil {
+		log.Printf("Failed to load scheduled products: %v", err)
+		return
+	}
+
+	for _, product := range products {
+		available := s.availableAt(product.PublishAt, product.UnpublishAt, product.AvailabilitySchedule, now)
+		if available == product.IsAvailable {
+			continue
+		}
+
+		claimed, err := s.productRepo.SetAvailability(product.ID, available)
+		if err != nil {
+			log.Printf("Failed to update availability of product %s: %v", product.ID, err)
+			continue
+		}
+		if claimed {
+			s.announceAvailability(product.ID, available)
+		}
+	}
+
+	sellerProducts, err := s.productRepo.GetScheduledSellerProducts()
+	if err != nil {
+		log.Printf("Failed to load scheduled seller products: %v", err)
+		return
+	}
+
+	changed := make(map[uuid.UUID]bool)
+	for _, sp := range sellerProducts {
+		available := s.availableAt(sp.PublishAt, sp.UnpublishAt, sp.AvailabilitySchedule, now)
+		if available == sp.IsAvailable {
+			continue
+		}
+
+		claimed, err := s.productRepo.SetSellerProductAvailability(sp.ID, available)
+		if err != nil {
+			log.Printf("Failed to update availability of seller product %s: %v", sp.ID, err)
+			continue
+		}
+		if claimed {
+			changed[sp.ProductID] = true
+		}
+	}
+
+	for productID := range changed {
+		s.refreshProduct(productID)
+	}
+}
+
+// announceAvailability reindexes a product whose availability changed and
+// publishes a published or unpublished event for it
+func (s *catalogService) announceAvailability(id uuid.UUID, available bool) {
+	s.invalidateProduct(id)
+
+	product, err := s.productRepo.GetByID(id)
+	if err != nil || product == nil {
+		log.Printf("Failed to load product %s after availability change: %v", id, err)
+		return
+	}
+
+	if err := s.IndexProduct(product); err != nil {
+		log.Printf("Failed to re-index product %s: %v", id, err)
+	}
+
+	action := "unpublished"
+	if available {
+		action = "published"
+	}
+	s.publishProductEvent(product, action)
+}
+
+// availableAt reports whether an item with the given availability window can
+// be shown at t. Weekly schedules are read in the configured time zone.
+func (s *catalogService) availableAt(publishAt *time.Time, unpublishAt *time.Time, schedule models.AvailabilitySchedule, t time.Time) bool {
+	if publishAt != nil && t.Before(*publishAt) {
+		return false
+	}
+	if unpublishAt != nil && !t.Before(*unpublishAt) {
+		return false
+	}
+	if len(schedule) == 0 {
+		return true
+	}
+
+	local := t.In(s.location)
+	minute := local.Hour()*60 + local.Minute()
+	for _, window := range schedule {
+		if windowOpen(window, local.Weekday(), minute) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// windowOpen reports whether a weekly window covers a minute of a weekday.
+// Windows that end at or before their start cover the evening of their days
+// and the morning after.
+func windowOpen(window *models.AvailabilityWindow, day time.Weekday, minute int) bool {
+	start, err := clockMinutes(window.Start)
+	if err != nil {
+		return false
+	}
+	end, err := clockMinutes(window.End)
+	if err != nil {
+		return false
+	}
+
+	previousDay := (day + 6) % 7
+	for _, d := range window.Days {
+		weekday, ok := scheduleWeekdays[d]
+		if !ok {
+			continue
+		}
+
+		if start < end {
+			if weekday == day && minute >= start && minute < end {
+				return true
+			}
+			continue
+		}
+
+		if weekday == day && minute >= start {
+			return true
+		}
+		if weekday == previousDay && minute < end {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clockMinutes converts an HH:MM time of day to minutes after midnight
+func clockMinutes(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func validateAvailability(publishAt *time.Time, unpublishAt *time.Time) error {
+	if publishAt != nil && unpublishAt != nil && !unpublishAt.After(*publishAt) {
+		return fmt.Errorf("unpublish_at must be after publish_at")
+	}
+	return nil
+}
+
+// normalizeSchedule stores an empty schedule as none, so that queries can
+// tell scheduled items apart with IS NULL
+func normalizeSchedule(schedule models.AvailabilitySchedule) models.AvailabilitySchedule {
+	if len(schedule) == 0 {
+		return nil
+	}
+	return schedule
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+// BarcodeError reports a barcode that is not a valid GTIN
+type BarcodeError struct {
+	Field   string
+	Message string
+}
+
+func (e *BarcodeError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// BarcodeConflictError rejects a barcode another product or variant holds
+type BarcodeConflictError struct {
+	Barcode string
+}
+
+func (e *BarcodeConflictError) Error() string {
+	return fmt.Sprintf("barcode %s is already in use", e.Barcode)
+}
+
+// barcodeClaim is a barcode a product, or a variant of it, is about to hold.
+// New products and variants claim with nil IDs.
+type barcodeClaim struct {
+	barcode   string
+	productID uuid.UUID
+	variantID *uuid.UUID
+}
+
+// LookupBarcode resolves a scanned barcode to the product or variant that
+// holds it, along with the seller listings of exactly that item, or of one
+// seller when sellerID is set. GTINs match whatever length they are written
+// at. It returns nil when no live product uses the barcode.
+func (s *catalogService) LookupBarcode(barcode string, sellerID *uuid.UUID) (*models.BarcodeMatch, error) {
+	owners, err := s.productRepo.FindBarcodeOwners([]string{cleanBarcode(barcode)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up barcode: %w", err)
+	}
+
+	for _, owner := range owners {
+		product, err := s.GetProduct(owner.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			continue
+		}
+
+		match := &models.BarcodeMatch{Product: product, Offers: []*models.SellerProduct{}}
+		if owner.VariantID != nil {
+			for _, variant := range product.Variants {
+				if variant.ID == *owner.VariantID {
+					match.Variant = variant
+					break
+				}
+			}
+			if match.Variant == nil {
+				// Inactive variants are not sold
+				continue
+			}
+		}
+
+		for _, sp := range product.SellerData {
+			if !sameVariant(sp.VariantID, owner.VariantID) {
+				continue
+			}
+			if sellerID != nil && sp.SellerID != *sellerID {
+				continue
+			}
+			match.Offers = append(match.Offers, sp)
+		}
+
+		return match, nil
+	}
+
+	return nil, nil
+}
+
+func (s *catalogService) GetProductByBarcode(barcode string) (*models.Product, error) {
+	match, err := s.LookupBarcode(barcode, nil)
+	if err != nil || match == nil {
+		return nil, err
+	}
+	return match.Product, nil
+}
+
+// normalizeProductBarcodes validates the barcodes of a new product and its
+// variants and checks that none of them is in use
+func (s *catalogService) normalizeProductBarcodes(req *models.CreateProductRequest) error {
+	var claims []barcodeClaim
+	if req.Barcode != nil {
+		if err := normalizeBarcode("barcode", req.Barcode); err != nil {
+			return err
+		}
+		claims = append(claims, barcodeClaim{barcode: *req.Barcode})
+	}
+	for _, variant := range req.Variants {
+		if variant.Barcode == nil {
+			continue
+		}
+		if err := normalizeBarcode("barcode", variant.Barcode); err != nil {
+			return fmt.Errorf("variant %s: %w", variant.Name, err)
+		}
+		claims = append(claims, barcodeClaim{barcode: *variant.Barcode})
+	}
+
+	return s.checkBarcodes(claims)
+}
+
+// normalizeBarcodeChange validates the barcode a product or variant is given
+// and checks that no other product or variant holds it. A barcode that does
+// not change is left alone, so products carrying a barcode from before
+// validation can still be edited.
+func (s *catalogService) normalizeBarcodeChange(field string, barcode *string, current *string, productID uuid.UUID, variantID *uuid.UUID) error {
+	if barcode == nil {
+		return nil
+	}
+	if current != nil && cleanBarcode(*barcode) == cleanBarcode(*current) {
+		*barcode = *current
+		return nil
+	}
+
+	if err := normalizeBarcode(field, barcode); err != nil {
+		return err
+	}
+	return s.checkBarcodes([]barcodeClaim{{barcode: *barcode, productID: productID, variantID: variantID}})
+}
+
+// checkBarcodes fails when two claims name the same GTIN or when a live
+// product or variant other than the claimant holds one of the barcodes. The
+// database enforces the same rule; checking first keeps a product from being
+// created with some of its variants missing.
+func (s *catalogService) checkBarcodes(claims []barcodeClaim) error {
+	if len(claims) == 0 {
+		return nil
+	}
+
+	barcodes := make([]string, len(claims))
+	claimed := make(map[string]bool, len(claims))
+	for i, claim := range claims {
+		key := gtinKey(claim.barcode)
+		if claimed[key] {
+			return &BarcodeError{Field: "barcode", Message: fmt.Sprintf("%s is used more than once", claim.barcode)}
+		}
+		claimed[key] = true
+		barcodes[i] = claim.barcode
+	}
+
+	owners, err := s.productRepo.FindBarcodeOwners(barcodes)
+	if err != nil {
+		return fmt.Errorf("failed to look up barcodes: %w", err)
+	}
+
+	for _, owner := range owners {
+		for _, claim := range claims {
+			if gtinKey(owner.Barcode) != gtinKey(claim.barcode) {
+				continue
+			}
+			if owner.ProductID == claim.productID && sameVariant(owner.VariantID, claim.variantID) {
+				continue
+			}
+			return &BarcodeConflictError{Barcode: claim.barcode}
+		}
+	}
+
+	return nil
+}
+
+// barcodeConflictError turns the repository's rejection of a barcode another
+// product or variant holds, which the database reports when a concurrent
+// write claims it first, into a BarcodeConflictError
+func barcodeConflictError(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	if strings.HasPrefix(message, "barcode ") && strings.HasSuffix(message, " is already in use") {
+		return &BarcodeConflictError{Barcode: strings.TrimSuffix(strings.TrimPrefix(message, "barcode "), " is already in use")}
+	}
+	return err
+}
+
+// normalizeBarcode checks that a barcode is a GTIN-8, GTIN-12 (UPC),
+// GTIN-13 (EAN) or GTIN-14 with a valid check digit and rewrites it without
+// the spaces and hyphens it is often printed with
+func normalizeBarcode(field string, barcode *string) error {
+	digits := cleanBarcode(*barcode)
+
+	switch len(digits) {
+	case 8, 12, 13, 14:
+	default:
+		return &BarcodeError{Field: field, Message: "must be a GTIN-8, GTIN-12, GTIN-13 or GTIN-14"}
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return &BarcodeError{Field: field, Message: "must contain digits only"}
+		}
+	}
+	if checkDigit(digits[:len(digits)-1]) != digits[len(digits)-1] {
+		return &BarcodeError{Field: field, Message: fmt.Sprintf("%s has an invalid check digit", digits)}
+	}
+
+	*barcode = digits
+	return nil
+}
+
+// checkDigit computes the GS1 check digit of a GTIN without its last digit:
+// digits are weighted 3 and 1 alternately from the right
+func checkDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		weight := 1
+		if (len(digits)-1-i)%2 == 0 {
+			weight = 3
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// cleanBarcode drops the spaces and hyphens barcodes are often printed with
+func cleanBarcode(barcode string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' {
+			return -1
+		}
+		return r
+	}, barcode)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+// MaxBatchProducts is the most products a batch lookup may ask for
+const MaxBatchProducts = 100
+
+// Batch operations
+func (s *catalogService) GetProductsByIDs(ids []uuid.UUID) ([]*models.Product, error) {
+	if len(ids) > MaxBatchProducts {
+		return nil, fmt.Errorf("at most %d products can be requested at once", MaxBatchProducts)
+	}
+
+	products, err := s.productRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]*models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	ordered := []*models.Product{}
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range ids {
+		if product, ok := byID[id]; ok && !seen[id] {
+			seen[id] = true
+			ordered = append(ordered, product)
+		}
+	}
+
+	if err := s.loadBatchDetails(ordered); err != nil {
+		return nil, err
+	}
+
+	return ordered, nil
+}
+
+func (s *catalogService) GetProductsBySKUs(skus []string) ([]*models.Product, error) {
+	if len(skus) > MaxBatchProducts {
+		return nil, fmt.Errorf("at most %d products can be requested at once", MaxBatchProducts)
+	}
+
+	products, err := s.productRepo.GetBySKUs(skus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	bySKU := make(map[string]*models.Product, len(products))
+	for _, product := range products {
+		bySKU[*product.SKU] = product
+	}
+
+	ordered := []*models.Product{}
+	seen := make(map[string]bool)
+	for _, sku := range skus {
+		if product, ok := bySKU[sku]; ok && !seen[sku] {
+			seen[sku] = true
+			ordered = append(ordered, product)
+		}
+	}
+
+	if err := s.loadBatchDetails(ordered); err != nil {
+		return nil, err
+	}
+
+	return ordered, nil
+}
+
+// loadBatchDetails attaches variants, media and bundle compositions to the
+// products of a batch lookup with one query per kind instead of per product
+func (s *catalogService) loadBatchDetails(products []*models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	productIDs := make([]uuid.UUID, len(products))
+	byID := make(map[uuid.UUID]*models.Product, len(products))
+	for i, product := range products {
+		productIDs[i] = product.ID
+		byID[product.ID] = product
+	}
+
+	variants, err := s.productRepo.GetVariantsByProductIDs(productIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get variants: %w", err)
+	}
+	variantsByID := make(map[uuid.UUID]*models.ProductVariant, len(variants))
+	for _, variant := range variants {
+		product := byID[variant.ProductID]
+		product.Variants = append(product.Variants, variant)
+		variantsByID[variant.ID] = variant
+	}
+
+	media, err := s.productRepo.GetMediaByProductIDs(productIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get media: %w", err)
+	}
+	for _, m := range media {
+		if m.VariantID == nil {
+			product := byID[m.ProductID]
+			product.Media = append(product.Media, m)
+		} else if variant, ok := variantsByID[*m.VariantID]; ok {
+			variant.Media = append(variant.Media, m)
+		}
+	}
+
+	for _, product := range products {
+		if product.ProductType != models.ProductTypeBundle {
+			continue
+		}
+		bundle, err := s.bundleRepo.GetBundle(product.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get bundle of product %s: %w", product.ID, err)
+		}
+		bundle.Stock = bundleStock(bundle)
+		product.Bundle = bundle
+	}
+
+	return nil
+}
@@ -0,0 +1,1180 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+// importColumns lists the canonical import fields. A job's header mapping
+// maps these names onto the headers used in the uploaded file.
+var importColumns = []string{
+	"name", "description", "category_id", "brand", "sku", "barcode", "base_price", "compare_at_price", "currency",
+	"tax_rate", "base_stock", "min_stock", "tags", "is_express_delivery", "preparation_time", "attributes",
+	"allergens", "may_contain", "dietary_labels", "ingredients", "nutrition",
+	"variant_name", "variant_sku", "variant_barcode", "variant_price", "variant_compare_at_price", "variant_stock",
+	"variant_attributes",
+	"locale",
+}
+
+const defaultImportCurrency = "TRY"
+
+// defaultImportPollInterval is used when IMPORT_POLL_INTERVAL is not a
+// positive duration
+const defaultImportPollInterval = 5 * time.Second
+
+// importRowAttempts and importRowRetryDelay bound the retries of recording an
+// import row. A job that cannot record a row fails, since resuming it would
+// otherwise skip or repeat rows.
+const (
+	importRowAttempts   = 3
+	importRowRetryDelay = time.Second
+)
+
+var importValidator = validator.New()
+
+var importContentTypes = map[string]string{
+	"csv":  "text/csv",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ImportError rejects an import job before it is queued
+type ImportError struct {
+	Message string
+}
+
+func (e *ImportError) Error() string {
+	return e.Message
+}
+
+// importFieldError reports a row failure attributable to a single column
+type importFieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *importFieldError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+// Bulk operations
+func (s *catalogService) CreateImportJob(req *models.CreateImportJobRequest, file io.Reader, fileSize int64) (*models.ImportJob, error) {
+	if fileSize > s.config.ImportMaxFileSize {
+		return nil, &ImportError{Message: fmt.Sprintf("file size %d exceeds maximum allowed size %d", fileSize, s.config.ImportMaxFileSize)}
+	}
+
+	for column := range req.HeaderMapping {
+		if !isImportColumn(column) {
+			return nil, &ImportError{Message: fmt.Sprintf("unknown import column %s", column)}
+		}
+	}
+
+	job := &models.ImportJob{
+		ID:            uuid.New(),
+		SellerID:      req.SellerID,
+		Status:        models.ImportJobStatusPending,
+		Format:        req.Format,
+		DryRun:        req.DryRun,
+		MatchBy:       req.MatchBy,
+		HeaderMapping: req.HeaderMapping,
+		FileName:      req.FileName,
+	}
+	job.ObjectName = fmt.Sprintf("imports/%s/%s/%s", req.SellerID, job.ID, req.FileName)
+
+	// Store the upload so the worker can stream it outside the HTTP request
+	ctx := context.Background()
+	err := s.mediaStore.Put(ctx, job.ObjectName, file, fileSize, importContentTypes[req.Format])
+	if err != nil {
+		return nil, fmt.Errorf("failed to store import file: %w", err)
+	}
+
+	err = s.importJobRepo.Create(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (s *catalogService) GetImportJob(id uuid.UUID) (*models.ImportJob, error) {
+	job, err := s.importJobRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (s *catalogService) GetImportJobRows(jobID uuid.UUID, status *models.ImportRowStatus, page int, limit int) (*models.ImportJobRowsResponse, error) {
+	offset := (page - 1) * limit
+	rows, total, err := s.importJobRepo.GetRows(jobID, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import job rows: %w", err)
+	}
+
+	totalPages := int(total) / limit
+	if int(total)%limit > 0 {
+		totalPages++
+	}
+
+	return &models.ImportJobRowsResponse{
+		Rows:       rows,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Import worker
+func (s *catalogService) StartImportWorker() {
+	interval := s.config.ImportPollInterval
+	if interval <= 0 {
+		log.Printf("Invalid import poll interval %s, using %s", interval, defaultImportPollInterval)
+		interval = defaultImportPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Println("Starting import worker...")
+
+	for range ticker.C {
+		s.processImportJobs()
+	}
+}
+
+func (s *catalogService) processImportJobs() {
+	for {
+		job, err := s.importJobRepo.ClaimNext(s.config.ImportJobLease)
+		if err != nil {
+			log.Printf("Failed to claim import job: %v", err)
+			return
+		}
+
+		if job == nil {
+			return
+		}
+
+		log.Printf("Processing import job %s (%s, dry_run=%t)", job.ID, job.Format, job.DryRun)
+
+		if err := s.runImportJob(job); err != nil {
+			log.Printf("Import job %s failed: %v", job.ID, err)
+			if err := s.importJobRepo.UpdateProgress(job); err != nil {
+				log.Printf("Failed to update import job %s progress: %v", job.ID, err)
+			}
+			if err := s.importJobRepo.Fail(job.ID, err.Error()); err != nil {
+				log.Printf("Failed to mark import job %s as failed: %v", job.ID, err)
+			}
+		}
+	}
+}
+
+func (s *catalogService) runImportJob(job *models.ImportJob) error {
+	ctx := context.Background()
+	object, err := s.mediaStore.Get(ctx, job.ObjectName)
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer object.Close()
+
+	reader, err := newImportRowReader(job.Format, object)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	header, err := reader.Next()
+	if err == io.EOF {
+		return fmt.Errorf("import file is empty")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns, err := resolveImportColumns(header, job.HeaderMapping, job.MatchBy)
+	if err != nil {
+		return err
+	}
+
+	// A job reclaimed after its worker stopped resumes after the last row it
+	// recorded. Those rows are replayed as a dry run, which rebuilds the
+	// duplicate checks without writing them twice.
+	resumeAfter, err := s.importJobRepo.ResumePoint(job)
+	if err != nil {
+		return fmt.Errorf("failed to resume import job: %w", err)
+	}
+	if resumeAfter > 0 {
+		log.Printf("Resuming import job %s after row %d", job.ID, resumeAfter)
+	}
+	replay := *job
+	replay.DryRun = true
+
+	seen := make(map[string]int)
+	rowNum := 1 // header
+	lastProgress := time.Now()
+
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		rowNum++
+
+		if rowNum <= resumeAfter {
+			if err == nil && !isBlankRecord(record) {
+				s.importRecord(&replay, columns, record, rowNum, seen)
+			} else if _, ok := err.(*csv.ParseError); !ok && err != nil {
+				return fmt.Errorf("failed to read row %d: %w", rowNum, err)
+			}
+			continue
+		}
+
+		var row *models.ImportJobRow
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			row = failedImportRow(job.ID, rowNum, parseErr)
+		} else if err != nil {
+			return fmt.Errorf("failed to read row %d: %w", rowNum, err)
+		} else if isBlankRecord(record) {
+			continue
+		} else {
+			row = s.importRecord(job, columns, record, rowNum, seen)
+		}
+
+		job.TotalRows++
+		job.ProcessedRows++
+		switch row.Status {
+		case models.ImportRowStatusCreated:
+			job.CreatedCount++
+		case models.ImportRowStatusUpdated:
+			job.UpdatedCount++
+		case models.ImportRowStatusValid:
+			if row.Action != nil && *row.Action == models.ImportRowActionUpdate {
+				job.UpdatedCount++
+			} else {
+				job.CreatedCount++
+			}
+		case models.ImportRowStatusFailed:
+			job.ErrorCount++
+		}
+
+		if err := s.recordImportRow(row); err != nil {
+			return fmt.Errorf("failed to record row %d: %w", rowNum, err)
+		}
+
+		// Progress updates double as the heartbeat that keeps the job's lease
+		if (s.config.ImportProgressEvery > 0 && job.ProcessedRows%s.config.ImportProgressEvery == 0) ||
+			(s.config.ImportJobLease > 0 && time.Since(lastProgress) >= s.config.ImportJobLease/4) {
+			if err := s.importJobRepo.UpdateProgress(job); err != nil {
+				log.Printf("Failed to update import job %s progress: %v", job.ID, err)
+			}
+			lastProgress = time.Now()
+		}
+	}
+
+	if err := s.importJobRepo.Complete(job); err != nil {
+		return fmt.Errorf("failed to complete import job: %w", err)
+	}
+
+	log.Printf("Import job %s completed: %d rows, %d created, %d updated, %d errors",
+		job.ID, job.ProcessedRows, job.CreatedCount, job.UpdatedCount, job.ErrorCount)
+
+	return nil
+}
+
+// recordImportRow stores the result of a row, retrying briefly on errors.
+// ResumePoint counts the recorded rows, so a row must not go missing.
+func (s *catalogService) recordImportRow(row *models.ImportJobRow) error {
+	var err error
+	for attempt := 1; attempt <= importRowAttempts; attempt++ {
+		if err = s.importJobRepo.AddRow(row); err == nil {
+			return nil
+		}
+		log.Printf("Failed to record import row %d of job %s (attempt %d): %v", row.RowNumber, row.JobID, attempt, err)
+		if attempt < importRowAttempts {
+			time.Sleep(importRowRetryDelay)
+		}
+	}
+	return err
+}
+
+// importRecord validates a single row and, unless the job is a dry run,
+// upserts the product matched by SKU or barcode. Rows with variant columns
+// upsert a variant of that product instead, and rows in a locale other than
+// the default one upsert a translation.
+func (s *catalogService) importRecord(job *models.ImportJob, columns map[string]int, record []string, rowNum int, seen map[string]int) *models.ImportJobRow {
+	fields, err := parseImportRecord(columns, record)
+	if err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+
+	matchKey := fields.SKU
+	if job.MatchBy == "barcode" {
+		matchKey = fields.Barcode
+	}
+
+	if locale := importCell(columns, record, "locale"); locale != "" && !strings.EqualFold(locale, s.config.DefaultLocale) {
+		row := s.importTranslationRecord(job, matchKey, locale, fields, importCell(columns, record, "variant_sku"), importCell(columns, record, "variant_name"), rowNum, seen)
+		row.MatchKey = matchKey
+		return row
+	}
+
+	variant, err := parseImportVariant(columns, record)
+	if err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	if variant != nil {
+		row := s.importVariantRecord(job, matchKey, variant, rowNum, seen)
+		row.MatchKey = matchKey
+		return row
+	}
+
+	var existing *models.Product
+	if matchKey != nil {
+		if firstRow, ok := seen[*matchKey]; ok {
+			return failedImportRow(job.ID, rowNum, &importFieldError{
+				Field:   job.MatchBy,
+				Message: fmt.Sprintf("duplicate value %q (first seen on row %d)", *matchKey, firstRow),
+			})
+		}
+		seen[*matchKey] = rowNum
+
+		if job.MatchBy == "barcode" {
+			existing, err = s.productRepo.GetByBarcode(*matchKey)
+		} else {
+			existing, err = s.productRepo.GetBySKU(*matchKey)
+		}
+		if err != nil {
+			return failedImportRow(job.ID, rowNum, fmt.Errorf("failed to look up existing product: %w", err))
+		}
+		if existing != nil {
+			if err := importOwnerError(job, *matchKey, existing); err != nil {
+				return failedImportRow(job.ID, rowNum, err)
+			}
+		}
+	}
+
+	var row *models.ImportJobRow
+	if existing != nil {
+		row = s.importUpdate(job, existing, fields, rowNum)
+	} else {
+		row = s.importCreate(job, fields, rowNum)
+	}
+	row.MatchKey = matchKey
+
+	return row
+}
+
+func (s *catalogService) importCreate(job *models.ImportJob, fields *models.UpdateProductRequest, rowNum int) *models.ImportJobRow {
+	if fields.Name == nil {
+		return failedImportRow(job.ID, rowNum, &importFieldError{Field: "name", Message: "required for new products"})
+	}
+	if fields.CategoryID == nil {
+		return failedImportRow(job.ID, rowNum, &importFieldError{Field: "category_id", Message: "required for new products"})
+	}
+	if fields.BasePrice == nil {
+		return failedImportRow(job.ID, rowNum, &importFieldError{Field: "base_price", Message: "required for new products"})
+	}
+	if fields.CompareAtPrice != nil && !fields.CompareAtPrice.IsZero() {
+		return failedImportRow(job.ID, rowNum, &importFieldError{Field: "compare_at_price", Message: "needs a price of the last 30 days to compare with"})
+	}
+
+	req := &models.CreateProductRequest{
+		Name:        *fields.Name,
+		Description: fields.Description,
+		CategoryID:  *fields.CategoryID,
+		Brand:       fields.Brand,
+		SKU:         fields.SKU,
+		Barcode:     fields.Barcode,
+		BasePrice:   *fields.BasePrice,
+		Currency:    defaultImportCurrency,
+		Tags:        fields.Tags,
+		Attributes:  fields.Attributes,
+		FoodInfo:    fields.FoodInfo,
+		// Imported products go through moderation like any other seller product
+		SellerID:        &job.SellerID,
+		SubmitForReview: true,
+	}
+	if fields.Currency != nil {
+		req.Currency = *fields.Currency
+	}
+	if fields.TaxRate != nil {
+		req.TaxRate = *fields.TaxRate
+	}
+	if fields.BaseStock != nil {
+		req.BaseStock = *fields.BaseStock
+	}
+	if fields.MinStock != nil {
+		req.MinStock = *fields.MinStock
+	}
+	if fields.IsExpressDelivery != nil {
+		req.IsExpressDelivery = *fields.IsExpressDelivery
+	}
+	if fields.PreparationTime != nil {
+		req.PreparationTime = *fields.PreparationTime
+	}
+
+	if err := importValidator.Struct(req); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	if err := s.normalizeProductRequest(req); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	if err := normalizeFoodInfo(req.FoodInfo); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	if err := s.normalizeProductBarcodes(req); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+
+	action := models.ImportRowActionCreate
+	row := &models.ImportJobRow{
+		ID:        uuid.New(),
+		JobID:     job.ID,
+		RowNumber: rowNum,
+		Status:    models.ImportRowStatusValid,
+		Action:    &action,
+	}
+	if job.DryRun {
+		return row
+	}
+
+	product, err := s.CreateProduct(req, importActor(job))
+	if err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+
+	row.Status = models.ImportRowStatusCreated
+	row.ProductID = &product.ID
+	return row
+}
+
+func (s *catalogService) importUpdate(job *models.ImportJob, existing *models.Product, fields *models.UpdateProductRequest, rowNum int) *models.ImportJobRow {
+	fields.FoodInfo = mergeImportFoodInfo(existing.FoodInfo, fields.FoodInfo)
+
+	if err := importValidator.Struct(fields); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	if err := s.normalizeProductUpdate(existing, fields); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	if err := normalizeFoodInfo(fields.FoodInfo); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	if err := s.normalizeBarcodeChange("barcode", fields.Barcode, existing.Barcode, existing.ID, nil); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+
+	action := models.ImportRowActionUpdate
+	row := &models.ImportJobRow{
+		ID:        uuid.New(),
+		JobID:     job.ID,
+		RowNumber: rowNum,
+		Status:    models.ImportRowStatusValid,
+		Action:    &action,
+		ProductID: &existing.ID,
+	}
+	if job.DryRun {
+		return row
+	}
+
+	if err := s.UpdateProduct(existing.ID, fields, importActor(job)); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+
+	row.Status = models.ImportRowStatusUpdated
+	return row
+}
+
+// importVariantRecord upserts a variant of the product matched by the row's
+// match key, matching existing variants by variant SKU or, failing that, name
+func (s *catalogService) importVariantRecord(job *models.ImportJob, matchKey *string, req *models.CreateVariantRequest, rowNum int, seen map[string]int) *models.ImportJobRow {
+	if matchKey == nil {
+		return failedImportRow(job.ID, rowNum, &importFieldError{Field: job.MatchBy, Message: "required to attach a variant"})
+	}
+
+	variantKey := req.Name
+	if req.SKU != nil {
+		variantKey = *req.SKU
+	}
+	seenKey := *matchKey + "/" + variantKey
+	if firstRow, ok := seen[seenKey]; ok {
+		return failedImportRow(job.ID, rowNum, &importFieldError{
+			Field:   "variant_sku",
+			Message: fmt.Sprintf("duplicate variant %q (first seen on row %d)", variantKey, firstRow),
+		})
+	}
+	seen[seenKey] = rowNum
+
+	var parent *models.Product
+	var err error
+	if job.MatchBy == "barcode" {
+		parent, err = s.productRepo.GetByBarcode(*matchKey)
+	} else {
+		parent, err = s.productRepo.GetBySKU(*matchKey)
+	}
+	if err != nil {
+		return failedImportRow(job.ID, rowNum, fmt.Errorf("failed to look up parent product: %w", err))
+	}
+
+	action := models.ImportRowActionCreate
+	row := &models.ImportJobRow{
+		ID:        uuid.New(),
+		JobID:     job.ID,
+		RowNumber: rowNum,
+		Status:    models.ImportRowStatusValid,
+		Action:    &action,
+	}
+
+	if parent == nil {
+		// In a dry run the parent may be created by an earlier row of the same file
+		if _, ok := seen[*matchKey]; ok && job.DryRun {
+			if req.Name == "" {
+				return failedImportRow(job.ID, rowNum, &importFieldError{Field: "variant_name", Message: "required for new variants"})
+			}
+			return row
+		}
+		return failedImportRow(job.ID, rowNum, &importFieldError{Field: job.MatchBy, Message: fmt.Sprintf("parent product %q not found", *matchKey)})
+	}
+	if err := importOwnerError(job, *matchKey, parent); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	row.ProductID = &parent.ID
+
+	variants, err := s.productRepo.GetVariants(parent.ID)
+	if err != nil {
+		return failedImportRow(job.ID, rowNum, fmt.Errorf("failed to get variants: %w", err))
+	}
+
+	var existing *models.ProductVariant
+	for _, v := range variants {
+		if req.SKU != nil && v.SKU != nil && *v.SKU == *req.SKU {
+			existing = v
+			break
+		}
+		if req.SKU == nil && req.Name != "" && v.Name == req.Name {
+			existing = v
+			break
+		}
+	}
+
+	if existing == nil {
+		if req.Name == "" {
+			return failedImportRow(job.ID, rowNum, &importFieldError{Field: "variant_name", Message: "required for new variants"})
+		}
+		if req.CompareAtPrice != nil && !req.CompareAtPrice.IsZero() {
+			return failedImportRow(job.ID, rowNum, &importFieldError{Field: "variant_compare_at_price", Message: "needs a price of the last 30 days to compare with"})
+		}
+		req.CompareAtPrice = nil
+		newVariantID := uuid.New()
+		if err := s.normalizeBarcodeChange("variant_barcode", req.Barcode, nil, parent.ID, &newVariantID); err != nil {
+			return failedImportRow(job.ID, rowNum, err)
+		}
+		if job.DryRun {
+			if _, err := s.normalizeVariant(parent.ID, req.Attributes); err != nil {
+				return failedImportRow(job.ID, rowNum, err)
+			}
+			return row
+		}
+
+		variant, err := s.CreateVariant(parent.ID, req, importActor(job))
+		if err != nil {
+			return failedImportRow(job.ID, rowNum, err)
+		}
+		row.Status = models.ImportRowStatusCreated
+		row.VariantID = &variant.ID
+		return row
+	}
+
+	action = models.ImportRowActionUpdate
+	row.VariantID = &existing.ID
+	if req.Name != "" {
+		existing.Name = req.Name
+	}
+	if req.SKU != nil {
+		existing.SKU = req.SKU
+	}
+	if err := s.normalizeBarcodeChange("variant_barcode", req.Barcode, existing.Barcode, parent.ID, &existing.ID); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	if req.Barcode != nil {
+		existing.Barcode = req.Barcode
+	}
+	if req.Price != nil {
+		existing.Price = req.Price
+	}
+	// A compare-at price of zero removes it
+	if req.CompareAtPrice != nil {
+		existing.CompareAtPrice = req.CompareAtPrice
+		if req.CompareAtPrice.IsZero() {
+			existing.CompareAtPrice = nil
+		}
+	}
+	if err := validateCompareAtPrice("variant_compare_at_price", existing.CompareAtPrice, existing.Price); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	if req.Stock != nil {
+		existing.Stock = req.Stock
+	}
+	if req.Attributes != nil {
+		existing.Attributes = req.Attributes
+	}
+	if job.DryRun {
+		if _, err := s.normalizeVariant(parent.ID, existing.Attributes); err != nil {
+			return failedImportRow(job.ID, rowNum, err)
+		}
+		return row
+	}
+
+	if err := s.UpdateVariant(existing.ID, existing, importActor(job)); err != nil {
+		var priceErr *PriceError
+		if errors.As(err, &priceErr) {
+			priceErr.Field = "variant_compare_at_price"
+		}
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	row.Status = models.ImportRowStatusUpdated
+	return row
+}
+
+// importTranslationRecord upserts the translation a row carries: the name and
+// description of the matched product or, when the row names a variant SKU,
+// the variant name of that variant
+func (s *catalogService) importTranslationRecord(job *models.ImportJob, matchKey *string, locale string, fields *models.UpdateProductRequest, variantSKU string, variantName string, rowNum int, seen map[string]int) *models.ImportJobRow {
+	locale, err := s.translationLocale(locale)
+	if err != nil {
+		return failedImportRow(job.ID, rowNum, &importFieldError{Field: "locale", Message: err.Error()})
+	}
+	if matchKey == nil {
+		return failedImportRow(job.ID, rowNum, &importFieldError{Field: job.MatchBy, Message: "required to attach a translation"})
+	}
+
+	req := &models.TranslationRequest{Description: fields.Description}
+	parentKey := *matchKey
+	if variantSKU != "" {
+		if variantName == "" {
+			return failedImportRow(job.ID, rowNum, &importFieldError{Field: "variant_name", Message: "required for variant translations"})
+		}
+		req.Name = variantName
+		parentKey = *matchKey + "/" + variantSKU
+	} else {
+		if fields.Name == nil {
+			return failedImportRow(job.ID, rowNum, &importFieldError{Field: "name", Message: "required for translations"})
+		}
+		req.Name = *fields.Name
+	}
+	if err := importValidator.Struct(req); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+
+	seenKey := parentKey + "@" + locale
+	if firstRow, ok := seen[seenKey]; ok {
+		return failedImportRow(job.ID, rowNum, &importFieldError{
+			Field:   "locale",
+			Message: fmt.Sprintf("duplicate %s translation of %q (first seen on row %d)", locale, parentKey, firstRow),
+		})
+	}
+	seen[seenKey] = rowNum
+
+	var product *models.Product
+	if job.MatchBy == "barcode" {
+		product, err = s.productRepo.GetByBarcode(*matchKey)
+	} else {
+		product, err = s.productRepo.GetBySKU(*matchKey)
+	}
+	if err != nil {
+		return failedImportRow(job.ID, rowNum, fmt.Errorf("failed to look up product: %w", err))
+	}
+
+	action := models.ImportRowActionUpdate
+	row := &models.ImportJobRow{
+		ID:        uuid.New(),
+		JobID:     job.ID,
+		RowNumber: rowNum,
+		Status:    models.ImportRowStatusValid,
+		Action:    &action,
+	}
+
+	if product == nil {
+		// In a dry run the product or variant may be created by an earlier row of the same file
+		if _, ok := seen[parentKey]; ok && job.DryRun {
+			return row
+		}
+		return failedImportRow(job.ID, rowNum, &importFieldError{Field: job.MatchBy, Message: fmt.Sprintf("product %q not found", *matchKey)})
+	}
+	if err := importOwnerError(job, *matchKey, product); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	row.ProductID = &product.ID
+
+	if variantSKU == "" {
+		if job.DryRun {
+			return row
+		}
+		if _, err := s.SetProductTranslation(product.ID, locale, req); err != nil {
+			return failedImportRow(job.ID, rowNum, err)
+		}
+		row.Status = models.ImportRowStatusUpdated
+		return row
+	}
+
+	variants, err := s.productRepo.GetVariants(product.ID)
+	if err != nil {
+		return failedImportRow(job.ID, rowNum, fmt.Errorf("failed to get variants: %w", err))
+	}
+	var variant *models.ProductVariant
+	for _, v := range variants {
+		if v.SKU != nil && *v.SKU == variantSKU {
+			variant = v
+			break
+		}
+	}
+	if variant == nil {
+		if _, ok := seen[parentKey]; ok && job.DryRun {
+			return row
+		}
+		return failedImportRow(job.ID, rowNum, &importFieldError{Field: "variant_sku", Message: fmt.Sprintf("variant %q not found", variantSKU)})
+	}
+	row.VariantID = &variant.ID
+	if job.DryRun {
+		return row
+	}
+
+	if _, err := s.SetVariantTranslation(product.ID, variant.ID, locale, req); err != nil {
+		return failedImportRow(job.ID, rowNum, err)
+	}
+	row.Status = models.ImportRowStatusUpdated
+	return row
+}
+
+// importOwnerError rejects a row matching a product the job's seller did not
+// submit: a seller's import may only change the seller's own products
+func importOwnerError(job *models.ImportJob, matchKey string, product *models.Product) error {
+	if product.SubmittedBy == nil || *product.SubmittedBy != job.SellerID {
+		return &importFieldError{Field: job.MatchBy, Message: fmt.Sprintf("product %q belongs to another seller", matchKey)}
+	}
+	return nil
+}
+
+// importActor identifies an import job as the actor of the changes it makes
+func importActor(job *models.ImportJob) string {
+	return fmt.Sprintf("import:%s", job.ID)
+}
+
+func failedImportRow(jobID uuid.UUID, rowNum int, err error) *models.ImportJobRow {
+	message := err.Error()
+	row := &models.ImportJobRow{
+		ID:        uuid.New(),
+		JobID:     jobID,
+		RowNumber: rowNum,
+		Status:    models.ImportRowStatusFailed,
+		Message:   &message,
+	}
+
+	var attributeErr *AttributeValidationError
+	var foodErr *FoodInfoError
+	var barcodeErr *BarcodeError
+	var priceErr *PriceError
+	if fieldErr, ok := err.(*importFieldError); ok {
+		row.Field = &fieldErr.Field
+	} else if errors.As(err, &attributeErr) {
+		field := "attributes"
+		row.Field = &field
+	} else if errors.As(err, &foodErr) {
+		// Food info errors name a path such as nutrition.per_100.sugars; its first part is the column
+		field := strings.SplitN(foodErr.Field, ".", 2)[0]
+		row.Field = &field
+	} else if errors.As(err, &barcodeErr) {
+		row.Field = &barcodeErr.Field
+	} else if errors.As(err, &priceErr) {
+		row.Field = &priceErr.Field
+	}
+
+	return row
+}
+
+// parseImportRecord converts a row into an update request where only the
+// non-empty cells are set, so the same value serves creates and upserts
+func parseImportRecord(columns map[string]int, record []string) (*models.UpdateProductRequest, error) {
+	value := func(column string) (string, bool) {
+		index, ok := columns[column]
+		if !ok || index >= len(record) {
+			return "", false
+		}
+		v := strings.TrimSpace(record[index])
+		return v, v != ""
+	}
+
+	fields := &models.UpdateProductRequest{}
+
+	if v, ok := value("name"); ok {
+		fields.Name = &v
+	}
+	if v, ok := value("description"); ok {
+		fields.Description = &v
+	}
+	if v, ok := value("category_id"); ok {
+		categoryID, err := uuid.Parse(v)
+		if err != nil {
+			return nil, &importFieldError{Field: "category_id", Message: err.Error()}
+		}
+		fields.CategoryID = &categoryID
+	}
+	if v, ok := value("brand"); ok {
+		fields.Brand = &v
+	}
+	if v, ok := value("sku"); ok {
+		fields.SKU = &v
+	}
+	if v, ok := value("barcode"); ok {
+		fields.Barcode = &v
+	}
+	if v, ok := value("base_price"); ok {
+		basePrice, err := decimal.NewFromString(v)
+		if err != nil {
+			return nil, &importFieldError{Field: "base_price", Message: err.Error()}
+		}
+		fields.BasePrice = &basePrice
+	}
+	if v, ok := value("compare_at_price"); ok {
+		compareAtPrice, err := decimal.NewFromString(v)
+		if err != nil {
+			return nil, &importFieldError{Field: "compare_at_price", Message: err.Error()}
+		}
+		fields.CompareAtPrice = &compareAtPrice
+	}
+	if v, ok := value("currency"); ok {
+		currency := strings.ToUpper(v)
+		fields.Currency = &currency
+	}
+	if v, ok := value("tax_rate"); ok {
+		taxRate, err := decimal.NewFromString(v)
+		if err != nil {
+			return nil, &importFieldError{Field: "tax_rate", Message: err.Error()}
+		}
+		fields.TaxRate = &taxRate
+	}
+	if v, ok := value("base_stock"); ok {
+		baseStock, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, &importFieldError{Field: "base_stock", Message: err.Error()}
+		}
+		fields.BaseStock = &baseStock
+	}
+	if v, ok := value("min_stock"); ok {
+		minStock, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, &importFieldError{Field: "min_stock", Message: err.Error()}
+		}
+		fields.MinStock = &minStock
+	}
+	if v, ok := value("tags"); ok {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				fields.Tags = append(fields.Tags, tag)
+			}
+		}
+	}
+	if v, ok := value("is_express_delivery"); ok {
+		isExpressDelivery, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, &importFieldError{Field: "is_express_delivery", Message: err.Error()}
+		}
+		fields.IsExpressDelivery = &isExpressDelivery
+	}
+	if v, ok := value("preparation_time"); ok {
+		preparationTime, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, &importFieldError{Field: "preparation_time", Message: err.Error()}
+		}
+		fields.PreparationTime = &preparationTime
+	}
+	if v, ok := value("attributes"); ok {
+		attributes, err := parseImportAttributes("attributes", v)
+		if err != nil {
+			return nil, err
+		}
+		fields.Attributes = attributes
+	}
+
+	// Food columns fill a single food info; columns left empty stay nil so
+	// that updates keep the current values
+	food := &models.FoodInfo{}
+	if v, ok := value("allergens"); ok {
+		food.Allergens = parseImportAllergens(v)
+	}
+	if v, ok := value("may_contain"); ok {
+		food.MayContain = parseImportAllergens(v)
+	}
+	if v, ok := value("dietary_labels"); ok {
+		for _, label := range strings.Split(v, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				food.DietaryLabels = append(food.DietaryLabels, models.DietaryLabel(strings.ToUpper(label)))
+			}
+		}
+	}
+	if v, ok := value("ingredients"); ok {
+		// Ingredients are separated by semicolons since they often contain commas themselves
+		for _, ingredient := range strings.Split(v, ";") {
+			if ingredient = strings.TrimSpace(ingredient); ingredient != "" {
+				food.Ingredients = append(food.Ingredients, ingredient)
+			}
+		}
+	}
+	if v, ok := value("nutrition"); ok {
+		var nutrition models.NutritionFacts
+		if err := json.Unmarshal([]byte(v), &nutrition); err != nil {
+			return nil, &importFieldError{Field: "nutrition", Message: "must be a JSON object"}
+		}
+		food.Nutrition = &nutrition
+	}
+	if food.Allergens != nil || food.MayContain != nil || food.DietaryLabels != nil || food.Ingredients != nil || food.Nutrition != nil {
+		fields.FoodInfo = food
+	}
+
+	return fields, nil
+}
+
+// parseImportAllergens reads a comma separated allergens cell such as "milk, eggs"
+func parseImportAllergens(value string) []models.Allergen {
+	var allergens []models.Allergen
+	for _, allergen := range strings.Split(value, ",") {
+		if allergen = strings.TrimSpace(allergen); allergen != "" {
+			allergens = append(allergens, models.Allergen(strings.ToUpper(allergen)))
+		}
+	}
+	return allergens
+}
+
+// mergeImportFoodInfo fills the food columns a row left empty with the
+// product's current values
+func mergeImportFoodInfo(current *models.FoodInfo, imported *models.FoodInfo) *models.FoodInfo {
+	if imported == nil || current == nil {
+		return imported
+	}
+
+	merged := *imported
+	if merged.Allergens == nil {
+		merged.Allergens = current.Allergens
+	}
+	if merged.MayContain == nil {
+		merged.MayContain = current.MayContain
+	}
+	if merged.DietaryLabels == nil {
+		merged.DietaryLabels = current.DietaryLabels
+	}
+	if merged.Ingredients == nil {
+		merged.Ingredients = current.Ingredients
+	}
+	if merged.Nutrition == nil {
+		merged.Nutrition = current.Nutrition
+	}
+	return &merged
+}
+
+// parseImportVariant returns the variant described by a row, or nil when the
+// row carries no variant name or SKU and therefore describes a product
+func parseImportVariant(columns map[string]int, record []string) (*models.CreateVariantRequest, error) {
+	value := func(column string) (string, bool) {
+		index, ok := columns[column]
+		if !ok || index >= len(record) {
+			return "", false
+		}
+		v := strings.TrimSpace(record[index])
+		return v, v != ""
+	}
+
+	name, hasName := value("variant_name")
+	sku, hasSKU := value("variant_sku")
+	if !hasName && !hasSKU {
+		return nil, nil
+	}
+
+	variant := &models.CreateVariantRequest{Name: name}
+	if hasSKU {
+		variant.SKU = &sku
+	}
+	if v, ok := value("variant_barcode"); ok {
+		variant.Barcode = &v
+	}
+	if v, ok := value("variant_price"); ok {
+		price, err := decimal.NewFromString(v)
+		if err != nil {
+			return nil, &importFieldError{Field: "variant_price", Message: err.Error()}
+		}
+		variant.Price = &price
+	}
+	if v, ok := value("variant_compare_at_price"); ok {
+		compareAtPrice, err := decimal.NewFromString(v)
+		if err != nil {
+			return nil, &importFieldError{Field: "variant_compare_at_price", Message: err.Error()}
+		}
+		variant.CompareAtPrice = &compareAtPrice
+	}
+	if v, ok := value("variant_stock"); ok {
+		stock, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, &importFieldError{Field: "variant_stock", Message: err.Error()}
+		}
+		variant.Stock = &stock
+	}
+	if v, ok := value("variant_attributes"); ok {
+		attributes, err := parseImportAttributes("variant_attributes", v)
+		if err != nil {
+			return nil, err
+		}
+		variant.Attributes = attributes
+	}
+
+	return variant, nil
+}
+
+// importCell returns the trimmed value of a column, or "" when the file does
+// not have the column
+func importCell(columns map[string]int, record []string, column string) string {
+	index, ok := columns[column]
+	if !ok || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+// parseImportAttributes reads an attributes cell holding a JSON object such
+// as {"color": "Red", "weight": 500}
+func parseImportAttributes(column string, value string) (models.Attributes, error) {
+	var attributes models.Attributes
+	if err := json.Unmarshal([]byte(value), &attributes); err != nil {
+		return nil, &importFieldError{Field: column, Message: "must be a JSON object"}
+	}
+	return attributes, nil
+}
+
+// resolveImportColumns maps canonical column names to their index in the
+// file header, honouring the job's header mapping
+func resolveImportColumns(header []string, mapping map[string]string, matchBy string) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff") // Excel writes a BOM at the start of CSV files
+		positions[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := make(map[string]int)
+	for _, column := range importColumns {
+		headerName := column
+		if mapped, ok := mapping[column]; ok {
+			headerName = mapped
+		}
+		if index, ok := positions[strings.ToLower(strings.TrimSpace(headerName))]; ok {
+			columns[column] = index
+		}
+	}
+
+	if _, ok := columns[matchBy]; ok {
+		return columns, nil
+	}
+
+	for _, column := range []string{"name", "category_id", "base_price"} {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("missing required column %s", column)
+		}
+	}
+
+	return columns, nil
+}
+
+func isImportColumn(column string) bool {
+	for _, c := range importColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// importRowReader streams rows from an uploaded import file
+type importRowReader interface {
+	Next() ([]string, error)
+	Close() error
+}
+
+func newImportRowReader(format string, r io.Reader) (importRowReader, error) {
+	switch format {
+	case "csv":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return &csvRowReader{reader: reader}, nil
+	case "xlsx":
+		file, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open XLSX file: %w", err)
+		}
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			file.Close()
+			return nil, fmt.Errorf("XLSX file has no sheets")
+		}
+		rows, err := file.Rows(sheets[0])
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read XLSX sheet: %w", err)
+		}
+		return &xlsxRowReader{file: file, rows: rows}, nil
+	default:
+		return nil, fmt.Errorf("unsupported import format %s", format)
+	}
+}
+
+type csvRowReader struct {
+	reader *csv.Reader
+}
+
+func (r *csvRowReader) Next() ([]string, error) {
+	return r.reader.Read()
+}
+
+func (r *csvRowReader) Close() error {
+	return nil
+}
+
+type xlsxRowReader struct {
+	file *excelize.File
+	rows *excelize.Rows
+}
+
+func (r *xlsxRowReader) Next() ([]string, error) {
+	if !r.rows.Next() {
+		if err := r.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return r.rows.Columns()
+}
+
+func (r *xlsxRowReader) Close() error {
+	r.rows.Close()
+	return r.file.Close()
+}
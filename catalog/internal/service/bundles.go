@@ -0,0 +1,405 @@
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+// BundleError reports a bundle definition or selection that is not valid
+type BundleError struct {
+	Message string
+}
+
+func (e *BundleError) Error() string {
+	return e.Message
+}
+
+// Bundle operations
+func (s *catalogService) GetBundle(productID uuid.UUID) (*models.Bundle, error) {
+	if _, err := s.getBundleProduct(productID); err != nil {
+		return nil, err
+	}
+
+	bundle, err := s.bundleRepo.GetBundle(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundle: %w", err)
+	}
+	bundle.Stock = bundleStock(bundle)
+
+	return bundle, nil
+}
+
+// SetBundle replaces the composition of a product, turning it into a bundle
+func (s *catalogService) SetBundle(productID uuid.UUID, req *models.BundleRequest) (*models.Bundle, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	// Bundles do not nest, so a component cannot become a bundle itself
+	containing, err := s.bundleRepo.GetBundleIDsContaining(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundles: %w", err)
+	}
+	if len(containing) > 0 {
+		return nil, &BundleError{Message: "product is part of another bundle and cannot be a bundle itself"}
+	}
+
+	bundle := &models.Bundle{ProductID: productID}
+	limited := false
+
+	for i, componentReq := range req.Components {
+		if err := s.checkBundleItem(productID, componentReq.ProductID, componentReq.VariantID); err != nil {
+			return nil, err
+		}
+
+		bundle.Components = append(bundle.Components, &models.BundleComponent{
+			ID:        uuid.New(),
+			BundleID:  productID,
+			ProductID: componentReq.ProductID,
+			VariantID: componentReq.VariantID,
+			Quantity:  componentReq.Quantity,
+			SortOrder: i,
+		})
+		limited = true
+	}
+
+	for i, groupReq := range req.ChoiceGroups {
+		if groupReq.MaxSelect > len(groupReq.Options) {
+			return nil, &BundleError{Message: fmt.Sprintf("choice group %s allows %d selections but has %d options", groupReq.Name, groupReq.MaxSelect, len(groupReq.Options))}
+		}
+
+		group := &models.BundleChoiceGroup{
+			ID:        uuid.New(),
+			BundleID:  productID,
+			Name:      groupReq.Name,
+			MinSelect: groupReq.MinSelect,
+			MaxSelect: groupReq.MaxSelect,
+			SortOrder: i,
+		}
+
+		defaults := 0
+		for j, optionReq := range groupReq.Options {
+			if err := s.checkBundleItem(productID, optionReq.ProductID, optionReq.VariantID); err != nil {
+				return nil, err
+			}
+			if optionReq.IsDefault {
+				defaults++
+			}
+
+			group.Options = append(group.Options, &models.BundleChoiceOption{
+				ID:         uuid.New(),
+				GroupID:    group.ID,
+				ProductID:  optionReq.ProductID,
+				VariantID:  optionReq.VariantID,
+				Quantity:   optionReq.Quantity,
+				PriceDelta: optionReq.PriceDelta,
+				IsDefault:  optionReq.IsDefault,
+				SortOrder:  j,
+			})
+		}
+		if defaults > group.MaxSelect {
+			return nil, &BundleError{Message: fmt.Sprintf("choice group %s has %d default options but allows %d selections", group.Name, defaults, group.MaxSelect)}
+		}
+
+		bundle.ChoiceGroups = append(bundle.ChoiceGroups, group)
+		if group.MinSelect > 0 {
+			limited = true
+		}
+	}
+
+	// Stock is derived from the components and required groups, so one of them is needed
+	if !limited {
+		return nil, &BundleError{Message: "a bundle needs at least one component or required choice group"}
+	}
+
+	err = s.bundleRepo.ReplaceBundle(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save bundle: %w", err)
+	}
+
+	s.refreshProduct(productID)
+
+	return s.GetBundle(productID)
+}
+
+// DeleteBundle removes the composition of a bundle, leaving a simple product
+func (s *catalogService) DeleteBundle(productID uuid.UUID) error {
+	if _, err := s.getBundleProduct(productID); err != nil {
+		return err
+	}
+
+	err := s.bundleRepo.DeleteBundle(productID)
+	if err != nil {
+		return fmt.Errorf("failed to delete bundle: %w", err)
+	}
+
+	s.refreshProduct(productID)
+
+	return nil
+}
+
+// ResolveBundleSelection checks the options chosen for a bundle against its
+// choice groups and stock and prices them. Groups without choices get their
+// default options.
+func (s *catalogService) ResolveBundleSelection(productID uuid.UUID, req *models.BundleSelectionRequest) (*models.BundleSelection, error) {
+	product, err := s.getBundleProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := s.bundleRepo.GetBundle(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundle: %w", err)
+	}
+
+	groups := make(map[uuid.UUID]*models.BundleChoiceGroup)
+	for _, group := range bundle.ChoiceGroups {
+		groups[group.ID] = group
+	}
+
+	chosen := make(map[uuid.UUID][]uuid.UUID)
+	for _, choice := range req.Choices {
+		if _, ok := groups[choice.GroupID]; !ok {
+			return nil, &BundleError{Message: fmt.Sprintf("choice group %s is not part of this bundle", choice.GroupID)}
+		}
+		if _, ok := chosen[choice.GroupID]; ok {
+			return nil, &BundleError{Message: fmt.Sprintf("choice group %s is listed more than once", choice.GroupID)}
+		}
+		chosen[choice.GroupID] = choice.OptionIDs
+	}
+
+	for _, component := range bundle.Components {
+		if component.Stock < component.Quantity {
+			return nil, &BundleError{Message: fmt.Sprintf("%s is out of stock", component.Name)}
+		}
+	}
+
+	selection := &models.BundleSelection{
+		ProductID:  product.ID,
+		Name:       product.Name,
+		BasePrice:  product.BasePrice,
+		Currency:   product.Currency,
+		Components: bundle.Components,
+		Options:    []*models.SelectedBundleOption{},
+	}
+	total := product.BasePrice
+
+	for _, group := range bundle.ChoiceGroups {
+		optionIDs, ok := chosen[group.ID]
+		options, err := selectedOptions(group, optionIDs, ok)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(options) < group.MinSelect {
+			return nil, &BundleError{Message: fmt.Sprintf("choice group %s requires at least %d selections", group.Name, group.MinSelect)}
+		}
+		if len(options) > group.MaxSelect {
+			return nil, &BundleError{Message: fmt.Sprintf("choice group %s allows at most %d selections", group.Name, group.MaxSelect)}
+		}
+
+		for _, option := range options {
+			if option.Stock < option.Quantity {
+				return nil, &BundleError{Message: fmt.Sprintf("%s is out of stock", option.Name)}
+			}
+
+			selection.Options = append(selection.Options, &models.SelectedBundleOption{
+				GroupID:    group.ID,
+				GroupName:  group.Name,
+				OptionID:   option.ID,
+				ProductID:  option.ProductID,
+				VariantID:  option.VariantID,
+				Name:       option.Name,
+				Quantity:   option.Quantity,
+				PriceDelta: option.PriceDelta,
+			})
+			total = total.Add(option.PriceDelta)
+		}
+	}
+
+	selection.TotalPrice = total
+
+	return selection, nil
+}
+
+// getBundleProduct returns a product that is a bundle
+func (s *catalogService) getBundleProduct(productID uuid.UUID) (*models.Product, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+	if product.ProductType != models.ProductTypeBundle {
+		return nil, fmt.Errorf("bundle not found")
+	}
+
+	return product, nil
+}
+
+// checkBundleItem checks that a component or option refers to an existing
+// simple product, and to one of its variants when a variant is given
+func (s *catalogService) checkBundleItem(bundleID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID) error {
+	if productID == bundleID {
+		return &BundleError{Message: "a bundle cannot contain itself"}
+	}
+
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return &BundleError{Message: fmt.Sprintf("product %s not found", productID)}
+	}
+	if product.ProductType == models.ProductTypeBundle {
+		return &BundleError{Message: fmt.Sprintf("product %s is a bundle; bundles cannot contain other bundles", productID)}
+	}
+
+	if variantID != nil {
+		variant, err := s.productRepo.GetVariant(*variantID)
+		if err != nil {
+			return fmt.Errorf("failed to get variant: %w", err)
+		}
+		if variant == nil || variant.ProductID != productID {
+			return &BundleError{Message: fmt.Sprintf("variant %s does not belong to product %s", *variantID, productID)}
+		}
+	}
+
+	return nil
+}
+
+// selectedOptions returns the options of a group with the given IDs, or the
+// group's default options when nothing was chosen for it
+func selectedOptions(group *models.BundleChoiceGroup, optionIDs []uuid.UUID, chosen bool) ([]*models.BundleChoiceOption, error) {
+	var options []*models.BundleChoiceOption
+
+	if !chosen {
+		for _, option := range group.Options {
+			if option.IsDefault {
+				options = append(options, option)
+			}
+		}
+		return options, nil
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range optionIDs {
+		if seen[id] {
+			return nil, &BundleError{Message: fmt.Sprintf("option %s is selected more than once", id)}
+		}
+		seen[id] = true
+
+		var option *models.BundleChoiceOption
+		for _, candidate := range group.Options {
+			if candidate.ID == id {
+				option = candidate
+				break
+			}
+		}
+		if option == nil {
+			return nil, &BundleError{Message: fmt.Sprintf("option %s is not part of choice group %s", id, group.Name)}
+		}
+		options = append(options, option)
+	}
+
+	return options, nil
+}
+
+// bundleStock derives how many bundles can be made: every component must be
+// available in its quantity, and every required group needs MinSelect
+// distinct options per bundle
+func bundleStock(bundle *models.Bundle) int {
+	stock := -1
+	limit := func(n int) {
+		if stock < 0 || n < stock {
+			stock = n
+		}
+	}
+
+	for _, component := range bundle.Components {
+		limit(component.Stock / component.Quantity)
+	}
+
+	for _, group := range bundle.ChoiceGroups {
+		if group.MinSelect == 0 {
+			continue
+		}
+
+		units := make([]int, len(group.Options))
+		for i, option := range group.Options {
+			units[i] = option.Stock / option.Quantity
+		}
+		limit(choiceGroupStock(units, group.MinSelect))
+	}
+
+	if stock < 0 {
+		return 0
+	}
+	return stock
+}
+
+// choiceGroupStock returns how many bundles a group can serve when each
+// bundle takes minSelect distinct options, given the units of each option.
+// An option serves at most one unit per bundle, so k bundles can be served
+// when the options together have minSelect*k units counting at most k of
+// each; the largest such k is found by binary search.
+func choiceGroupStock(units []int, minSelect int) int {
+	total := 0
+	for _, u := range units {
+		total += u
+	}
+
+	served := func(k int) bool {
+		available := 0
+		for _, u := range units {
+			if u < k {
+				available += u
+			} else {
+				available += k
+			}
+		}
+		return available >= minSelect*k
+	}
+
+	low, high := 0, total/minSelect
+	for low < high {
+		mid := (low + high + 1) / 2
+		if served(mid) {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+
+	return low
+}
+
+// reindexBundlesContaining reindexes the bundles a product is part of, since
+// their stock follows the product's
+func (s *catalogService) reindexBundlesContaining(productID uuid.UUID) {
+	bundleIDs, err := s.bundleRepo.GetBundleIDsContaining(productID)
+	if err != nil {
+		log.Printf("Failed to get bundles containing product %s: %v", productID, err)
+		return
+	}
+
+	for _, bundleID := range bundleIDs {
+		s.invalidateProduct(bundleID)
+
+		bundle, err := s.productRepo.GetByID(bundleID)
+		if err != nil || bundle == nil {
+			continue
+		}
+		if err := s.IndexProduct(bundle); err != nil {
+			log.Printf("Failed to re-index bundle %s: %v", bundleID, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// Products are cached under one key each. Featured lists, category trees and
+// recommendations come in several variants (per limit, per root and per
+// product), so each kind is kept in one Redis hash that a write drops as a
+// whole.
+//
+// Entries are dropped by the write paths of this service as they change the
+// data, and dropped again when the product event of the change is consumed.
+// The second drop removes entries that a concurrent read cached from the
+// data as it was before the write. Every instance shares the same Redis, so
+// one instance of the consumer group is enough. Changes made to the database
+// outside this service are only picked up when the entries expire. Featured
+// lists also expire at the next publish or unpublish time of a product.
+const (
+	productCacheKey         = "catalog:product:"
+	featuredCacheKey        = "catalog:featured"
+	categoryTreeCacheKey    = "catalog:category_trees"
+	searchSettingsCacheKey  = "catalog:search_settings"
+	recommendationsCacheKey = "catalog:recommendations"
+)
+
+// cacheTimeout bounds every cache call so a slow Redis degrades to database reads
+const cacheTimeout = 200 * time.Millisecond
+
+func (s *catalogService) cachedProduct(id uuid.UUID) *models.Product {
+	var product *models.Product
+	if !s.cacheGet(productCacheKey+id.String(), "", s.config.CacheProductTTL, &product) {
+		return nil
+	}
+	return product
+}
+
+func (s *catalogService) cacheProduct(product *models.Product) {
+	s.cacheSet(productCacheKey+product.ID.String(), "", s.config.CacheProductTTL, product)
+}
+
+// invalidateProduct drops a changed product and the featured lists and
+// recommendations it may appear in
+func (s *catalogService) invalidateProduct(id uuid.UUID) {
+	s.cacheDelete(productCacheKey+id.String(), featuredCacheKey, recommendationsCacheKey)
+}
+
+// featuredTTL is the TTL of featured lists, shortened to expire them when the
+// next product is published or unpublished
+func (s *catalogService) featuredTTL() time.Duration {
+	ttl := s.config.CacheFeaturedTTL
+	if s.redisClient == nil || ttl <= 0 {
+		return ttl
+	}
+
+	boundary, err := s.productRepo.GetNextPublishBoundary()
+	if err != nil {
+		log.Printf("Failed to get the next publish time: %v", err)
+		return 0
+	}
+	if boundary != nil {
+		if until := time.Until(*boundary); until < ttl {
+			return until
+		}
+	}
+	return ttl
+}
+
+// StartProductEventConsumer drops the cached product of every product event
+func (s *catalogService) StartProductEventConsumer() {
+	s.consumeEvents(s.config.KafkaTopic, kafka.LastOffset, s.invalidateChangedProduct)
+}
+
+// invalidateChangedProduct drops the product of a product event. Malformed
+// events are skipped.
+func (s *catalogService) invalidateChangedProduct(value []byte) error {
+	var event models.ProductChangeEvent
+	if err := json.Unmarshal(value, &event); err != nil {
+		log.Printf("Skipping malformed product event: %v", err)
+		return nil
+	}
+
+	if event.ProductID != uuid.Nil {
+		s.invalidateProduct(event.ProductID)
+	}
+	return nil
+}
+
+// invalidateCategories drops every cached category tree
+func (s *catalogService) invalidateCategories() {
+	s.cacheDelete(categoryTreeCacheKey)
+}
+
+// cacheGet reads a cached value into dest. Without field the key holds the
+// value itself, otherwise the field of the hash at key does. Misses, disabled
+// caching and Redis errors all report false.
+func (s *catalogService) cacheGet(key string, field string, ttl time.Duration, dest interface{}) bool {
+	if s.redisClient == nil || ttl <= 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+
+	var data []byte
+	var err error
+	if field == "" {
+		data, err = s.redisClient.Get(ctx, key).Bytes()
+	} else {
+		data, err = s.redisClient.HGet(ctx, key, field).Bytes()
+	}
+	if err != nil {
+		return false
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		log.Printf("Failed to decode cached %s: %v", key, err)
+		return false
+	}
+	return true
+}
+
+// cacheSet stores a value for ttl. A hash expires as a whole, so its fields
+// live at most ttl after the latest write to it.
+func (s *catalogService) cacheSet(key string, field string, ttl time.Duration, value interface{}) {
+	if s.redisClient == nil || ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to encode %s for the cache: %v", key, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+
+	if field == "" {
+		err = s.redisClient.Set(ctx, key, data, ttl).Err()
+	} else {
+		pipe := s.redisClient.TxPipeline()
+		pipe.HSet(ctx, key, field, data)
+		pipe.Expire(ctx, key, ttl)
+		_, err = pipe.Exec(ctx)
+	}
+	if err != nil {
+		log.Printf("Failed to cache %s: %v", key, err)
+	}
+}
+
+func (s *catalogService) cacheDelete(keys ...string) {
+	if s.redisClient == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+
+	if err := s.redisClient.Del(ctx, keys...).Err(); err != nil {
+		log.Printf("Failed to invalidate cached %v: %v", keys, err)
+	}
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+// exportColumns starts with the import columns so that an export can be fed
+// back to the importer unchanged; the remaining columns are informational
+// and ignored on import
+var exportColumns = append(append([]string{}, importColumns...),
+	"product_id", "variant_id", "category", "seller_sku", "seller_price", "seller_stock", "seller_is_visible", "image_urls",
+)
+
+const exportSheetName = "Products"
+
+// Export operations
+func (s *catalogService) ExportCatalog(req *models.CatalogExportRequest, w io.Writer) error {
+	if req.SellerID == nil && req.CategoryID == nil {
+		return fmt.Errorf("seller_id or category_id is required")
+	}
+
+	products, err := s.loadExportProducts(req.SellerID, req.CategoryID, !req.IncludeInactive)
+	if err != nil {
+		return err
+	}
+
+	writer, err := newExportRowWriter(req.Format, w)
+	if err != nil {
+		return err
+	}
+
+	productTranslations, variantTranslations, err := s.loadExportTranslations(products)
+	if err != nil {
+		return err
+	}
+
+	if err := writer.Write(exportColumns); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, product := range products {
+		records := exportRecords(product, req.SellerID, s.config.DefaultLocale)
+		records = append(records, exportTranslationRecords(product, productTranslations[product.ID], variantTranslations)...)
+		for _, record := range records {
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write row: %w", err)
+			}
+		}
+	}
+
+	return writer.Close()
+}
+
+// loadExportProducts fetches the products of an export or feed together with
+// their variants, media and seller listings
+func (s *catalogService) loadExportProducts(sellerID *uuid.UUID, categoryID *uuid.UUID, activeOnly bool) ([]*models.Product, error) {
+	products, err := s.productRepo.GetForExport(sellerID, categoryID, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products for export: %w", err)
+	}
+
+	for _, product := range products {
+		variants, err := s.productRepo.GetVariants(product.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get variants of product %s: %w", product.ID, err)
+		}
+		product.Variants = variants
+
+		media, err := s.productRepo.GetAllMedia(product.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get media of product %s: %w", product.ID, err)
+		}
+		for _, m := range media {
+			if m.VariantID == nil {
+				product.Media = append(product.Media, m)
+				continue
+			}
+			for _, variant := range variants {
+				if variant.ID == *m.VariantID {
+					variant.Media = append(variant.Media, m)
+				}
+			}
+		}
+
+		sellerData, err := s.productRepo.GetSellerData(product.ID, sellerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get seller data of product %s: %w", product.ID, err)
+		}
+		product.SellerData = sellerData
+	}
+
+	return products, nil
+}
+
+// loadExportTranslations fetches the translations of the exported products
+// and their variants, keyed by product and variant ID
+func (s *catalogService) loadExportTranslations(products []*models.Product) (map[uuid.UUID][]*models.ProductTranslation, map[uuid.UUID][]*models.VariantTranslation, error) {
+	var productIDs, variantIDs []uuid.UUID
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID)
+		for _, variant := range product.Variants {
+			variantIDs = append(variantIDs, variant.ID)
+		}
+	}
+
+	translations, err := s.translationRepo.GetProductTranslations(productIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get product translations: %w", err)
+	}
+	productTranslations := make(map[uuid.UUID][]*models.ProductTranslation)
+	for _, translation := range translations {
+		productTranslations[translation.ProductID] = append(productTranslations[translation.ProductID], translation)
+	}
+
+	variantRows, err := s.translationRepo.GetVariantTranslations(variantIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get variant translations: %w", err)
+	}
+	variantTranslations := make(map[uuid.UUID][]*models.VariantTranslation)
+	for _, translation := range variantRows {
+		variantTranslations[translation.VariantID] = append(variantTranslations[translation.VariantID], translation)
+	}
+
+	return productTranslations, variantTranslations, nil
+}
+
+// exportRecords renders a product row followed by one row per variant. Variant
+// rows repeat the product columns so the importer can attach them by SKU or barcode.
+func exportRecords(product *models.Product, sellerID *uuid.UUID, defaultLocale string) [][]string {
+	values := map[string]string{
+		"locale":              defaultLocale,
+		"name":                product.Name,
+		"description":         stringValue(product.Description),
+		"category_id":         product.CategoryID.String(),
+		"brand":               stringValue(product.Brand),
+		"sku":                 stringValue(product.SKU),
+		"barcode":             stringValue(product.Barcode),
+		"base_price":          product.BasePrice.String(),
+		"compare_at_price":    decimalString(product.CompareAtPrice),
+		"currency":            product.Currency,
+		"tax_rate":            product.TaxRate.String(),
+		"base_stock":          strconv.Itoa(product.BaseStock),
+		"min_stock":           strconv.Itoa(product.MinStock),
+		"tags":                strings.Join(product.Tags, ","),
+		"is_express_delivery": strconv.FormatBool(product.IsExpressDelivery),
+		"preparation_time":    strconv.Itoa(product.PreparationTime),
+		"attributes":          attributesJSON(product.Attributes),
+		"product_id":          product.ID.String(),
+		"image_urls":          imageURLs(product.Media),
+	}
+	if product.Category != nil {
+		values["category"] = product.Category.Name
+	}
+	if food := product.FoodInfo; food != nil {
+		values["allergens"] = joinAllergens(food.Allergens)
+		values["may_contain"] = joinAllergens(food.MayContain)
+		labels := make([]string, len(food.DietaryLabels))
+		for i, label := range food.DietaryLabels {
+			labels[i] = string(label)
+		}
+		values["dietary_labels"] = strings.Join(labels, ",")
+		values["ingredients"] = strings.Join(food.Ingredients, "; ")
+		if food.Nutrition != nil {
+			if data, err := json.Marshal(food.Nutrition); err == nil {
+				values["nutrition"] = string(data)
+			}
+		}
+	}
+	if sellerID != nil {
+		setSellerValues(values, findSellerListing(product.SellerData, nil))
+	}
+
+	records := [][]string{exportRecord(values)}
+
+	for _, variant := range product.Variants {
+		variantValues := make(map[string]string, len(values))
+		for column, value := range values {
+			variantValues[column] = value
+		}
+		variantValues["variant_name"] = variant.Name
+		variantValues["variant_sku"] = stringValue(variant.SKU)
+		variantValues["variant_barcode"] = stringValue(variant.Barcode)
+		variantValues["variant_price"] = ""
+		if variant.Price != nil {
+			variantValues["variant_price"] = variant.Price.String()
+		}
+		variantValues["variant_compare_at_price"] = decimalString(variant.CompareAtPrice)
+		variantValues["variant_stock"] = intString(variant.Stock)
+		variantValues["variant_attributes"] = attributesJSON(variant.Attributes)
+		variantValues["variant_id"] = variant.ID.String()
+		variantValues["image_urls"] = imageURLs(variant.Media)
+		if sellerID != nil {
+			setSellerValues(variantValues, findSellerListing(product.SellerData, &variant.ID))
+		}
+
+		records = append(records, exportRecord(variantValues))
+	}
+
+	return records
+}
+
+// exportTranslationRecords renders one row per translation. Translation rows
+// carry only the match columns and the translated text, which is what the
+// importer reads from a row in a locale other than the default one.
+func exportTranslationRecords(product *models.Product, translations []*models.ProductTranslation, variantTranslations map[uuid.UUID][]*models.VariantTranslation) [][]string {
+	keys := map[string]string{
+		"sku":        stringValue(product.SKU),
+		"barcode":    stringValue(product.Barcode),
+		"product_id": product.ID.String(),
+	}
+
+	var records [][]string
+	for _, translation := range translations {
+		values := map[string]string{
+			"locale":      translation.Locale,
+			"name":        translation.Name,
+			"description": stringValue(translation.Description),
+		}
+		for column, value := range keys {
+			values[column] = value
+		}
+		records = append(records, exportRecord(values))
+	}
+
+	for _, variant := range product.Variants {
+		// The importer finds the variant of a translation row by its SKU
+		if variant.SKU == nil {
+			continue
+		}
+		for _, translation := range variantTranslations[variant.ID] {
+			values := map[string]string{
+				"locale":       translation.Locale,
+				"variant_name": translation.Name,
+				"variant_sku":  *variant.SKU,
+				"variant_id":   variant.ID.String(),
+			}
+			for column, value := range keys {
+				values[column] = value
+			}
+			records = append(records, exportRecord(values))
+		}
+	}
+
+	return records
+}
+
+func exportRecord(values map[string]string) []string {
+	record := make([]string, len(exportColumns))
+	for i, column := range exportColumns {
+		record[i] = values[column]
+	}
+	return record
+}
+
+func setSellerValues(values map[string]string, sp *models.SellerProduct) {
+	values["seller_sku"] = ""
+	values["seller_price"] = ""
+	values["seller_stock"] = ""
+	values["seller_is_visible"] = ""
+	if sp == nil {
+		return
+	}
+
+	values["seller_sku"] = stringValue(sp.SellerSKU)
+	if sp.Price != nil {
+		values["seller_price"] = sp.Price.String()
+	}
+	values["seller_stock"] = intString(sp.Stock)
+	values["seller_is_visible"] = strconv.FormatBool(sp.IsVisible)
+}
+
+func findSellerListing(sellerData []*models.SellerProduct, variantID *uuid.UUID) *models.SellerProduct {
+	for _, sp := range sellerData {
+		if variantID == nil && sp.VariantID == nil {
+			return sp
+		}
+		if variantID != nil && sp.VariantID != nil && *sp.VariantID == *variantID {
+			return sp
+		}
+	}
+	return nil
+}
+
+func attributesJSON(attributes models.Attributes) string {
+	if len(attributes) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func joinAllergens(allergens []models.Allergen) string {
+	names := make([]string, len(allergens))
+	for i, allergen := range allergens {
+		names[i] = string(allergen)
+	}
+	return strings.Join(names, ",")
+}
+
+func imageURLs(media []*models.ProductMedia) string {
+	var urls []string
+	for _, m := range media {
+		if m.Type == "image" {
+			urls = append(urls, m.URL)
+		}
+	}
+	return strings.Join(urls, ",")
+}
+
+// exportRowWriter writes rows of an export file
+type exportRowWriter interface {
+	Write(record []string) error
+	Close() error
+}
+
+func newExportRowWriter(format string, w io.Writer) (exportRowWriter, error) {
+	switch format {
+	case "csv":
+		return &csvRowWriter{writer: csv.NewWriter(w)}, nil
+	case "xlsx":
+		file := excelize.NewFile()
+		if err := file.SetSheetName("Sheet1", exportSheetName); err != nil {
+			return nil, fmt.Errorf("failed to create XLSX sheet: %w", err)
+		}
+		stream, err := file.NewStreamWriter(exportSheetName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create XLSX writer: %w", err)
+		}
+		return &xlsxRowWriter{file: file, stream: stream, w: w}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %s", format)
+	}
+}
+
+type csvRowWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvRowWriter) Write(record []string) error {
+	return w.writer.Write(record)
+}
+
+func (w *csvRowWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type xlsxRowWriter struct {
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	w      io.Writer
+	row    int
+}
+
+func (w *xlsxRowWriter) Write(record []string) error {
+	w.row++
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(record))
+	for i, v := range record {
+		values[i] = v
+	}
+	return w.stream.SetRow(cell, values)
+}
+
+func (w *xlsxRowWriter) Close() error {
+	defer w.file.Close()
+
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	return w.file.Write(w.w)
+}
@@ -14,10 +14,10 @@ import (
 	"github.com/cebeuygun/platform/services/catalog/internal/config"
 	"github.com/cebeuygun/platform/services/catalog/internal/models"
 	"github.com/cebeuygun/platform/services/catalog/internal/repository"
+	"github.com/cebeuygun/platform/services/catalog/internal/storage"
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/google/uuid"
-	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/redis/go-redis/v9"
 	"github.com/segmentio/kafka-go"
 	"github.com/shopspring/decimal"
 	"golang.org/x/sync/errgroup"
@@ -28,37 +28,153 @@ type CatalogService interface {
 	CreateCategory(req *models.CreateCategoryRequest) (*models.Category, error)
 	GetCategory(id uuid.UUID) (*models.Category, error)
 	GetCategories(parentID *uuid.UUID, activeOnly bool) ([]*models.Category, error)
-	GetCategoryTree() ([]*models.Category, error)
+	GetCategoryTree(rootID *uuid.UUID) ([]*models.Category, error)
+	GetCategoryPath(id uuid.UUID) ([]*models.Category, error)
 	UpdateCategory(id uuid.UUID, req *models.UpdateCategoryRequest) error
-	DeleteCategory(id uuid.UUID) error
+	MoveCategory(id uuid.UUID, req *models.MoveCategoryRequest) (*models.Category, error)
+	ReorderCategories(req *models.ReorderCategoriesRequest) ([]*models.Category, error)
+	DeleteCategory(id uuid.UUID, actor string) error
+
+	// Attribute schema operations
+	GetCategoryAttributes(categoryID uuid.UUID) ([]*models.CategoryAttribute, error)
+	CreateCategoryAttribute(categoryID uuid.UUID, req *models.CategoryAttributeRequest) (*models.CategoryAttribute, error)
+	UpdateCategoryAttribute(categoryID uuid.UUID, attributeID uuid.UUID, req *models.CategoryAttributeRequest) (*models.CategoryAttribute, error)
+	DeleteCategoryAttribute(categoryID uuid.UUID, attributeID uuid.UUID) error
 
 	// Product operations
-	CreateProduct(req *models.CreateProductRequest) (*models.Product, error)
+	CreateProduct(req *models.CreateProductRequest, actor string) (*models.Product, error)
 	GetProduct(id uuid.UUID) (*models.Product, error)
 	GetProductBySKU(sku string) (*models.Product, error)
 	GetProductByBarcode(barcode string) (*models.Product, error)
+	LookupBarcode(barcode string, sellerID *uuid.UUID) (*models.BarcodeMatch, error)
 	SearchProducts(req *models.SearchRequest) (*models.SearchResponse, error)
-	UpdateProduct(id uuid.UUID, req *models.UpdateProductRequest) error
-	DeleteProduct(id uuid.UUID) error
+	UpdateProduct(id uuid.UUID, req *models.UpdateProductRequest, actor string) error
+	DeleteProduct(id uuid.UUID, actor string) error
 	GetFeaturedProducts(limit int) ([]*models.Product, error)
-	GetProductsByCategory(categoryID uuid.UUID, limit int, offset int) ([]*models.Product, int64, error)
+	GetProductsByCategory(categoryID uuid.UUID, limit int, cursor string) (*models.SearchResponse, error)
+	GetProductsByIDs(ids []uuid.UUID) ([]*models.Product, error)
+	GetProductsBySKUs(skus []string) ([]*models.Product, error)
+	GetRecommendations(productID uuid.UUID, limit int) (*models.Recommendations, error)
+
+	// Moderation operations
+	SubmitProduct(id uuid.UUID, actor string) (*models.Product, error)
+	ApproveProduct(id uuid.UUID, actor string) (*models.Product, error)
+	RejectProduct(id uuid.UUID, req *models.RejectProductRequest, actor string) (*models.Product, error)
+	GetModerationQueue(req *models.ModerationQueueRequest) (*models.ModerationQueueResponse, error)
+
+	// Duplicate operations
+	FindDuplicateProducts(id uuid.UUID) ([]*models.DuplicateCandidate, error)
+	MergeProducts(canonicalID uuid.UUID, req *models.MergeProductsRequest, actor string) (*models.ProductMergeResult, error)
+
+	// Bundle operations
+	GetBundle(productID uuid.UUID) (*models.Bundle, error)
+	SetBundle(productID uuid.UUID, req *models.BundleRequest) (*models.Bundle, error)
+	DeleteBundle(productID uuid.UUID) error
+	ResolveBundleSelection(productID uuid.UUID, req *models.BundleSelectionRequest) (*models.BundleSelection, error)
+
+	// Translation operations
+	GetProductTranslations(productID uuid.UUID) (*models.ProductTranslations, error)
+	SetProductTranslation(productID uuid.UUID, locale string, req *models.TranslationRequest) (*models.ProductTranslation, error)
+	DeleteProductTranslation(productID uuid.UUID, locale string) error
+	SetVariantTranslation(productID uuid.UUID, variantID uuid.UUID, locale string, req *models.TranslationRequest) (*models.VariantTranslation, error)
+	DeleteVariantTranslation(productID uuid.UUID, variantID uuid.UUID, locale string) error
+	GetCategoryTranslations(categoryID uuid.UUID) ([]*models.CategoryTranslation, error)
+	SetCategoryTranslation(categoryID uuid.UUID, locale string, req *models.TranslationRequest) (*models.CategoryTranslation, error)
+	DeleteCategoryTranslation(categoryID uuid.UUID, locale string) error
+	NegotiateLocales(acceptLanguage string) []string
+	LocalizeProducts(products []*models.Product, locales []string)
+	LocalizeCategories(categories []*models.Category, locales []string)
 
 	// Variant operations
-	CreateVariant(productID uuid.UUID, req *models.CreateVariantRequest) (*models.ProductVariant, error)
-	UpdateVariant(id uuid.UUID, variant *models.ProductVariant) error
-	DeleteVariant(id uuid.UUID) error
+	CreateVariant(productID uuid.UUID, req *models.CreateVariantRequest, actor string) (*models.ProductVariant, error)
+	UpdateVariant(id uuid.UUID, variant *models.ProductVariant, actor string) error
+	DeleteVariant(id uuid.UUID, actor string) error
 
 	// Media operations
 	UploadMedia(productID uuid.UUID, variantID *uuid.UUID, file io.Reader, fileName string, fileSize int64, mimeType string) (*models.ProductMedia, error)
 	DeleteMedia(id uuid.UUID) error
+	CreateMediaUpload(productID uuid.UUID, req *models.CreateMediaUploadRequest) (*models.PresignedUpload, error)
+	ConfirmMediaUpload(productID uuid.UUID, uploadID uuid.UUID) (*models.ProductMedia, error)
+
+	// Revision history
+	GetRevisions(req *models.RevisionListRequest) (*models.RevisionListResponse, error)
+	GetRevision(productID uuid.UUID, revisionID uuid.UUID) (*models.Revision, error)
+	CompareRevisions(productID uuid.UUID, fromID uuid.UUID, toID uuid.UUID) (*models.RevisionComparison, error)
+	RestoreRevision(productID uuid.UUID, revisionID uuid.UUID, actor string) (interface{}, error)
+
+	// Price history
+	GetPriceHistory(req *models.PriceHistoryRequest) (*models.PriceHistoryResponse, error)
+
+	// Trash operations
+	GetDeletedProducts(page int, limit int) (*models.DeletedProductListResponse, error)
+	RestoreProduct(id uuid.UUID, actor string) (*models.Product, error)
+	GetDeletedCategories() ([]*models.Category, error)
+	RestoreCategory(id uuid.UUID) (*models.Category, error)
+	StartOrderCreatedConsumer()
+	StartRetentionPurge()
+
+	// Cache operations
+	StartProductEventConsumer()
 
 	// Seller operations
-	UpsertSellerProduct(sellerID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID, req *models.SellerProductRequest) (*models.SellerProduct, error)
-	DeleteSellerProduct(id uuid.UUID) error
-	GetSellerProducts(sellerID uuid.UUID, productID *uuid.UUID) ([]*models.SellerProduct, error)
+	UpsertSellerProduct(sellerID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID, req *models.SellerProductRequest, actor string) (*models.SellerProduct, error)
+	DeleteSellerProduct(id uuid.UUID, actor string) error
+	GetSellerProducts(sellerID uuid.UUID, productID *uuid.UUID, page int, limit int) (*models.SellerProductListResponse, error)
+	ListSellerProducts(req *models.SellerProductListRequest) (*models.SellerProductListResponse, error)
+	ExportSellerProducts(req *models.SellerProductListRequest, w io.Writer) error
+	GetLowStockReport(sellerID uuid.UUID) ([]*models.LowStockItem, error)
+
+	// Seller location operations
+	GetSellerLocations(sellerID uuid.UUID) ([]*models.SellerLocation, error)
+	CreateSellerLocation(sellerID uuid.UUID, req *models.SellerLocationRequest) (*models.SellerLocation, error)
+	UpdateSellerLocation(sellerID uuid.UUID, locationID uuid.UUID, req *models.SellerLocationRequest) (*models.SellerLocation, error)
+	DeleteSellerLocation(sellerID uuid.UUID, locationID uuid.UUID) error
+	CreateDeliveryZone(sellerID uuid.UUID, locationID uuid.UUID, req *models.DeliveryZoneRequest) (*models.DeliveryZone, error)
+	UpdateDeliveryZone(sellerID uuid.UUID, locationID uuid.UUID, zoneID uuid.UUID, req *models.DeliveryZoneRequest) (*models.DeliveryZone, error)
+	DeleteDeliveryZone(sellerID uuid.UUID, locationID uuid.UUID, zoneID uuid.UUID) error
+
+	// Review operations
+	CreateReview(productID uuid.UUID, customerID uuid.UUID, req *models.CreateReviewRequest) (*models.Review, error)
+	AddReviewPhoto(reviewID uuid.UUID, customerID uuid.UUID, file io.Reader, fileSize int64, mimeType string) (*models.Review, error)
+	VoteReview(reviewID uuid.UUID, voterID uuid.UUID, helpful bool) (*models.Review, error)
+	DeleteReviewVote(reviewID uuid.UUID, voterID uuid.UUID) (*models.Review, error)
+	ReplyToReview(sellerID uuid.UUID, reviewID uuid.UUID, req *models.ReviewReplyRequest) (*models.Review, error)
+	ListReviews(req *models.ReviewListRequest) (*models.ReviewListResponse, error)
+	ApproveReview(id uuid.UUID, actor string) (*models.Review, error)
+	RejectReview(id uuid.UUID, req *models.RejectReviewRequest, actor string) (*models.Review, error)
+	StartOrderDeliveredConsumer()
 
 	// Bulk operations
-	BulkImport(sellerID uuid.UUID, csvData io.Reader) (*models.BulkImportResponse, error)
+	CreateImportJob(req *models.CreateImportJobRequest, file io.Reader, fileSize int64) (*models.ImportJob, error)
+	GetImportJob(id uuid.UUID) (*models.ImportJob, error)
+	GetImportJobRows(jobID uuid.UUID, status *models.ImportRowStatus, page int, limit int) (*models.ImportJobRowsResponse, error)
+	StartImportWorker()
+	ExportCatalog(req *models.CatalogExportRequest, w io.Writer) error
+
+	// Feed operations
+	GenerateMerchantFeed(format string) (*models.MerchantFeed, error)
+	StartMerchantFeedScheduler()
+
+	// Media processing
+	StartMediaWorker()
+
+	// Stock alerts
+	StartStockAlertPublisher()
+
+	// Availability operations
+	SetProductAvailability(id uuid.UUID, req *models.ProductAvailabilityRequest, actor string) (*models.Product, error)
+	StartAvailabilityScheduler()
+
+	// Search settings operations
+	GetLiveSearchSettings() (*models.SearchSettings, error)
+	GetSearchSettingsVersions() ([]*models.SearchSettings, error)
+	GetSearchSettingsVersion(version int) (*models.SearchSettings, error)
+	GetSearchSettingsDraft() (*models.SearchSettings, error)
+	SaveSearchSettingsDraft(req *models.SearchSettingsRequest, actor string) (*models.SearchSettings, error)
+	DiscardSearchSettingsDraft() error
+	RestoreSearchSettings(version int, actor string) (*models.SearchSettings, error)
+	PublishSearchSettings(actor string) (*models.SearchSettings, error)
+	PreviewSearch(req *models.SearchRequest, version *int, at time.Time) (*models.SearchPreview, error)
 
 	// Search operations
 	IndexProduct(product *models.Product) error
@@ -66,17 +182,41 @@ type CatalogService interface {
 }
 
 type catalogService struct {
-	categoryRepo repository.CategoryRepository
-	productRepo  repository.ProductRepository
-	esClient     *elasticsearch.Client
-	minioClient  *minio.Client
-	kafkaWriter  *kafka.Writer
-	config       *config.Config
+	categoryRepo       repository.CategoryRepository
+	productRepo        repository.ProductRepository
+	importJobRepo      repository.ImportJobRepository
+	mediaUploadRepo    repository.MediaUploadRepository
+	revisionRepo       repository.RevisionRepository
+	bundleRepo         repository.BundleRepository
+	translationRepo    repository.TranslationRepository
+	locationRepo       repository.SellerLocationRepository
+	reviewRepo         repository.ReviewRepository
+	duplicateRepo      repository.DuplicateRepository
+	searchSettingsRepo repository.SearchSettingsRepository
+	recommendationRepo repository.RecommendationRepository
+	esClient           *elasticsearch.Client
+	mediaStore         storage.MediaStore
+	kafkaWriter        *kafka.Writer
+	redisClient        *redis.Client  // nil when caching is disabled
+	location           *time.Location // time zone of availability schedules
+	config             *config.Config
 }
 
 func NewCatalogService(
 	categoryRepo repository.CategoryRepository,
 	productRepo repository.ProductRepository,
+	importJobRepo repository.ImportJobRepository,
+	mediaUploadRepo repository.MediaUploadRepository,
+	revisionRepo repository.RevisionRepository,
+	bundleRepo repository.BundleRepository,
+	translationRepo repository.TranslationRepository,
+	locationRepo repository.SellerLocationRepository,
+	reviewRepo repository.ReviewRepository,
+	duplicateRepo repository.DuplicateRepository,
+	searchSettingsRepo repository.SearchSettingsRepository,
+	recommendationRepo repository.RecommendationRepository,
+	mediaStore storage.MediaStore,
+	redisClient *redis.Client,
 	cfg *config.Config,
 ) (CatalogService, error) {
 	// Initialize Elasticsearch client
@@ -87,42 +227,36 @@ func NewCatalogService(
 		return nil, fmt.Errorf("failed to create elasticsearch client: %w", err)
 	}
 
-	// Initialize MinIO client
-	minioClient, err := minio.New(cfg.MinIOEndpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(cfg.MinIOAccessKey, cfg.MinIOSecretKey, ""),
-		Secure: cfg.MinIOUseSSL,
-	})
+	location, err := time.LoadLocation(cfg.AvailabilityTimezone)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create minio client: %w", err)
+		return nil, fmt.Errorf("failed to load availability timezone: %w", err)
 	}
 
-	// Ensure bucket exists
-	ctx := context.Background()
-	exists, err := minioClient.BucketExists(ctx, cfg.MinIOBucketName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check bucket existence: %w", err)
-	}
-	if !exists {
-		err = minioClient.MakeBucket(ctx, cfg.MinIOBucketName, minio.MakeBucketOptions{})
-		if err != nil {
-			return nil, fmt.Errorf("failed to create bucket: %w", err)
-		}
-	}
-
-	// Initialize Kafka writer
+	// Initialize Kafka writer (topic is set per message)
 	kafkaWriter := &kafka.Writer{
 		Addr:     kafka.TCP(cfg.KafkaBrokers...),
-		Topic:    cfg.KafkaTopic,
 		Balancer: &kafka.LeastBytes{},
 	}
 
 	return &catalogService{
-		categoryRepo: categoryRepo,
-		productRepo:  productRepo,
-		esClient:     esClient,
-		minioClient:  minioClient,
-		kafkaWriter:  kafkaWriter,
-		config:       cfg,
+		categoryRepo:       categoryRepo,
+		productRepo:        productRepo,
+		importJobRepo:      importJobRepo,
+		mediaUploadRepo:    mediaUploadRepo,
+		revisionRepo:       revisionRepo,
+		bundleRepo:         bundleRepo,
+		translationRepo:    translationRepo,
+		locationRepo:       locationRepo,
+		reviewRepo:         reviewRepo,
+		duplicateRepo:      duplicateRepo,
+		searchSettingsRepo: searchSettingsRepo,
+		recommendationRepo: recommendationRepo,
+		esClient:           esClient,
+		mediaStore:         mediaStore,
+		kafkaWriter:        kafkaWriter,
+		redisClient:        redisClient,
+		location:           location,
+		config:             cfg,
 	}, nil
 }
 
@@ -143,6 +277,8 @@ func (s *catalogService) CreateCategory(req *models.CreateCategoryRequest) (*mod
 		return nil, fmt.Errorf("failed to create category: %w", err)
 	}
 
+	s.invalidateCategories()
+
 	return category, nil
 }
 
@@ -169,31 +305,50 @@ func (s *catalogService) GetCategories(parentID *uuid.UUID, activeOnly bool) ([]
 		return nil, fmt.Errorf("failed to get categories: %w", err)
 	}
 
-	// Get product counts for each category
-	for _, category := range categories {
-		count, err := s.categoryRepo.GetProductCount(category.ID)
-		if err == nil {
-			category.ProductCount = count
-		}
-	}
+	s.fillProductCounts(categories)
 
 	return categories, nil
 }
 
-func (s *catalogService) GetCategoryTree() ([]*models.Category, error) {
-	return s.categoryRepo.GetTree()
-}
-
 func (s *catalogService) UpdateCategory(id uuid.UUID, req *models.UpdateCategoryRequest) error {
-	return s.categoryRepo.Update(id, req)
+	if req.ParentID != nil {
+		if err := s.checkCategoryParent(id, *req.ParentID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.categoryRepo.Update(id, req); err != nil {
+		return err
+	}
+
+	s.invalidateCategories()
+	return nil
 }
 
-func (s *catalogService) DeleteCategory(id uuid.UUID) error {
-	return s.categoryRepo.Delete(id)
+func (s *catalogService) DeleteCategory(id uuid.UUID, actor string) error {
+	if err := s.categoryRepo.Delete(id, actor); err != nil {
+		return err
+	}
+
+	s.invalidateCategories()
+	return nil
 }
 
 // Product operations
-func (s *catalogService) CreateProduct(req *models.CreateProductRequest) (*models.Product, error) {
+func (s *catalogService) CreateProduct(req *models.CreateProductRequest, actor string) (*models.Product, error) {
+	if err := s.normalizeProductRequest(req); err != nil {
+		return nil, err
+	}
+	if err := validateAvailability(req.PublishAt, req.UnpublishAt); err != nil {
+		return nil, err
+	}
+	if err := normalizeFoodInfo(req.FoodInfo); err != nil {
+		return nil, err
+	}
+	if err := s.normalizeProductBarcodes(req); err != nil {
+		return nil, err
+	}
+
 	product := &models.Product{
 		ID:                uuid.New(),
 		Name:              req.Name,
@@ -215,13 +370,23 @@ func (s *catalogService) CreateProduct(req *models.CreateProductRequest) (*model
 		IsActive:          true,
 		IsExpressDelivery: req.IsExpressDelivery,
 		PreparationTime:   req.PreparationTime,
+		Status:            models.ProductStatusDraft,
+		SubmittedBy:       req.SellerID,
+		ProductType:       models.ProductTypeSimple,
+		PublishAt:            req.PublishAt,
+		UnpublishAt:          req.UnpublishAt,
+		AvailabilitySchedule: normalizeSchedule(req.AvailabilitySchedule),
+		FoodInfo:             req.FoodInfo,
 	}
+	product.IsAvailable = s.availableAt(product.PublishAt, product.UnpublishAt, product.AvailabilitySchedule, time.Now())
 
 	err := s.productRepo.Create(product)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create product: %w", err)
+		return nil, fmt.Errorf("failed to create product: %w", barcodeConflictError(err))
 	}
 
+	s.recordRevision(models.RevisionEntityProduct, product.ID, product.ID, models.RevisionActionCreate, actor, nil, product, nil)
+
 	// Create variants if provided
 	if len(req.Variants) > 0 {
 		for _, variantReq := range req.Variants {
@@ -243,10 +408,25 @@ func (s *catalogService) CreateProduct(req *models.CreateProductRequest) (*model
 			err := s.productRepo.CreateVariant(variant)
 			if err != nil {
 				log.Printf("Failed to create variant: %v", err)
+				continue
 			}
+
+			s.recordRevision(models.RevisionEntityVariant, variant.ID, product.ID, models.RevisionActionCreate, actor, nil, variant, nil)
+		}
+	}
+
+	// New products only go live once a moderator approves them
+	if req.SubmitForReview {
+		submitted, err := s.submitProduct(product, actor)
+		if err != nil {
+			log.Printf("Failed to submit product %s for review: %v", product.ID, err)
+		} else {
+			product = submitted
 		}
 	}
 
+	s.invalidateProduct(product.ID)
+
 	// Index in Elasticsearch
 	go func() {
 		if err := s.IndexProduct(product); err != nil {
@@ -261,6 +441,10 @@ func (s *catalogService) CreateProduct(req *models.CreateProductRequest) (*model
 }
 
 func (s *catalogService) GetProduct(id uuid.UUID) (*models.Product, error) {
+	if product := s.cachedProduct(id); product != nil {
+		return product, nil
+	}
+
 	product, err := s.productRepo.GetByID(id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get product: %w", err)
@@ -294,7 +478,20 @@ func (s *catalogService) GetProduct(id uuid.UUID) (*models.Product, error) {
 			return nil
 		})
 
+		if product.ProductType == models.ProductTypeBundle {
+			g.Go(func() error {
+				bundle, err := s.bundleRepo.GetBundle(product.ID)
+				if err == nil {
+					bundle.Stock = bundleStock(bundle)
+					product.Bundle = bundle
+				}
+				return nil
+			})
+		}
+
 		g.Wait()
+
+		s.cacheProduct(product)
 	}
 
 	return product, nil
@@ -304,36 +501,128 @@ func (s *catalogService) GetProductBySKU(sku string) (*models.Product, error) {
 	return s.productRepo.GetBySKU(sku)
 }
 
-func (s *catalogService) GetProductByBarcode(barcode string) (*models.Product, error) {
-	return s.productRepo.GetByBarcode(barcode)
+// SearchProducts searches with the live search settings
+func (s *catalogService) SearchProducts(req *models.SearchRequest) (*models.SearchResponse, error) {
+	settings, err := s.liveSearchSettings()
+	if err != nil {
+		return nil, err
+	}
+	applySearchSettings(req, settings, time.Now())
+
+	return s.search(req)
 }
 
-func (s *catalogService) SearchProducts(req *models.SearchRequest) (*models.SearchResponse, error) {
-	products, total, err := s.productRepo.Search(req)
+// search runs a search whose query the search settings have been applied to
+// and returns a page of products. A cursor continues after the previous page
+// regardless of edits in between; without one, Page selects an offset page.
+func (s *catalogService) search(req *models.SearchRequest) (*models.SearchResponse, error) {
+	if req.Cursor != "" {
+		after, err := decodeSearchCursor(req)
+		if err != nil {
+			return nil, err
+		}
+		req.After = after
+	}
+
+	// Facets come from the attribute schema, so they need a category. They
+	// are the same on every page, so later cursor pages leave them out.
+	var facets []*models.AttributeFacet
+	if req.CategoryID != nil && req.After == nil {
+		var err error
+		facets, err = s.attributeFacets(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	products, hasMore, err := s.productRepo.Search(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search products: %w", err)
 	}
 
-	totalPages := int(total) / req.Limit
-	if int(total)%req.Limit > 0 {
-		totalPages++
+	if req.Latitude != nil && req.Longitude != nil {
+		if err := s.attachOffers(products, models.GeoPoint{Latitude: *req.Latitude, Longitude: *req.Longitude}); err != nil {
+			return nil, err
+		}
 	}
 
-	return &models.SearchResponse{
-		Products:   products,
-		Total:      total,
-		Page:       req.Page,
-		Limit:      req.Limit,
-		TotalPages: totalPages,
-	}, nil
+	response := &models.SearchResponse{
+		Products: products,
+		Limit:    req.Limit,
+		Facets:   facets,
+	}
+	if req.After == nil {
+		response.Page = req.Page
+	}
+	if hasMore {
+		response.NextCursor = encodeSearchCursor(req, products[len(products)-1])
+	}
+
+	if req.IncludeTotal {
+		total, approximate, err := s.searchTotal(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count products: %w", err)
+		}
+
+		response.Total = total
+		response.TotalApproximate = approximate
+		response.TotalPages = int(total) / req.Limit
+		if int(total)%req.Limit > 0 {
+			response.TotalPages++
+		}
+	}
+
+	return response, nil
 }
 
-func (s *catalogService) UpdateProduct(id uuid.UUID, req *models.UpdateProductRequest) error {
-	err := s.productRepo.Update(id, req)
+func (s *catalogService) UpdateProduct(id uuid.UUID, req *models.UpdateProductRequest, actor string) error {
+	// Capture the product before the update for its revision and to validate
+	// attribute and category changes
+	previous, err := s.productRepo.GetByID(id)
 	if err != nil {
-		return fmt.Errorf("failed to update product: %w", err)
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+	if previous == nil {
+		return fmt.Errorf("product not found")
 	}
 
+	if err := s.normalizeProductUpdate(previous, req); err != nil {
+		return err
+	}
+	if err := normalizeFoodInfo(req.FoodInfo); err != nil {
+		return err
+	}
+	if err := s.normalizeBarcodeChange("barcode", req.Barcode, previous.Barcode, id, nil); err != nil {
+		return err
+	}
+	if err := validateCompareAtPrice("compare_at_price", req.CompareAtPrice, &previous.BasePrice); err != nil {
+		return err
+	}
+
+	err = s.productRepo.Update(id, req)
+	if err != nil {
+		return fmt.Errorf("failed to update product: %w", compareAtPriceError("compare_at_price", barcodeConflictError(err)))
+	}
+
+	product, err := s.productRepo.GetByID(id)
+	if err == nil && product != nil {
+		s.recordRevision(models.RevisionEntityProduct, id, id, models.RevisionActionUpdate, actor, previous, product, nil)
+
+		if err := s.remoderateProduct(previous, product, actor); err != nil {
+			s.refreshProduct(id)
+			return fmt.Errorf("failed to moderate product change: %w", err)
+		}
+	}
+
+	s.refreshProduct(id)
+
+	return nil
+}
+
+// refreshProduct re-indexes a changed product and publishes its update event
+func (s *catalogService) refreshProduct(id uuid.UUID) {
+	s.invalidateProduct(id)
+
 	// Re-index in Elasticsearch
 	go func() {
 		product, err := s.productRepo.GetByID(id)
@@ -342,6 +631,9 @@ func (s *catalogService) UpdateProduct(id uuid.UUID, req *models.UpdateProductRe
 				log.Printf("Failed to re-index product %s: %v", id, err)
 			}
 		}
+
+		// Bundle stock is derived from their components
+		s.reindexBundlesContaining(id)
 	}()
 
 	// Publish to Kafka
@@ -351,21 +643,33 @@ func (s *catalogService) UpdateProduct(id uuid.UUID, req *models.UpdateProductRe
 			s.publishProductEvent(product, "updated")
 		}
 	}()
-
-	return nil
 }
 
-func (s *catalogService) DeleteProduct(id uuid.UUID) error {
-	err := s.productRepo.Delete(id)
+func (s *catalogService) DeleteProduct(id uuid.UUID, actor string) error {
+	previous, err := s.productRepo.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+	if previous == nil {
+		return fmt.Errorf("product not found")
+	}
+
+	err = s.productRepo.Delete(id, actor)
 	if err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
+	s.recordRevision(models.RevisionEntityProduct, id, id, models.RevisionActionDelete, actor, previous, nil, nil)
+	s.invalidateProduct(id)
+
 	// Remove from Elasticsearch
 	go func() {
 		if err := s.RemoveFromIndex(id); err != nil {
 			log.Printf("Failed to remove product %s from index: %v", id, err)
 		}
+
+		// Bundles treat deleted components as out of stock
+		s.reindexBundlesContaining(id)
 	}()
 
 	// Publish to Kafka
@@ -375,17 +679,55 @@ func (s *catalogService) DeleteProduct(id uuid.UUID) error {
 }
 
 func (s *catalogService) GetFeaturedProducts(limit int) ([]*models.Product, error) {
-	return s.productRepo.GetFeatured(limit)
+	field := strconv.Itoa(limit)
+
+	var products []*models.Product
+	if s.cacheGet(featuredCacheKey, field, s.config.CacheFeaturedTTL, &products) {
+		return products, nil
+	}
+
+	products, err := s.productRepo.GetFeatured(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheSet(featuredCacheKey, field, s.featuredTTL(), products)
+
+	return products, nil
 }
 
-func (s *catalogService) GetProductsByCategory(categoryID uuid.UUID, limit int, offset int) ([]*models.Product, int64, error) {
-	return s.productRepo.GetByCategory(categoryID, limit, offset)
+// GetProductsByCategory lists the active products of a category, newest
+// first, a page at a time. An empty cursor starts at the first page.
+func (s *catalogService) GetProductsByCategory(categoryID uuid.UUID, limit int, cursor string) (*models.SearchResponse, error) {
+	active := true
+	return s.SearchProducts(&models.SearchRequest{
+		CategoryID: &categoryID,
+		IsActive:   &active,
+		Page:       1,
+		Limit:      limit,
+		SortBy:     "relevance",
+		SortOrder:  "desc",
+		Cursor:     cursor,
+	})
 }
 
 // Variant operations
-func (s *catalogService) CreateVariant(productID uuid.UUID, req *models.CreateVariantRequest) (*models.ProductVariant, error) {
+func (s *catalogService) CreateVariant(productID uuid.UUID, req *models.CreateVariantRequest, actor string) (*models.ProductVariant, error) {
+	attributes, err := s.normalizeVariant(productID, req.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	variantID := uuid.New()
+	if err := s.normalizeBarcodeChange("barcode", req.Barcode, nil, productID, &variantID); err != nil {
+		return nil, err
+	}
+	if req.CompareAtPrice != nil {
+		return nil, &PriceError{Field: "compare_at_price", Message: "needs a price of the last 30 days to compare with"}
+	}
+
 	variant := &models.ProductVariant{
-		ID:         uuid.New(),
+		ID:         variantID,
 		ProductID:  productID,
 		Name:       req.Name,
 		SKU:        req.SKU,
@@ -394,16 +736,19 @@ func (s *catalogService) CreateVariant(productID uuid.UUID, req *models.CreateVa
 		Stock:      req.Stock,
 		Weight:     req.Weight,
 		Dimensions: req.Dimensions,
-		Attributes: req.Attributes,
+		Attributes: attributes,
 		IsActive:   true,
 		SortOrder:  req.SortOrder,
 	}
 
-	err := s.productRepo.CreateVariant(variant)
+	err = s.productRepo.CreateVariant(variant)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create variant: %w", err)
+		return nil, fmt.Errorf("failed to create variant: %w", barcodeConflictError(err))
 	}
 
+	s.recordRevision(models.RevisionEntityVariant, variant.ID, productID, models.RevisionActionCreate, actor, nil, variant, nil)
+	s.invalidateProduct(productID)
+
 	// Re-index parent product
 	go func() {
 		product, err := s.productRepo.GetByID(productID)
@@ -417,16 +762,97 @@ func (s *catalogService) CreateVariant(productID uuid.UUID, req *models.CreateVa
 	return variant, nil
 }
 
-func (s *catalogService) UpdateVariant(id uuid.UUID, variant *models.ProductVariant) error {
-	return s.productRepo.UpdateVariant(id, variant)
+func (s *catalogService) UpdateVariant(id uuid.UUID, variant *models.ProductVariant, actor string) error {
+	if variant.Barcode != nil {
+		current, err := s.productRepo.GetVariant(id)
+		if err != nil {
+			return fmt.Errorf("failed to get variant: %w", err)
+		}
+		if current == nil {
+			return fmt.Errorf("variant not found")
+		}
+		if err := s.normalizeBarcodeChange("barcode", variant.Barcode, current.Barcode, current.ProductID, &id); err != nil {
+			return err
+		}
+	}
+
+	return s.updateVariant(id, variant, actor, models.RevisionActionUpdate, nil)
 }
 
-func (s *catalogService) DeleteVariant(id uuid.UUID) error {
-	return s.productRepo.DeleteVariant(id)
+func (s *catalogService) updateVariant(id uuid.UUID, variant *models.ProductVariant, actor string, action models.RevisionAction, restoredFrom *uuid.UUID) error {
+	attributes, err := s.normalizeVariant(variant.ProductID, variant.Attributes)
+	if err != nil {
+		return err
+	}
+	variant.Attributes = attributes
+
+	if err := validateCompareAtPrice("compare_at_price", variant.CompareAtPrice, variant.Price); err != nil {
+		return err
+	}
+
+	previous, err := s.productRepo.GetVariant(id)
+	if err != nil {
+		return fmt.Errorf("failed to get variant: %w", err)
+	}
+
+	err = s.productRepo.UpdateVariant(id, variant)
+	if err != nil {
+		return compareAtPriceError("compare_at_price", barcodeConflictError(err))
+	}
+
+	updated, err := s.productRepo.GetVariant(id)
+	if err == nil && updated != nil {
+		s.recordRevision(models.RevisionEntityVariant, id, updated.ProductID, action, actor, previous, updated, restoredFrom)
+		s.invalidateProduct(updated.ProductID)
+	}
+
+	return nil
+}
+
+func (s *catalogService) DeleteVariant(id uuid.UUID, actor string) error {
+	previous, err := s.productRepo.GetVariant(id)
+	if err != nil {
+		return fmt.Errorf("failed to get variant: %w", err)
+	}
+
+	err = s.productRepo.DeleteVariant(id)
+	if err != nil {
+		return err
+	}
+
+	if previous != nil {
+		s.recordRevision(models.RevisionEntityVariant, id, previous.ProductID, models.RevisionActionDelete, actor, previous, nil, nil)
+		s.invalidateProduct(previous.ProductID)
+	}
+
+	return nil
 }
 
 // Media operations
 func (s *catalogService) UploadMedia(productID uuid.UUID, variantID *uuid.UUID, file io.Reader, fileName string, fileSize int64, mimeType string) (*models.ProductMedia, error) {
+	if err := s.validateMediaFile(mimeType, fileSize); err != nil {
+		return nil, err
+	}
+
+	// Images are validated and deduplicated up front, then processed by the media worker
+	if strings.HasPrefix(mimeType, "image/") {
+		return s.uploadImage(productID, variantID, file, fileName, mimeType)
+	}
+
+	// Generate unique file name
+	objectName := fmt.Sprintf("products/%s/%s", productID, uuid.New().String()+"-"+fileName)
+
+	// Upload to media storage
+	ctx := context.Background()
+	err := s.mediaStore.Put(ctx, objectName, file, fileSize, mimeType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	return s.createVideoMedia(productID, variantID, objectName, fileName, fileSize, mimeType)
+}
+
+func (s *catalogService) validateMediaFile(mimeType string, fileSize int64) error {
 	// Validate file type
 	allowed := false
 	for _, allowedType := range s.config.AllowedFileTypes {
@@ -436,63 +862,76 @@ func (s *catalogService) UploadMedia(productID uuid.UUID, variantID *uuid.UUID,
 		}
 	}
 	if !allowed {
-		return nil, fmt.Errorf("file type %s not allowed", mimeType)
+		return fmt.Errorf("file type %s not allowed", mimeType)
 	}
 
 	// Validate file size
 	if fileSize > s.config.MaxFileSize {
-		return nil, fmt.Errorf("file size %d exceeds maximum allowed size %d", fileSize, s.config.MaxFileSize)
-	}
-
-	// Generate unique file name
-	objectName := fmt.Sprintf("products/%s/%s", productID, uuid.New().String()+"-"+fileName)
-
-	// Upload to MinIO
-	ctx := context.Background()
-	_, err := s.minioClient.PutObject(ctx, s.config.MinIOBucketName, objectName, file, fileSize, minio.PutObjectOptions{
-		ContentType: mimeType,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to upload file: %w", err)
+		return fmt.Errorf("file size %d exceeds maximum allowed size %d", fileSize, s.config.MaxFileSize)
 	}
 
-	// Generate URL
-	url := fmt.Sprintf("http://%s/%s/%s", s.config.MinIOEndpoint, s.config.MinIOBucketName, objectName)
-
-	// Determine media type
-	mediaType := "image"
-	if strings.HasPrefix(mimeType, "video/") {
-		mediaType = "video"
-	}
+	return nil
+}
 
-	// Create media record
+func (s *catalogService) createVideoMedia(productID uuid.UUID, variantID *uuid.UUID, objectName string, fileName string, fileSize int64, mimeType string) (*models.ProductMedia, error) {
 	media := &models.ProductMedia{
-		ID:        uuid.New(),
-		ProductID: productID,
-		VariantID: variantID,
-		Type:      mediaType,
-		URL:       url,
-		FileName:  fileName,
-		FileSize:  fileSize,
-		MimeType:  mimeType,
-		SortOrder: 0,
-		IsActive:  true,
+		ID:               uuid.New(),
+		ProductID:        productID,
+		VariantID:        variantID,
+		Type:             "video",
+		URL:              s.mediaStore.URL(objectName),
+		FileName:         fileName,
+		FileSize:         fileSize,
+		MimeType:         mimeType,
+		SortOrder:        0,
+		IsActive:         true,
+		ProcessingStatus: models.MediaProcessingStatusReady,
 	}
 
-	err = s.productRepo.CreateMedia(media)
+	err := s.productRepo.CreateMedia(media)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create media record: %w", err)
 	}
 
+	s.invalidateProduct(productID)
+
 	return media, nil
 }
 
 func (s *catalogService) DeleteMedia(id uuid.UUID) error {
-	return s.productRepo.DeleteMedia(id)
+	productID, err := s.productRepo.DeleteMedia(id)
+	if err != nil {
+		return err
+	}
+
+	s.invalidateProduct(productID)
+	return nil
 }
 
 // Seller operations
-func (s *catalogService) UpsertSellerProduct(sellerID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID, req *models.SellerProductRequest) (*models.SellerProduct, error) {
+func (s *catalogService) UpsertSellerProduct(sellerID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID, req *models.SellerProductRequest, actor string) (*models.SellerProduct, error) {
+	return s.upsertSellerProduct(sellerID, productID, variantID, req, actor, nil)
+}
+
+// upsertSellerProduct creates or replaces a seller override. When restored is
+// set the change is recorded as a restore of that revision, and a deleted
+// override gets its previous ID back.
+func (s *catalogService) upsertSellerProduct(sellerID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID, req *models.SellerProductRequest, actor string, restored *models.Revision) (*models.SellerProduct, error) {
+	if err := validateAvailability(req.PublishAt, req.UnpublishAt); err != nil {
+		return nil, err
+	}
+	if err := validateCompareAtPrice("compare_at_price", req.CompareAtPrice, req.Price); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.findSellerProduct(sellerID, productID, variantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller product: %w", err)
+	}
+	if existing == nil && req.CompareAtPrice != nil {
+		return nil, &PriceError{Field: "compare_at_price", Message: "needs a price of the last 30 days to compare with"}
+	}
+
 	sellerProduct := &models.SellerProduct{
 		ID:              uuid.New(),
 		SellerID:        sellerID,
@@ -500,6 +939,7 @@ func (s *catalogService) UpsertSellerProduct(sellerID uuid.UUID, productID uuid.
 		VariantID:       variantID,
 		SellerSKU:       req.SellerSKU,
 		Price:           req.Price,
+		CompareAtPrice:  req.CompareAtPrice,
 		Stock:           req.Stock,
 		MinStock:        req.MinStock,
 		MaxStock:        req.MaxStock,
@@ -507,185 +947,213 @@ func (s *catalogService) UpsertSellerProduct(sellerID uuid.UUID, productID uuid.
 		IsVisible:       req.IsVisible != nil && *req.IsVisible,
 		PreparationTime: req.PreparationTime,
 		Notes:           req.Notes,
+		PublishAt:            req.PublishAt,
+		UnpublishAt:          req.UnpublishAt,
+		AvailabilitySchedule: normalizeSchedule(req.AvailabilitySchedule),
 	}
-
-	err := s.productRepo.UpsertSellerProduct(sellerProduct)
-	if err != nil {
-		return nil, fmt.Errorf("failed to upsert seller product: %w", err)
+	sellerProduct.IsAvailable = s.availableAt(sellerProduct.PublishAt, sellerProduct.UnpublishAt, sellerProduct.AvailabilitySchedule, time.Now())
+	if existing != nil {
+		sellerProduct.ID = existing.ID
+	} else if restored != nil {
+		sellerProduct.ID = restored.EntityID
+	}
+	// A restore keeps the compare-at price, which only holds against the
+	// prices before it was set, as long as the listing keeps a price
+	if restored != nil && existing != nil && sellerProduct.Price != nil {
+		sellerProduct.CompareAtPrice = existing.CompareAtPrice
 	}
 
-	return sellerProduct, nil
-}
+	applyStockVisibility(existing, sellerProduct, req)
 
-func (s *catalogService) DeleteSellerProduct(id uuid.UUID) error {
-	return s.productRepo.DeleteSellerProduct(id)
-}
+	err = s.productRepo.UpsertSellerProduct(sellerProduct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert seller product: %w", compareAtPriceError("compare_at_price", err))
+	}
 
-func (s *catalogService) GetSellerProducts(sellerID uuid.UUID, productID *uuid.UUID) ([]*models.SellerProduct, error) {
-	if productID != nil {
-		return s.productRepo.GetSellerData(*productID, &sellerID)
+	action := models.RevisionActionUpdate
+	var restoredFrom *uuid.UUID
+	switch {
+	case restored != nil:
+		action = models.RevisionActionRestore
+		restoredFrom = &restored.ID
+	case existing == nil:
+		action = models.RevisionActionCreate
 	}
+	if stored, err := s.productRepo.GetSellerProduct(sellerProduct.ID); err == nil && stored != nil {
+		s.recordRevision(models.RevisionEntitySellerProduct, sellerProduct.ID, productID, action, actor, existing, stored, restoredFrom)
+	}
+	s.invalidateProduct(productID)
 
-	// If no specific product ID, we need a different query - this would require a new repository method
-	return nil, fmt.Errorf("getting all seller products not implemented")
+	return sellerProduct, nil
 }
 
-// Bulk operations
-func (s *catalogService) BulkImport(sellerID uuid.UUID, csvData io.Reader) (*models.BulkImportResponse, error) {
-	reader := csv.NewReader(csvData)
-	records, err := reader.ReadAll()
+func (s *catalogService) DeleteSellerProduct(id uuid.UUID, actor string) error {
+	previous, err := s.productRepo.GetSellerProduct(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV: %w", err)
+		return fmt.Errorf("failed to get seller product: %w", err)
 	}
 
-	if len(records) == 0 {
-		return &models.BulkImportResponse{
-			Success: false,
-			Message: "No data found in CSV",
-		}, nil
+	err = s.productRepo.DeleteSellerProduct(id)
+	if err != nil {
+		return err
 	}
 
-	// Expected CSV format: name,description,category_id,brand,sku,barcode,base_price,currency,tax_rate,base_stock,min_stock,tags,is_express_delivery,preparation_time
-	header := records[0]
-	expectedHeaders := []string{"name", "description", "category_id", "brand", "sku", "barcode", "base_price", "currency", "tax_rate", "base_stock", "min_stock", "tags", "is_express_delivery", "preparation_time"}
-	
-	// Validate headers
-	if len(header) < len(expectedHeaders) {
-		return &models.BulkImportResponse{
-			Success: false,
-			Message: "Invalid CSV format. Missing required columns.",
-		}, nil
+	if previous != nil {
+		s.recordRevision(models.RevisionEntitySellerProduct, id, previous.ProductID, models.RevisionActionDelete, actor, previous, nil, nil)
+		s.invalidateProduct(previous.ProductID)
 	}
 
-	response := &models.BulkImportResponse{
-		ProcessedCount: len(records) - 1, // Exclude header
-		SuccessCount:   0,
-		ErrorCount:     0,
-		Errors:         []models.BulkImportError{},
-	}
+	return nil
+}
 
-	// Process each row
-	for i, record := range records[1:] { // Skip header
-		rowNum := i + 2 // +2 because we skip header and arrays are 0-indexed
+// GetSellerProducts returns a page of a seller's listings of a product or,
+// without one, of all the seller's listings, most recently updated first
+func (s *catalogService) GetSellerProducts(sellerID uuid.UUID, productID *uuid.UUID, page int, limit int) (*models.SellerProductListResponse, error) {
+	if page < 1 || limit < 1 {
+		return nil, fmt.Errorf("page and limit must be positive")
+	}
 
-		err := s.processBulkImportRow(sellerID, record, rowNum)
-		if err != nil {
-			response.ErrorCount++
-			response.Errors = append(response.Errors, models.BulkImportError{
-				Row:     rowNum,
-				Message: err.Error(),
-			})
-		} else {
-			response.SuccessCount++
-		}
+	if productID == nil {
+		return s.ListSellerProducts(&models.SellerProductListRequest{
+			SellerID:  sellerID,
+			Page:      page,
+			Limit:     limit,
+			SortOrder: "desc",
+		})
 	}
 
-	response.Success = response.ErrorCount == 0
-	if response.Success {
-		response.Message = fmt.Sprintf("Successfully imported %d products", response.SuccessCount)
-	} else {
-		response.Message = fmt.Sprintf("Imported %d products with %d errors", response.SuccessCount, response.ErrorCount)
+	sellerProducts, err := s.productRepo.GetSellerData(*productID, &sellerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller products: %w", err)
 	}
 
-	return response, nil
-}
+	// A seller has a listing per variant of a product at most, so they are paged in memory
+	total := len(sellerProducts)
+	start := min((page-1)*limit, total)
+	end := min(start+limit, total)
 
-func (s *catalogService) processBulkImportRow(sellerID uuid.UUID, record []string, rowNum int) error {
-	if len(record) < 14 {
-		return fmt.Errorf("insufficient columns")
+	totalPages := total / limit
+	if total%limit > 0 {
+		totalPages++
 	}
 
-	// Parse category ID
-	categoryID, err := uuid.Parse(record[2])
-	if err != nil {
-		return fmt.Errorf("invalid category_id: %v", err)
-	}
+	return &models.SellerProductListResponse{
+		Items:      sellerProducts[start:end],
+		Total:      int64(total),
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+	}, nil
+}
 
-	// Parse base price
-	basePrice, err := decimal.NewFromString(record[6])
+func (s *catalogService) ListSellerProducts(req *models.SellerProductListRequest) (*models.SellerProductListResponse, error) {
+	sellerProducts, total, err := s.productRepo.GetBySeller(req)
 	if err != nil {
-		return fmt.Errorf("invalid base_price: %v", err)
+		return nil, fmt.Errorf("failed to list seller products: %w", err)
 	}
 
-	// Parse tax rate
-	taxRate, err := decimal.NewFromString(record[8])
-	if err != nil {
-		return fmt.Errorf("invalid tax_rate: %v", err)
+	totalPages := int(total) / req.Limit
+	if int(total)%req.Limit > 0 {
+		totalPages++
 	}
 
-	// Parse base stock
-	baseStock, err := strconv.Atoi(record[9])
-	if err != nil {
-		return fmt.Errorf("invalid base_stock: %v", err)
-	}
+	return &models.SellerProductListResponse{
+		Items:      sellerProducts,
+		Total:      total,
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
 
-	// Parse min stock
-	minStock, err := strconv.Atoi(record[10])
-	if err != nil {
-		return fmt.Errorf("invalid min_stock: %v", err)
+// ExportSellerProducts writes every listing matching the filters as CSV,
+// ignoring pagination. Rows are written as they are read, so an error can
+// leave w with part of the export.
+func (s *catalogService) ExportSellerProducts(req *models.SellerProductListRequest, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"seller_product_id", "product_id", "variant_id", "product_name", "variant_name", "category",
+		"seller_sku", "price", "stock", "min_stock", "max_stock", "stock_state", "is_active", "is_visible",
+		"preparation_time", "updated_at"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
 	}
 
-	// Parse tags
-	var tags []string
-	if record[11] != "" {
-		tags = strings.Split(record[11], ",")
-		for i, tag := range tags {
-			tags[i] = strings.TrimSpace(tag)
+	err := s.productRepo.EachBySeller(req, func(sp *models.SellerProduct) error {
+		// Listings fall back to their variant's price and stock, then the product's
+		product := sp.Product
+		price := product.BasePrice
+		if sp.Price != nil {
+			price = *sp.Price
+		} else if sp.VariantPrice != nil {
+			price = *sp.VariantPrice
+		}
+		stock := product.BaseStock
+		if sp.Stock != nil {
+			stock = *sp.Stock
+		} else if sp.VariantStock != nil {
+			stock = *sp.VariantStock
+		}
+		minStock := product.MinStock
+		if sp.MinStock != nil {
+			minStock = *sp.MinStock
+		}
+		preparationTime := product.PreparationTime
+		if sp.PreparationTime != nil {
+			preparationTime = *sp.PreparationTime
 		}
-	}
 
-	// Parse is_express_delivery
-	isExpressDelivery, err := strconv.ParseBool(record[12])
-	if err != nil {
-		return fmt.Errorf("invalid is_express_delivery: %v", err)
-	}
+		var categoryName string
+		if product.Category != nil {
+			categoryName = product.Category.Name
+		}
 
-	// Parse preparation_time
-	preparationTime, err := strconv.Atoi(record[13])
+		record := []string{
+			sp.ID.String(),
+			sp.ProductID.String(),
+			uuidString(sp.VariantID),
+			product.Name,
+			stringValue(sp.VariantName),
+			categoryName,
+			stringValue(sp.SellerSKU),
+			price.String(),
+			strconv.Itoa(stock),
+			strconv.Itoa(minStock),
+			intString(sp.MaxStock),
+			string(sp.StockState),
+			strconv.FormatBool(sp.IsActive),
+			strconv.FormatBool(sp.IsVisible),
+			strconv.Itoa(preparationTime),
+			sp.UpdatedAt.Format(time.RFC3339),
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("invalid preparation_time: %v", err)
+		return fmt.Errorf("failed to export seller products: %w", err)
 	}
 
-	// Create product
-	product := &models.Product{
-		ID:                uuid.New(),
-		Name:              record[0],
-		Description:       &record[1],
-		CategoryID:        categoryID,
-		Brand:             &record[3],
-		SKU:               &record[4],
-		Barcode:           &record[5],
-		BasePrice:         basePrice,
-		Currency:          record[7],
-		TaxRate:           taxRate,
-		BaseStock:         baseStock,
-		MinStock:          minStock,
-		Tags:              tags,
-		IsActive:          true,
-		IsExpressDelivery: isExpressDelivery,
-		PreparationTime:   preparationTime,
-	}
+	writer.Flush()
+	return writer.Error()
+}
 
-	err = s.productRepo.Create(product)
+func (s *catalogService) GetLowStockReport(sellerID uuid.UUID) ([]*models.LowStockItem, error) {
+	items, err := s.productRepo.GetLowStockBySeller(sellerID)
 	if err != nil {
-		return fmt.Errorf("failed to create product: %v", err)
+		return nil, fmt.Errorf("failed to get low stock report: %w", err)
 	}
 
-	// Index in Elasticsearch
-	go func() {
-		if err := s.IndexProduct(product); err != nil {
-			log.Printf("Failed to index product %s: %v", product.ID, err)
-		}
-	}()
-
-	// Publish to Kafka
-	go s.publishProductEvent(product, "created")
-
-	return nil
+	return items, nil
 }
 
 // Search operations
 func (s *catalogService) IndexProduct(product *models.Product) error {
+	// Only approved products are searchable
+	if product.Status != models.ProductStatusApproved {
+		return s.RemoveFromIndex(product.ID)
+	}
+
 	// Get category name
 	var categoryName string
 	if product.Category != nil {
@@ -697,28 +1165,77 @@ func (s *catalogService) IndexProduct(product *models.Product) error {
 		}
 	}
 
+	// Text per locale, starting with the default locale stored on the product
+	names := map[string]string{s.config.DefaultLocale: product.Name}
+	descriptions := map[string]string{s.config.DefaultLocale: stringValue(product.Description)}
+	categoryNames := map[string]string{s.config.DefaultLocale: categoryName}
+
+	translations, err := s.translationRepo.GetProductTranslations([]uuid.UUID{product.ID})
+	if err != nil {
+		return fmt.Errorf("failed to get product translations: %w", err)
+	}
+	for _, t := range translations {
+		names[t.Locale] = t.Name
+		descriptions[t.Locale] = stringValue(t.Description)
+	}
+
+	categoryTranslations, err := s.translationRepo.GetCategoryTranslations([]uuid.UUID{product.CategoryID})
+	if err != nil {
+		return fmt.Errorf("failed to get category translations: %w", err)
+	}
+	for _, t := range categoryTranslations {
+		categoryNames[t.Locale] = t.Name
+	}
+
+	// Bundles are in stock as far as their components are
+	stock := product.BaseStock
+	if product.ProductType == models.ProductTypeBundle {
+		bundle, err := s.bundleRepo.GetBundle(product.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get bundle: %w", err)
+		}
+		stock = bundleStock(bundle)
+	}
+
 	// Create document
 	doc := models.ProductDocument{
 		ID:                product.ID,
 		Name:              product.Name,
-		Description:       *product.Description,
+		Description:       stringValue(product.Description),
 		CategoryID:        product.CategoryID,
 		CategoryName:      categoryName,
-		Brand:             *product.Brand,
-		SKU:               *product.SKU,
-		Barcode:           *product.Barcode,
+		Brand:             stringValue(product.Brand),
+		SKU:               stringValue(product.SKU),
+		Barcode:           stringValue(product.Barcode),
 		BasePrice:         product.BasePrice,
+		CompareAtPrice:    product.CompareAtPrice,
+		ReferencePrice:    product.ReferencePrice,
 		Currency:          product.Currency,
 		TaxRate:           product.TaxRate,
-		BaseStock:         product.BaseStock,
+		BaseStock:         stock,
 		Tags:              product.Tags,
 		Attributes:        product.Attributes,
 		IsActive:          product.IsActive,
 		IsExpressDelivery: product.IsExpressDelivery,
 		PreparationTime:   product.PreparationTime,
+		IsAvailable:       product.IsAvailable,
+		ProductType:       product.ProductType,
+		RatingAverage:     product.RatingAverage.InexactFloat64(),
+		RatingCount:       product.RatingCount,
+		PublishAt:         product.PublishAt,
+		UnpublishAt:       product.UnpublishAt,
+		Names:             names,
+		Descriptions:      descriptions,
+		CategoryNames:     categoryNames,
 		CreatedAt:         product.CreatedAt,
 		UpdatedAt:         product.UpdatedAt,
 	}
+	if product.FoodInfo != nil {
+		doc.Allergens = product.FoodInfo.Allergens
+		doc.MayContain = product.FoodInfo.MayContain
+		doc.DietaryLabels = product.FoodInfo.DietaryLabels
+		doc.Ingredients = product.FoodInfo.Ingredients
+	}
 
 	// Convert to JSON
 	docJSON, err := json.Marshal(doc)
@@ -763,7 +1280,40 @@ func (s *catalogService) RemoveFromIndex(productID uuid.UUID) error {
 	return nil
 }
 
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func intString(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+func decimalString(value *decimal.Decimal) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
+func uuidString(value *uuid.UUID) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
 func (s *catalogService) publishProductEvent(product *models.Product, action string) {
+	// Consumers only see products once they are approved
+	if product.Status != models.ProductStatusApproved && action != "deleted" && action != "unpublished" {
+		return
+	}
+
 	event := map[string]interface{}{
 		"action":     action,
 		"product_id": product.ID,
@@ -782,6 +1332,7 @@ func (s *catalogService) publishProductEvent(product *models.Product, action str
 
 	err = s.kafkaWriter.WriteMessages(context.Background(),
 		kafka.Message{
+			Topic: s.config.KafkaTopic,
 			Key:   []byte(product.ID.String()),
 			Value: eventJSON,
 		},
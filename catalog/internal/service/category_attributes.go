@@ -0,0 +1,491 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var attributeCodePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+var (
+	attributeTrueValues  = []string{"true", "yes", "evet", "1"}
+	attributeFalseValues = []string{"false", "no", "hayır", "hayir", "0"}
+)
+
+// AttributeValidationError reports an attribute that does not match the
+// category attribute schema
+type AttributeValidationError struct {
+	Attribute string
+	Message   string
+}
+
+func (e *AttributeValidationError) Error() string {
+	return fmt.Sprintf("attribute %s %s", e.Attribute, e.Message)
+}
+
+// Attribute schema operations
+func (s *catalogService) GetCategoryAttributes(categoryID uuid.UUID) ([]*models.CategoryAttribute, error) {
+	category, err := s.categoryRepo.GetByID(categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	if category == nil {
+		return nil, fmt.Errorf("category not found")
+	}
+
+	attributes, err := s.categoryRepo.GetEffectiveAttributes(categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category attributes: %w", err)
+	}
+
+	return attributes, nil
+}
+
+func (s *catalogService) CreateCategoryAttribute(categoryID uuid.UUID, req *models.CategoryAttributeRequest) (*models.CategoryAttribute, error) {
+	category, err := s.categoryRepo.GetByID(categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	if category == nil {
+		return nil, fmt.Errorf("category not found")
+	}
+
+	attribute := &models.CategoryAttribute{
+		ID:         uuid.New(),
+		CategoryID: categoryID,
+	}
+	if err := applyAttributeRequest(attribute, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkAttributeCode(attribute); err != nil {
+		return nil, err
+	}
+
+	err = s.categoryRepo.CreateAttribute(attribute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create category attribute: %w", err)
+	}
+
+	return attribute, nil
+}
+
+func (s *catalogService) UpdateCategoryAttribute(categoryID uuid.UUID, attributeID uuid.UUID, req *models.CategoryAttributeRequest) (*models.CategoryAttribute, error) {
+	attribute, err := s.categoryRepo.GetAttribute(attributeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category attribute: %w", err)
+	}
+	if attribute == nil || attribute.CategoryID != categoryID {
+		return nil, fmt.Errorf("attribute not found")
+	}
+
+	if err := applyAttributeRequest(attribute, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkAttributeCode(attribute); err != nil {
+		return nil, err
+	}
+
+	err = s.categoryRepo.UpdateAttribute(attribute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update category attribute: %w", err)
+	}
+
+	return attribute, nil
+}
+
+func (s *catalogService) DeleteCategoryAttribute(categoryID uuid.UUID, attributeID uuid.UUID) error {
+	attribute, err := s.categoryRepo.GetAttribute(attributeID)
+	if err != nil {
+		return fmt.Errorf("failed to get category attribute: %w", err)
+	}
+	if attribute == nil || attribute.CategoryID != categoryID {
+		return fmt.Errorf("attribute not found")
+	}
+
+	return s.categoryRepo.DeleteAttribute(attributeID)
+}
+
+// checkAttributeCode rejects a code already used by another attribute of the
+// same category. Redefining an inherited code is allowed.
+func (s *catalogService) checkAttributeCode(attribute *models.CategoryAttribute) error {
+	existing, err := s.categoryRepo.GetEffectiveAttributes(attribute.CategoryID)
+	if err != nil {
+		return fmt.Errorf("failed to get category attributes: %w", err)
+	}
+
+	for _, other := range existing {
+		if other.ID != attribute.ID && !other.Inherited && other.Code == attribute.Code {
+			return &AttributeValidationError{Attribute: attribute.Code, Message: "is already defined for this category"}
+		}
+	}
+
+	return nil
+}
+
+// applyAttributeRequest checks an attribute definition and copies it onto attribute
+func applyAttributeRequest(attribute *models.CategoryAttribute, req *models.CategoryAttributeRequest) error {
+	code := strings.ToLower(strings.Join(strings.Fields(req.Code), "_"))
+	if !attributeCodePattern.MatchString(code) {
+		return &AttributeValidationError{Attribute: req.Code, Message: "code may only contain letters, digits and underscores"}
+	}
+
+	var options []string
+	seen := make(map[string]bool)
+	for _, option := range req.Options {
+		option = strings.TrimSpace(option)
+		key := strings.ToLower(option)
+		if option == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		options = append(options, option)
+	}
+
+	switch req.Type {
+	case models.AttributeTypeEnum:
+		if len(options) == 0 {
+			return &AttributeValidationError{Attribute: code, Message: "requires at least one option"}
+		}
+		if req.MinValue != nil || req.MaxValue != nil {
+			return &AttributeValidationError{Attribute: code, Message: "cannot have a value range"}
+		}
+	case models.AttributeTypeNumber:
+		if len(options) > 0 {
+			return &AttributeValidationError{Attribute: code, Message: "cannot have options"}
+		}
+		if req.MinValue != nil && req.MaxValue != nil && req.MinValue.GreaterThan(*req.MaxValue) {
+			return &AttributeValidationError{Attribute: code, Message: "min_value is greater than max_value"}
+		}
+	case models.AttributeTypeBoolean:
+		if len(options) > 0 || req.MinValue != nil || req.MaxValue != nil {
+			return &AttributeValidationError{Attribute: code, Message: "cannot have options or a value range"}
+		}
+	}
+
+	attribute.Code = code
+	attribute.Name = strings.TrimSpace(req.Name)
+	attribute.Type = req.Type
+	attribute.Unit = req.Unit
+	attribute.Options = options
+	attribute.MinValue = req.MinValue
+	attribute.MaxValue = req.MaxValue
+	attribute.IsRequired = req.IsRequired
+	attribute.IsVariant = req.IsVariant
+	attribute.IsFilterable = req.IsFilterable
+	attribute.SortOrder = req.SortOrder
+	if attribute.Type != models.AttributeTypeNumber {
+		attribute.Unit = nil
+	}
+
+	return nil
+}
+
+// normalizeProductRequest validates the attributes of a new product and its
+// variants against the category schema, rewriting them to canonical codes and values
+func (s *catalogService) normalizeProductRequest(req *models.CreateProductRequest) error {
+	schema, err := s.categoryRepo.GetEffectiveAttributes(req.CategoryID)
+	if err != nil {
+		return fmt.Errorf("failed to get category attributes: %w", err)
+	}
+
+	attributes, err := normalizeProductAttributes(schema, req.Attributes, len(req.Variants) > 0)
+	if err != nil {
+		return err
+	}
+	req.Attributes = attributes
+
+	for _, variant := range req.Variants {
+		variantAttributes, err := normalizeVariantAttributes(schema, attributes, variant.Attributes)
+		if err != nil {
+			return fmt.Errorf("variant %s: %w", variant.Name, err)
+		}
+		variant.Attributes = variantAttributes
+	}
+
+	return nil
+}
+
+// normalizeProductUpdate validates the attributes of an updated product when
+// its attributes or category change
+func (s *catalogService) normalizeProductUpdate(current *models.Product, req *models.UpdateProductRequest) error {
+	if req.Attributes == nil && req.CategoryID == nil {
+		return nil
+	}
+
+	categoryID := current.CategoryID
+	if req.CategoryID != nil {
+		categoryID = *req.CategoryID
+	}
+	attributes := current.Attributes
+	if req.Attributes != nil {
+		attributes = req.Attributes
+	}
+
+	schema, err := s.categoryRepo.GetEffectiveAttributes(categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to get category attributes: %w", err)
+	}
+	if len(schema) == 0 {
+		return nil
+	}
+
+	variants, err := s.productRepo.GetVariants(current.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get variants: %w", err)
+	}
+
+	attributes, err = normalizeProductAttributes(schema, attributes, len(variants) > 0)
+	if err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		if _, err := normalizeVariantAttributes(schema, attributes, variant.Attributes); err != nil {
+			return fmt.Errorf("variant %s: %w", variant.Name, err)
+		}
+	}
+
+	req.Attributes = attributes
+	return nil
+}
+
+// normalizeVariant validates the attributes of a variant of an existing product
+func (s *catalogService) normalizeVariant(productID uuid.UUID, attributes models.Attributes) (models.Attributes, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	schema, err := s.categoryRepo.GetEffectiveAttributes(product.CategoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category attributes: %w", err)
+	}
+
+	return normalizeVariantAttributes(schema, product.Attributes, attributes)
+}
+
+// normalizeProductAttributes checks product attribute values. Required
+// variant attributes may instead be set on every variant when there are any.
+func normalizeProductAttributes(schema []*models.CategoryAttribute, attributes models.Attributes, hasVariants bool) (models.Attributes, error) {
+	if len(schema) == 0 {
+		return attributes, nil
+	}
+
+	normalized, err := normalizeAttributes(schema, attributes, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attribute := range schema {
+		if !attribute.IsRequired || (attribute.IsVariant && hasVariants) {
+			continue
+		}
+		if _, ok := normalized[attribute.Code]; !ok {
+			return nil, &AttributeValidationError{Attribute: attribute.Code, Message: "is required"}
+		}
+	}
+
+	return normalized, nil
+}
+
+// normalizeVariantAttributes checks variant attribute values. Values set on
+// the product count towards the variant's required attributes.
+func normalizeVariantAttributes(schema []*models.CategoryAttribute, productAttributes models.Attributes, attributes models.Attributes) (models.Attributes, error) {
+	if len(schema) == 0 {
+		return attributes, nil
+	}
+
+	normalized, err := normalizeAttributes(schema, attributes, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attribute := range schema {
+		if !attribute.IsRequired || !attribute.IsVariant {
+			continue
+		}
+		_, onVariant := normalized[attribute.Code]
+		_, onProduct := productAttributes[attribute.Code]
+		if !onVariant && !onProduct {
+			return nil, &AttributeValidationError{Attribute: attribute.Code, Message: "is required"}
+		}
+	}
+
+	return normalized, nil
+}
+
+// normalizeAttributes maps attribute keys, matched case-insensitively against
+// codes and names, to their codes and converts the values to the attribute type
+func normalizeAttributes(schema []*models.CategoryAttribute, attributes models.Attributes, variant bool) (models.Attributes, error) {
+	normalized := make(models.Attributes, len(attributes))
+
+	for key, value := range attributes {
+		if value == nil {
+			continue
+		}
+
+		attribute := findAttribute(schema, key)
+		if attribute == nil {
+			return nil, &AttributeValidationError{Attribute: key, Message: "is not defined for this category"}
+		}
+		if variant && !attribute.IsVariant {
+			return nil, &AttributeValidationError{Attribute: attribute.Code, Message: "cannot be set on a variant"}
+		}
+		if _, ok := normalized[attribute.Code]; ok {
+			return nil, &AttributeValidationError{Attribute: attribute.Code, Message: "is given more than once"}
+		}
+
+		converted, err := normalizeAttributeValue(attribute, value)
+		if err != nil {
+			return nil, err
+		}
+		normalized[attribute.Code] = converted
+	}
+
+	return normalized, nil
+}
+
+func normalizeAttributeValue(attribute *models.CategoryAttribute, value interface{}) (interface{}, error) {
+	text := strings.TrimSpace(fmt.Sprint(value))
+
+	switch attribute.Type {
+	case models.AttributeTypeEnum:
+		for _, option := range attribute.Options {
+			if equalFold(option, text) {
+				return option, nil
+			}
+		}
+		return nil, &AttributeValidationError{
+			Attribute: attribute.Code,
+			Message:   fmt.Sprintf("must be one of %s", strings.Join(attribute.Options, ", ")),
+		}
+
+	case models.AttributeTypeNumber:
+		var number decimal.Decimal
+		var err error
+		switch v := value.(type) {
+		case float64:
+			number = decimal.NewFromFloat(v)
+		case int:
+			number = decimal.NewFromInt(int64(v))
+		default:
+			// Accept "1,5" and a trailing unit such as "500 g"
+			if attribute.Unit != nil && len(text) > len(*attribute.Unit) &&
+				strings.EqualFold(text[len(text)-len(*attribute.Unit):], *attribute.Unit) {
+				text = strings.TrimSpace(text[:len(text)-len(*attribute.Unit)])
+			}
+			number, err = decimal.NewFromString(strings.Replace(text, ",", ".", 1))
+		}
+		if err != nil {
+			return nil, &AttributeValidationError{Attribute: attribute.Code, Message: "must be a number"}
+		}
+		if attribute.MinValue != nil && number.LessThan(*attribute.MinValue) {
+			return nil, &AttributeValidationError{Attribute: attribute.Code, Message: fmt.Sprintf("must be at least %s", attribute.MinValue)}
+		}
+		if attribute.MaxValue != nil && number.GreaterThan(*attribute.MaxValue) {
+			return nil, &AttributeValidationError{Attribute: attribute.Code, Message: fmt.Sprintf("must be at most %s", attribute.MaxValue)}
+		}
+		return number.InexactFloat64(), nil
+
+	case models.AttributeTypeBoolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		lower := strings.ToLower(text)
+		for _, v := range attributeTrueValues {
+			if lower == v {
+				return true, nil
+			}
+		}
+		for _, v := range attributeFalseValues {
+			if lower == v {
+				return false, nil
+			}
+		}
+		return nil, &AttributeValidationError{Attribute: attribute.Code, Message: "must be true or false"}
+	}
+
+	return nil, &AttributeValidationError{Attribute: attribute.Code, Message: fmt.Sprintf("has unknown type %s", attribute.Type)}
+}
+
+func findAttribute(schema []*models.CategoryAttribute, key string) *models.CategoryAttribute {
+	key = strings.TrimSpace(key)
+	for _, attribute := range schema {
+		if equalFold(attribute.Code, key) || equalFold(attribute.Name, key) {
+			return attribute
+		}
+	}
+	return nil
+}
+
+// equalFold compares case-insensitively under both the default and the
+// Turkish case mappings, so that "KIRMIZI" matches "Kırmızı"
+func equalFold(a, b string) bool {
+	return strings.EqualFold(a, b) ||
+		strings.ToLowerSpecial(unicode.TurkishCase, a) == strings.ToLowerSpecial(unicode.TurkishCase, b)
+}
+
+// resolveAttributeFilters maps search filters onto the category schema so
+// that they compare against the stored canonical values
+func resolveAttributeFilters(schema []*models.CategoryAttribute, filters []*models.AttributeFilter) error {
+	for _, filter := range filters {
+		attribute := findAttribute(schema, filter.Code)
+		if attribute == nil {
+			return &AttributeValidationError{Attribute: filter.Code, Message: "is not defined for this category"}
+		}
+		filter.Code = attribute.Code
+
+		for i, value := range filter.Values {
+			converted, err := normalizeAttributeValue(attribute, value)
+			if err != nil {
+				return err
+			}
+			switch v := converted.(type) {
+			case float64:
+				filter.Values[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				filter.Values[i] = fmt.Sprint(v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// attributeFacets returns the facets of the filterable attributes of the searched category
+func (s *catalogService) attributeFacets(req *models.SearchRequest) ([]*models.AttributeFacet, error) {
+	schema, err := s.categoryRepo.GetEffectiveAttributes(*req.CategoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category attributes: %w", err)
+	}
+
+	if err := resolveAttributeFilters(schema, req.AttributeFilters); err != nil {
+		return nil, err
+	}
+
+	var filterable []*models.CategoryAttribute
+	for _, attribute := range schema {
+		if attribute.IsFilterable {
+			filterable = append(filterable, attribute)
+		}
+	}
+	if len(filterable) == 0 {
+		return nil, nil
+	}
+
+	facets, err := s.productRepo.GetAttributeFacets(req, filterable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attribute facets: %w", err)
+	}
+
+	return facets, nil
+}
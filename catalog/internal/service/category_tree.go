@@ -0,0 +1,131 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+// Category tree operations
+func (s *catalogService) GetCategoryTree(rootID *uuid.UUID) ([]*models.Category, error) {
+	field := "all"
+	if rootID != nil {
+		field = rootID.String()
+	}
+
+	var categories []*models.Category
+	if s.cacheGet(categoryTreeCacheKey, field, s.config.CacheCategoryTreeTTL, &categories) {
+		return categories, nil
+	}
+
+	if rootID != nil {
+		root, err := s.categoryRepo.GetByID(*rootID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get category: %w", err)
+		}
+		if root == nil {
+			return nil, fmt.Errorf("category not found")
+		}
+	}
+
+	categories, err := s.categoryRepo.GetTree(rootID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category tree: %w", err)
+	}
+
+	s.cacheSet(categoryTreeCacheKey, field, s.config.CacheCategoryTreeTTL, categories)
+
+	return categories, nil
+}
+
+// GetCategoryPath returns the breadcrumb of a category, starting at its root
+func (s *catalogService) GetCategoryPath(id uuid.UUID) ([]*models.Category, error) {
+	path, err := s.categoryRepo.GetPath(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category path: %w", err)
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("category not found")
+	}
+
+	return path, nil
+}
+
+func (s *catalogService) MoveCategory(id uuid.UUID, req *models.MoveCategoryRequest) (*models.Category, error) {
+	if req.ParentID != nil {
+		if err := s.checkCategoryParent(id, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	err := s.categoryRepo.Move(id, req.ParentID, req.SortOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateCategories()
+
+	return s.GetCategory(id)
+}
+
+func (s *catalogService) ReorderCategories(req *models.ReorderCategoriesRequest) ([]*models.Category, error) {
+	seen := make(map[uuid.UUID]bool, len(req.CategoryIDs))
+	for _, id := range req.CategoryIDs {
+		if seen[id] {
+			return nil, fmt.Errorf("category %s is listed more than once", id)
+		}
+		seen[id] = true
+	}
+
+	err := s.categoryRepo.Reorder(req.ParentID, req.CategoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateCategories()
+
+	return s.GetCategories(req.ParentID, false)
+}
+
+// checkCategoryParent rejects parents that would put a category inside its
+// own subtree. The path trigger enforces the same rule in the database.
+func (s *catalogService) checkCategoryParent(id uuid.UUID, parentID uuid.UUID) error {
+	if parentID == id {
+		return fmt.Errorf("category cannot be its own parent")
+	}
+
+	parent, err := s.categoryRepo.GetByID(parentID)
+	if err != nil {
+		return fmt.Errorf("failed to get parent category: %w", err)
+	}
+	if parent == nil {
+		return fmt.Errorf("parent category not found")
+	}
+
+	if strings.Contains("/"+parent.Path+"/", "/"+id.String()+"/") {
+		return fmt.Errorf("category cannot be moved under its own subtree")
+	}
+
+	return nil
+}
+
+// fillProductCounts sets the rolled up product counts of the given categories
+func (s *catalogService) fillProductCounts(categories []*models.Category) {
+	ids := make([]uuid.UUID, len(categories))
+	for i, category := range categories {
+		ids[i] = category.ID
+	}
+
+	counts, err := s.categoryRepo.GetProductCounts(ids)
+	if err != nil {
+		log.Printf("Failed to get category product counts: %v", err)
+		return
+	}
+
+	for _, category := range categories {
+		category.ProductCount = counts[category.ID]
+	}
+}
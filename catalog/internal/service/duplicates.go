@@ -0,0 +1,665 @@
+package service
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math/bits"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// duplicateMinScore is the score from which a product is reported as a likely duplicate
+	duplicateMinScore = 0.5
+	// duplicateImageMaxDistance is the number of differing hash bits up to
+	// which two images count as the same picture. It has to stay below 8 for
+	// the banded image lookup to find every match.
+	duplicateImageMaxDistance = 6
+	// duplicateNameCandidates is how many similarly named products are scored
+	duplicateNameCandidates = 50
+	// duplicateMaxResults is how many candidates the matcher returns
+	duplicateMaxResults = 20
+	// duplicateModerationFlags is how many candidates a moderation flag names
+	duplicateModerationFlags = 3
+)
+
+// Weights of the signals in the duplicate score. Matching barcodes settle it.
+const (
+	duplicateNameWeight  = 0.55
+	duplicateBrandWeight = 0.15
+	duplicateImageWeight = 0.3
+)
+
+// MergeError reports a merge the products involved do not allow
+type MergeError struct {
+	Message string
+}
+
+func (e *MergeError) Error() string {
+	return e.Message
+}
+
+// Duplicate detection operations
+func (s *catalogService) FindDuplicateProducts(id uuid.UUID) ([]*models.DuplicateCandidate, error) {
+	product, err := s.productRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	return s.findDuplicates(product)
+}
+
+// findDuplicates gathers products sharing a barcode, a similar name or a
+// similar image with the product and returns those that score high enough,
+// best first
+func (s *catalogService) findDuplicates(product *models.Product) ([]*models.DuplicateCandidate, error) {
+	variants, err := s.productRepo.GetVariants(product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variants: %w", err)
+	}
+
+	var keys []string
+	for _, barcode := range productBarcodes(product, variants) {
+		if key := gtinKey(barcode); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	barcodeMatches, err := s.duplicateRepo.FindByGTIN(keys, product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find products by barcode: %w", err)
+	}
+
+	nameMatches, err := s.duplicateRepo.FindBySimilarName(product.Name, product.ID, duplicateNameCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find products by name: %w", err)
+	}
+
+	media, err := s.productRepo.GetAllMedia(product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product media: %w", err)
+	}
+	var hashes []int64
+	for _, m := range media {
+		if m.PerceptualHash != nil {
+			hashes = append(hashes, *m.PerceptualHash)
+		}
+	}
+	imageMatches, err := s.duplicateRepo.FindBySimilarImage(hashes, product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find products by image: %w", err)
+	}
+
+	sameBarcode := make(map[uuid.UUID]bool, len(barcodeMatches))
+	ids := append([]uuid.UUID{}, barcodeMatches...)
+	for _, id := range barcodeMatches {
+		sameBarcode[id] = true
+	}
+	ids = append(ids, nameMatches...)
+	imageDistance := make(map[uuid.UUID]int, len(imageMatches))
+	for id, candidateHashes := range imageMatches {
+		distance := closestImage(hashes, candidateHashes)
+		if distance <= duplicateImageMaxDistance {
+			imageDistance[id] = distance
+			ids = append(ids, id)
+		}
+	}
+
+	products, err := s.productRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	key := newDuplicateKey(product)
+	candidates := []*models.DuplicateCandidate{}
+	for _, candidate := range products {
+		distance, sameImage := imageDistance[candidate.ID]
+		if !sameImage {
+			distance = -1
+		}
+
+		score, reasons := scoreDuplicate(key, newDuplicateKey(candidate), sameBarcode[candidate.ID], distance)
+		if score >= duplicateMinScore {
+			candidates = append(candidates, &models.DuplicateCandidate{
+				Product: candidate,
+				Score:   score,
+				Reasons: reasons,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Product.ID.String() < candidates[j].Product.ID.String()
+	})
+	if len(candidates) > duplicateMaxResults {
+		candidates = candidates[:duplicateMaxResults]
+	}
+
+	return candidates, nil
+}
+
+// duplicateFlag names the most likely duplicates of a product for moderators
+func (s *catalogService) duplicateFlag(product *models.Product) (*models.ModerationFlag, error) {
+	candidates, err := s.findDuplicates(product)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	var matches []string
+	for i, candidate := range candidates {
+		if i == duplicateModerationFlags {
+			break
+		}
+		reasons := make([]string, len(candidate.Reasons))
+		for j, reason := range candidate.Reasons {
+			reasons[j] = strings.ToLower(string(reason))
+		}
+		matches = append(matches, fmt.Sprintf("%s (%s)", candidate.Product.ID, strings.Join(reasons, ", ")))
+	}
+
+	return &models.ModerationFlag{
+		Check:   models.ModerationCheckDuplicate,
+		Message: fmt.Sprintf("may duplicate %s", strings.Join(matches, "; ")),
+	}, nil
+}
+
+// MergeProducts folds duplicate products into the canonical one. Every
+// seller listing of a duplicate moves to the matching canonical product or
+// variant, and the seller that submitted a duplicate keeps its price and
+// stock as a listing. Reviews and bundle references follow; the duplicates
+// go to the trash for good, pointing at the canonical product.
+func (s *catalogService) MergeProducts(canonicalID uuid.UUID, req *models.MergeProductsRequest, actor string) (*models.ProductMergeResult, error) {
+	canonical, err := s.productRepo.GetByID(canonicalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if canonical == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+	if canonical.ProductType == models.ProductTypeBundle {
+		return nil, &MergeError{Message: "bundles cannot be merged"}
+	}
+
+	var duplicateIDs []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range req.DuplicateIDs {
+		if id == canonicalID {
+			return nil, &MergeError{Message: "a product cannot be merged into itself"}
+		}
+		if !seen[id] {
+			seen[id] = true
+			duplicateIDs = append(duplicateIDs, id)
+		}
+	}
+
+	duplicates, err := s.productRepo.GetByIDs(duplicateIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+	found := make(map[uuid.UUID]*models.Product, len(duplicates))
+	for _, duplicate := range duplicates {
+		found[duplicate.ID] = duplicate
+	}
+	for _, id := range duplicateIDs {
+		duplicate := found[id]
+		if duplicate == nil {
+			return nil, &MergeError{Message: fmt.Sprintf("duplicate product %s not found", id)}
+		}
+		if duplicate.ProductType == models.ProductTypeBundle {
+			return nil, &MergeError{Message: "bundles cannot be merged"}
+		}
+	}
+
+	canonicalVariants, err := s.productRepo.GetVariants(canonicalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variants: %w", err)
+	}
+	canonicalOffers, err := s.productRepo.GetSellerData(canonicalID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller products: %w", err)
+	}
+
+	// Sellers keep one listing per canonical product or variant, the one
+	// they already have on it first
+	listed := make(map[string]bool)
+	for _, sp := range canonicalOffers {
+		listed[offerKey(sp.SellerID, sp.VariantID)] = true
+	}
+
+	merge := &models.ProductMerge{
+		CanonicalID:  canonicalID,
+		DuplicateIDs: duplicateIDs,
+		VariantMap:   make(map[uuid.UUID]uuid.UUID),
+		Actor:        actor,
+	}
+	result := &models.ProductMergeResult{
+		MergedIDs:     duplicateIDs,
+		MovedOffers:   []*models.SellerProduct{},
+		CreatedOffers: []*models.SellerProduct{},
+		SkippedOffers: []uuid.UUID{},
+	}
+	movedFrom := make(map[uuid.UUID]models.SellerProduct)
+	now := time.Now()
+
+	for _, id := range duplicateIDs {
+		duplicate := found[id]
+
+		variants, err := s.productRepo.GetVariants(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get variants: %w", err)
+		}
+		for _, variant := range variants {
+			match := matchVariant(variant, canonicalVariants)
+			if match == nil {
+				return nil, &MergeError{Message: fmt.Sprintf("variant %q of product %s has no matching variant on product %s", variant.Name, id, canonicalID)}
+			}
+			merge.VariantMap[variant.ID] = match.ID
+		}
+
+		offers, err := s.productRepo.GetSellerData(id, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get seller products: %w", err)
+		}
+		for _, sp := range offers {
+			var variantID *uuid.UUID
+			if sp.VariantID != nil {
+				mapped, ok := merge.VariantMap[*sp.VariantID]
+				if !ok {
+					// Listings of inactive variants have nothing to move to
+					result.SkippedOffers = append(result.SkippedOffers, sp.ID)
+					continue
+				}
+				variantID = &mapped
+			}
+
+			key := offerKey(sp.SellerID, variantID)
+			if listed[key] {
+				result.SkippedOffers = append(result.SkippedOffers, sp.ID)
+				continue
+			}
+			listed[key] = true
+
+			movedFrom[sp.ID] = *sp
+			sp.ProductID = canonicalID
+			sp.VariantID = variantID
+			merge.MovedOffers = append(merge.MovedOffers, sp)
+		}
+
+		if duplicate.SubmittedBy == nil {
+			continue
+		}
+		sellerID := *duplicate.SubmittedBy
+
+		// The submitting seller sold the duplicate at its own price and stock
+		if len(variants) == 0 {
+			if !listed[offerKey(sellerID, nil)] {
+				listed[offerKey(sellerID, nil)] = true
+				merge.CreatedOffers = append(merge.CreatedOffers, s.mergedOffer(canonicalID, duplicate, nil, sellerID, now))
+			}
+			continue
+		}
+		for _, variant := range variants {
+			mapped := merge.VariantMap[variant.ID]
+			if !listed[offerKey(sellerID, &mapped)] {
+				listed[offerKey(sellerID, &mapped)] = true
+				offer := s.mergedOffer(canonicalID, duplicate, variant, sellerID, now)
+				offer.VariantID = &mapped
+				merge.CreatedOffers = append(merge.CreatedOffers, offer)
+			}
+		}
+	}
+
+	movedReviews, err := s.duplicateRepo.Merge(merge)
+	if err != nil {
+		if err.Error() == "product not found" || strings.HasPrefix(err.Error(), "seller ") {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to merge products: %w", err)
+	}
+
+	for _, sp := range merge.MovedOffers {
+		before := movedFrom[sp.ID]
+		s.recordRevision(models.RevisionEntitySellerProduct, sp.ID, canonicalID, models.RevisionActionUpdate, actor, &before, sp, nil)
+	}
+	for _, sp := range merge.CreatedOffers {
+		s.recordRevision(models.RevisionEntitySellerProduct, sp.ID, canonicalID, models.RevisionActionCreate, actor, nil, sp, nil)
+	}
+	for _, id := range duplicateIDs {
+		s.recordRevision(models.RevisionEntityProduct, id, id, models.RevisionActionDelete, actor, found[id], nil, nil)
+		s.invalidateProduct(id)
+
+		duplicateID := id
+		go func() {
+			if err := s.RemoveFromIndex(duplicateID); err != nil {
+				log.Printf("Failed to remove product %s from index: %v", duplicateID, err)
+			}
+		}()
+		go s.publishProductEvent(&models.Product{ID: duplicateID}, "deleted")
+	}
+
+	if movedReviews > 0 {
+		if err := s.reviewRepo.RefreshProductRating(canonicalID); err != nil {
+			log.Printf("Failed to refresh rating of product %s: %v", canonicalID, err)
+		}
+	}
+	s.refreshProduct(canonicalID)
+
+	result.Product, err = s.productRepo.GetByID(canonicalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	result.MovedOffers = append(result.MovedOffers, merge.MovedOffers...)
+	result.CreatedOffers = append(result.CreatedOffers, merge.CreatedOffers...)
+	result.MovedReviews = movedReviews
+
+	return result, nil
+}
+
+// mergedOffer turns the price and stock a seller set on its own duplicate
+// product, or one of its variants, into a listing of the canonical product
+func (s *catalogService) mergedOffer(canonicalID uuid.UUID, duplicate *models.Product, variant *models.ProductVariant, sellerID uuid.UUID, now time.Time) *models.SellerProduct {
+	price := duplicate.BasePrice
+	stock := duplicate.BaseStock
+	minStock := duplicate.MinStock
+	preparationTime := duplicate.PreparationTime
+	sku := duplicate.SKU
+	if variant != nil {
+		if variant.Price != nil {
+			price = *variant.Price
+		}
+		if variant.Stock != nil {
+			stock = *variant.Stock
+		}
+		sku = variant.SKU
+	}
+
+	offer := &models.SellerProduct{
+		ID:                   uuid.New(),
+		SellerID:             sellerID,
+		ProductID:            canonicalID,
+		SellerSKU:            sku,
+		Price:                &price,
+		Stock:                &stock,
+		MinStock:             &minStock,
+		MaxStock:             duplicate.MaxStock,
+		IsActive:             duplicate.IsActive,
+		IsVisible:            true,
+		PreparationTime:      &preparationTime,
+		PublishAt:            duplicate.PublishAt,
+		UnpublishAt:          duplicate.UnpublishAt,
+		AvailabilitySchedule: duplicate.AvailabilitySchedule,
+	}
+	offer.IsAvailable = s.availableAt(offer.PublishAt, offer.UnpublishAt, offer.AvailabilitySchedule, now)
+
+	return offer
+}
+
+func offerKey(sellerID uuid.UUID, variantID *uuid.UUID) string {
+	if variantID == nil {
+		return sellerID.String()
+	}
+	return sellerID.String() + "/" + variantID.String()
+}
+
+// matchVariant finds the variant among candidates that sells the same item:
+// same GTIN, same attributes or same name
+func matchVariant(variant *models.ProductVariant, candidates []*models.ProductVariant) *models.ProductVariant {
+	if key := gtinKey(stringValue(variant.Barcode)); key != "" {
+		for _, candidate := range candidates {
+			if gtinKey(stringValue(candidate.Barcode)) == key {
+				return candidate
+			}
+		}
+	}
+
+	if len(variant.Attributes) > 0 {
+		for _, candidate := range candidates {
+			if reflect.DeepEqual(variant.Attributes, candidate.Attributes) {
+				return candidate
+			}
+		}
+	}
+
+	name := strings.Join(duplicateWords(variant.Name), " ")
+	for _, candidate := range candidates {
+		if strings.Join(duplicateWords(candidate.Name), " ") == name {
+			return candidate
+		}
+	}
+
+	return nil
+}
+
+func productBarcodes(product *models.Product, variants []*models.ProductVariant) []string {
+	var barcodes []string
+	if product.Barcode != nil {
+		barcodes = append(barcodes, *product.Barcode)
+	}
+	for _, variant := range variants {
+		if variant.Barcode != nil {
+			barcodes = append(barcodes, *variant.Barcode)
+		}
+	}
+	return barcodes
+}
+
+// gtinKey makes barcodes comparable across GTIN-8/12/13/14 by left-padding
+// them to 14 digits, like the gtin_key SQL function. Barcodes that are not
+// GTINs have no key.
+func gtinKey(barcode string) string {
+	digits := cleanBarcode(barcode)
+
+	if len(digits) < 8 || len(digits) > 14 {
+		return ""
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return ""
+		}
+	}
+
+	return strings.Repeat("0", 14-len(digits)) + digits
+}
+
+// duplicateKey is the normalized form of a product the matcher compares
+type duplicateKey struct {
+	words map[string]bool
+	sizes string // normalized quantities and pack sizes
+	brand string
+}
+
+func newDuplicateKey(product *models.Product) *duplicateKey {
+	text := product.Name
+	brand := strings.Join(duplicateWords(stringValue(product.Brand)), " ")
+	if brand != "" {
+		// Sellers put the brand in the name or only in the brand field
+		text += " " + brand
+	}
+
+	sizes, rest := duplicateSizes(duplicateFold(text))
+	words := make(map[string]bool)
+	for _, word := range duplicateWords(rest) {
+		words[word] = true
+	}
+
+	return &duplicateKey{words: words, sizes: strings.Join(sizes, " "), brand: brand}
+}
+
+// scoreDuplicate rates how likely two products sell the same item, from 0 to
+// 1. Matching barcodes settle it; otherwise different sizes or different
+// brands rule a match out. imageDistance is -1 when no images are alike.
+func scoreDuplicate(a, b *duplicateKey, sameBarcode bool, imageDistance int) (float64, []models.DuplicateReason) {
+	reasons := []models.DuplicateReason{}
+	if sameBarcode {
+		reasons = append(reasons, models.DuplicateReasonBarcode)
+	}
+
+	sameBrand := a.brand != "" && a.brand == b.brand
+	conflict := (a.sizes != "" && b.sizes != "" && a.sizes != b.sizes) ||
+		(a.brand != "" && b.brand != "" && !sameBrand)
+	if conflict && !sameBarcode {
+		return 0, reasons
+	}
+
+	score := 0.0
+	if similarity := wordSimilarity(a.words, b.words); similarity > 0 {
+		score += duplicateNameWeight * similarity
+		if similarity >= 0.6 {
+			reasons = append(reasons, models.DuplicateReasonName)
+		}
+	}
+	if sameBrand {
+		score += duplicateBrandWeight
+		reasons = append(reasons, models.DuplicateReasonBrand)
+	}
+	if imageDistance >= 0 {
+		score += duplicateImageWeight * (1 - float64(imageDistance)/float64(duplicateImageMaxDistance+1))
+		reasons = append(reasons, models.DuplicateReasonImage)
+	}
+
+	if sameBarcode {
+		return 1, reasons
+	}
+	return score, reasons
+}
+
+// wordSimilarity is the Dice coefficient of two word sets
+func wordSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
+
+var duplicateFolder = strings.NewReplacer(
+	"ç", "c", "ğ", "g", "ı", "i", "ö", "o", "ş", "s", "ü", "u", "â", "a", "î", "i", "û", "u",
+)
+
+// duplicateFold lowercases text under the Turkish case rules and strips the
+// Turkish diacritics, so that "ŞEKERSİZ" and "sekersiz" compare equal
+func duplicateFold(text string) string {
+	return duplicateFolder.Replace(strings.ToLowerSpecial(unicode.TurkishCase, text))
+}
+
+// duplicateWords splits folded text into words, dropping single letters
+func duplicateWords(text string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(duplicateFold(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) > 1 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+var (
+	duplicateQuantityPattern = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*(ml|cl|lt|litre|liter|l|kg|kilo|gram|gr|g|mg)\b`)
+	duplicatePackPattern     = regexp.MustCompile(`(\d+)\s*(?:x|'?li|'?lu|adet|pack|pk)\b`)
+)
+
+// duplicateUnits converts quantities to millilitres or grams
+var duplicateUnits = map[string]struct {
+	unit   string
+	factor float64
+}{
+	"ml": {"ml", 1}, "cl": {"ml", 10}, "lt": {"ml", 1000}, "litre": {"ml", 1000}, "liter": {"ml", 1000}, "l": {"ml", 1000},
+	"mg": {"g", 0.001}, "gr": {"g", 1}, "g": {"g", 1}, "gram": {"g", 1}, "kg": {"g", 1000}, "kilo": {"g", 1000},
+}
+
+// duplicateSizes extracts quantities and pack sizes from folded text, so that
+// "1 L", "1lt" and "1000 ml" all read 1000ml, and returns them sorted along
+// with the text left over
+func duplicateSizes(text string) ([]string, string) {
+	var sizes []string
+
+	text = duplicateQuantityPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := duplicateQuantityPattern.FindStringSubmatch(match)
+		value, err := strconv.ParseFloat(strings.Replace(parts[1], ",", ".", 1), 64)
+		if err != nil {
+			return match
+		}
+		unit := duplicateUnits[parts[2]]
+		sizes = append(sizes, strconv.FormatFloat(value*unit.factor, 'f', -1, 64)+unit.unit)
+		return " "
+	})
+
+	text = duplicatePackPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := duplicatePackPattern.FindStringSubmatch(match)
+		if parts[1] != "1" {
+			sizes = append(sizes, parts[1]+"pk")
+		}
+		return " "
+	})
+
+	sort.Strings(sizes)
+	unique := sizes[:0]
+	for i, size := range sizes {
+		if i == 0 || size != sizes[i-1] {
+			unique = append(unique, size)
+		}
+	}
+	return unique, text
+}
+
+// perceptualHash is the 64-bit difference hash of an image: the image is
+// shrunk to 9x8 and every bit tells whether a pixel is brighter than its
+// right neighbour. Resized and recompressed copies hash alike.
+func perceptualHash(img image.Image) int64 {
+	small := flatten(img, 9, 8)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if luminance(small, x, y) > luminance(small, x+1, y) {
+				hash |= 1 << uint(y*8+x)
+			}
+		}
+	}
+
+	return int64(hash)
+}
+
+func luminance(img *image.RGBA, x, y int) uint32 {
+	c := img.RGBAAt(x, y)
+	return 299*uint32(c.R) + 587*uint32(c.G) + 114*uint32(c.B)
+}
+
+// closestImage returns the smallest Hamming distance between any two hashes
+func closestImage(hashes []int64, candidates []int64) int {
+	closest := 64
+	for _, a := range hashes {
+		for _, b := range candidates {
+			if distance := bits.OnesCount64(uint64(a ^ b)); distance < closest {
+				closest = distance
+			}
+		}
+	}
+	return closest
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// FoodInfoError reports food labelling that is inconsistent or implausible
+type FoodInfoError struct {
+	Field   string
+	Message string
+}
+
+func (e *FoodInfoError) Error() string {
+	return fmt.Sprintf("food_info.%s %s", e.Field, e.Message)
+}
+
+// dietaryLabelConflicts lists the allergens a product cannot declare when it
+// carries a dietary label
+var dietaryLabelConflicts = map[models.DietaryLabel][]models.Allergen{
+	models.DietaryLabelVegan: {
+		models.AllergenMilk, models.AllergenEggs, models.AllergenFish, models.AllergenCrustaceans, models.AllergenMolluscs,
+	},
+	models.DietaryLabelVegetarian: {
+		models.AllergenFish, models.AllergenCrustaceans, models.AllergenMolluscs,
+	},
+	models.DietaryLabelGlutenFree: {models.AllergenGluten},
+}
+
+var (
+	// kilojoulesPerKilocalorie converts between the two energy declarations
+	kilojoulesPerKilocalorie = decimal.RequireFromString("4.184")
+	// sugarFreeLimit is the most sugar per 100 g or 100 ml a sugar free product may contain
+	sugarFreeLimit = decimal.RequireFromString("0.5")
+	hundred        = decimal.NewFromInt(100)
+)
+
+// normalizeFoodInfo validates the food labelling of a product and rewrites it
+// to canonical form: lists without duplicates, traces that are not also
+// declared as allergens, and energy and per serving values derived where
+// they can be
+func normalizeFoodInfo(info *models.FoodInfo) error {
+	if info == nil {
+		return nil
+	}
+
+	info.Allergens = uniqueAllergens(info.Allergens, nil)
+	info.MayContain = uniqueAllergens(info.MayContain, info.Allergens)
+
+	labels := []models.DietaryLabel{}
+	seen := make(map[models.DietaryLabel]bool)
+	for _, label := range info.DietaryLabels {
+		if !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+	info.DietaryLabels = labels
+
+	var ingredients []string
+	for _, ingredient := range info.Ingredients {
+		if ingredient = strings.TrimSpace(ingredient); ingredient != "" {
+			ingredients = append(ingredients, ingredient)
+		}
+	}
+	info.Ingredients = ingredients
+
+	for _, label := range info.DietaryLabels {
+		for _, allergen := range dietaryLabelConflicts[label] {
+			if containsAllergen(info.Allergens, allergen) {
+				return &FoodInfoError{Field: "dietary_labels", Message: fmt.Sprintf("%s conflicts with allergen %s", label, allergen)}
+			}
+		}
+	}
+	// A gluten free claim rules out traces as well
+	if seen[models.DietaryLabelGlutenFree] && containsAllergen(info.MayContain, models.AllergenGluten) {
+		return &FoodInfoError{Field: "dietary_labels", Message: "GLUTEN_FREE conflicts with may contain GLUTEN"}
+	}
+
+	if info.Nutrition == nil {
+		return nil
+	}
+	if err := normalizeNutrition(info.Nutrition); err != nil {
+		return err
+	}
+
+	if seen[models.DietaryLabelSugarFree] {
+		if sugars := info.Nutrition.Per100.Sugars; sugars != nil && sugars.GreaterThan(sugarFreeLimit) {
+			return &FoodInfoError{Field: "dietary_labels", Message: fmt.Sprintf("SUGAR_FREE allows at most %s g sugars per 100 %s", sugarFreeLimit, info.Nutrition.Unit)}
+		}
+	}
+
+	return nil
+}
+
+// normalizeNutrition checks a nutrition declaration and fills in the energy
+// unit that was left out and the per serving values when only the serving
+// size is given
+func normalizeNutrition(nutrition *models.NutritionFacts) error {
+	if nutrition.ServingSize != nil && !nutrition.ServingSize.IsPositive() {
+		return &FoodInfoError{Field: "nutrition.serving_size", Message: "must be positive"}
+	}
+	if nutrition.PerServing != nil && nutrition.ServingSize == nil {
+		return &FoodInfoError{Field: "nutrition.per_serving", Message: "requires serving_size"}
+	}
+
+	if err := checkNutritionValues("nutrition.per_100", nutrition.Per100); err != nil {
+		return err
+	}
+
+	// Nutrients are weights, so per 100 g they cannot add up to more than
+	// 100 g. Liquids are declared per 100 ml and can be denser than water.
+	if nutrition.Unit == "g" {
+		total := decimal.Zero
+		for _, value := range []*decimal.Decimal{nutrition.Per100.Fat, nutrition.Per100.Carbohydrates, nutrition.Per100.Fibre, nutrition.Per100.Protein, nutrition.Per100.Salt} {
+			if value != nil {
+				total = total.Add(*value)
+			}
+		}
+		if total.GreaterThan(hundred) {
+			return &FoodInfoError{Field: "nutrition.per_100", Message: fmt.Sprintf("nutrients add up to %s g, more than 100 g", total)}
+		}
+	}
+
+	if nutrition.PerServing != nil {
+		return checkNutritionValues("nutrition.per_serving", nutrition.PerServing)
+	}
+	if nutrition.ServingSize != nil {
+		nutrition.PerServing = scaleNutritionValues(nutrition.Per100, nutrition.ServingSize.Div(hundred))
+	}
+
+	return nil
+}
+
+// checkNutritionValues rejects negative values and nutrients that exceed the
+// nutrient they are part of, and derives the missing energy unit
+func checkNutritionValues(field string, values *models.NutritionValues) error {
+	named := []struct {
+		name  string
+		value *decimal.Decimal
+	}{
+		{"energy_kj", values.EnergyKJ},
+		{"energy_kcal", values.EnergyKcal},
+		{"fat", values.Fat},
+		{"saturated_fat", values.SaturatedFat},
+		{"carbohydrates", values.Carbohydrates},
+		{"sugars", values.Sugars},
+		{"fibre", values.Fibre},
+		{"protein", values.Protein},
+		{"salt", values.Salt},
+	}
+	for _, n := range named {
+		if n.value != nil && n.value.IsNegative() {
+			return &FoodInfoError{Field: field + "." + n.name, Message: "cannot be negative"}
+		}
+	}
+
+	if values.SaturatedFat != nil && values.Fat != nil && values.SaturatedFat.GreaterThan(*values.Fat) {
+		return &FoodInfoError{Field: field + ".saturated_fat", Message: "cannot exceed fat"}
+	}
+	if values.Sugars != nil && values.Carbohydrates != nil && values.Sugars.GreaterThan(*values.Carbohydrates) {
+		return &FoodInfoError{Field: field + ".sugars", Message: "cannot exceed carbohydrates"}
+	}
+
+	if values.EnergyKJ == nil && values.EnergyKcal != nil {
+		kj := values.EnergyKcal.Mul(kilojoulesPerKilocalorie).Round(0)
+		values.EnergyKJ = &kj
+	}
+	if values.EnergyKcal == nil && values.EnergyKJ != nil {
+		kcal := values.EnergyKJ.Div(kilojoulesPerKilocalorie).Round(0)
+		values.EnergyKcal = &kcal
+	}
+
+	return nil
+}
+
+// scaleNutritionValues multiplies every declared value by factor
+func scaleNutritionValues(values *models.NutritionValues, factor decimal.Decimal) *models.NutritionValues {
+	scale := func(value *decimal.Decimal) *decimal.Decimal {
+		if value == nil {
+			return nil
+		}
+		scaled := value.Mul(factor).Round(1)
+		return &scaled
+	}
+
+	return &models.NutritionValues{
+		EnergyKJ:      scale(values.EnergyKJ),
+		EnergyKcal:    scale(values.EnergyKcal),
+		Fat:           scale(values.Fat),
+		SaturatedFat:  scale(values.SaturatedFat),
+		Carbohydrates: scale(values.Carbohydrates),
+		Sugars:        scale(values.Sugars),
+		Fibre:         scale(values.Fibre),
+		Protein:       scale(values.Protein),
+		Salt:          scale(values.Salt),
+	}
+}
+
+// uniqueAllergens returns allergens without duplicates and without the ones
+// in exclude, never nil so that an empty list is stored as []
+func uniqueAllergens(allergens []models.Allergen, exclude []models.Allergen) []models.Allergen {
+	unique := []models.Allergen{}
+	for _, allergen := range allergens {
+		if !containsAllergen(unique, allergen) && !containsAllergen(exclude, allergen) {
+			unique = append(unique, allergen)
+		}
+	}
+	return unique
+}
+
+func containsAllergen(allergens []models.Allergen, allergen models.Allergen) bool {
+	for _, a := range allergens {
+		if a == allergen {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,518 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/rwcarlsen/goexif/exif"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+// Longest side, in pixels, of each image derivative
+const (
+	thumbnailSize = 150
+	mediumSize    = 600
+	largeSize     = 1200
+)
+
+const webpTimeout = 30 * time.Second
+
+// defaultMediaPollInterval is used when MEDIA_POLL_INTERVAL is not a
+// positive duration
+const defaultMediaPollInterval = 2 * time.Second
+
+// uploadImage validates an image upload, reuses an identical image when one
+// exists and otherwise stores the raw file for the media worker to process
+func (s *catalogService) uploadImage(productID uuid.UUID, variantID *uuid.UUID, file io.Reader, fileName string, mimeType string) (*models.ProductMedia, error) {
+	data, err := io.ReadAll(io.LimitReader(file, s.config.MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > s.config.MaxFileSize {
+		return nil, fmt.Errorf("file size exceeds maximum allowed size %d", s.config.MaxFileSize)
+	}
+
+	return s.storeImage(productID, variantID, data, fileName, mimeType, "")
+}
+
+// storeImage creates the media record of an image. rawObjectName names an
+// already stored raw upload; when empty the data is stored first.
+func (s *catalogService) storeImage(productID uuid.UUID, variantID *uuid.UUID, data []byte, fileName string, mimeType string, rawObjectName string) (*models.ProductMedia, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	width, height := cfg.Width, cfg.Height
+	if orientationSwapsAxes(exifOrientation(data)) {
+		width, height = height, width
+	}
+	if err := s.validateImageDimensions(width, height); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	contentHash := hex.EncodeToString(sum[:])
+
+	duplicates, err := s.productRepo.GetMediaByHash(contentHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for duplicate media: %w", err)
+	}
+	for _, duplicate := range duplicates {
+		if duplicate.ProductID == productID && sameVariant(duplicate.VariantID, variantID) {
+			s.removeRawObject(rawObjectName)
+			return duplicate, nil
+		}
+	}
+
+	media := &models.ProductMedia{
+		ID:          uuid.New(),
+		ProductID:   productID,
+		VariantID:   variantID,
+		Type:        "image",
+		FileName:    fileName,
+		FileSize:    int64(len(data)),
+		MimeType:    mimeType,
+		SortOrder:   0,
+		IsActive:    true,
+		ContentHash: &contentHash,
+		Width:       &width,
+		Height:      &height,
+	}
+
+	if len(duplicates) > 0 && duplicates[0].ProcessingStatus == models.MediaProcessingStatusReady {
+		// Reuse the renditions of an identical image attached elsewhere
+		source := duplicates[0]
+		media.URL = source.URL
+		media.FileSize = source.FileSize
+		media.MimeType = source.MimeType
+		media.Width = source.Width
+		media.Height = source.Height
+		media.Derivatives = source.Derivatives
+		media.PerceptualHash = source.PerceptualHash
+		media.ProcessingStatus = models.MediaProcessingStatusReady
+		s.removeRawObject(rawObjectName)
+	} else {
+		if rawObjectName == "" {
+			rawObjectName = fmt.Sprintf("media-raw/%s", media.ID)
+			ctx := context.Background()
+			err = s.mediaStore.Put(ctx, rawObjectName, bytes.NewReader(data), int64(len(data)), mimeType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to upload file: %w", err)
+			}
+		}
+
+		// The URL points at the processed original, which exists once the media is READY
+		_, ext := originalEncoding(format)
+		media.URL = s.mediaStore.URL(fmt.Sprintf("%s/original.%s", mediaObjectPrefix(media), ext))
+		media.RawObjectName = &rawObjectName
+		media.ProcessingStatus = models.MediaProcessingStatusPending
+	}
+
+	err = s.productRepo.CreateMedia(media)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create media record: %w", err)
+	}
+
+	s.invalidateProduct(productID)
+
+	return media, nil
+}
+
+// removeRawObject deletes a raw upload that is no longer needed
+func (s *catalogService) removeRawObject(objectName string) {
+	if objectName == "" {
+		return
+	}
+
+	if err := s.mediaStore.Remove(context.Background(), objectName); err != nil {
+		log.Printf("Failed to remove raw upload %s: %v", objectName, err)
+	}
+}
+
+func (s *catalogService) validateImageDimensions(width, height int) error {
+	if width < s.config.ImageMinWidth || height < s.config.ImageMinHeight {
+		return fmt.Errorf("image resolution %dx%d is below the minimum %dx%d", width, height, s.config.ImageMinWidth, s.config.ImageMinHeight)
+	}
+
+	longest, shortest := width, height
+	if height > width {
+		longest, shortest = height, width
+	}
+	if s.config.ImageMaxAspectRatio > 0 && float64(longest)/float64(shortest) > s.config.ImageMaxAspectRatio {
+		return fmt.Errorf("image aspect ratio %dx%d exceeds the maximum of %.1f:1", width, height, s.config.ImageMaxAspectRatio)
+	}
+
+	return nil
+}
+
+// Media worker
+func (s *catalogService) StartMediaWorker() {
+	interval := s.config.MediaPollInterval
+	if interval <= 0 {
+		log.Printf("Invalid media poll interval %s, using %s", interval, defaultMediaPollInterval)
+		interval = defaultMediaPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Println("Starting media worker...")
+
+	go s.backfillPerceptualHashes()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.processPendingMedia()
+			s.expireMediaUploads()
+		}
+	}
+}
+
+func (s *catalogService) processPendingMedia() {
+	for {
+		media, err := s.productRepo.ClaimPendingMedia()
+		if err != nil {
+			log.Printf("Failed to claim pending media: %v", err)
+			return
+		}
+
+		if media == nil {
+			return
+		}
+
+		if err := s.processImage(media); err != nil {
+			log.Printf("Failed to process media %s: %v", media.ID, err)
+			if err := s.productRepo.FailMediaProcessing(media.ID, err.Error()); err != nil {
+				log.Printf("Failed to mark media %s as failed: %v", media.ID, err)
+			}
+		}
+		s.invalidateProduct(media.ProductID)
+	}
+}
+
+// backfillPerceptualHashes hashes the images processed before duplicate
+// detection existed, from their thumbnails. Images that cannot be read are
+// skipped until the next start.
+func (s *catalogService) backfillPerceptualHashes() {
+	after := uuid.Nil
+	hashed := 0
+	for {
+		batch, err := s.productRepo.GetUnhashedMedia(after, 100)
+		if err != nil {
+			log.Printf("Failed to load images to hash: %v", err)
+			return
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, media := range batch {
+			after = media.ID
+			if err := s.hashStoredImage(media); err != nil {
+				log.Printf("Failed to hash media %s: %v", media.ID, err)
+				continue
+			}
+			hashed++
+		}
+	}
+
+	if hashed > 0 {
+		log.Printf("Computed perceptual hashes of %d images", hashed)
+	}
+}
+
+func (s *catalogService) hashStoredImage(media *models.ProductMedia) error {
+	object, err := s.mediaStore.Get(context.Background(), mediaObjectPrefix(media)+"/thumbnail.jpg")
+	if err != nil {
+		return fmt.Errorf("failed to open thumbnail: %w", err)
+	}
+	defer object.Close()
+
+	img, _, err := image.Decode(object)
+	if err != nil {
+		return fmt.Errorf("failed to decode thumbnail: %w", err)
+	}
+
+	return s.productRepo.SetPerceptualHash(media, perceptualHash(img))
+}
+
+// processImage strips metadata from the raw upload by re-encoding it and
+// stores the resized derivatives next to the cleaned original
+func (s *catalogService) processImage(media *models.ProductMedia) error {
+	if media.RawObjectName == nil {
+		return fmt.Errorf("media has no raw upload")
+	}
+
+	ctx := context.Background()
+	object, err := s.mediaStore.Get(ctx, *media.RawObjectName)
+	if err != nil {
+		return fmt.Errorf("failed to open raw upload: %w", err)
+	}
+	data, err := io.ReadAll(object)
+	object.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read raw upload: %w", err)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+	img = applyOrientation(img, exifOrientation(data))
+
+	prefix := mediaObjectPrefix(media)
+
+	// Re-encoding drops EXIF and any other metadata from the original
+	var original bytes.Buffer
+	contentType, ext := originalEncoding(format)
+	if ext == "png" {
+		err = png.Encode(&original, img)
+	} else {
+		err = jpeg.Encode(&original, flatten(img, img.Bounds().Dx(), img.Bounds().Dy()), &jpeg.Options{Quality: s.config.ImageJPEGQuality})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode original: %w", err)
+	}
+	originalSize := int64(original.Len())
+	originalName := fmt.Sprintf("%s/original.%s", prefix, ext)
+	if err := s.putMediaObject(originalName, &original, contentType); err != nil {
+		return err
+	}
+
+	derivatives := &models.MediaDerivatives{}
+	if derivatives.Thumbnail, err = s.storeDerivative(prefix+"/thumbnail.jpg", img, thumbnailSize); err != nil {
+		return err
+	}
+	if derivatives.Medium, err = s.storeDerivative(prefix+"/medium.jpg", img, mediumSize); err != nil {
+		return err
+	}
+	if derivatives.Large, err = s.storeDerivative(prefix+"/large.jpg", img, largeSize); err != nil {
+		return err
+	}
+
+	webp, err := s.encodeWebP(resize(img, largeSize))
+	if err != nil {
+		// WebP is optional; the other derivatives are still usable without it
+		log.Printf("Skipping WebP derivative for media %s: %v", media.ID, err)
+	} else {
+		webpName := prefix + "/large.webp"
+		if err := s.putMediaObject(webpName, bytes.NewReader(webp), "image/webp"); err != nil {
+			return err
+		}
+		derivatives.WebP = s.mediaStore.URL(webpName)
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	hash := perceptualHash(img)
+	media.URL = s.mediaStore.URL(originalName)
+	media.FileSize = originalSize
+	media.MimeType = contentType
+	media.Width = &width
+	media.Height = &height
+	media.Derivatives = derivatives
+	media.PerceptualHash = &hash
+
+	if err := s.productRepo.CompleteMediaProcessing(media); err != nil {
+		return fmt.Errorf("failed to update media: %w", err)
+	}
+
+	if err := s.mediaStore.Remove(ctx, *media.RawObjectName); err != nil {
+		log.Printf("Failed to remove raw upload of media %s: %v", media.ID, err)
+	}
+
+	return nil
+}
+
+func (s *catalogService) storeDerivative(objectName string, img image.Image, size int) (string, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resize(img, size), &jpeg.Options{Quality: s.config.ImageJPEGQuality}); err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", objectName, err)
+	}
+
+	if err := s.putMediaObject(objectName, &buf, "image/jpeg"); err != nil {
+		return "", err
+	}
+
+	return s.mediaStore.URL(objectName), nil
+}
+
+func (s *catalogService) putMediaObject(objectName string, r io.Reader, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	err = s.mediaStore.Put(ctx, objectName, bytes.NewReader(data), int64(len(data)), contentType)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", objectName, err)
+	}
+
+	return nil
+}
+
+// encodeWebP converts an image with the cwebp tool, since the Go image
+// libraries can only decode WebP
+func (s *catalogService) encodeWebP(img image.Image) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "catalog-webp-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.png")
+	output := filepath.Join(dir, "output.webp")
+
+	file, err := os.Create(input)
+	if err != nil {
+		return nil, err
+	}
+	err = png.Encode(file, img)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webpTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.config.ImageCWebPPath, "-quiet", "-q", fmt.Sprint(s.config.ImageJPEGQuality), input, "-o", output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("cwebp failed: %v: %s", err, out)
+	}
+
+	return os.ReadFile(output)
+}
+
+func mediaObjectPrefix(media *models.ProductMedia) string {
+	return fmt.Sprintf("products/%s/%s", media.ProductID, media.ID)
+}
+
+// originalEncoding keeps PNG originals lossless and stores everything else as JPEG
+func originalEncoding(format string) (contentType string, ext string) {
+	if format == "png" {
+		return "image/png", "png"
+	}
+	return "image/jpeg", "jpg"
+}
+
+func sameVariant(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// resize scales an image to fit within size x size without upscaling
+func resize(img image.Image, size int) image.Image {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width > size || height > size {
+		if width >= height {
+			height = height * size / width
+			width = size
+		} else {
+			width = width * size / height
+			height = size
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	return flatten(img, width, height)
+}
+
+// flatten draws an image scaled to width x height onto a white background so
+// that transparent areas do not turn black when encoded as JPEG
+func flatten(img image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+	return dst
+}
+
+// exifOrientation returns the EXIF orientation tag, or 1 when there is none
+func exifOrientation(data []byte) int {
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 1
+	}
+
+	tag, err := x.Get(exif.Orientation)
+	if err != nil {
+		return 1
+	}
+
+	orientation, err := tag.Int(0)
+	if err != nil || orientation < 1 || orientation > 8 {
+		return 1
+	}
+
+	return orientation
+}
+
+func orientationSwapsAxes(orientation int) bool {
+	return orientation >= 5
+}
+
+// applyOrientation rotates and flips an image so that it displays upright
+// once its EXIF orientation tag has been stripped
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	dstWidth, dstHeight := width, height
+	if orientationSwapsAxes(orientation) {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirror horizontally
+				dx, dy = width-1-x, y
+			case 3: // rotate 180
+				dx, dy = width-1-x, height-1-y
+			case 4: // mirror vertically
+				dx, dy = x, height-1-y
+			case 5: // transpose
+				dx, dy = y, x
+			case 6: // rotate 90 clockwise
+				dx, dy = height-1-y, x
+			case 7: // transverse
+				dx, dy = height-1-y, width-1-x
+			case 8: // rotate 90 counter-clockwise
+				dx, dy = y, width-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+const expiredUploadBatchSize = 100
+
+// Presigned upload operations
+func (s *catalogService) CreateMediaUpload(productID uuid.UUID, req *models.CreateMediaUploadRequest) (*models.PresignedUpload, error) {
+	if err := s.validateMediaFile(req.ContentType, req.FileSize); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	upload := &models.MediaUpload{
+		ID:          uuid.New(),
+		ProductID:   productID,
+		VariantID:   req.VariantID,
+		FileName:    filepath.Base(req.FileName),
+		ContentType: req.ContentType,
+		FileSize:    req.FileSize,
+		Status:      models.MediaUploadStatusPending,
+		ExpiresAt:   time.Now().Add(s.config.MediaUploadExpiry),
+	}
+
+	// Images land in the raw area for the media worker; videos are stored as is
+	if strings.HasPrefix(req.ContentType, "image/") {
+		upload.ObjectName = fmt.Sprintf("media-raw/%s", upload.ID)
+	} else {
+		upload.ObjectName = fmt.Sprintf("products/%s/%s-%s", productID, upload.ID, upload.FileName)
+	}
+
+	ctx := context.Background()
+	url, err := s.mediaStore.PresignPut(ctx, upload.ObjectName, s.config.MediaUploadExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	err = s.mediaUploadRepo.Create(upload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create media upload: %w", err)
+	}
+
+	return &models.PresignedUpload{
+		Upload:    upload,
+		URL:       url,
+		Method:    "PUT",
+		Headers:   map[string]string{"Content-Type": upload.ContentType},
+		ExpiresAt: upload.ExpiresAt,
+	}, nil
+}
+
+// ConfirmMediaUpload is called by the client once the presigned PUT has
+// finished. The stored object goes through the same validation and
+// processing as a multipart upload.
+func (s *catalogService) ConfirmMediaUpload(productID uuid.UUID, uploadID uuid.UUID) (*models.ProductMedia, error) {
+	upload, err := s.mediaUploadRepo.GetByID(uploadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media upload: %w", err)
+	}
+	if upload == nil || upload.ProductID != productID {
+		return nil, fmt.Errorf("media upload not found")
+	}
+
+	if upload.Status != models.MediaUploadStatusPending {
+		return nil, fmt.Errorf("media upload is %s", strings.ToLower(string(upload.Status)))
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		return nil, fmt.Errorf("media upload has expired")
+	}
+
+	ctx := context.Background()
+	info, err := s.mediaStore.Stat(ctx, upload.ObjectName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check uploaded file: %w", err)
+	}
+	if info == nil {
+		return nil, fmt.Errorf("file has not been uploaded")
+	}
+
+	media, err := s.storeUploadedMedia(upload, info.Size)
+	if err != nil {
+		s.removeRawObject(upload.ObjectName)
+		if markErr := s.mediaUploadRepo.MarkFailed(upload.ID, err.Error()); markErr != nil {
+			log.Printf("Failed to mark media upload %s as failed: %v", upload.ID, markErr)
+		}
+		return nil, err
+	}
+
+	err = s.mediaUploadRepo.MarkConfirmed(upload.ID, media.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm media upload: %w", err)
+	}
+
+	return media, nil
+}
+
+func (s *catalogService) storeUploadedMedia(upload *models.MediaUpload, size int64) (*models.ProductMedia, error) {
+	if size > s.config.MaxFileSize {
+		return nil, fmt.Errorf("file size %d exceeds maximum allowed size %d", size, s.config.MaxFileSize)
+	}
+
+	if !strings.HasPrefix(upload.ContentType, "image/") {
+		return s.createVideoMedia(upload.ProductID, upload.VariantID, upload.ObjectName, upload.FileName, size, upload.ContentType)
+	}
+
+	ctx := context.Background()
+	object, err := s.mediaStore.Get(ctx, upload.ObjectName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(object, s.config.MaxFileSize+1))
+	object.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	if int64(len(data)) > s.config.MaxFileSize {
+		return nil, fmt.Errorf("file size exceeds maximum allowed size %d", s.config.MaxFileSize)
+	}
+
+	return s.storeImage(upload.ProductID, upload.VariantID, data, upload.FileName, upload.ContentType, upload.ObjectName)
+}
+
+// expireMediaUploads removes files of presigned uploads that were never confirmed
+func (s *catalogService) expireMediaUploads() {
+	uploads, err := s.mediaUploadRepo.ExpirePending(expiredUploadBatchSize)
+	if err != nil {
+		log.Printf("Failed to expire media uploads: %v", err)
+		return
+	}
+
+	for _, upload := range uploads {
+		s.removeRawObject(upload.ObjectName)
+	}
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+var merchantFeedContentTypes = map[string]string{
+	"xml": "application/xml",
+	"tsv": "text/tab-separated-values",
+}
+
+var merchantFeedColumns = []string{
+	"id", "item_group_id", "title", "description", "link", "image_link", "additional_image_link",
+	"availability", "price", "brand", "gtin", "mpn", "identifier_exists", "condition", "product_type",
+}
+
+// merchantItem is a product entry in a Google Merchant Center feed
+type merchantItem struct {
+	ID                   string   `xml:"g:id"`
+	ItemGroupID          string   `xml:"g:item_group_id,omitempty"`
+	Title                string   `xml:"g:title"`
+	Description          string   `xml:"g:description"`
+	Link                 string   `xml:"g:link"`
+	ImageLink            string   `xml:"g:image_link"`
+	AdditionalImageLinks []string `xml:"g:additional_image_link,omitempty"`
+	Availability         string   `xml:"g:availability"`
+	Price                string   `xml:"g:price"`
+	Brand                string   `xml:"g:brand,omitempty"`
+	GTIN                 string   `xml:"g:gtin,omitempty"`
+	MPN                  string   `xml:"g:mpn,omitempty"`
+	IdentifierExists     string   `xml:"g:identifier_exists,omitempty"`
+	Condition            string   `xml:"g:condition"`
+	ProductType          string   `xml:"g:product_type,omitempty"`
+}
+
+type merchantRSS struct {
+	XMLName xml.Name        `xml:"rss"`
+	Version string          `xml:"version,attr"`
+	XMLNSG  string          `xml:"xmlns:g,attr"`
+	Channel merchantChannel `xml:"channel"`
+}
+
+type merchantChannel struct {
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description"`
+	Items       []merchantItem `xml:"item"`
+}
+
+// Feed operations
+func (s *catalogService) GenerateMerchantFeed(format string) (*models.MerchantFeed, error) {
+	if _, ok := merchantFeedContentTypes[format]; !ok {
+		return nil, fmt.Errorf("unsupported feed format %s", format)
+	}
+
+	products, err := s.loadExportProducts(nil, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	items, skipped := s.buildMerchantItems(products)
+	return s.storeMerchantFeed(format, items, skipped)
+}
+
+// Feed scheduler
+func (s *catalogService) StartMerchantFeedScheduler() {
+	if s.config.MerchantFeedInterval <= 0 {
+		log.Println("Merchant feed scheduler disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.config.MerchantFeedInterval)
+	defer ticker.Stop()
+
+	log.Println("Starting merchant feed scheduler...")
+
+	s.generateMerchantFeeds()
+	for {
+		select {
+		case <-ticker.C:
+			s.generateMerchantFeeds()
+		}
+	}
+}
+
+func (s *catalogService) generateMerchantFeeds() {
+	products, err := s.loadExportProducts(nil, nil, true)
+	if err != nil {
+		log.Printf("Failed to load products for merchant feed: %v", err)
+		return
+	}
+
+	items, skipped := s.buildMerchantItems(products)
+	for _, format := range s.config.MerchantFeedFormats {
+		format = strings.TrimSpace(format)
+		if _, ok := merchantFeedContentTypes[format]; !ok {
+			log.Printf("Skipping unsupported merchant feed format %q", format)
+			continue
+		}
+
+		feed, err := s.storeMerchantFeed(format, items, skipped)
+		if err != nil {
+			log.Printf("Failed to generate %s merchant feed: %v", format, err)
+			continue
+		}
+
+		log.Printf("Generated %s merchant feed with %d items (%d skipped)", format, feed.ItemCount, feed.SkippedCount)
+	}
+}
+
+// buildMerchantItems converts products into feed items, one per variant for
+// products with variants. Items without an image are skipped because Merchant
+// Center rejects them.
+func (s *catalogService) buildMerchantItems(products []*models.Product) ([]merchantItem, int) {
+	var items []merchantItem
+	skipped := 0
+
+	for _, product := range products {
+		base := merchantItem{
+			ID:          product.ID.String(),
+			Title:       product.Name,
+			Description: stringValue(product.Description),
+			Link:        fmt.Sprintf("%s/products/%s", strings.TrimRight(s.config.MerchantFeedStoreURL, "/"), product.ID),
+			Brand:       stringValue(product.Brand),
+			GTIN:        stringValue(product.Barcode),
+			MPN:         stringValue(product.SKU),
+			Condition:   "new",
+		}
+		if base.Description == "" {
+			base.Description = product.Name
+		}
+		if product.Category != nil {
+			base.ProductType = product.Category.Name
+		}
+
+		if len(product.Variants) == 0 {
+			item := base
+			item.Availability = merchantAvailability(product.BaseStock)
+			item.Price = merchantPrice(product.BasePrice, product.Currency)
+			if !setMerchantImages(&item, product.Media) {
+				skipped++
+				continue
+			}
+			setIdentifierExists(&item)
+			items = append(items, item)
+			continue
+		}
+
+		for _, variant := range product.Variants {
+			item := base
+			item.ID = variant.ID.String()
+			item.ItemGroupID = product.ID.String()
+			item.Title = fmt.Sprintf("%s - %s", product.Name, variant.Name)
+			if variant.Barcode != nil {
+				item.GTIN = *variant.Barcode
+			}
+			if variant.SKU != nil {
+				item.MPN = *variant.SKU
+			}
+
+			stock := product.BaseStock
+			if variant.Stock != nil {
+				stock = *variant.Stock
+			}
+			price := product.BasePrice
+			if variant.Price != nil {
+				price = *variant.Price
+			}
+			item.Availability = merchantAvailability(stock)
+			item.Price = merchantPrice(price, product.Currency)
+
+			media := variant.Media
+			if len(media) == 0 {
+				media = product.Media
+			}
+			if !setMerchantImages(&item, media) {
+				skipped++
+				continue
+			}
+			setIdentifierExists(&item)
+			items = append(items, item)
+		}
+	}
+
+	return items, skipped
+}
+
+func (s *catalogService) storeMerchantFeed(format string, items []merchantItem, skipped int) (*models.MerchantFeed, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "xml" {
+		err = s.writeMerchantXML(&buf, items)
+	} else {
+		err = writeMerchantTSV(&buf, items)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %s feed: %w", format, err)
+	}
+
+	objectName := fmt.Sprintf("feeds/google-merchant.%s", format)
+	ctx := context.Background()
+	err = s.mediaStore.Put(ctx, objectName, &buf, int64(buf.Len()), merchantFeedContentTypes[format])
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload feed: %w", err)
+	}
+
+	return &models.MerchantFeed{
+		Format:       format,
+		URL:          s.mediaStore.URL(objectName),
+		ItemCount:    len(items),
+		SkippedCount: skipped,
+		GeneratedAt:  time.Now().UTC(),
+	}, nil
+}
+
+func (s *catalogService) writeMerchantXML(w io.Writer, items []merchantItem) error {
+	feed := merchantRSS{
+		Version: "2.0",
+		XMLNSG:  "http://base.google.com/ns/1.0",
+		Channel: merchantChannel{
+			Title:       s.config.MerchantFeedTitle,
+			Link:        s.config.MerchantFeedStoreURL,
+			Description: fmt.Sprintf("%s product feed", s.config.MerchantFeedTitle),
+			Items:       items,
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(feed)
+}
+
+func writeMerchantTSV(w io.Writer, items []merchantItem) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = '\t'
+
+	if err := writer.Write(merchantFeedColumns); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		record := []string{
+			item.ID,
+			item.ItemGroupID,
+			item.Title,
+			item.Description,
+			item.Link,
+			item.ImageLink,
+			strings.Join(item.AdditionalImageLinks, ","),
+			item.Availability,
+			item.Price,
+			item.Brand,
+			item.GTIN,
+			item.MPN,
+			item.IdentifierExists,
+			item.Condition,
+			item.ProductType,
+		}
+		for i, value := range record {
+			// Merchant Center TSV does not allow tabs or line breaks inside values
+			record[i] = strings.Join(strings.Fields(value), " ")
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func setMerchantImages(item *merchantItem, media []*models.ProductMedia) bool {
+	for _, m := range media {
+		if m.Type != "image" {
+			continue
+		}
+		if item.ImageLink == "" {
+			item.ImageLink = m.URL
+		} else if len(item.AdditionalImageLinks) < 10 {
+			item.AdditionalImageLinks = append(item.AdditionalImageLinks, m.URL)
+		}
+	}
+	return item.ImageLink != ""
+}
+
+func setIdentifierExists(item *merchantItem) {
+	if item.GTIN == "" && (item.Brand == "" || item.MPN == "") {
+		item.IdentifierExists = "no"
+	}
+}
+
+func merchantAvailability(stock int) string {
+	if stock > 0 {
+		return "in_stock"
+	}
+	return "out_of_stock"
+}
+
+func merchantPrice(price decimal.Decimal, currency string) string {
+	return fmt.Sprintf("%s %s", price.StringFixed(2), currency)
+}
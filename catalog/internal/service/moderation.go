@@ -0,0 +1,369 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// moderationSystemActor is recorded as reviewer when the automatic checks reject a product
+const moderationSystemActor = "system"
+
+// Moderation operations
+func (s *catalogService) SubmitProduct(id uuid.UUID, actor string) (*models.Product, error) {
+	product, err := s.productRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	if product.Status != models.ProductStatusDraft && product.Status != models.ProductStatusRejected {
+		return nil, fmt.Errorf("product cannot be submitted from status %s", product.Status)
+	}
+
+	return s.submitProduct(product, actor)
+}
+
+// submitProduct runs the automatic checks and moves the product to the
+// review queue, or rejects it straight away when it contains banned words
+func (s *catalogService) submitProduct(product *models.Product, actor string) (*models.Product, error) {
+	flags, err := s.runModerationChecks(product)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queueProduct(product, flags, actor)
+}
+
+// queueProduct moves the product to the review queue with the findings of the
+// automatic checks, or rejects it when it contains banned words
+func (s *catalogService) queueProduct(product *models.Product, flags models.ModerationFlags, actor string) (*models.Product, error) {
+	previous := *product
+	now := time.Now()
+
+	product.Status = models.ProductStatusPendingReview
+	product.SubmittedAt = &now
+	product.ReviewedBy = nil
+	product.ReviewedAt = nil
+	product.RejectionReason = nil
+	product.RejectionNote = nil
+	product.ModerationFlags = flags
+
+	for _, flag := range flags {
+		if flag.Check == models.ModerationCheckBannedWords {
+			reviewer := moderationSystemActor
+			reason := models.RejectionReasonBannedContent
+			note := flag.Message
+
+			product.Status = models.ProductStatusRejected
+			product.ReviewedBy = &reviewer
+			product.ReviewedAt = &now
+			product.RejectionReason = &reason
+			product.RejectionNote = &note
+			break
+		}
+	}
+
+	err := s.productRepo.UpdateModeration(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit product: %w", err)
+	}
+
+	s.recordRevision(models.RevisionEntityProduct, product.ID, product.ID, models.RevisionActionUpdate, actor, &previous, product, nil)
+	s.invalidateProduct(product.ID)
+
+	return product, nil
+}
+
+func (s *catalogService) ApproveProduct(id uuid.UUID, actor string) (*models.Product, error) {
+	product, err := s.productRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	if product.Status != models.ProductStatusPendingReview {
+		return nil, fmt.Errorf("product cannot be approved from status %s", product.Status)
+	}
+
+	// Refresh the flags in case images or prices changed while the product waited
+	flags, err := s.runModerationChecks(product)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := *product
+	now := time.Now()
+	if actor == "" {
+		actor = moderationSystemActor
+	}
+
+	product.Status = models.ProductStatusApproved
+	product.ReviewedBy = &actor
+	product.ReviewedAt = &now
+	product.RejectionReason = nil
+	product.RejectionNote = nil
+	product.ModerationFlags = flags
+
+	err = s.productRepo.UpdateModeration(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve product: %w", err)
+	}
+
+	s.recordRevision(models.RevisionEntityProduct, product.ID, product.ID, models.RevisionActionUpdate, actor, &previous, product, nil)
+	s.invalidateProduct(product.ID)
+
+	go func() {
+		if err := s.IndexProduct(product); err != nil {
+			log.Printf("Failed to index product %s: %v", product.ID, err)
+		}
+	}()
+
+	go s.publishProductEvent(product, "approved")
+
+	return product, nil
+}
+
+// RejectProduct rejects a product waiting for review, or takes down an approved one
+func (s *catalogService) RejectProduct(id uuid.UUID, req *models.RejectProductRequest, actor string) (*models.Product, error) {
+	product, err := s.productRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	if product.Status != models.ProductStatusPendingReview && product.Status != models.ProductStatusApproved {
+		return nil, fmt.Errorf("product cannot be rejected from status %s", product.Status)
+	}
+
+	previous := *product
+	now := time.Now()
+	if actor == "" {
+		actor = moderationSystemActor
+	}
+	reason := req.Reason
+
+	product.Status = models.ProductStatusRejected
+	product.ReviewedBy = &actor
+	product.ReviewedAt = &now
+	product.RejectionReason = &reason
+	product.RejectionNote = req.Note
+
+	err = s.productRepo.UpdateModeration(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject product: %w", err)
+	}
+
+	s.recordRevision(models.RevisionEntityProduct, product.ID, product.ID, models.RevisionActionUpdate, actor, &previous, product, nil)
+	s.invalidateProduct(product.ID)
+
+	if previous.Status == models.ProductStatusApproved {
+		go func() {
+			if err := s.RemoveFromIndex(product.ID); err != nil {
+				log.Printf("Failed to remove product %s from index: %v", product.ID, err)
+			}
+		}()
+
+		go s.publishProductEvent(product, "unpublished")
+	}
+
+	return product, nil
+}
+
+// remoderateProduct re-runs the automatic checks when the content or price of
+// an approved seller product changed. A check that flags the product where it
+// did not on approval sends it back to the review queue and takes it down.
+func (s *catalogService) remoderateProduct(previous, product *models.Product, actor string) error {
+	if previous.Status != models.ProductStatusApproved || product.Status != models.ProductStatusApproved || product.SubmittedBy == nil {
+		return nil
+	}
+	if !moderatedContentChanged(previous, product) {
+		return nil
+	}
+
+	flags, err := s.runModerationChecks(product)
+	if err != nil {
+		return err
+	}
+	if !newModerationFlags(previous.ModerationFlags, flags) {
+		return nil
+	}
+
+	product, err = s.queueProduct(product, flags, actor)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.RemoveFromIndex(product.ID); err != nil {
+			log.Printf("Failed to remove product %s from index: %v", product.ID, err)
+		}
+	}()
+
+	go s.publishProductEvent(product, "unpublished")
+
+	return nil
+}
+
+// moderatedContentChanged reports whether a change touches what the automatic
+// checks look at: the content, the category and the price
+func moderatedContentChanged(previous, product *models.Product) bool {
+	return previous.Name != product.Name ||
+		stringValue(previous.Description) != stringValue(product.Description) ||
+		stringValue(previous.Brand) != stringValue(product.Brand) ||
+		previous.CategoryID != product.CategoryID ||
+		!previous.BasePrice.Equal(product.BasePrice) ||
+		!reflect.DeepEqual(previous.Tags, product.Tags) ||
+		!reflect.DeepEqual(previous.Attributes, product.Attributes)
+}
+
+// newModerationFlags reports whether a check flags the product that did not
+// flag it when it was approved
+func newModerationFlags(approved, flags models.ModerationFlags) bool {
+	checks := map[models.ModerationCheck]bool{}
+	for _, flag := range approved {
+		checks[flag.Check] = true
+	}
+
+	for _, flag := range flags {
+		if flag.Check == models.ModerationCheckBannedWords || !checks[flag.Check] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *catalogService) GetModerationQueue(req *models.ModerationQueueRequest) (*models.ModerationQueueResponse, error) {
+	products, total, err := s.productRepo.GetModerationQueue(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get moderation queue: %w", err)
+	}
+
+	totalPages := int(total) / req.Limit
+	if int(total)%req.Limit > 0 {
+		totalPages++
+	}
+
+	return &models.ModerationQueueResponse{
+		Products:   products,
+		Total:      total,
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// runModerationChecks returns the findings of the automatic checks. Banned
+// words reject the product; the other findings are hints for the moderator.
+func (s *catalogService) runModerationChecks(product *models.Product) (models.ModerationFlags, error) {
+	flags := models.ModerationFlags{}
+
+	text := []string{product.Name, stringValue(product.Description), stringValue(product.Brand)}
+	text = append(text, product.Tags...)
+	if hits := bannedWordHits(strings.Join(text, " "), s.config.ModerationBannedWords); len(hits) > 0 {
+		flags = append(flags, &models.ModerationFlag{
+			Check:   models.ModerationCheckBannedWords,
+			Message: fmt.Sprintf("contains banned words: %s", strings.Join(hits, ", ")),
+		})
+	}
+
+	if s.config.ModerationMinImages > 0 {
+		media, err := s.productRepo.GetAllMedia(product.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get product media: %w", err)
+		}
+
+		images := 0
+		for _, m := range media {
+			if m.Type == "image" {
+				images++
+			}
+		}
+		if images < s.config.ModerationMinImages {
+			flags = append(flags, &models.ModerationFlag{
+				Check:   models.ModerationCheckMissingImages,
+				Message: fmt.Sprintf("has %d images, at least %d expected", images, s.config.ModerationMinImages),
+			})
+		}
+	}
+
+	if s.config.ModerationPriceOutlierFactor > 1 {
+		median, samples, err := s.productRepo.GetCategoryPriceMedian(product.CategoryID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get category price median: %w", err)
+		}
+
+		if samples >= s.config.ModerationPriceMinSamples && median.IsPositive() {
+			factor := decimal.NewFromFloat(s.config.ModerationPriceOutlierFactor)
+			if product.BasePrice.GreaterThan(median.Mul(factor)) || product.BasePrice.Mul(factor).LessThan(median) {
+				flags = append(flags, &models.ModerationFlag{
+					Check:   models.ModerationCheckPriceOutlier,
+					Message: fmt.Sprintf("base price %s is far from the category median %s", product.BasePrice.StringFixed(2), median.StringFixed(2)),
+				})
+			}
+		}
+	}
+
+	flag, err := s.duplicateFlag(product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicates: %w", err)
+	}
+	if flag != nil {
+		flags = append(flags, flag)
+	}
+
+	return flags, nil
+}
+
+// bannedWordHits returns the banned words or phrases that appear in the text
+// as whole words. Matching ignores case under both the default and the
+// Turkish case rules so that dotted and dotless i spellings are caught.
+func bannedWordHits(text string, banned []string) []string {
+	var hits []string
+	if len(banned) == 0 {
+		return hits
+	}
+
+	normalized := []string{moderationTokens(text, false), moderationTokens(text, true)}
+	for _, word := range banned {
+		for i, turkish := range []bool{false, true} {
+			phrase := moderationTokens(word, turkish)
+			if strings.TrimSpace(phrase) != "" && strings.Contains(normalized[i], phrase) {
+				hits = append(hits, word)
+				break
+			}
+		}
+	}
+
+	return hits
+}
+
+// moderationTokens lowercases text and joins its words with single spaces,
+// padded so that phrases can be matched on word boundaries
+func moderationTokens(text string, turkish bool) string {
+	if turkish {
+		text = strings.ToLowerSpecial(unicode.TurkishCase, text)
+	} else {
+		text = strings.ToLower(text)
+	}
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return " " + strings.Join(words, " ") + " "
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// PriceError rejects a compare-at price
+type PriceError struct {
+	Field   string
+	Message string
+}
+
+func (e *PriceError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// GetPriceHistory returns the price changes of a product, its variants and
+// seller listings, newest first
+func (s *catalogService) GetPriceHistory(req *models.PriceHistoryRequest) (*models.PriceHistoryResponse, error) {
+	changes, total, err := s.productRepo.GetPriceHistory(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	totalPages := int(total) / req.Limit
+	if int(total)%req.Limit > 0 {
+		totalPages++
+	}
+
+	return &models.PriceHistoryResponse{
+		Changes:    changes,
+		Total:      total,
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// validateCompareAtPrice rejects what can be told about a compare-at price
+// without the price history, which the database checks it against when it
+// is written
+func validateCompareAtPrice(field string, compareAt *decimal.Decimal, price *decimal.Decimal) error {
+	if compareAt == nil {
+		return nil
+	}
+	if compareAt.IsNegative() {
+		return &PriceError{Field: field, Message: "must not be negative"}
+	}
+	if price == nil {
+		return &PriceError{Field: field, Message: "needs a price of its own"}
+	}
+	return nil
+}
+
+// compareAtPriceError turns the repository's rejection of a compare-at price
+// into a PriceError
+func compareAtPriceError(field string, err error) error {
+	if err != nil && strings.HasPrefix(err.Error(), "compare_at_price ") {
+		return &PriceError{Field: field, Message: strings.TrimPrefix(err.Error(), "compare_at_price ")}
+	}
+	return err
+}
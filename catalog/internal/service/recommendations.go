@@ -0,0 +1,175 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// coPurchaseMaxItems is the size above which an order is not counted
+	// into co-purchases: bulk orders pair everything with everything
+	coPurchaseMaxItems = 50
+
+	// similarCandidates is how many products of the same category or with
+	// shared tags are scored for similarity
+	similarCandidates = 200
+
+	// Weights of the signals of the similarity score, adding up to 1
+	similarCategoryWeight  = 0.4
+	similarAttributeWeight = 0.35
+	similarTagWeight       = 0.25
+)
+
+// GetRecommendations returns the products frequently bought together with a
+// product and the products most similar to it, up to limit of each. It
+// returns nil when the product does not exist.
+func (s *catalogService) GetRecommendations(productID uuid.UUID, limit int) (*models.Recommendations, error) {
+	field := fmt.Sprintf("%s:%d", productID, limit)
+
+	var recommendations *models.Recommendations
+	if s.cacheGet(recommendationsCacheKey, field, s.config.CacheRecommendationsTTL, &recommendations) && recommendations != nil {
+		return recommendations, nil
+	}
+
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, nil
+	}
+
+	boughtTogetherIDs, err := s.recommendationRepo.FindBoughtTogether(productID, s.config.RecommendationMinCoPurchases, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find products bought together: %w", err)
+	}
+	boughtTogether, err := s.GetProductsByIDs(boughtTogetherIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	similar, err := s.similarProducts(product, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations = &models.Recommendations{
+		ProductID:      productID,
+		BoughtTogether: boughtTogether,
+		Similar:        similar,
+	}
+
+	s.cacheSet(recommendationsCacheKey, field, s.config.CacheRecommendationsTTL, recommendations)
+
+	return recommendations, nil
+}
+
+// similarProducts scores the candidates sharing the category or tags of a
+// product by category, attribute values and tags, and returns the best ones
+func (s *catalogService) similarProducts(product *models.Product, limit int) ([]*models.Product, error) {
+	ids, err := s.recommendationRepo.FindSimilarCandidates(product, similarCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar products: %w", err)
+	}
+
+	candidates, err := s.productRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	scores := make(map[uuid.UUID]float64, len(candidates))
+	for _, candidate := range candidates {
+		scores[candidate.ID] = similarityScore(product, candidate)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if scores[a.ID] != scores[b.ID] {
+			return scores[a.ID] > scores[b.ID]
+		}
+		if !a.RatingAverage.Equal(b.RatingAverage) {
+			return a.RatingAverage.GreaterThan(b.RatingAverage)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	if err := s.loadBatchDetails(candidates); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// similarityScore rates how alike two products are between 0 and 1: whether
+// they share a category, the share of the product's attribute values the
+// candidate has too, and the overlap of their tags
+func similarityScore(product *models.Product, candidate *models.Product) float64 {
+	score := 0.0
+	if candidate.CategoryID == product.CategoryID {
+		score += similarCategoryWeight
+	}
+
+	if len(product.Attributes) > 0 {
+		matched := 0
+		for code, value := range product.Attributes {
+			if other, ok := candidate.Attributes[code]; ok && reflect.DeepEqual(value, other) {
+				matched++
+			}
+		}
+		score += similarAttributeWeight * float64(matched) / float64(len(product.Attributes))
+	}
+
+	// Jaccard similarity of the tags
+	tags := make(map[string]bool, len(product.Tags))
+	for _, tag := range product.Tags {
+		tags[strings.ToLower(tag)] = true
+	}
+	shared, union := 0, len(tags)
+	seen := make(map[string]bool, len(candidate.Tags))
+	for _, tag := range candidate.Tags {
+		tag = strings.ToLower(tag)
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		if tags[tag] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	if union > 0 {
+		score += similarTagWeight * float64(shared) / float64(union)
+	}
+
+	return score
+}
+
+// recordCoPurchases counts the products of a new order as bought together
+func (s *catalogService) recordCoPurchases(event *models.OrderCreatedEvent, orderedAt time.Time) error {
+	seen := make(map[uuid.UUID]bool)
+	var productIDs []uuid.UUID
+	for _, item := range event.Items {
+		if item.ProductID != uuid.Nil && !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+
+	if event.OrderID == uuid.Nil || len(productIDs) < 2 || len(productIDs) > coPurchaseMaxItems {
+		return nil
+	}
+
+	if err := s.recommendationRepo.RecordOrder(event.OrderID, productIDs, orderedAt); err != nil {
+		return fmt.Errorf("failed to record co-purchases of order %s: %w", event.OrderID, err)
+	}
+	return nil
+}
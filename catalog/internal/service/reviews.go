@@ -0,0 +1,444 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// maxReviewPhotos is the number of photos a review can carry
+const maxReviewPhotos = 5
+
+// eventRetryDelay is how long the event consumers wait before retrying an
+// event they could not handle
+const eventRetryDelay = 5 * time.Second
+
+// ReviewError reports a review operation the request is not allowed to make
+type ReviewError struct {
+	Message string
+}
+
+func (e *ReviewError) Error() string {
+	return e.Message
+}
+
+// Review operations
+func (s *catalogService) CreateReview(productID uuid.UUID, customerID uuid.UUID, req *models.CreateReviewRequest) (*models.Review, error) {
+	eligibility, err := s.reviewRepo.GetEligibility(req.OrderID, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review eligibility: %w", err)
+	}
+	if eligibility == nil || eligibility.CustomerID != customerID {
+		return nil, fmt.Errorf("order not eligible for review")
+	}
+
+	review := &models.Review{
+		ID:         uuid.New(),
+		ProductID:  productID,
+		VariantID:  eligibility.VariantID,
+		OrderID:    eligibility.OrderID,
+		CustomerID: customerID,
+		SellerID:   eligibility.SellerID,
+		Rating:     req.Rating,
+		Title:      req.Title,
+		Body:       req.Body,
+		Photos:     models.ReviewPhotos{},
+		Status:     models.ReviewStatusPending,
+	}
+
+	// Reviews with banned words never reach the moderation queue
+	text := stringValue(req.Title) + " " + stringValue(req.Body)
+	if hits := bannedWordHits(text, s.config.ModerationBannedWords); len(hits) > 0 {
+		reviewer := moderationSystemActor
+		note := fmt.Sprintf("contains banned words: %s", strings.Join(hits, ", "))
+		now := time.Now()
+
+		review.Status = models.ReviewStatusRejected
+		review.ModeratedBy = &reviewer
+		review.ModeratedAt = &now
+		review.ModerationNote = &note
+	}
+
+	if err := s.reviewRepo.Create(review); err != nil {
+		if err.Error() == "review already exists" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create review: %w", err)
+	}
+
+	return review, nil
+}
+
+// AddReviewPhoto stores a photo on the customer's review while it waits for
+// moderation. The photo is re-encoded, which drops EXIF data such as the
+// location it was taken at.
+func (s *catalogService) AddReviewPhoto(reviewID uuid.UUID, customerID uuid.UUID, file io.Reader, fileSize int64, mimeType string) (*models.Review, error) {
+	review, err := s.customerReview(reviewID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if review.Status != models.ReviewStatusPending {
+		return nil, fmt.Errorf("review cannot take photos in status %s", review.Status)
+	}
+	if len(review.Photos) >= maxReviewPhotos {
+		return nil, &ReviewError{Message: fmt.Sprintf("reviews can have at most %d photos", maxReviewPhotos)}
+	}
+
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, &ReviewError{Message: "review photos must be images"}
+	}
+	if err := s.validateMediaFile(mimeType, fileSize); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, s.config.MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > s.config.MaxFileSize {
+		return nil, fmt.Errorf("file size exceeds maximum allowed size %d", s.config.MaxFileSize)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+	img = resize(applyOrientation(img, exifOrientation(data)), largeSize)
+
+	photo := &models.ReviewPhoto{
+		ID:     uuid.New(),
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+
+	prefix := fmt.Sprintf("reviews/%s/%s", review.ID, photo.ID)
+	if photo.URL, err = s.storeDerivative(prefix+"/large.jpg", img, largeSize); err != nil {
+		return nil, err
+	}
+	if photo.ThumbnailURL, err = s.storeDerivative(prefix+"/thumbnail.jpg", img, thumbnailSize); err != nil {
+		s.removeReviewPhoto(prefix)
+		return nil, err
+	}
+
+	if err := s.reviewRepo.AddPhoto(review, photo, maxReviewPhotos); err != nil {
+		s.removeReviewPhoto(prefix)
+		if err.Error() == "review cannot take more photos" {
+			return nil, &ReviewError{Message: fmt.Sprintf("reviews can have at most %d photos", maxReviewPhotos)}
+		}
+		return nil, fmt.Errorf("failed to add review photo: %w", err)
+	}
+
+	return review, nil
+}
+
+// removeReviewPhoto removes the stored objects of a photo that was not added
+func (s *catalogService) removeReviewPhoto(prefix string) {
+	ctx := context.Background()
+	for _, name := range []string{prefix + "/large.jpg", prefix + "/thumbnail.jpg"} {
+		if err := s.mediaStore.Remove(ctx, name); err != nil {
+			log.Printf("Failed to remove review photo %s: %v", name, err)
+		}
+	}
+}
+
+// VoteReview records whether a customer found an approved review helpful.
+// Voting again replaces the earlier vote.
+func (s *catalogService) VoteReview(reviewID uuid.UUID, voterID uuid.UUID, helpful bool) (*models.Review, error) {
+	review, err := s.votableReview(reviewID, voterID)
+	if err != nil {
+		return nil, err
+	}
+
+	review.HelpfulCount, review.NotHelpfulCount, err = s.reviewRepo.Vote(review.ID, voterID, helpful)
+	if err != nil {
+		return nil, fmt.Errorf("failed to vote on review: %w", err)
+	}
+
+	return review, nil
+}
+
+func (s *catalogService) DeleteReviewVote(reviewID uuid.UUID, voterID uuid.UUID) (*models.Review, error) {
+	review, err := s.votableReview(reviewID, voterID)
+	if err != nil {
+		return nil, err
+	}
+
+	review.HelpfulCount, review.NotHelpfulCount, err = s.reviewRepo.DeleteVote(review.ID, voterID)
+	if err != nil {
+		if err.Error() == "vote not found" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to delete review vote: %w", err)
+	}
+
+	return review, nil
+}
+
+// ReplyToReview stores the seller's public reply to a review of one of their
+// listings, replacing an earlier reply
+func (s *catalogService) ReplyToReview(sellerID uuid.UUID, reviewID uuid.UUID, req *models.ReviewReplyRequest) (*models.Review, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	if review == nil || review.SellerID != sellerID {
+		return nil, fmt.Errorf("review not found")
+	}
+
+	if review.Status == models.ReviewStatusRejected {
+		return nil, fmt.Errorf("review cannot be replied to in status %s", review.Status)
+	}
+
+	if hits := bannedWordHits(req.Reply, s.config.ModerationBannedWords); len(hits) > 0 {
+		return nil, &ReviewError{Message: fmt.Sprintf("reply contains banned words: %s", strings.Join(hits, ", "))}
+	}
+
+	now := time.Now()
+	review.SellerReply = &req.Reply
+	review.SellerRepliedAt = &now
+
+	if err := s.reviewRepo.UpdateReply(review); err != nil {
+		return nil, fmt.Errorf("failed to reply to review: %w", err)
+	}
+
+	return review, nil
+}
+
+// ListReviews returns a page of reviews. Listings of a product's approved
+// reviews include the product's rating summary.
+func (s *catalogService) ListReviews(req *models.ReviewListRequest) (*models.ReviewListResponse, error) {
+	reviews, total, err := s.reviewRepo.List(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+
+	totalPages := int(total) / req.Limit
+	if int(total)%req.Limit > 0 {
+		totalPages++
+	}
+
+	response := &models.ReviewListResponse{
+		Reviews:    reviews,
+		Total:      total,
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalPages: totalPages,
+	}
+
+	if req.ProductID != nil && req.Status == models.ReviewStatusApproved {
+		response.Summary, err = s.reviewRepo.GetRatingSummary(*req.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rating summary: %w", err)
+		}
+	}
+
+	return response, nil
+}
+
+// ApproveReview publishes a pending review and counts it in the product's rating
+func (s *catalogService) ApproveReview(id uuid.UUID, actor string) (*models.Review, error) {
+	review, err := s.reviewRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	if review == nil {
+		return nil, fmt.Errorf("review not found")
+	}
+
+	if review.Status != models.ReviewStatusPending {
+		return nil, fmt.Errorf("review cannot be approved from status %s", review.Status)
+	}
+
+	return s.moderateReview(review, models.ReviewStatusApproved, nil, actor)
+}
+
+// RejectReview rejects a pending review, or takes down an approved one
+func (s *catalogService) RejectReview(id uuid.UUID, req *models.RejectReviewRequest, actor string) (*models.Review, error) {
+	review, err := s.reviewRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	if review == nil {
+		return nil, fmt.Errorf("review not found")
+	}
+
+	if review.Status == models.ReviewStatusRejected {
+		return nil, fmt.Errorf("review cannot be rejected from status %s", review.Status)
+	}
+
+	return s.moderateReview(review, models.ReviewStatusRejected, req.Note, actor)
+}
+
+// moderateReview stores the moderation decision and, when the review enters
+// or leaves the approved set, refreshes the product's rating everywhere it is shown
+func (s *catalogService) moderateReview(review *models.Review, status models.ReviewStatus, note *string, actor string) (*models.Review, error) {
+	wasApproved := review.Status == models.ReviewStatusApproved
+	now := time.Now()
+	if actor == "" {
+		actor = moderationSystemActor
+	}
+
+	review.Status = status
+	review.ModeratedBy = &actor
+	review.ModeratedAt = &now
+	review.ModerationNote = note
+
+	if err := s.reviewRepo.UpdateModeration(review); err != nil {
+		return nil, fmt.Errorf("failed to moderate review: %w", err)
+	}
+
+	if wasApproved || status == models.ReviewStatusApproved {
+		if err := s.refreshProductRating(review.ProductID); err != nil {
+			return nil, err
+		}
+	}
+
+	return review, nil
+}
+
+// refreshProductRating recomputes a product's rating aggregates and reindexes
+// the product so that rating sorts see them
+func (s *catalogService) refreshProductRating(productID uuid.UUID) error {
+	if err := s.reviewRepo.RefreshProductRating(productID); err != nil {
+		return fmt.Errorf("failed to refresh product rating: %w", err)
+	}
+
+	s.invalidateProduct(productID)
+
+	go func() {
+		product, err := s.productRepo.GetByID(productID)
+		if err != nil || product == nil {
+			log.Printf("Failed to load product %s for reindexing: %v", productID, err)
+			return
+		}
+		if err := s.IndexProduct(product); err != nil {
+			log.Printf("Failed to index product %s: %v", productID, err)
+		}
+	}()
+
+	return nil
+}
+
+// customerReview returns a review written by the customer. Other customers'
+// reviews are reported as not found.
+func (s *catalogService) customerReview(reviewID uuid.UUID, customerID uuid.UUID) (*models.Review, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	if review == nil || review.CustomerID != customerID {
+		return nil, fmt.Errorf("review not found")
+	}
+	return review, nil
+}
+
+// votableReview returns an approved review the voter did not write
+func (s *catalogService) votableReview(reviewID uuid.UUID, voterID uuid.UUID) (*models.Review, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	if review == nil || review.Status != models.ReviewStatusApproved {
+		return nil, fmt.Errorf("review not found")
+	}
+	if review.CustomerID == voterID {
+		return nil, &ReviewError{Message: "customers cannot vote on their own reviews"}
+	}
+	return review, nil
+}
+
+// Order delivered consumer
+func (s *catalogService) StartOrderDeliveredConsumer() {
+	s.consumeEvents(s.config.KafkaOrderDeliveredTopic, kafka.LastOffset, s.recordDeliveredOrder)
+}
+
+// consumeEvents hands every event of a topic to handle, retrying it until it
+// is handled before committing its offset
+func (s *catalogService) consumeEvents(topic string, startOffset int64, handle func(value []byte) error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     s.config.KafkaBrokers,
+		Topic:       topic,
+		GroupID:     s.config.KafkaConsumerGroup,
+		MinBytes:    10e3, // 10KB
+		MaxBytes:    10e6, // 10MB
+		MaxWait:     1 * time.Second,
+		StartOffset: startOffset,
+	})
+	defer reader.Close()
+
+	log.Printf("Starting event consumer on topic %s...", topic)
+
+	ctx := context.Background()
+	for {
+		message, err := reader.FetchMessage(ctx)
+		if err != nil {
+			log.Printf("Failed to read %s event: %v", topic, err)
+			time.Sleep(eventRetryDelay)
+			continue
+		}
+
+		// Keep the event uncommitted until it is stored
+		for {
+			err := handle(message.Value)
+			if err == nil {
+				break
+			}
+			log.Printf("Failed to handle %s event at offset %d: %v", topic, message.Offset, err)
+			time.Sleep(eventRetryDelay)
+		}
+
+		if err := reader.CommitMessages(ctx, message); err != nil {
+			log.Printf("Failed to commit %s event at offset %d: %v", topic, message.Offset, err)
+		}
+	}
+}
+
+// recordDeliveredOrder makes the items of a delivered order reviewable.
+// Malformed events and other order statuses are skipped.
+func (s *catalogService) recordDeliveredOrder(value []byte) error {
+	var event models.OrderDeliveredEvent
+	if err := json.Unmarshal(value, &event); err != nil {
+		log.Printf("Skipping malformed order delivered event: %v", err)
+		return nil
+	}
+
+	if event.Status != "DELIVERED" || event.OrderID == uuid.Nil || event.CustomerID == uuid.Nil {
+		return nil
+	}
+
+	deliveredAt := event.Timestamp
+	if deliveredAt.IsZero() {
+		deliveredAt = time.Now()
+	}
+
+	for _, item := range event.Items {
+		if item.ProductID == uuid.Nil {
+			continue
+		}
+
+		err := s.reviewRepo.UpsertEligibility(&models.ReviewEligibility{
+			OrderID:     event.OrderID,
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			CustomerID:  event.CustomerID,
+			SellerID:    event.SellerID,
+			DeliveredAt: deliveredAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store review eligibility of order %s: %w", event.OrderID, err)
+		}
+	}
+
+	return nil
+}
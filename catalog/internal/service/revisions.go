@@ -0,0 +1,408 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// defaultRevisionActor is recorded when a change has no known actor
+const defaultRevisionActor = "system"
+
+// revisionIgnoredFields are left out of snapshots and diffs: timestamps
+// change on every write, is_available follows the clock and the rest are
+// computed, not stored on the entity
+var revisionIgnoredFields = map[string]bool{
+	"created_at":   true,
+	"updated_at":   true,
+	"category":     true,
+	"variants":     true,
+	"media":        true,
+	"seller_data":  true,
+	"product":      true,
+	"variant_name": true,
+	"stock_state":  true,
+	"is_available": true,
+}
+
+// Revision history operations
+func (s *catalogService) GetRevisions(req *models.RevisionListRequest) (*models.RevisionListResponse, error) {
+	revisions, total, err := s.revisionRepo.List(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revisions: %w", err)
+	}
+
+	totalPages := int(total) / req.Limit
+	if int(total)%req.Limit > 0 {
+		totalPages++
+	}
+
+	return &models.RevisionListResponse{
+		Revisions:  revisions,
+		Total:      total,
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (s *catalogService) GetRevision(productID uuid.UUID, revisionID uuid.UUID) (*models.Revision, error) {
+	revision, err := s.revisionRepo.GetByID(revisionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revision: %w", err)
+	}
+	if revision == nil || revision.ProductID != productID {
+		return nil, fmt.Errorf("revision not found")
+	}
+
+	return revision, nil
+}
+
+// CompareRevisions returns the field-level diff between two revisions of the same entity
+func (s *catalogService) CompareRevisions(productID uuid.UUID, fromID uuid.UUID, toID uuid.UUID) (*models.RevisionComparison, error) {
+	from, err := s.GetRevision(productID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetRevision(productID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.EntityType != to.EntityType || from.EntityID != to.EntityID {
+		return nil, fmt.Errorf("revisions belong to different entities")
+	}
+
+	fromFields, err := revisionFields(from.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := revisionFields(to.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RevisionComparison{
+		From:    from,
+		To:      to,
+		Changes: diffRevisionFields(fromFields, toFields),
+	}, nil
+}
+
+// RestoreRevision writes the snapshot of a revision back to its entity,
+// re-creating it if it was deleted, and records the restore as a new revision
+func (s *catalogService) RestoreRevision(productID uuid.UUID, revisionID uuid.UUID, actor string) (interface{}, error) {
+	revision, err := s.GetRevision(productID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch revision.EntityType {
+	case models.RevisionEntityProduct:
+		return s.restoreProduct(revision, actor)
+	case models.RevisionEntityVariant:
+		return s.restoreVariant(revision, actor)
+	case models.RevisionEntitySellerProduct:
+		return s.restoreSellerProduct(revision, actor)
+	default:
+		return nil, fmt.Errorf("unsupported revision entity type %s", revision.EntityType)
+	}
+}
+
+func (s *catalogService) restoreProduct(revision *models.Revision, actor string) (*models.Product, error) {
+	product := &models.Product{}
+	if err := json.Unmarshal(revision.Snapshot, product); err != nil {
+		return nil, fmt.Errorf("failed to read revision snapshot: %w", err)
+	}
+	product.ID = revision.EntityID
+	product.AvailabilitySchedule = normalizeSchedule(product.AvailabilitySchedule)
+	product.IsAvailable = s.availableAt(product.PublishAt, product.UnpublishAt, product.AvailabilitySchedule, time.Now())
+
+	current, err := s.productRepo.GetByID(product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if current == nil {
+		// A deleted product is taken out of the trash and overwritten
+		current, err = s.productRepo.GetDeletedByID(product.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get product: %w", err)
+		}
+		if current != nil && current.MergedInto != nil {
+			return nil, &TrashError{Message: fmt.Sprintf("product was merged into product %s", current.MergedInto)}
+		}
+	}
+
+	if current == nil {
+		// A purged product was last reviewed against rules and prices that may
+		// have changed since, so it has to go through moderation again
+		product.Status = models.ProductStatusDraft
+		product.SubmittedAt = nil
+		product.ReviewedBy = nil
+		product.ReviewedAt = nil
+		product.RejectionReason = nil
+		product.RejectionNote = nil
+		product.ModerationFlags = nil
+
+		err = s.productRepo.Create(product)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore product: %w", barcodeConflictError(err))
+		}
+	} else {
+		// Snapshot attributes are checked against the category schema as it is now
+		req := &models.UpdateProductRequest{
+			CategoryID: &product.CategoryID,
+			Attributes: product.Attributes,
+			BaseStock:  &product.BaseStock,
+			MinStock:   &product.MinStock,
+		}
+		if req.Attributes == nil {
+			req.Attributes = models.Attributes{}
+		}
+		if err := s.normalizeProductUpdate(current, req); err != nil {
+			return nil, err
+		}
+		product.Attributes = req.Attributes
+
+		if current.DeletedAt != nil {
+			if err := s.productRepo.Undelete(product.ID); err != nil {
+				if err.Error() == "product SKU or barcode is used by another product" {
+					return nil, &TrashError{Message: err.Error()}
+				}
+				return nil, fmt.Errorf("failed to restore product: %w", err)
+			}
+		}
+
+		err = s.productRepo.Restore(product)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore product: %w", err)
+		}
+	}
+
+	restored, err := s.productRepo.GetByID(product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if restored == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	s.recordRevision(models.RevisionEntityProduct, product.ID, product.ID, models.RevisionActionRestore, actor, current, restored, &revision.ID)
+
+	if current != nil {
+		if err := s.remoderateProduct(current, restored, actor); err != nil {
+			s.refreshProduct(product.ID)
+			return nil, fmt.Errorf("failed to moderate restored product: %w", err)
+		}
+	}
+
+	s.refreshProduct(product.ID)
+
+	return restored, nil
+}
+
+func (s *catalogService) restoreVariant(revision *models.Revision, actor string) (*models.ProductVariant, error) {
+	variant := &models.ProductVariant{}
+	if err := json.Unmarshal(revision.Snapshot, variant); err != nil {
+		return nil, fmt.Errorf("failed to read revision snapshot: %w", err)
+	}
+	variant.ID = revision.EntityID
+	variant.ProductID = revision.ProductID
+
+	current, err := s.productRepo.GetVariant(variant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant: %w", err)
+	}
+
+	if current != nil {
+		// A restore keeps the compare-at price, which only holds against the
+		// prices before it was set, as long as the variant keeps a price
+		variant.CompareAtPrice = nil
+		if variant.Price != nil {
+			variant.CompareAtPrice = current.CompareAtPrice
+		}
+		err = s.updateVariant(variant.ID, variant, actor, models.RevisionActionRestore, &revision.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore variant: %w", err)
+		}
+	} else {
+		attributes, err := s.normalizeVariant(variant.ProductID, variant.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		variant.Attributes = attributes
+
+		err = s.productRepo.CreateVariant(variant)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore variant: %w", barcodeConflictError(err))
+		}
+
+		s.recordRevision(models.RevisionEntityVariant, variant.ID, variant.ProductID, models.RevisionActionRestore, actor, nil, variant, &revision.ID)
+	}
+
+	s.refreshProduct(variant.ProductID)
+
+	return s.productRepo.GetVariant(variant.ID)
+}
+
+func (s *catalogService) restoreSellerProduct(revision *models.Revision, actor string) (*models.SellerProduct, error) {
+	sp := &models.SellerProduct{}
+	if err := json.Unmarshal(revision.Snapshot, sp); err != nil {
+		return nil, fmt.Errorf("failed to read revision snapshot: %w", err)
+	}
+
+	req := &models.SellerProductRequest{
+		SellerSKU:            sp.SellerSKU,
+		Price:                sp.Price,
+		Stock:                sp.Stock,
+		MinStock:             sp.MinStock,
+		MaxStock:             sp.MaxStock,
+		IsActive:             &sp.IsActive,
+		IsVisible:            &sp.IsVisible,
+		PreparationTime:      sp.PreparationTime,
+		Notes:                sp.Notes,
+		PublishAt:            sp.PublishAt,
+		UnpublishAt:          sp.UnpublishAt,
+		AvailabilitySchedule: sp.AvailabilitySchedule,
+	}
+
+	sellerProduct, err := s.upsertSellerProduct(sp.SellerID, revision.ProductID, sp.VariantID, req, actor, revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore seller product: %w", err)
+	}
+
+	return sellerProduct, nil
+}
+
+// recordRevision appends a revision for a change. Before and after are the
+// entity's states around the change; either is nil for creates and deletes.
+// Updates that change no field are not recorded. Failures are logged so that
+// the change itself is not reported as failed.
+func (s *catalogService) recordRevision(entityType models.RevisionEntityType, entityID uuid.UUID, productID uuid.UUID, action models.RevisionAction, actor string, before interface{}, after interface{}, restoredFrom *uuid.UUID) {
+	beforeFields, err := snapshotFields(before)
+	if err != nil {
+		log.Printf("Failed to snapshot %s %s: %v", entityType, entityID, err)
+		return
+	}
+	afterFields, err := snapshotFields(after)
+	if err != nil {
+		log.Printf("Failed to snapshot %s %s: %v", entityType, entityID, err)
+		return
+	}
+
+	snapshotSource := afterFields
+	var changes models.FieldChanges
+	if action == models.RevisionActionDelete {
+		snapshotSource = beforeFields
+	} else {
+		changes = diffRevisionFields(beforeFields, afterFields)
+		if action == models.RevisionActionUpdate && len(changes) == 0 {
+			return
+		}
+	}
+
+	snapshot, err := json.Marshal(snapshotSource)
+	if err != nil {
+		log.Printf("Failed to snapshot %s %s: %v", entityType, entityID, err)
+		return
+	}
+
+	if actor == "" {
+		actor = defaultRevisionActor
+	}
+
+	revision := &models.Revision{
+		ID:           uuid.New(),
+		EntityType:   entityType,
+		EntityID:     entityID,
+		ProductID:    productID,
+		Action:       action,
+		Actor:        actor,
+		Changes:      changes,
+		Snapshot:     snapshot,
+		RestoredFrom: restoredFrom,
+	}
+
+	if err := s.revisionRepo.Create(revision); err != nil {
+		log.Printf("Failed to record revision of %s %s: %v", entityType, entityID, err)
+	}
+}
+
+// snapshotFields converts an entity to its stored fields keyed by JSON name
+func snapshotFields(entity interface{}) (map[string]interface{}, error) {
+	if entity == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisionFields(data)
+}
+
+func revisionFields(data []byte) (map[string]interface{}, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to read revision snapshot: %w", err)
+	}
+
+	for field := range fields {
+		if revisionIgnoredFields[field] {
+			delete(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+// diffRevisionFields returns the fields whose values differ between two snapshots
+func diffRevisionFields(before, after map[string]interface{}) models.FieldChanges {
+	changes := models.FieldChanges{}
+
+	for field, to := range after {
+		from := before[field]
+		if !revisionValuesEqual(from, to) {
+			changes[field] = &models.FieldChange{From: from, To: to}
+		}
+	}
+	for field, from := range before {
+		if _, ok := after[field]; !ok && from != nil {
+			changes[field] = &models.FieldChange{From: from, To: nil}
+		}
+	}
+
+	return changes
+}
+
+// revisionValuesEqual compares snapshot values, treating decimals that only
+// differ in scale (10.5 and 10.50) as equal
+func revisionValuesEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	aString, aOK := a.(string)
+	bString, bOK := b.(string)
+	if !aOK || !bOK {
+		return false
+	}
+
+	aDecimal, err := decimal.NewFromString(aString)
+	if err != nil {
+		return false
+	}
+	bDecimal, err := decimal.NewFromString(bString)
+	if err != nil {
+		return false
+	}
+
+	return aDecimal.Equal(bDecimal)
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+)
+
+// exactCountThreshold is the estimated result size below which counting the
+// results exactly is cheap enough to do instead
+const exactCountThreshold = 1000
+
+// CursorError reports a pagination cursor that cannot be used for a search
+type CursorError struct {
+	Message string
+}
+
+func (e *CursorError) Error() string {
+	return e.Message
+}
+
+// encodeSearchCursor returns the opaque token of the position after product
+// in the sort order of req
+func encodeSearchCursor(req *models.SearchRequest, product *models.Product) string {
+	cursor := models.SearchCursor{
+		SortBy:    req.SortBy,
+		SortOrder: req.SortOrder,
+		ID:        product.ID,
+	}
+
+	switch req.SortBy {
+	case "name":
+		cursor.Value = product.Name
+	case "price":
+		cursor.Value = product.BasePrice.String()
+	case "rating":
+		cursor.Value = product.RatingAverage.String()
+	case "updated_at":
+		cursor.Value = product.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case "relevance":
+		cursor.Value = product.CreatedAt.UTC().Format(time.RFC3339Nano)
+		cursor.Rank = productRank(req.Ranking, product)
+	default:
+		cursor.Value = product.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeSearchCursor reads a token made by encodeSearchCursor. A cursor only
+// continues the sort order it was made for.
+func decodeSearchCursor(req *models.SearchRequest) (*models.SearchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(req.Cursor)
+	if err != nil {
+		return nil, &CursorError{Message: "malformed cursor"}
+	}
+
+	var cursor models.SearchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Value == "" {
+		return nil, &CursorError{Message: "malformed cursor"}
+	}
+
+	if cursor.SortBy != req.SortBy || cursor.SortOrder != req.SortOrder {
+		return nil, &CursorError{Message: "cursor was issued for a different sort order"}
+	}
+
+	return &cursor, nil
+}
+
+// searchTotal counts the results of a search. Offset pages report the exact
+// count their page numbers rely on. Cursor pages report the planner's
+// estimate, counting exactly only when the estimate is small.
+func (s *catalogService) searchTotal(req *models.SearchRequest) (int64, bool, error) {
+	if req.After == nil {
+		total, err := s.productRepo.CountSearch(req)
+		return total, false, err
+	}
+
+	estimate, err := s.productRepo.EstimateSearchCount(req)
+	if err != nil {
+		return 0, false, err
+	}
+	if estimate >= exactCountThreshold {
+		return estimate, true, nil
+	}
+
+	total, err := s.productRepo.CountSearch(req)
+	return total, false, err
+}
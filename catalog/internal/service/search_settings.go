@@ -0,0 +1,517 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/google/uuid"
+)
+
+// SearchSettingsError reports search settings that cannot be saved, or a
+// draft or version that does not exist
+type SearchSettingsError struct {
+	Message  string
+	NotFound bool
+}
+
+func (e *SearchSettingsError) Error() string {
+	return e.Message
+}
+
+// GetLiveSearchSettings returns the settings searches apply, or nil before
+// any version has been published
+func (s *catalogService) GetLiveSearchSettings() (*models.SearchSettings, error) {
+	settings, err := s.liveSearchSettings()
+	if err != nil || settings.Version == 0 {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *catalogService) GetSearchSettingsVersions() ([]*models.SearchSettings, error) {
+	versions, err := s.searchSettingsRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get search settings versions: %w", err)
+	}
+	return versions, nil
+}
+
+func (s *catalogService) GetSearchSettingsVersion(version int) (*models.SearchSettings, error) {
+	settings, err := s.searchSettingsRepo.GetByVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get search settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *catalogService) GetSearchSettingsDraft() (*models.SearchSettings, error) {
+	settings, err := s.searchSettingsRepo.GetDraft()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get search settings draft: %w", err)
+	}
+	return settings, nil
+}
+
+// SaveSearchSettingsDraft replaces the draft, which searches only apply
+// once it is published
+func (s *catalogService) SaveSearchSettingsDraft(req *models.SearchSettingsRequest, actor string) (*models.SearchSettings, error) {
+	settings, err := normalizeSearchSettings(req)
+	if err != nil {
+		return nil, err
+	}
+	settings.CreatedBy = actor
+
+	if err := s.searchSettingsRepo.SaveDraft(settings); err != nil {
+		return nil, fmt.Errorf("failed to save search settings draft: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *catalogService) DiscardSearchSettingsDraft() error {
+	return s.searchSettingsRepo.DeleteDraft()
+}
+
+// RestoreSearchSettings copies an earlier version into the draft, from where
+// it can be previewed and published again
+func (s *catalogService) RestoreSearchSettings(version int, actor string) (*models.SearchSettings, error) {
+	previous, err := s.searchSettingsRepo.GetByVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get search settings: %w", err)
+	}
+	if previous == nil {
+		return nil, &SearchSettingsError{Message: "search settings version not found", NotFound: true}
+	}
+	if previous.Status == models.SearchSettingsStatusDraft {
+		return previous, nil
+	}
+
+	settings := &models.SearchSettings{
+		Synonyms:  previous.Synonyms,
+		Stopwords: previous.Stopwords,
+		Rules:     previous.Rules,
+		CreatedBy: actor,
+	}
+	if err := s.searchSettingsRepo.SaveDraft(settings); err != nil {
+		return nil, fmt.Errorf("failed to save search settings draft: %w", err)
+	}
+	return settings, nil
+}
+
+// PublishSearchSettings makes the draft live. Other instances pick it up
+// once their cached copy of the previous version expires; the search
+// analyzer of the index is updated in the background.
+func (s *catalogService) PublishSearchSettings(actor string) (*models.SearchSettings, error) {
+	settings, err := s.searchSettingsRepo.PublishDraft(actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish search settings: %w", err)
+	}
+	if settings == nil {
+		return nil, &SearchSettingsError{Message: "search settings draft not found", NotFound: true}
+	}
+
+	s.cacheDelete(searchSettingsCacheKey)
+
+	go func() {
+		if err := s.syncSearchAnalyzer(settings); err != nil {
+			log.Printf("Failed to apply search settings version %d to the search index: %v", settings.Version, err)
+		}
+	}()
+
+	return settings, nil
+}
+
+// syncSearchAnalyzer puts the synonyms and stopwords of the settings into
+// the search analyzer of the Elasticsearch index, so that searches served
+// from the index read queries the same way. Analyzers can only be changed on
+// a closed index, so the index is closed for the moment it takes.
+func (s *catalogService) syncSearchAnalyzer(settings *models.SearchSettings) error {
+	body, err := json.Marshal(searchAnalyzerSettings(settings))
+	if err != nil {
+		return fmt.Errorf("failed to marshal analyzer settings: %w", err)
+	}
+
+	index := s.config.ElasticsearchIndex
+	res, err := s.esClient.Indices.Close([]string{index})
+	if err := esError(res, err, "close index"); err != nil {
+		return err
+	}
+
+	res, err = s.esClient.Indices.PutSettings(bytes.NewReader(body), s.esClient.Indices.PutSettings.WithIndex(index))
+	putErr := esError(res, err, "update analyzer")
+
+	// Reopen the index even when the update failed, with the analyzer it had
+	res, err = s.esClient.Indices.Open([]string{index})
+	if err := esError(res, err, "open index"); err != nil {
+		return err
+	}
+
+	return putErr
+}
+
+// esError turns a failed Elasticsearch request into an error and closes the
+// response body
+func esError(res *esapi.Response, err error, action string) error {
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch failed to %s: %s", action, res.String())
+	}
+	return nil
+}
+
+// searchAnalyzerSettings returns index settings that redefine the search
+// analyzer with the stopwords and synonym sets of the settings. Terms are
+// written in the Solr synonym format, so commas within a term are escaped.
+func searchAnalyzerSettings(settings *models.SearchSettings) map[string]interface{} {
+	synonyms := []string{}
+	for _, set := range settings.Synonyms {
+		terms := make([]string, len(set.Terms))
+		for i, term := range set.Terms {
+			terms[i] = strings.ReplaceAll(term, ",", `\,`)
+		}
+		synonyms = append(synonyms, strings.Join(terms, ", "))
+	}
+
+	stopwords := settings.Stopwords
+	if len(stopwords) == 0 {
+		stopwords = []string{"_none_"}
+	}
+
+	return map[string]interface{}{
+		"analysis": map[string]interface{}{
+			"filter": map[string]interface{}{
+				"catalog_stopwords": map[string]interface{}{
+					"type":      "stop",
+					"stopwords": stopwords,
+				},
+				"catalog_synonyms": map[string]interface{}{
+					"type":     "synonym_graph",
+					"synonyms": synonyms,
+				},
+			},
+			"analyzer": map[string]interface{}{
+				"search_analyzer": map[string]interface{}{
+					"type":      "custom",
+					"tokenizer": "standard",
+					"filter":    []string{"lowercase", "turkish_stop", "catalog_stopwords", "synonym_filter", "catalog_synonyms"},
+				},
+			},
+		},
+	}
+}
+
+// PreviewSearch runs a search with a version of the settings, the draft when
+// version is nil, as it would run at the given time
+func (s *catalogService) PreviewSearch(req *models.SearchRequest, version *int, at time.Time) (*models.SearchPreview, error) {
+	var settings *models.SearchSettings
+	var err error
+	if version != nil {
+		settings, err = s.searchSettingsRepo.GetByVersion(*version)
+	} else {
+		settings, err = s.searchSettingsRepo.GetDraft()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get search settings: %w", err)
+	}
+	if settings == nil {
+		if version != nil {
+			return nil, &SearchSettingsError{Message: "search settings version not found", NotFound: true}
+		}
+		return nil, &SearchSettingsError{Message: "search settings draft not found", NotFound: true}
+	}
+
+	rules := applySearchSettings(req, settings, at)
+	results, err := s.search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.SearchPreview{
+		Version: settings.Version,
+		Terms:   req.QueryTerms,
+		Rules:   rules,
+		Results: results,
+	}
+	if preview.Terms == nil {
+		preview.Terms = [][]string{}
+	}
+	return preview, nil
+}
+
+// liveSearchSettings returns the live settings, or empty settings of version
+// 0 before any version has been published. Both are cached, so that searches
+// do not each read the settings.
+func (s *catalogService) liveSearchSettings() (*models.SearchSettings, error) {
+	var settings *models.SearchSettings
+	if s.cacheGet(searchSettingsCacheKey, "", s.config.CacheSearchSettingsTTL, &settings) && settings != nil {
+		return settings, nil
+	}
+
+	settings, err := s.searchSettingsRepo.GetLive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get search settings: %w", err)
+	}
+	if settings == nil {
+		settings = &models.SearchSettings{}
+	}
+
+	s.cacheSet(searchSettingsCacheKey, "", s.config.CacheSearchSettingsTTL, settings)
+	return settings, nil
+}
+
+// normalizeSearchSettings lower-cases and tidies the terms of a request and
+// checks that every rule says where it applies and what it moves
+func normalizeSearchSettings(req *models.SearchSettingsRequest) (*models.SearchSettings, error) {
+	settings := &models.SearchSettings{
+		Synonyms:  models.SynonymSets{},
+		Stopwords: []string{},
+		Rules:     models.MerchandisingRules{},
+	}
+
+	for i, set := range req.Synonyms {
+		var terms []string
+		seen := make(map[string]bool)
+		for _, term := range set.Terms {
+			term = strings.Join(searchWords(term), " ")
+			if term == "" || seen[term] {
+				continue
+			}
+			seen[term] = true
+			terms = append(terms, term)
+		}
+		if len(terms) < 2 {
+			return nil, &SearchSettingsError{Message: fmt.Sprintf("synonym set %d needs at least two different terms", i+1)}
+		}
+		settings.Synonyms = append(settings.Synonyms, models.SynonymSet{Terms: terms})
+	}
+
+	seen := make(map[string]bool)
+	for _, stopword := range req.Stopwords {
+		words := searchWords(stopword)
+		if len(words) != 1 {
+			return nil, &SearchSettingsError{Message: fmt.Sprintf("stopword %q must be a single word", stopword)}
+		}
+		if !seen[words[0]] {
+			seen[words[0]] = true
+			settings.Stopwords = append(settings.Stopwords, words[0])
+		}
+	}
+
+	for _, rule := range req.Rules {
+		if rule.Query != nil {
+			query := strings.Join(searchWords(*rule.Query), " ")
+			if query == "" {
+				rule.Query = nil
+			} else {
+				rule.Query = &query
+			}
+		}
+		if rule.Query == nil && rule.CategoryID == nil {
+			return nil, &SearchSettingsError{Message: fmt.Sprintf("rule %s needs a query or a category", rule.Name)}
+		}
+		if len(rule.ProductIDs) == 0 && len(rule.Brands) == 0 {
+			return nil, &SearchSettingsError{Message: fmt.Sprintf("rule %s needs products or brands", rule.Name)}
+		}
+		if rule.StartsAt != nil && rule.EndsAt != nil && !rule.EndsAt.After(*rule.StartsAt) {
+			return nil, &SearchSettingsError{Message: fmt.Sprintf("rule %s must end after it starts", rule.Name)}
+		}
+		for i, brand := range rule.Brands {
+			rule.Brands[i] = strings.TrimSpace(brand)
+		}
+		settings.Rules = append(settings.Rules, rule)
+	}
+
+	return settings, nil
+}
+
+// applySearchSettings rewrites the query of req into term groups, dropping
+// stopwords and adding synonyms, and ranks products by the merchandising
+// rules that apply to the search at the given time. It returns those rules.
+// Rules only rank relevance-sorted searches; shoppers who pick another sort
+// get exactly that order.
+func applySearchSettings(req *models.SearchRequest, settings *models.SearchSettings, at time.Time) []models.MerchandisingRule {
+	synonyms := make(map[string][]string)
+	for _, set := range settings.Synonyms {
+		for _, term := range set.Terms {
+			synonyms[term] = appendNew(synonyms[term], set.Terms...)
+		}
+	}
+
+	var canonical string
+	req.QueryTerms, canonical = analyzeQuery(req.Query, synonyms, settings.Stopwords)
+
+	rules := []models.MerchandisingRule{}
+	for _, rule := range settings.Rules {
+		if rule.StartsAt != nil && at.Before(*rule.StartsAt) {
+			continue
+		}
+		if rule.EndsAt != nil && !at.Before(*rule.EndsAt) {
+			continue
+		}
+		if rule.CategoryID != nil && (req.CategoryID == nil || *req.CategoryID != *rule.CategoryID) {
+			continue
+		}
+		if rule.Query != nil {
+			if _, ruleCanonical := analyzeQuery(*rule.Query, synonyms, settings.Stopwords); ruleCanonical != canonical {
+				continue
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	if req.SortBy == "relevance" {
+		req.Ranking = rankTiers(rules)
+	}
+	return rules
+}
+
+// analyzeQuery splits a query into groups of terms that each have to match:
+// one group per word that is not a stopword, holding the word and its
+// synonyms. A query that is all stopwords keeps them. A query that is a
+// multi-word synonym as a whole becomes a single group. It also returns the
+// query with each word, or the whole query, replaced by the first term of
+// its synonym set, by which rules recognize their query among its synonyms.
+func analyzeQuery(query string, synonyms map[string][]string, stopwords []string) ([][]string, string) {
+	words := searchWords(query)
+	if len(words) == 0 {
+		return nil, ""
+	}
+
+	if terms, ok := synonyms[strings.Join(words, " ")]; ok && len(words) > 1 {
+		return [][]string{terms}, terms[0]
+	}
+
+	stopword := make(map[string]bool, len(stopwords))
+	for _, word := range stopwords {
+		stopword[word] = true
+	}
+	var kept []string
+	for _, word := range words {
+		if !stopword[word] {
+			kept = append(kept, word)
+		}
+	}
+	if len(kept) > 0 {
+		words = kept
+	}
+
+	groups := make([][]string, len(words))
+	canonical := make([]string, len(words))
+	for i, word := range words {
+		groups[i] = []string{word}
+		canonical[i] = word
+		if terms, ok := synonyms[word]; ok {
+			groups[i] = appendNew(groups[i], terms...)
+			canonical[i] = terms[0]
+		}
+	}
+
+	return groups, strings.Join(canonical, " ")
+}
+
+// rankTiers turns the rules that apply to a search into ranking tiers:
+// pinned products one by one in the order they are listed, then pinned
+// brands and boosted products ahead of the other results, and buried
+// products after them
+func rankTiers(rules []models.MerchandisingRule) []*models.RankTier {
+	var ahead, behind []*models.RankTier
+	for _, action := range []models.MerchandisingAction{models.MerchandisingActionPin, models.MerchandisingActionBoost, models.MerchandisingActionBury} {
+		for _, rule := range rules {
+			if rule.Action != action {
+				continue
+			}
+
+			brands := make([]string, len(rule.Brands))
+			for i, brand := range rule.Brands {
+				brands[i] = strings.ToLower(brand)
+			}
+
+			switch action {
+			case models.MerchandisingActionPin:
+				for _, id := range rule.ProductIDs {
+					ahead = append(ahead, &models.RankTier{ProductIDs: []uuid.UUID{id}, Brands: []string{}})
+				}
+				if len(brands) > 0 {
+					ahead = append(ahead, &models.RankTier{ProductIDs: []uuid.UUID{}, Brands: brands})
+				}
+			case models.MerchandisingActionBoost:
+				ahead = append(ahead, &models.RankTier{ProductIDs: rule.ProductIDs, Brands: brands})
+			case models.MerchandisingActionBury:
+				behind = append(behind, &models.RankTier{ProductIDs: rule.ProductIDs, Brands: brands})
+			}
+		}
+	}
+
+	for i, tier := range ahead {
+		tier.Rank = i - len(ahead)
+	}
+	for i, tier := range behind {
+		tier.Rank = i + 1
+	}
+	return append(ahead, behind...)
+}
+
+// productRank is the tier a product is in, matching it the way the search
+// query does
+func productRank(tiers []*models.RankTier, product *models.Product) int {
+	brand := ""
+	if product.Brand != nil {
+		brand = strings.ToLower(*product.Brand)
+	}
+
+	for _, tier := range tiers {
+		for _, id := range tier.ProductIDs {
+			if id == product.ID {
+				return tier.Rank
+			}
+		}
+		for _, tierBrand := range tier.Brands {
+			if brand != "" && tierBrand == brand {
+				return tier.Rank
+			}
+		}
+	}
+	return 0
+}
+
+// searchWords lower-cases text and splits it into words, trimming the
+// punctuation around them
+func searchWords(text string) []string {
+	var words []string
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		word := strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// appendNew appends the values that are not in list yet
+func appendNew(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+// LocationError reports a seller location or delivery zone that cannot be stored
+type LocationError struct {
+	Message string
+}
+
+func (e *LocationError) Error() string {
+	return e.Message
+}
+
+// Seller location operations
+func (s *catalogService) GetSellerLocations(sellerID uuid.UUID) ([]*models.SellerLocation, error) {
+	locations, err := s.locationRepo.GetLocationsBySeller(sellerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller locations: %w", err)
+	}
+
+	locationIDs := make([]uuid.UUID, len(locations))
+	byID := make(map[uuid.UUID]*models.SellerLocation, len(locations))
+	for i, location := range locations {
+		location.Zones = []*models.DeliveryZone{}
+		locationIDs[i] = location.ID
+		byID[location.ID] = location
+	}
+
+	zones, err := s.locationRepo.GetZonesByLocationIDs(locationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery zones: %w", err)
+	}
+	for _, zone := range zones {
+		location := byID[zone.LocationID]
+		location.Zones = append(location.Zones, zone)
+	}
+
+	return locations, nil
+}
+
+func (s *catalogService) CreateSellerLocation(sellerID uuid.UUID, req *models.SellerLocationRequest) (*models.SellerLocation, error) {
+	location := &models.SellerLocation{
+		ID:       uuid.New(),
+		SellerID: sellerID,
+		IsActive: true,
+		Zones:    []*models.DeliveryZone{},
+	}
+	applyLocationRequest(location, req)
+
+	if err := s.locationRepo.CreateLocation(location); err != nil {
+		return nil, fmt.Errorf("failed to create seller location: %w", err)
+	}
+
+	return location, nil
+}
+
+func (s *catalogService) UpdateSellerLocation(sellerID uuid.UUID, locationID uuid.UUID, req *models.SellerLocationRequest) (*models.SellerLocation, error) {
+	location, err := s.sellerLocation(sellerID, locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	location.IsActive = true
+	applyLocationRequest(location, req)
+
+	if err := s.locationRepo.UpdateLocation(location); err != nil {
+		return nil, fmt.Errorf("failed to update seller location: %w", err)
+	}
+
+	zones, err := s.locationRepo.GetZonesByLocationIDs([]uuid.UUID{location.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery zones: %w", err)
+	}
+	location.Zones = zones
+
+	return location, nil
+}
+
+func (s *catalogService) DeleteSellerLocation(sellerID uuid.UUID, locationID uuid.UUID) error {
+	if _, err := s.sellerLocation(sellerID, locationID); err != nil {
+		return err
+	}
+
+	if err := s.locationRepo.DeleteLocation(locationID); err != nil {
+		return fmt.Errorf("failed to delete seller location: %w", err)
+	}
+
+	return nil
+}
+
+func (s *catalogService) CreateDeliveryZone(sellerID uuid.UUID, locationID uuid.UUID, req *models.DeliveryZoneRequest) (*models.DeliveryZone, error) {
+	if _, err := s.sellerLocation(sellerID, locationID); err != nil {
+		return nil, err
+	}
+
+	zone := &models.DeliveryZone{
+		ID:         uuid.New(),
+		LocationID: locationID,
+		IsActive:   true,
+	}
+	if err := applyZoneRequest(zone, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.locationRepo.CreateZone(zone); err != nil {
+		return nil, fmt.Errorf("failed to create delivery zone: %w", err)
+	}
+
+	return zone, nil
+}
+
+func (s *catalogService) UpdateDeliveryZone(sellerID uuid.UUID, locationID uuid.UUID, zoneID uuid.UUID, req *models.DeliveryZoneRequest) (*models.DeliveryZone, error) {
+	zone, err := s.deliveryZone(sellerID, locationID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	zone.IsActive = true
+	if err := applyZoneRequest(zone, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.locationRepo.UpdateZone(zone); err != nil {
+		return nil, fmt.Errorf("failed to update delivery zone: %w", err)
+	}
+
+	return zone, nil
+}
+
+func (s *catalogService) DeleteDeliveryZone(sellerID uuid.UUID, locationID uuid.UUID, zoneID uuid.UUID) error {
+	if _, err := s.deliveryZone(sellerID, locationID, zoneID); err != nil {
+		return err
+	}
+
+	if err := s.locationRepo.DeleteZone(zoneID); err != nil {
+		return fmt.Errorf("failed to delete delivery zone: %w", err)
+	}
+
+	return nil
+}
+
+// sellerLocation returns a location of the seller. Other sellers' locations
+// are reported as not found.
+func (s *catalogService) sellerLocation(sellerID uuid.UUID, locationID uuid.UUID) (*models.SellerLocation, error) {
+	location, err := s.locationRepo.GetLocation(locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller location: %w", err)
+	}
+	if location == nil || location.SellerID != sellerID {
+		return nil, fmt.Errorf("seller location not found")
+	}
+	return location, nil
+}
+
+// deliveryZone returns a zone of the seller's location
+func (s *catalogService) deliveryZone(sellerID uuid.UUID, locationID uuid.UUID, zoneID uuid.UUID) (*models.DeliveryZone, error) {
+	if _, err := s.sellerLocation(sellerID, locationID); err != nil {
+		return nil, err
+	}
+
+	zone, err := s.locationRepo.GetZone(zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery zone: %w", err)
+	}
+	if zone == nil || zone.LocationID != locationID {
+		return nil, fmt.Errorf("delivery zone not found")
+	}
+	return zone, nil
+}
+
+func applyLocationRequest(location *models.SellerLocation, req *models.SellerLocationRequest) {
+	location.Name = req.Name
+	location.Address = req.Address
+	location.Latitude = *req.Latitude
+	location.Longitude = *req.Longitude
+	if req.IsActive != nil {
+		location.IsActive = *req.IsActive
+	}
+}
+
+// applyZoneRequest checks that a zone request describes exactly one shape
+// and stores it on zone
+func applyZoneRequest(zone *models.DeliveryZone, req *models.DeliveryZoneRequest) error {
+	switch req.Type {
+	case models.DeliveryZoneTypeRadius:
+		if req.RadiusKm == nil {
+			return &LocationError{Message: "radius zones require radius_km"}
+		}
+		if len(req.Polygon) > 0 {
+			return &LocationError{Message: "radius zones cannot have a polygon"}
+		}
+	case models.DeliveryZoneTypePolygon:
+		if req.RadiusKm != nil {
+			return &LocationError{Message: "polygon zones cannot have radius_km"}
+		}
+		if err := validatePolygon(req.Polygon); err != nil {
+			return err
+		}
+	default:
+		return &LocationError{Message: fmt.Sprintf("unknown zone type %s", req.Type)}
+	}
+
+	zone.Name = req.Name
+	zone.Type = req.Type
+	zone.RadiusKm = req.RadiusKm
+	zone.Polygon = nil
+	if req.Type == models.DeliveryZoneTypePolygon {
+		zone.Polygon = req.Polygon
+	}
+	if req.IsActive != nil {
+		zone.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// validatePolygon requires at least three distinct vertices with no vertex
+// repeated next to itself. A repeated first vertex at the end is allowed.
+func validatePolygon(polygon models.GeoPolygon) error {
+	vertices := polygon
+	if len(vertices) > 1 && vertices[0] == vertices[len(vertices)-1] {
+		vertices = vertices[:len(vertices)-1]
+	}
+
+	if len(vertices) < 3 {
+		return &LocationError{Message: "polygon zones require at least 3 vertices"}
+	}
+
+	for i, vertex := range vertices {
+		if vertex == vertices[(i+1)%len(vertices)] {
+			return &LocationError{Message: fmt.Sprintf("polygon vertex %d repeats the one before it", (i+1)%len(vertices))}
+		}
+	}
+
+	return nil
+}
+
+// attachOffers sets the offers of the sellers delivering to point on each
+// product. Products keep no offers when none of their sellers deliver there.
+func (s *catalogService) attachOffers(products []*models.Product, point models.GeoPoint) error {
+	productIDs := make([]uuid.UUID, len(products))
+	byID := make(map[uuid.UUID]*models.Product, len(products))
+	for i, product := range products {
+		productIDs[i] = product.ID
+		byID[product.ID] = product
+	}
+
+	offers, err := s.locationRepo.GetOffers(productIDs, point)
+	if err != nil {
+		return fmt.Errorf("failed to get seller offers: %w", err)
+	}
+
+	for _, offer := range offers {
+		if product, ok := byID[offer.ProductID]; ok {
+			product.Offers = append(product.Offers, offer)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// defaultStockAlertPollInterval is used when STOCK_ALERT_POLL_INTERVAL is
+// not a positive duration
+const defaultStockAlertPollInterval = 5 * time.Second
+
+// stockAlertBatchSize is the number of pending alerts published at a time
+const stockAlertBatchSize = 100
+
+// StartStockAlertPublisher publishes the stock alerts that database triggers
+// record whenever a product, variant or seller listing runs low or out of
+// stock, whichever path changed it
+func (s *catalogService) StartStockAlertPublisher() {
+	interval := s.config.StockAlertPollInterval
+	if interval <= 0 {
+		log.Printf("Invalid stock alert poll interval %s, using %s", interval, defaultStockAlertPollInterval)
+		interval = defaultStockAlertPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Println("Starting stock alert publisher...")
+
+	for range ticker.C {
+		s.publishPendingStockAlerts()
+	}
+}
+
+func (s *catalogService) publishPendingStockAlerts() {
+	for {
+		published, err := s.productRepo.PublishStockAlerts(stockAlertBatchSize, s.publishStockAlerts)
+		if err != nil {
+			log.Printf("Failed to publish stock alerts: %v", err)
+			return
+		}
+		if published < stockAlertBatchSize {
+			return
+		}
+	}
+}
+
+// applyStockVisibility hides a seller listing while it has no stock and
+// restores it once restocked, unless the seller set visibility explicitly
+func applyStockVisibility(existing *models.SellerProduct, sellerProduct *models.SellerProduct, req *models.SellerProductRequest) {
+	if sellerProduct.Stock == nil {
+		return
+	}
+
+	if *sellerProduct.Stock <= 0 {
+		if sellerProduct.IsVisible {
+			sellerProduct.IsVisible = false
+			sellerProduct.HiddenByStock = true
+		} else if existing != nil && existing.HiddenByStock && req.IsVisible == nil {
+			sellerProduct.HiddenByStock = true
+		}
+		return
+	}
+
+	if existing != nil && existing.HiddenByStock && req.IsVisible == nil {
+		sellerProduct.IsVisible = true
+	}
+}
+
+// findSellerProduct returns the seller's current listing for a product/variant pair
+func (s *catalogService) findSellerProduct(sellerID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID) (*models.SellerProduct, error) {
+	sellerData, err := s.productRepo.GetSellerData(productID, &sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sp := range sellerData {
+		if variantID == nil && sp.VariantID == nil {
+			return sp, nil
+		}
+		if variantID != nil && sp.VariantID != nil && *sp.VariantID == *variantID {
+			return sp, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// publishStockAlerts sends alerts to the low stock or the depleted topic
+func (s *catalogService) publishStockAlerts(events []*models.StockAlertEvent) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		topic := s.config.KafkaStockLowTopic
+		if event.State == models.StockStateDepleted {
+			topic = s.config.KafkaStockDepletedTopic
+		}
+		event.EventType = topic
+		event.Timestamp = event.Timestamp.UTC()
+
+		eventJSON, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal stock alert event: %w", err)
+		}
+
+		messages = append(messages, kafka.Message{
+			Topic: topic,
+			Key:   []byte(event.ProductID.String()),
+			Value: eventJSON,
+		})
+	}
+
+	if err := s.kafkaWriter.WriteMessages(context.Background(), messages...); err != nil {
+		return fmt.Errorf("failed to write stock alert events: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+)
+
+// TranslationError reports a translation for a locale that cannot be translated to
+type TranslationError struct {
+	Message string
+}
+
+func (e *TranslationError) Error() string {
+	return e.Message
+}
+
+// Translation operations
+func (s *catalogService) GetProductTranslations(productID uuid.UUID) (*models.ProductTranslations, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	translations, err := s.translationRepo.GetProductTranslations([]uuid.UUID{productID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product translations: %w", err)
+	}
+
+	variants, err := s.productRepo.GetVariants(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variants: %w", err)
+	}
+	variantTranslations, err := s.translationRepo.GetVariantTranslations(variantIDs(variants))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant translations: %w", err)
+	}
+
+	return &models.ProductTranslations{
+		DefaultLocale: s.config.DefaultLocale,
+		Product:       translations,
+		Variants:      variantTranslations,
+	}, nil
+}
+
+func (s *catalogService) SetProductTranslation(productID uuid.UUID, locale string, req *models.TranslationRequest) (*models.ProductTranslation, error) {
+	locale, err := s.translationLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	translation := &models.ProductTranslation{
+		ProductID:   productID,
+		Locale:      locale,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	err = s.translationRepo.UpsertProductTranslation(translation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save product translation: %w", err)
+	}
+
+	s.refreshProduct(productID)
+
+	return translation, nil
+}
+
+func (s *catalogService) DeleteProductTranslation(productID uuid.UUID, locale string) error {
+	err := s.translationRepo.DeleteProductTranslation(productID, strings.ToLower(locale))
+	if err != nil {
+		return err
+	}
+
+	s.refreshProduct(productID)
+
+	return nil
+}
+
+func (s *catalogService) SetVariantTranslation(productID uuid.UUID, variantID uuid.UUID, locale string, req *models.TranslationRequest) (*models.VariantTranslation, error) {
+	locale, err := s.translationLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := s.productRepo.GetVariant(variantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant: %w", err)
+	}
+	if variant == nil || variant.ProductID != productID {
+		return nil, fmt.Errorf("variant not found")
+	}
+
+	translation := &models.VariantTranslation{
+		VariantID: variantID,
+		Locale:    locale,
+		Name:      req.Name,
+	}
+
+	err = s.translationRepo.UpsertVariantTranslation(translation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save variant translation: %w", err)
+	}
+
+	s.refreshProduct(productID)
+
+	return translation, nil
+}
+
+func (s *catalogService) DeleteVariantTranslation(productID uuid.UUID, variantID uuid.UUID, locale string) error {
+	variant, err := s.productRepo.GetVariant(variantID)
+	if err != nil {
+		return fmt.Errorf("failed to get variant: %w", err)
+	}
+	if variant == nil || variant.ProductID != productID {
+		return fmt.Errorf("variant not found")
+	}
+
+	err = s.translationRepo.DeleteVariantTranslation(variantID, strings.ToLower(locale))
+	if err != nil {
+		return err
+	}
+
+	s.refreshProduct(productID)
+
+	return nil
+}
+
+func (s *catalogService) GetCategoryTranslations(categoryID uuid.UUID) ([]*models.CategoryTranslation, error) {
+	category, err := s.categoryRepo.GetByID(categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	if category == nil {
+		return nil, fmt.Errorf("category not found")
+	}
+
+	translations, err := s.translationRepo.GetCategoryTranslations([]uuid.UUID{categoryID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category translations: %w", err)
+	}
+
+	return translations, nil
+}
+
+func (s *catalogService) SetCategoryTranslation(categoryID uuid.UUID, locale string, req *models.TranslationRequest) (*models.CategoryTranslation, error) {
+	locale, err := s.translationLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := s.categoryRepo.GetByID(categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	if category == nil {
+		return nil, fmt.Errorf("category not found")
+	}
+
+	translation := &models.CategoryTranslation{
+		CategoryID:  categoryID,
+		Locale:      locale,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	err = s.translationRepo.UpsertCategoryTranslation(translation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save category translation: %w", err)
+	}
+
+	return translation, nil
+}
+
+func (s *catalogService) DeleteCategoryTranslation(categoryID uuid.UUID, locale string) error {
+	return s.translationRepo.DeleteCategoryTranslation(categoryID, strings.ToLower(locale))
+}
+
+// translationLocale checks that content can be translated to a locale. The
+// default locale is not a translation: its text lives on the entity itself.
+func (s *catalogService) translationLocale(locale string) (string, error) {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if locale == s.config.DefaultLocale {
+		return "", &TranslationError{Message: fmt.Sprintf("%s is the default locale; update the text on the entity itself", locale)}
+	}
+	if !s.isSupportedLocale(locale) {
+		return "", &TranslationError{Message: fmt.Sprintf("locale %s is not supported (supported: %s)", locale, strings.Join(s.config.SupportedLocales, ", "))}
+	}
+	return locale, nil
+}
+
+func (s *catalogService) isSupportedLocale(locale string) bool {
+	if locale == s.config.DefaultLocale {
+		return true
+	}
+	for _, supported := range s.config.SupportedLocales {
+		if supported == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateLocales turns an Accept-Language header into the chain of
+// supported locales to read content in, most preferred first. A regional
+// tag falls back to its language (en-GB to en), and the chain always ends
+// with the default locale.
+func (s *catalogService) NegotiateLocales(acceptLanguage string) []string {
+	type weightedTag struct {
+		tag    string
+		weight float64
+	}
+
+	var tags []weightedTag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		weight := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if q, ok := strings.CutPrefix(param, "q="); ok {
+				if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+					weight = parsed
+				}
+			}
+		}
+		if weight <= 0 {
+			continue
+		}
+		tags = append(tags, weightedTag{tag: strings.ReplaceAll(tag, "_", "-"), weight: weight})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].weight > tags[j].weight })
+
+	var locales []string
+	add := func(locale string) {
+		if !s.isSupportedLocale(locale) {
+			return
+		}
+		for _, l := range locales {
+			if l == locale {
+				return
+			}
+		}
+		locales = append(locales, locale)
+	}
+
+	for _, t := range tags {
+		add(t.tag)
+		if language, _, ok := strings.Cut(t.tag, "-"); ok {
+			add(language)
+		}
+	}
+	add(s.config.DefaultLocale)
+
+	return locales
+}
+
+// LocalizeProducts rewrites the names and descriptions of products, their
+// variants and categories to the first locale of the chain each one is
+// translated to. Products keep their default text when translations cannot
+// be loaded.
+func (s *catalogService) LocalizeProducts(products []*models.Product, locales []string) {
+	if len(products) == 0 || s.isDefaultChain(locales) {
+		return
+	}
+
+	var productIDs []uuid.UUID
+	var variants []*models.ProductVariant
+	var categories []*models.Category
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID)
+		variants = append(variants, product.Variants...)
+		if product.Category != nil {
+			categories = append(categories, product.Category)
+		}
+	}
+
+	translations, err := s.translationRepo.GetProductTranslations(productIDs)
+	if err != nil {
+		log.Printf("Failed to load product translations: %v", err)
+		return
+	}
+	byProduct := make(map[uuid.UUID]map[string]*models.ProductTranslation)
+	for _, t := range translations {
+		if byProduct[t.ProductID] == nil {
+			byProduct[t.ProductID] = make(map[string]*models.ProductTranslation)
+		}
+		byProduct[t.ProductID][t.Locale] = t
+	}
+
+	variantTranslations, err := s.translationRepo.GetVariantTranslations(variantIDs(variants))
+	if err != nil {
+		log.Printf("Failed to load variant translations: %v", err)
+		return
+	}
+	byVariant := make(map[uuid.UUID]map[string]string)
+	for _, t := range variantTranslations {
+		if byVariant[t.VariantID] == nil {
+			byVariant[t.VariantID] = make(map[string]string)
+		}
+		byVariant[t.VariantID][t.Locale] = t.Name
+	}
+
+	s.LocalizeCategories(categories, locales)
+
+	for _, product := range products {
+		productTranslations := byProduct[product.ID]
+		names := make(map[string]string)
+		descriptions := make(map[string]string)
+		for locale, t := range productTranslations {
+			names[locale] = t.Name
+			if t.Description != nil {
+				descriptions[locale] = *t.Description
+			}
+		}
+
+		name, locale := s.localizedText(product.Name, names, locales)
+		product.Name = name
+		product.Locale = locale
+		if product.Description != nil {
+			description, _ := s.localizedText(*product.Description, descriptions, locales)
+			product.Description = &description
+		} else if description, locale := s.localizedText("", descriptions, locales); locale != s.config.DefaultLocale {
+			product.Description = &description
+		}
+
+		for _, variant := range product.Variants {
+			variant.Name, _ = s.localizedText(variant.Name, byVariant[variant.ID], locales)
+		}
+	}
+}
+
+// LocalizeCategories rewrites the names and descriptions of categories and
+// their children to the first locale of the chain each one is translated to
+func (s *catalogService) LocalizeCategories(categories []*models.Category, locales []string) {
+	if len(categories) == 0 || s.isDefaultChain(locales) {
+		return
+	}
+
+	var all []*models.Category
+	var collect func(categories []*models.Category)
+	collect = func(categories []*models.Category) {
+		for _, category := range categories {
+			all = append(all, category)
+			collect(category.Children)
+		}
+	}
+	collect(categories)
+
+	categoryIDs := make([]uuid.UUID, len(all))
+	for i, category := range all {
+		categoryIDs[i] = category.ID
+	}
+
+	translations, err := s.translationRepo.GetCategoryTranslations(categoryIDs)
+	if err != nil {
+		log.Printf("Failed to load category translations: %v", err)
+		return
+	}
+	names := make(map[uuid.UUID]map[string]string)
+	descriptions := make(map[uuid.UUID]map[string]string)
+	for _, t := range translations {
+		if names[t.CategoryID] == nil {
+			names[t.CategoryID] = make(map[string]string)
+			descriptions[t.CategoryID] = make(map[string]string)
+		}
+		names[t.CategoryID][t.Locale] = t.Name
+		if t.Description != nil {
+			descriptions[t.CategoryID][t.Locale] = *t.Description
+		}
+	}
+
+	for _, category := range all {
+		category.Name, category.Locale = s.localizedText(category.Name, names[category.ID], locales)
+		if category.Description != nil {
+			description, _ := s.localizedText(*category.Description, descriptions[category.ID], locales)
+			category.Description = &description
+		} else if description, locale := s.localizedText("", descriptions[category.ID], locales); locale != s.config.DefaultLocale {
+			category.Description = &description
+		}
+	}
+}
+
+// localizedText returns the text of the first locale in the chain that has
+// one, and that locale. The default locale's text is the stored value.
+func (s *catalogService) localizedText(value string, translations map[string]string, locales []string) (string, string) {
+	for _, locale := range locales {
+		if locale == s.config.DefaultLocale {
+			return value, locale
+		}
+		if text, ok := translations[locale]; ok && text != "" {
+			return text, locale
+		}
+	}
+	return value, s.config.DefaultLocale
+}
+
+// isDefaultChain reports whether a locale chain reads the stored text only
+func (s *catalogService) isDefaultChain(locales []string) bool {
+	return len(locales) == 0 || locales[0] == s.config.DefaultLocale
+}
+
+func variantIDs(variants []*models.ProductVariant) []uuid.UUID {
+	ids := make([]uuid.UUID, len(variants))
+	for i, variant := range variants {
+		ids[i] = variant.ID
+	}
+	return ids
+}
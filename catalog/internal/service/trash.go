@@ -0,0 +1,230 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/models"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// TrashError reports a deleted product or category that cannot be restored yet
+type TrashError struct {
+	Message string
+}
+
+func (e *TrashError) Error() string {
+	return e.Message
+}
+
+// Trash operations
+func (s *catalogService) GetDeletedProducts(page int, limit int) (*models.DeletedProductListResponse, error) {
+	products, total, err := s.productRepo.GetDeleted(page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted products: %w", err)
+	}
+
+	totalPages := int(total) / limit
+	if int(total)%limit > 0 {
+		totalPages++
+	}
+
+	return &models.DeletedProductListResponse{
+		Products:   products,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// RestoreProduct takes a product out of the trash. Its category has to be
+// restored first.
+func (s *catalogService) RestoreProduct(id uuid.UUID, actor string) (*models.Product, error) {
+	deleted, err := s.productRepo.GetDeletedByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if deleted == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+	if deleted.MergedInto != nil {
+		return nil, &TrashError{Message: fmt.Sprintf("product was merged into product %s", deleted.MergedInto)}
+	}
+
+	category, err := s.categoryRepo.GetByID(deleted.CategoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	if category == nil {
+		return nil, &TrashError{Message: "product category is deleted"}
+	}
+
+	if err := s.productRepo.Undelete(id); err != nil {
+		if err.Error() == "product not found" {
+			return nil, err
+		}
+		if err.Error() == "product SKU or barcode is used by another product" {
+			return nil, &TrashError{Message: err.Error()}
+		}
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	product, err := s.productRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	s.recordRevision(models.RevisionEntityProduct, id, id, models.RevisionActionRestore, actor, deleted, product, nil)
+	s.refreshProduct(id)
+
+	return product, nil
+}
+
+func (s *catalogService) GetDeletedCategories() ([]*models.Category, error) {
+	categories, err := s.categoryRepo.GetDeleted()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+// RestoreCategory takes a category out of the trash. Its parent has to be
+// restored first.
+func (s *catalogService) RestoreCategory(id uuid.UUID) (*models.Category, error) {
+	deleted, err := s.categoryRepo.GetDeletedByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	if deleted == nil {
+		return nil, fmt.Errorf("category not found")
+	}
+
+	if deleted.ParentID != nil {
+		parent, err := s.categoryRepo.GetByID(*deleted.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent category: %w", err)
+		}
+		if parent == nil {
+			return nil, &TrashError{Message: "parent category is deleted"}
+		}
+	}
+
+	if err := s.categoryRepo.Undelete(id); err != nil {
+		if err.Error() == "category not found" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to restore category: %w", err)
+	}
+
+	s.invalidateCategories()
+
+	return s.GetCategory(id)
+}
+
+// Ordered products consumer
+//
+// The order service keeps its order items to itself, so the catalog learns
+// which products were ever ordered from order.created events. A new consumer
+// group starts at the oldest retained event; older orders come from the
+// one-off backfill of ordered_products.
+func (s *catalogService) StartOrderCreatedConsumer() {
+	s.consumeEvents(s.config.KafkaOrderCreatedTopic, kafka.FirstOffset, s.recordOrderedProducts)
+}
+
+// recordOrderedProducts marks the products of a new order as ordered and
+// counts them as bought together. Malformed events are skipped.
+func (s *catalogService) recordOrderedProducts(value []byte) error {
+	var event models.OrderCreatedEvent
+	if err := json.Unmarshal(value, &event); err != nil {
+		log.Printf("Skipping malformed order created event: %v", err)
+		return nil
+	}
+
+	orderedAt := event.Timestamp
+	if orderedAt.IsZero() {
+		orderedAt = time.Now()
+	}
+
+	var productIDs []uuid.UUID
+	for _, item := range event.Items {
+		if item.ProductID != uuid.Nil {
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+
+	if err := s.productRepo.RecordOrderedProducts(productIDs, orderedAt); err != nil {
+		return fmt.Errorf("failed to record ordered products of order %s: %w", event.OrderID, err)
+	}
+
+	return s.recordCoPurchases(&event, orderedAt)
+}
+
+// StartRetentionPurge permanently removes products and categories that have
+// been in the trash longer than the retention period on every tick
+func (s *catalogService) StartRetentionPurge() {
+	if s.config.RetentionPurgeAfter <= 0 || s.config.RetentionPurgeInterval <= 0 {
+		log.Println("Retention purge disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.config.RetentionPurgeInterval)
+	defer ticker.Stop()
+
+	log.Println("Starting retention purge...")
+
+	s.purgeDeleted()
+	for range ticker.C {
+		s.purgeDeleted()
+	}
+}
+
+func (s *catalogService) purgeDeleted() {
+	before := time.Now().Add(-s.config.RetentionPurgeAfter)
+
+	batchSize := s.config.RetentionPurgeBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	// Until ordered_products covers orders from before the consumer started,
+	// products that old orders refer to cannot be told apart
+	backfilled, err := s.productRepo.OrderedProductsBackfilled()
+	if err != nil {
+		log.Printf("Failed to check the ordered products backfill: %v", err)
+	} else if !backfilled {
+		log.Println("Skipping the product purge until ordered products are backfilled from order items")
+	}
+
+	purged := 0
+	for backfilled {
+		ids, err := s.productRepo.PurgeDeleted(before, batchSize)
+		if err != nil {
+			log.Printf("Failed to purge deleted products: %v", err)
+			break
+		}
+
+		// Deleted products already left the cache and the search index
+		purged += len(ids)
+
+		if len(ids) < batchSize {
+			break
+		}
+	}
+
+	categories, err := s.categoryRepo.PurgeDeleted(before)
+	if err != nil {
+		log.Printf("Failed to purge deleted categories: %v", err)
+	}
+	if purged > 0 || categories > 0 {
+		s.invalidateCategories()
+		log.Printf("Purged %d deleted products and %d deleted categories", purged, categories)
+	}
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStore keeps objects on the local filesystem. It serves them over HTTP
+// and accepts presigned PUT uploads, so catalog can run without MinIO.
+type LocalStore struct {
+	dir        string
+	baseURL    string
+	signingKey []byte
+	maxPutSize int64
+}
+
+func NewLocalStore(dir, baseURL, signingKey string, maxPutSize int64) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create media directory: %w", err)
+	}
+
+	return &LocalStore{
+		dir:        dir,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		signingKey: []byte(signingKey),
+		maxPutSize: maxPutSize,
+	}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error {
+	filePath, err := s.path(objectName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func (s *LocalStore) Get(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	filePath, err := s.path(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(filePath)
+}
+
+func (s *LocalStore) Stat(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	filePath, err := s.path(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectInfo{
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(filePath)),
+	}, nil
+}
+
+func (s *LocalStore) Remove(ctx context.Context, objectName string) error {
+	filePath, err := s.path(objectName)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStore) PresignPut(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	if _, err := s.path(objectName); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(objectName, expires))
+
+	return s.URL(objectName) + "?" + query.Encode(), nil
+}
+
+func (s *LocalStore) URL(objectName string) string {
+	return s.baseURL + "/" + objectName
+}
+
+// ServeHTTP serves stored objects and accepts presigned PUT uploads. It
+// expects the request path to be the object name.
+func (s *LocalStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	objectName := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	filePath, err := s.path(objectName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		http.ServeFile(w, r, filePath)
+	case http.MethodPut:
+		if err := s.verify(objectName, r.URL.Query()); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		body := http.MaxBytesReader(w, r.Body, s.maxPutSize)
+		if err := s.Put(r.Context(), objectName, body, r.ContentLength, r.Header.Get("Content-Type")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *LocalStore) verify(objectName string, query url.Values) error {
+	expires := query.Get("expires")
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expires")
+	}
+	if time.Now().Unix() > expiresAt {
+		return fmt.Errorf("upload URL expired")
+	}
+
+	expected := s.sign(objectName, expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
+
+func (s *LocalStore) sign(objectName, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(objectName + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path resolves an object name inside the store directory, rejecting names
+// that would escape it
+func (s *LocalStore) path(objectName string) (string, error) {
+	cleaned := path.Clean("/" + objectName)
+	if cleaned == "/" || strings.Contains(objectName, "..") {
+		return "", fmt.Errorf("invalid object name %s", objectName)
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(cleaned)), nil
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cebeuygun/platform/services/catalog/internal/config"
+)
+
+// MediaStore is the object storage backend for product media, import files
+// and generated feeds
+type MediaStore interface {
+	Put(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, objectName string) (io.ReadCloser, error)
+	Stat(ctx context.Context, objectName string) (*ObjectInfo, error)
+	Remove(ctx context.Context, objectName string) error
+	// PresignPut returns a URL that accepts a single PUT of the object until expiry
+	PresignPut(ctx context.Context, objectName string, expiry time.Duration) (string, error)
+	// URL returns the public URL of an object
+	URL(objectName string) string
+}
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// NewMediaStore creates the backend selected by MEDIA_STORE
+func NewMediaStore(cfg *config.Config) (MediaStore, error) {
+	switch cfg.MediaStore {
+	case "minio":
+		return NewMinIOStore(cfg.MinIOEndpoint, cfg.MinIOAccessKey, cfg.MinIOSecretKey, cfg.MinIOUseSSL, cfg.MinIOBucketName)
+	case "local":
+		store, err := NewLocalStore(cfg.MediaLocalDir, cfg.MediaPublicURL, cfg.MediaSigningKey, cfg.MaxFileSize)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown media store %s", cfg.MediaStore)
+	}
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type minioStore struct {
+	client     *minio.Client
+	endpoint   string
+	bucketName string
+
+	mu          sync.Mutex
+	bucketReady bool
+}
+
+// NewMinIOStore creates a MinIO backed store. An unreachable MinIO does not
+// fail construction; the bucket is created on the first successful write.
+func NewMinIOStore(endpoint, accessKey, secretKey string, useSSL bool, bucketName string) (MediaStore, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create minio client: %w", err)
+	}
+
+	store := &minioStore{
+		client:     client,
+		endpoint:   endpoint,
+		bucketName: bucketName,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.ensureBucket(ctx); err != nil {
+		log.Printf("MinIO bucket %s not ready, will retry on first write: %v", bucketName, err)
+	}
+
+	return store, nil
+}
+
+func (s *minioStore) ensureBucket(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bucketReady {
+		return nil
+	}
+
+	exists, err := s.client.BucketExists(ctx, s.bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket existence: %w", err)
+	}
+	if !exists {
+		err = s.client.MakeBucket(ctx, s.bucketName, minio.MakeBucketOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %w", err)
+		}
+	}
+
+	s.bucketReady = true
+	return nil
+}
+
+func (s *minioStore) Put(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error {
+	if err := s.ensureBucket(ctx); err != nil {
+		return err
+	}
+
+	_, err := s.client.PutObject(ctx, s.bucketName, objectName, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+func (s *minioStore) Get(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	return s.client.GetObject(ctx, s.bucketName, objectName, minio.GetObjectOptions{})
+}
+
+func (s *minioStore) Stat(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucketName, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &ObjectInfo{
+		Size:        info.Size,
+		ContentType: info.ContentType,
+	}, nil
+}
+
+func (s *minioStore) Remove(ctx context.Context, objectName string) error {
+	return s.client.RemoveObject(ctx, s.bucketName, objectName, minio.RemoveObjectOptions{})
+}
+
+func (s *minioStore) PresignPut(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	if err := s.ensureBucket(ctx); err != nil {
+		return "", err
+	}
+
+	u, err := s.client.PresignedPutObject(ctx, s.bucketName, objectName, expiry)
+	if err != nil {
+		return "", err
+	}
+
+	return u.String(), nil
+}
+
+func (s *minioStore) URL(objectName string) string {
+	return fmt.Sprintf("http://%s/%s/%s", s.endpoint, s.bucketName, objectName)
+}
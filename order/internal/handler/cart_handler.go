@@ -1,8 +1,8 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/cebeuygun/platform/services/order/internal/models"
 	"github.com/cebeuygun/platform/services/order/internal/service"
@@ -106,7 +106,8 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 	item, err := h.service.AddToCart(customerID, &req)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "cart can only contain items from a single seller" {
+		var bundleErr *service.BundleSelectionError
+		if err.Error() == "cart can only contain items from a single seller" || errors.As(err, &bundleErr) {
 			statusCode = http.StatusBadRequest
 		}
 		
@@ -50,6 +50,7 @@ type CartItem struct {
 	Quantity  int             `json:"quantity" db:"quantity"`
 	UnitPrice decimal.Decimal `json:"unit_price" db:"unit_price"`
 	Notes     *string         `json:"notes,omitempty" db:"notes"`
+	BundleSelection *BundleSelection `json:"bundle_selection,omitempty" db:"bundle_selection"`
 	CreatedAt time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time       `json:"updated_at" db:"updated_at"`
 	
@@ -105,12 +106,46 @@ type OrderItem struct {
 	UnitPrice decimal.Decimal `json:"unit_price" db:"unit_price"`
 	TotalPrice decimal.Decimal `json:"total_price" db:"total_price"`
 	Notes     *string         `json:"notes,omitempty" db:"notes"`
+	BundleSelection *BundleSelection `json:"bundle_selection,omitempty" db:"bundle_selection"`
 	CreatedAt time.Time       `json:"created_at" db:"created_at"`
 	
 	// Computed fields
 	Product *Product `json:"product,omitempty" db:"-"`
 }
 
+// BundleSelection is the catalog's validated and priced choice of options
+// for a bundle product. It is kept with the cart item and copied to the
+// order item, so the order records what the customer chose.
+type BundleSelection struct {
+	ProductID  uuid.UUID               `json:"product_id"`
+	Name       string                  `json:"name"`
+	BasePrice  decimal.Decimal         `json:"base_price"`
+	Currency   string                  `json:"currency"`
+	Components []*BundleComponent      `json:"components"`
+	Options    []*SelectedBundleOption `json:"options"`
+	TotalPrice decimal.Decimal         `json:"total_price"`
+}
+
+// BundleComponent is a product or variant included in every bundle
+type BundleComponent struct {
+	ProductID uuid.UUID  `json:"product_id"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty"`
+	Name      string     `json:"name"`
+	Quantity  int        `json:"quantity"`
+}
+
+// SelectedBundleOption is an option chosen in a BundleSelection
+type SelectedBundleOption struct {
+	GroupID    uuid.UUID       `json:"group_id"`
+	GroupName  string          `json:"group_name"`
+	OptionID   uuid.UUID       `json:"option_id"`
+	ProductID  uuid.UUID       `json:"product_id"`
+	VariantID  *uuid.UUID      `json:"variant_id,omitempty"`
+	Name       string          `json:"name"`
+	Quantity   int             `json:"quantity"`
+	PriceDelta decimal.Decimal `json:"price_delta"`
+}
+
 // Address represents delivery address
 type Address struct {
 	Street     string  `json:"street"`
@@ -153,6 +188,18 @@ type AddToCartRequest struct {
 	VariantID *uuid.UUID `json:"variant_id,omitempty"`
 	Quantity  int       `json:"quantity" validate:"required,min=1"`
 	Notes     *string   `json:"notes,omitempty"`
+	Bundle    *BundleSelectionRequest `json:"bundle,omitempty"`
+}
+
+// BundleSelectionRequest lists the options chosen per choice group of a
+// bundle product. Groups that are left out get their default options.
+type BundleSelectionRequest struct {
+	Choices []*BundleChoice `json:"choices" validate:"omitempty,dive"`
+}
+
+type BundleChoice struct {
+	GroupID   uuid.UUID   `json:"group_id" validate:"required"`
+	OptionIDs []uuid.UUID `json:"option_ids"`
 }
 
 type UpdateCartItemRequest struct {
@@ -237,6 +284,7 @@ type OrderItemEvent struct {
 	Quantity   int             `json:"quantity"`
 	UnitPrice  decimal.Decimal `json:"unit_price"`
 	TotalPrice decimal.Decimal `json:"total_price"`
+	BundleSelection *BundleSelection `json:"bundle_selection,omitempty"`
 }
 
 // State machine validation
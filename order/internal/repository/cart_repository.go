@@ -2,7 +2,6 @@ package repository
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 
 	"github.com/cebeuygun/platform/services/order/internal/models"
@@ -124,14 +123,20 @@ func (r *cartRepository) GetCartByCustomerID(customerID uuid.UUID) (*models.Cart
 }
 
 func (r *cartRepository) AddItem(item *models.CartItem) error {
+	bundleSelectionJSON, err := marshalBundleSelection(item.BundleSelection)
+	if err != nil {
+		return err
+	}
+	
 	query := `
-		INSERT INTO cart_items (id, cart_id, product_id, variant_id, seller_id, quantity, unit_price, notes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO cart_items (id, cart_id, product_id, variant_id, seller_id, quantity, unit_price, notes, bundle_selection)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (cart_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::uuid))
 		DO UPDATE SET
 			quantity = cart_items.quantity + EXCLUDED.quantity,
 			unit_price = EXCLUDED.unit_price,
 			notes = EXCLUDED.notes,
+			bundle_selection = EXCLUDED.bundle_selection,
 			updated_at = now()
 		RETURNING created_at, updated_at`
 	
@@ -145,6 +150,7 @@ func (r *cartRepository) AddItem(item *models.CartItem) error {
 		item.Quantity,
 		item.UnitPrice,
 		item.Notes,
+		bundleSelectionJSON,
 	).Scan(&item.CreatedAt, &item.UpdatedAt)
 }
 
@@ -219,7 +225,7 @@ func (r *cartRepository) RemoveItem(id uuid.UUID) error {
 
 func (r *cartRepository) GetCartItems(cartID uuid.UUID) ([]*models.CartItem, error) {
 	query := `
-		SELECT id, cart_id, product_id, variant_id, seller_id, quantity, unit_price, notes, created_at, updated_at
+		SELECT id, cart_id, product_id, variant_id, seller_id, quantity, unit_price, notes, bundle_selection, created_at, updated_at
 		FROM cart_items
 		WHERE cart_id = $1
 		ORDER BY created_at`
@@ -233,6 +239,7 @@ func (r *cartRepository) GetCartItems(cartID uuid.UUID) ([]*models.CartItem, err
 	var items []*models.CartItem
 	for rows.Next() {
 		item := &models.CartItem{}
+		var bundleSelectionJSON []byte
 		err := rows.Scan(
 			&item.ID,
 			&item.CartID,
@@ -242,12 +249,18 @@ func (r *cartRepository) GetCartItems(cartID uuid.UUID) ([]*models.CartItem, err
 			&item.Quantity,
 			&item.UnitPrice,
 			&item.Notes,
+			&bundleSelectionJSON,
 			&item.CreatedAt,
 			&item.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		
+		item.BundleSelection, err = unmarshalBundleSelection(bundleSelectionJSON)
+		if err != nil {
+			return nil, err
+		}
 		items = append(items, item)
 	}
 	
@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	"github.com/cebeuygun/platform/services/order/internal/models"
 	"github.com/google/uuid"
@@ -90,9 +89,14 @@ func (r *orderRepository) Create(order *models.Order) error {
 }
 
 func (r *orderRepository) createOrderItemTx(tx *sql.Tx, item *models.OrderItem) error {
+	bundleSelectionJSON, err := marshalBundleSelection(item.BundleSelection)
+	if err != nil {
+		return err
+	}
+	
 	query := `
-		INSERT INTO order_items (id, order_id, product_id, variant_id, quantity, unit_price, total_price, notes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO order_items (id, order_id, product_id, variant_id, quantity, unit_price, total_price, notes, bundle_selection)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING created_at`
 	
 	return tx.QueryRow(
@@ -105,6 +109,7 @@ func (r *orderRepository) createOrderItemTx(tx *sql.Tx, item *models.OrderItem)
 		item.UnitPrice,
 		item.TotalPrice,
 		item.Notes,
+		bundleSelectionJSON,
 	).Scan(&item.CreatedAt)
 }
 
@@ -349,7 +354,7 @@ func (r *orderRepository) SetActualDeliveryTime(id uuid.UUID) error {
 
 func (r *orderRepository) GetOrderItems(orderID uuid.UUID) ([]*models.OrderItem, error) {
 	query := `
-		SELECT id, order_id, product_id, variant_id, quantity, unit_price, total_price, notes, created_at
+		SELECT id, order_id, product_id, variant_id, quantity, unit_price, total_price, notes, bundle_selection, created_at
 		FROM order_items
 		WHERE order_id = $1
 		ORDER BY created_at`
@@ -363,6 +368,7 @@ func (r *orderRepository) GetOrderItems(orderID uuid.UUID) ([]*models.OrderItem,
 	var items []*models.OrderItem
 	for rows.Next() {
 		item := &models.OrderItem{}
+		var bundleSelectionJSON []byte
 		err := rows.Scan(
 			&item.ID,
 			&item.OrderID,
@@ -372,11 +378,17 @@ func (r *orderRepository) GetOrderItems(orderID uuid.UUID) ([]*models.OrderItem,
 			&item.UnitPrice,
 			&item.TotalPrice,
 			&item.Notes,
+			&bundleSelectionJSON,
 			&item.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		
+		item.BundleSelection, err = unmarshalBundleSelection(bundleSelectionJSON)
+		if err != nil {
+			return nil, err
+		}
 		items = append(items, item)
 	}
 	
@@ -384,9 +396,14 @@ func (r *orderRepository) GetOrderItems(orderID uuid.UUID) ([]*models.OrderItem,
 }
 
 func (r *orderRepository) CreateOrderItem(item *models.OrderItem) error {
+	bundleSelectionJSON, err := marshalBundleSelection(item.BundleSelection)
+	if err != nil {
+		return err
+	}
+	
 	query := `
-		INSERT INTO order_items (id, order_id, product_id, variant_id, quantity, unit_price, total_price, notes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO order_items (id, order_id, product_id, variant_id, quantity, unit_price, total_price, notes, bundle_selection)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING created_at`
 	
 	return r.db.QueryRow(
@@ -399,5 +416,35 @@ func (r *orderRepository) CreateOrderItem(item *models.OrderItem) error {
 		item.UnitPrice,
 		item.TotalPrice,
 		item.Notes,
+		bundleSelectionJSON,
 	).Scan(&item.CreatedAt)
-}
\ No newline at end of file
+}
+
+// marshalBundleSelection serializes the bundle selection of a cart or order
+// item, NULL for items that are not bundles
+func marshalBundleSelection(selection *models.BundleSelection) (*string, error) {
+	if selection == nil {
+		return nil, nil
+	}
+	
+	selectionJSON, err := json.Marshal(selection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize bundle selection: %w", err)
+	}
+	
+	value := string(selectionJSON)
+	return &value, nil
+}
+
+func unmarshalBundleSelection(selectionJSON []byte) (*models.BundleSelection, error) {
+	if len(selectionJSON) == 0 {
+		return nil, nil
+	}
+	
+	selection := &models.BundleSelection{}
+	if err := json.Unmarshal(selectionJSON, selection); err != nil {
+		return nil, fmt.Errorf("failed to deserialize bundle selection: %w", err)
+	}
+	
+	return selection, nil
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -40,6 +41,16 @@ type OrderService interface {
 	StartOutboxProcessor()
 }
 
+// BundleSelectionError reports options chosen for a bundle that the catalog
+// service rejected
+type BundleSelectionError struct {
+	Message string
+}
+
+func (e *BundleSelectionError) Error() string {
+	return e.Message
+}
+
 type orderService struct {
 	cartRepo    repository.CartRepository
 	orderRepo   repository.OrderRepository
@@ -124,16 +135,37 @@ func (s *orderService) AddToCart(customerID uuid.UUID, req *models.AddToCartRequ
 		return nil, fmt.Errorf("cart can only contain items from a single seller")
 	}
 	
+	// Bundles are priced by the catalog service from the chosen options
+	unitPrice := product.BasePrice
+	var selection *models.BundleSelection
+	if req.Bundle != nil {
+		selection, err = s.resolveBundleSelection(req.ProductID, req.Bundle)
+		if err != nil {
+			return nil, err
+		}
+		unitPrice = selection.TotalPrice
+	}
+	
+	// A cart item holds a single selection, so the same bundle with other
+	// options cannot be added to it
+	for _, existing := range cart.Items {
+		if existing.ProductID == req.ProductID && sameVariant(existing.VariantID, req.VariantID) &&
+			!sameBundleOptions(existing.BundleSelection, selection) {
+			return nil, &BundleSelectionError{Message: "bundle is already in the cart with other options"}
+		}
+	}
+	
 	// Create cart item
 	item := &models.CartItem{
-		ID:        uuid.New(),
-		CartID:    cart.ID,
-		ProductID: req.ProductID,
-		VariantID: req.VariantID,
-		SellerID:  sellerID,
-		Quantity:  req.Quantity,
-		UnitPrice: product.BasePrice,
-		Notes:     req.Notes,
+		ID:              uuid.New(),
+		CartID:          cart.ID,
+		ProductID:       req.ProductID,
+		VariantID:       req.VariantID,
+		SellerID:        sellerID,
+		Quantity:        req.Quantity,
+		UnitPrice:       unitPrice,
+		Notes:           req.Notes,
+		BundleSelection: selection,
 	}
 	
 	err = s.cartRepo.AddItem(item)
@@ -276,13 +308,14 @@ func (s *orderService) CreateOrder(customerID uuid.UUID, req *models.CreateOrder
 	// Convert cart items to order items
 	for _, cartItem := range cart.Items {
 		orderItem := &models.OrderItem{
-			ID:         uuid.New(),
-			ProductID:  cartItem.ProductID,
-			VariantID:  cartItem.VariantID,
-			Quantity:   cartItem.Quantity,
-			UnitPrice:  cartItem.UnitPrice,
-			TotalPrice: cartItem.UnitPrice.Mul(decimal.NewFromInt(int64(cartItem.Quantity))),
-			Notes:      cartItem.Notes,
+			ID:              uuid.New(),
+			ProductID:       cartItem.ProductID,
+			VariantID:       cartItem.VariantID,
+			Quantity:        cartItem.Quantity,
+			UnitPrice:       cartItem.UnitPrice,
+			TotalPrice:      cartItem.UnitPrice.Mul(decimal.NewFromInt(int64(cartItem.Quantity))),
+			Notes:           cartItem.Notes,
+			BundleSelection: cartItem.BundleSelection,
 		}
 		order.Items = append(order.Items, orderItem)
 	}
@@ -546,6 +579,70 @@ func (s *orderService) getProductSellerID(productID uuid.UUID) (uuid.UUID, error
 	return uuid.New(), nil
 }
 
+// resolveBundleSelection has the catalog service check the options chosen
+// for a bundle product against its choice groups and stock, and price them
+func (s *orderService) resolveBundleSelection(productID uuid.UUID, req *models.BundleSelectionRequest) (*models.BundleSelection, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize bundle selection: %w", err)
+	}
+	
+	url := fmt.Sprintf("%s/api/v1/products/%s/bundle/selection", s.config.CatalogServiceURL, productID)
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve bundle selection: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	var result struct {
+		Data  *models.BundleSelection `json:"data"`
+		Error string                  `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode bundle selection: %w", err)
+	}
+	
+	switch {
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound:
+		return nil, &BundleSelectionError{Message: result.Error}
+	case resp.StatusCode != http.StatusOK || result.Data == nil:
+		return nil, fmt.Errorf("catalog service returned %d resolving bundle selection: %s", resp.StatusCode, result.Error)
+	}
+	
+	return result.Data, nil
+}
+
+func sameVariant(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// sameBundleOptions reports whether two selections chose the same options;
+// items that are not bundles have no selection
+func sameBundleOptions(a, b *models.BundleSelection) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if len(a.Options) != len(b.Options) {
+		return false
+	}
+	
+	chosen := make(map[uuid.UUID]bool)
+	for _, option := range a.Options {
+		chosen[option.OptionID] = true
+	}
+	for _, option := range b.Options {
+		if !chosen[option.OptionID] {
+			return false
+		}
+	}
+	
+	return true
+}
+
 func (s *orderService) getSellerName(sellerID uuid.UUID) (string, error) {
 	// This would typically call the user/seller service
 	return "Sample Seller", nil
@@ -572,11 +669,12 @@ func (s *orderService) publishOrderEvent(order *models.Order, topic string) erro
 	// Convert order items
 	for _, item := range order.Items {
 		payload.Items = append(payload.Items, models.OrderItemEvent{
-			ProductID:  item.ProductID,
-			VariantID:  item.VariantID,
-			Quantity:   item.Quantity,
-			UnitPrice:  item.UnitPrice,
-			TotalPrice: item.TotalPrice,
+			ProductID:       item.ProductID,
+			VariantID:       item.VariantID,
+			Quantity:        item.Quantity,
+			UnitPrice:       item.UnitPrice,
+			TotalPrice:      item.TotalPrice,
+			BundleSelection: item.BundleSelection,
 		})
 	}
 	
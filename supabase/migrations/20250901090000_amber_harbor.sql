@@ -0,0 +1,7 @@
+-- Track seller listings hidden automatically because they ran out of stock
+ALTER TABLE seller_products
+    ADD COLUMN IF NOT EXISTS hidden_by_stock BOOLEAN NOT NULL DEFAULT false;
+
+-- Create indexes for low-stock reporting
+CREATE INDEX IF NOT EXISTS idx_seller_products_seller_stock
+    ON seller_products(seller_id, stock) WHERE stock IS NOT NULL;
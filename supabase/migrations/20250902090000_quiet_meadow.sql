@@ -0,0 +1,48 @@
+-- Create import_jobs table for asynchronous bulk product imports
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    seller_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'RUNNING', 'COMPLETED', 'FAILED')),
+    format VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'xlsx')),
+    dry_run BOOLEAN NOT NULL DEFAULT false,
+    match_by VARCHAR(20) NOT NULL DEFAULT 'sku' CHECK (match_by IN ('sku', 'barcode')),
+    header_mapping JSONB,
+    file_name VARCHAR(255) NOT NULL,
+    object_name TEXT NOT NULL,
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    created_count INTEGER NOT NULL DEFAULT 0,
+    updated_count INTEGER NOT NULL DEFAULT 0,
+    error_count INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create import_job_rows table for per-row import status
+CREATE TABLE IF NOT EXISTS import_job_rows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id UUID NOT NULL REFERENCES import_jobs(id) ON DELETE CASCADE,
+    row_number INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('VALID', 'CREATED', 'UPDATED', 'FAILED')),
+    action VARCHAR(20) CHECK (action IN ('CREATE', 'UPDATE')),
+    product_id UUID,
+    match_key VARCHAR(100),
+    field VARCHAR(100),
+    message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_import_jobs_seller_id ON import_jobs(seller_id);
+CREATE INDEX IF NOT EXISTS idx_import_jobs_status ON import_jobs(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_import_job_rows_job_id ON import_job_rows(job_id, row_number);
+CREATE INDEX IF NOT EXISTS idx_import_job_rows_status ON import_job_rows(job_id, status);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_import_jobs_updated_at
+    BEFORE UPDATE ON import_jobs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
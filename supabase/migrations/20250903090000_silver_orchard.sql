@@ -0,0 +1,2 @@
+-- Record the variant touched by variant rows in bulk imports
+ALTER TABLE import_job_rows ADD COLUMN IF NOT EXISTS variant_id UUID;
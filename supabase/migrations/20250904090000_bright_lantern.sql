@@ -0,0 +1,14 @@
+-- Add image processing columns to product_media
+ALTER TABLE product_media ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+ALTER TABLE product_media ADD COLUMN IF NOT EXISTS width INTEGER;
+ALTER TABLE product_media ADD COLUMN IF NOT EXISTS height INTEGER;
+ALTER TABLE product_media ADD COLUMN IF NOT EXISTS derivatives JSONB;
+ALTER TABLE product_media ADD COLUMN IF NOT EXISTS processing_status VARCHAR(20) NOT NULL DEFAULT 'READY'
+    CHECK (processing_status IN ('PENDING', 'PROCESSING', 'READY', 'FAILED'));
+ALTER TABLE product_media ADD COLUMN IF NOT EXISTS processing_error TEXT;
+ALTER TABLE product_media ADD COLUMN IF NOT EXISTS raw_object_name TEXT;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_product_media_content_hash ON product_media(content_hash);
+CREATE INDEX IF NOT EXISTS idx_product_media_processing ON product_media(processing_status, created_at)
+    WHERE processing_status IN ('PENDING', 'PROCESSING');
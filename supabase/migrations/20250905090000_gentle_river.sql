@@ -0,0 +1,26 @@
+-- Create media_uploads table for presigned direct-to-storage uploads
+CREATE TABLE IF NOT EXISTS media_uploads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE,
+    object_name TEXT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    file_size BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'CONFIRMED', 'FAILED', 'EXPIRED')),
+    media_id UUID REFERENCES product_media(id) ON DELETE SET NULL,
+    error_message TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_media_uploads_product_id ON media_uploads(product_id);
+CREATE INDEX IF NOT EXISTS idx_media_uploads_pending ON media_uploads(expires_at) WHERE status = 'PENDING';
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_media_uploads_updated_at
+    BEFORE UPDATE ON media_uploads
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,28 @@
+-- Create category_attributes table for typed, inherited category attribute schemas
+CREATE TABLE IF NOT EXISTS category_attributes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('ENUM', 'NUMBER', 'BOOLEAN')),
+    unit VARCHAR(20),
+    options TEXT[] NOT NULL DEFAULT '{}',
+    min_value DECIMAL(15,4),
+    max_value DECIMAL(15,4),
+    is_required BOOLEAN NOT NULL DEFAULT false,
+    is_variant BOOLEAN NOT NULL DEFAULT false,
+    is_filterable BOOLEAN NOT NULL DEFAULT false,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (category_id, code)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_category_attributes_category_id ON category_attributes(category_id);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_category_attributes_updated_at
+    BEFORE UPDATE ON category_attributes
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,88 @@
+-- Store a materialized path on categories for subtree queries and cycle prevention.
+-- The path is the slash separated list of ancestor ids ending with the category itself.
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS path TEXT;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS depth INTEGER NOT NULL DEFAULT 0;
+
+-- Backfill paths of existing categories
+WITH RECURSIVE category_tree AS (
+    SELECT id, id::text AS path, 0 AS depth
+    FROM categories
+    WHERE parent_id IS NULL
+
+    UNION ALL
+
+    SELECT c.id, ct.path || '/' || c.id::text, ct.depth + 1
+    FROM categories c
+    INNER JOIN category_tree ct ON c.parent_id = ct.id
+)
+UPDATE categories c
+SET path = ct.path, depth = ct.depth
+FROM category_tree ct
+WHERE c.id = ct.id;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_categories_path ON categories(path text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_sort ON categories(parent_id, sort_order);
+
+-- Compute the path from the parent and reject moves under the category's own subtree
+CREATE OR REPLACE FUNCTION set_category_path()
+RETURNS TRIGGER AS $$
+DECLARE
+    parent_path TEXT;
+    parent_depth INTEGER;
+BEGIN
+    IF NEW.parent_id IS NULL THEN
+        NEW.path := NEW.id::text;
+        NEW.depth := 0;
+        RETURN NEW;
+    END IF;
+
+    SELECT path, depth INTO parent_path, parent_depth
+    FROM categories
+    WHERE id = NEW.parent_id;
+
+    IF parent_path IS NULL THEN
+        RAISE EXCEPTION 'parent category % not found', NEW.parent_id
+            USING ERRCODE = 'foreign_key_violation';
+    END IF;
+
+    IF '/' || parent_path || '/' LIKE '%/' || NEW.id::text || '/%' THEN
+        RAISE EXCEPTION 'category % cannot be moved under its own subtree', NEW.id
+            USING ERRCODE = 'check_violation';
+    END IF;
+
+    NEW.path := parent_path || '/' || NEW.id::text;
+    NEW.depth := parent_depth + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Rewrite the paths of all descendants when a category moves
+CREATE OR REPLACE FUNCTION move_category_subtree()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Descendant updates fire this trigger again; the first level already covers them
+    IF pg_trigger_depth() > 1 THEN
+        RETURN NULL;
+    END IF;
+
+    UPDATE categories
+    SET path = NEW.path || substr(path, length(OLD.path) + 1),
+        depth = depth + (NEW.depth - OLD.depth)
+    WHERE path LIKE OLD.path || '/%';
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER set_categories_path
+    BEFORE INSERT OR UPDATE OF parent_id ON categories
+    FOR EACH ROW
+    EXECUTE FUNCTION set_category_path();
+
+-- Not limited to UPDATE OF path: the path is changed by the BEFORE trigger, not the SET list
+CREATE TRIGGER move_categories_subtree
+    AFTER UPDATE ON categories
+    FOR EACH ROW
+    WHEN (OLD.path IS DISTINCT FROM NEW.path)
+    EXECUTE FUNCTION move_category_subtree();
@@ -0,0 +1,33 @@
+-- Create revisions table for the append-only change history of products,
+-- variants and seller overrides. Rows outlive the entities they describe,
+-- so there are no foreign keys to them.
+CREATE TABLE IF NOT EXISTS revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entity_type VARCHAR(20) NOT NULL CHECK (entity_type IN ('PRODUCT', 'VARIANT', 'SELLER_PRODUCT')),
+    entity_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    revision INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('CREATE', 'UPDATE', 'DELETE', 'RESTORE')),
+    actor VARCHAR(255) NOT NULL,
+    changes JSONB,
+    snapshot JSONB NOT NULL,
+    restored_from UUID REFERENCES revisions(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (entity_type, entity_id, revision)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_revisions_product_id ON revisions(product_id, created_at DESC);
+
+-- Revisions are append-only
+CREATE OR REPLACE FUNCTION prevent_revision_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'revisions are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER revisions_append_only
+    BEFORE UPDATE OR DELETE ON revisions
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_revision_changes();
@@ -0,0 +1,17 @@
+-- Moderation workflow for seller-submitted products.
+-- Products that exist already stay live, so they start out APPROVED.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'APPROVED'
+    CHECK (status IN ('DRAFT', 'PENDING_REVIEW', 'APPROVED', 'REJECTED'));
+ALTER TABLE products ALTER COLUMN status SET DEFAULT 'DRAFT';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS submitted_by UUID;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS submitted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reviewed_by VARCHAR(255);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS rejection_reason VARCHAR(50);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS rejection_note TEXT;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS moderation_flags JSONB;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_products_status ON products(status);
+CREATE INDEX IF NOT EXISTS idx_products_review_queue ON products(submitted_at) WHERE status = 'PENDING_REVIEW';
+CREATE INDEX IF NOT EXISTS idx_products_submitted_by ON products(submitted_by, status);
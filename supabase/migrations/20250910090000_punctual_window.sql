@@ -0,0 +1,25 @@
+-- Availability windows for products and seller listings: publish/unpublish
+-- times plus a recurring weekly schedule. is_available is maintained by the
+-- availability scheduler so that listings can filter on it cheaply.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS availability_schedule JSONB;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_available BOOLEAN NOT NULL DEFAULT true;
+
+ALTER TABLE seller_products ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE seller_products ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE seller_products ADD COLUMN IF NOT EXISTS availability_schedule JSONB;
+ALTER TABLE seller_products ADD COLUMN IF NOT EXISTS is_available BOOLEAN NOT NULL DEFAULT true;
+
+ALTER TABLE products ADD CONSTRAINT products_publish_window_check
+    CHECK (publish_at IS NULL OR unpublish_at IS NULL OR unpublish_at > publish_at);
+ALTER TABLE seller_products ADD CONSTRAINT seller_products_publish_window_check
+    CHECK (publish_at IS NULL OR unpublish_at IS NULL OR unpublish_at > publish_at);
+
+-- Create indexes
+-- The scheduler only visits rows with a window or that it has hidden
+CREATE INDEX IF NOT EXISTS idx_products_scheduled ON products(id)
+    WHERE publish_at IS NOT NULL OR unpublish_at IS NOT NULL OR availability_schedule IS NOT NULL OR is_available = false;
+CREATE INDEX IF NOT EXISTS idx_seller_products_scheduled ON seller_products(id)
+    WHERE publish_at IS NOT NULL OR unpublish_at IS NOT NULL OR availability_schedule IS NOT NULL OR is_available = false;
+CREATE INDEX IF NOT EXISTS idx_products_available ON products(is_available);
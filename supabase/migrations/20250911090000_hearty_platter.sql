@@ -0,0 +1,46 @@
+-- Bundle products (combo menus, multipacks) composed of other products.
+-- Components are always included; choice groups let the customer pick options.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS product_type VARCHAR(20) NOT NULL DEFAULT 'SIMPLE'
+    CHECK (product_type IN ('SIMPLE', 'BUNDLE'));
+
+CREATE TABLE IF NOT EXISTS bundle_components (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    bundle_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (product_id <> bundle_id)
+);
+
+CREATE TABLE IF NOT EXISTS bundle_choice_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    bundle_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    min_select INTEGER NOT NULL DEFAULT 0 CHECK (min_select >= 0),
+    max_select INTEGER NOT NULL DEFAULT 1 CHECK (max_select >= 1),
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (max_select >= min_select)
+);
+
+CREATE TABLE IF NOT EXISTS bundle_choice_options (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES bundle_choice_groups(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    price_delta DECIMAL(12,2) NOT NULL DEFAULT 0,
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_products_product_type ON products(product_type);
+CREATE INDEX IF NOT EXISTS idx_bundle_components_bundle ON bundle_components(bundle_id, sort_order);
+CREATE INDEX IF NOT EXISTS idx_bundle_components_product ON bundle_components(product_id);
+CREATE INDEX IF NOT EXISTS idx_bundle_choice_groups_bundle ON bundle_choice_groups(bundle_id, sort_order);
+CREATE INDEX IF NOT EXISTS idx_bundle_choice_options_group ON bundle_choice_options(group_id, sort_order);
+CREATE INDEX IF NOT EXISTS idx_bundle_choice_options_product ON bundle_choice_options(product_id);
@@ -0,0 +1,13 @@
+-- Food labelling: allergens (the 14 declared under EU 1169/2011 and the
+-- Turkish Food Codex), may-contain traces, dietary labels, ingredients and
+-- nutrition facts per 100 g/ml and per serving
+ALTER TABLE products ADD COLUMN IF NOT EXISTS food_info JSONB;
+
+ALTER TABLE products ADD CONSTRAINT products_food_info_object
+    CHECK (food_info IS NULL OR jsonb_typeof(food_info) = 'object');
+
+-- Create indexes
+-- free_from filters use ?| on the allergen lists, dietary label filters use @>
+CREATE INDEX IF NOT EXISTS idx_products_food_allergens ON products USING GIN ((food_info->'allergens'));
+CREATE INDEX IF NOT EXISTS idx_products_food_may_contain ON products USING GIN ((food_info->'may_contain'));
+CREATE INDEX IF NOT EXISTS idx_products_food_dietary_labels ON products USING GIN ((food_info->'dietary_labels'));
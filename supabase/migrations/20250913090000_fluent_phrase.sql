@@ -0,0 +1,37 @@
+-- Per-locale translations of product, variant and category text. The default
+-- locale's text stays on the entity itself; these tables hold the others.
+CREATE TABLE IF NOT EXISTS product_translations (
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    locale VARCHAR(10) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, locale)
+);
+
+CREATE TABLE IF NOT EXISTS variant_translations (
+    variant_id UUID NOT NULL REFERENCES product_variants(id) ON DELETE CASCADE,
+    locale VARCHAR(10) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (variant_id, locale)
+);
+
+CREATE TABLE IF NOT EXISTS category_translations (
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    locale VARCHAR(10) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (category_id, locale)
+);
+
+-- Create indexes
+-- Search matches translated names the same way it matches products.name
+CREATE INDEX IF NOT EXISTS idx_product_translations_name_trgm ON product_translations USING GIN(name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_product_translations_locale ON product_translations(locale);
+CREATE INDEX IF NOT EXISTS idx_variant_translations_locale ON variant_translations(locale);
+CREATE INDEX IF NOT EXISTS idx_category_translations_locale ON category_translations(locale);
@@ -0,0 +1,6 @@
+-- Keyset pagination walks products in (sort column, id) order. Shoppers only
+-- ever see approved products, so the indexes cover just those.
+CREATE INDEX IF NOT EXISTS idx_products_keyset_name ON products(name, id) WHERE status = 'APPROVED';
+CREATE INDEX IF NOT EXISTS idx_products_keyset_price ON products(base_price, id) WHERE status = 'APPROVED';
+CREATE INDEX IF NOT EXISTS idx_products_keyset_created_at ON products(created_at, id) WHERE status = 'APPROVED';
+CREATE INDEX IF NOT EXISTS idx_products_keyset_updated_at ON products(updated_at, id) WHERE status = 'APPROVED';
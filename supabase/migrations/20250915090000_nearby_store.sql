@@ -0,0 +1,54 @@
+-- Seller store locations and the areas they deliver to. A seller serves a
+-- point when one of its active locations has an active zone covering it.
+CREATE TABLE IF NOT EXISTS seller_locations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    seller_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    address TEXT,
+    latitude DOUBLE PRECISION NOT NULL CHECK (latitude >= -90 AND latitude <= 90),
+    longitude DOUBLE PRECISION NOT NULL CHECK (longitude >= -180 AND longitude <= 180),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Radius zones reach radius_km around their location. Polygon zones keep the
+-- vertices as entered in polygon and the shape itself in area for PostGIS.
+CREATE TABLE IF NOT EXISTS seller_delivery_zones (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    location_id UUID NOT NULL REFERENCES seller_locations(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    zone_type VARCHAR(20) NOT NULL CHECK (zone_type IN ('RADIUS', 'POLYGON')),
+    radius_km DOUBLE PRECISION CHECK (radius_km IS NULL OR (radius_km > 0 AND radius_km <= 100)),
+    polygon JSONB,
+    area geography(Polygon, 4326),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT seller_delivery_zones_shape_check CHECK (
+        (zone_type = 'RADIUS' AND radius_km IS NOT NULL AND area IS NULL) OR
+        (zone_type = 'POLYGON' AND radius_km IS NULL AND area IS NOT NULL)
+    )
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_seller_locations_seller_id ON seller_locations(seller_id);
+CREATE INDEX IF NOT EXISTS idx_seller_locations_point ON seller_locations
+    USING GIST ((ST_Point(longitude, latitude)::geography));
+CREATE INDEX IF NOT EXISTS idx_seller_delivery_zones_location_id ON seller_delivery_zones(location_id);
+CREATE INDEX IF NOT EXISTS idx_seller_delivery_zones_area ON seller_delivery_zones USING GIST(area);
+
+-- Location-aware searches look up the visible listings of each product
+CREATE INDEX IF NOT EXISTS idx_seller_products_product_visible ON seller_products(product_id, seller_id)
+    WHERE is_active = true AND is_visible = true;
+
+-- Create triggers for updated_at
+CREATE TRIGGER update_seller_locations_updated_at
+    BEFORE UPDATE ON seller_locations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_seller_delivery_zones_updated_at
+    BEFORE UPDATE ON seller_delivery_zones
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
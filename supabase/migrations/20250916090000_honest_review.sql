@@ -0,0 +1,62 @@
+-- Order items the order service reported delivered. Each one lets its
+-- customer review the product once for that order.
+CREATE TABLE IF NOT EXISTS review_eligibilities (
+    order_id UUID NOT NULL,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants(id) ON DELETE SET NULL,
+    customer_id UUID NOT NULL,
+    seller_id UUID NOT NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (order_id, product_id)
+);
+
+CREATE TABLE IF NOT EXISTS product_reviews (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES product_variants(id) ON DELETE SET NULL,
+    order_id UUID NOT NULL,
+    customer_id UUID NOT NULL,
+    seller_id UUID NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    title VARCHAR(150),
+    body TEXT,
+    photos JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED')),
+    moderated_by VARCHAR(100),
+    moderated_at TIMESTAMP WITH TIME ZONE,
+    moderation_note TEXT,
+    seller_reply TEXT,
+    seller_replied_at TIMESTAMP WITH TIME ZONE,
+    helpful_count INTEGER NOT NULL DEFAULT 0,
+    not_helpful_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (order_id, product_id)
+);
+
+CREATE TABLE IF NOT EXISTS review_votes (
+    review_id UUID NOT NULL REFERENCES product_reviews(id) ON DELETE CASCADE,
+    voter_id UUID NOT NULL,
+    helpful BOOLEAN NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (review_id, voter_id)
+);
+
+-- Aggregates of the approved reviews, kept on the product for listing and sorting
+ALTER TABLE products ADD COLUMN IF NOT EXISTS rating_average NUMERIC(3,2) NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS rating_count INTEGER NOT NULL DEFAULT 0;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_review_eligibilities_customer_id ON review_eligibilities(customer_id);
+CREATE INDEX IF NOT EXISTS idx_product_reviews_product_status ON product_reviews(product_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_product_reviews_seller_status ON product_reviews(seller_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_product_reviews_pending ON product_reviews(created_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_products_keyset_rating ON products(rating_average, id) WHERE status = 'APPROVED';
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_product_reviews_updated_at
+    BEFORE UPDATE ON product_reviews
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,25 @@
+-- Deleted products and categories are kept, hidden from reads, until the
+-- retention purge removes them
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+
+-- Deleted products release their SKU and barcode; restoring one fails while another product uses them
+DROP INDEX IF EXISTS idx_products_sku_unique;
+DROP INDEX IF EXISTS idx_products_barcode_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku_unique ON products(sku) WHERE sku IS NOT NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_barcode_unique ON products(barcode) WHERE barcode IS NOT NULL AND deleted_at IS NULL;
+
+-- Products that appeared in an order, as reported by the order service's
+-- order.created events. The purge never removes them. No foreign key, so
+-- that the record outlives the product.
+CREATE TABLE IF NOT EXISTS ordered_products (
+    product_id UUID PRIMARY KEY,
+    first_ordered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_ordered_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_categories_deleted_at ON categories(deleted_at) WHERE deleted_at IS NOT NULL;
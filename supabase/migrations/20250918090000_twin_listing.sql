@@ -0,0 +1,28 @@
+-- Products folded into another product by a duplicate merge stay in the
+-- trash, pointing at the product that replaced them
+ALTER TABLE products ADD COLUMN IF NOT EXISTS merged_into UUID REFERENCES products(id) ON DELETE SET NULL;
+
+-- gtin_key makes barcodes comparable across GTIN-8/12/13/14 by left-padding
+-- them to 14 digits. Barcodes that are not GTINs have no key.
+CREATE OR REPLACE FUNCTION gtin_key(barcode TEXT) RETURNS TEXT AS $$
+    SELECT CASE
+        WHEN regexp_replace(barcode, '[\s-]', '', 'g') ~ '^[0-9]{8,14}$'
+        THEN lpad(regexp_replace(barcode, '[\s-]', '', 'g'), 14, '0')
+    END
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Difference hash of processed images, compared by Hamming distance. The
+-- hash is split into 8 one-byte bands tagged with their position, so that
+-- images at most 7 bits apart always share a band.
+CREATE OR REPLACE FUNCTION perceptual_hash_bands(hash BIGINT) RETURNS INTEGER[] AS $$
+    SELECT ARRAY(SELECT (band * 256 + ((hash >> (band * 8)) & 255))::INTEGER FROM generate_series(0, 7) AS band)
+$$ LANGUAGE SQL IMMUTABLE;
+
+ALTER TABLE product_media ADD COLUMN IF NOT EXISTS perceptual_hash BIGINT;
+ALTER TABLE product_media ADD COLUMN IF NOT EXISTS perceptual_hash_bands INTEGER[]
+    GENERATED ALWAYS AS (perceptual_hash_bands(perceptual_hash)) STORED;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_products_gtin_key ON products(gtin_key(barcode)) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_product_variants_gtin_key ON product_variants(gtin_key(barcode));
+CREATE INDEX IF NOT EXISTS idx_product_media_perceptual_hash_bands ON product_media USING GIN(perceptual_hash_bands);
@@ -0,0 +1,81 @@
+-- A GTIN identifies one item: among live products it belongs to at most one
+-- product or variant, compared by gtin_key so that GTIN-13 8690504000013 and
+-- GTIN-14 08690504000013 clash. Barcodes that are not GTINs keep relying on
+-- the per-table unique indexes.
+CREATE OR REPLACE FUNCTION barcode_gtin_taken(key TEXT, own_product UUID, own_variant UUID)
+RETURNS BOOLEAN AS $$
+BEGIN
+    IF key IS NULL THEN
+        RETURN false;
+    END IF;
+
+    -- Serialize writers of the same GTIN so that concurrent inserts see each other
+    PERFORM pg_advisory_xact_lock(hashtext('gtin:' || key));
+
+    RETURN EXISTS (
+        SELECT 1 FROM products p
+        WHERE gtin_key(p.barcode) = key AND p.deleted_at IS NULL
+          AND (own_variant IS NOT NULL OR p.id <> own_product)
+    ) OR EXISTS (
+        SELECT 1 FROM product_variants v
+        INNER JOIN products p ON v.product_id = p.id
+        WHERE gtin_key(v.barcode) = key AND p.deleted_at IS NULL
+          AND v.id IS DISTINCT FROM own_variant
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION check_product_barcode_gtin()
+RETURNS TRIGGER AS $$
+DECLARE
+    variant RECORD;
+BEGIN
+    IF NEW.deleted_at IS NOT NULL THEN
+        RETURN NULL;
+    END IF;
+
+    IF barcode_gtin_taken(gtin_key(NEW.barcode), NEW.id, NULL) THEN
+        RAISE EXCEPTION 'barcode % is already in use', NEW.barcode
+            USING ERRCODE = 'unique_violation', CONSTRAINT = 'barcode_gtin_unique';
+    END IF;
+
+    -- A restored product brings the barcodes of its variants back
+    IF TG_OP = 'UPDATE' AND OLD.deleted_at IS NOT NULL THEN
+        FOR variant IN SELECT id, barcode FROM product_variants WHERE product_id = NEW.id LOOP
+            IF barcode_gtin_taken(gtin_key(variant.barcode), NEW.id, variant.id) THEN
+                RAISE EXCEPTION 'barcode % is already in use', variant.barcode
+                    USING ERRCODE = 'unique_violation', CONSTRAINT = 'barcode_gtin_unique';
+            END IF;
+        END LOOP;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION check_variant_barcode_gtin()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM products WHERE id = NEW.product_id AND deleted_at IS NULL) THEN
+        RETURN NULL;
+    END IF;
+
+    IF barcode_gtin_taken(gtin_key(NEW.barcode), NEW.product_id, NEW.id) THEN
+        RAISE EXCEPTION 'barcode % is already in use', NEW.barcode
+            USING ERRCODE = 'unique_violation', CONSTRAINT = 'barcode_gtin_unique';
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- AFTER triggers see the row as written, including a product just taken out of the trash
+CREATE TRIGGER products_barcode_gtin_unique
+    AFTER INSERT OR UPDATE OF barcode, deleted_at ON products
+    FOR EACH ROW
+    EXECUTE FUNCTION check_product_barcode_gtin();
+
+CREATE TRIGGER product_variants_barcode_gtin_unique
+    AFTER INSERT OR UPDATE OF barcode, product_id ON product_variants
+    FOR EACH ROW
+    EXECUTE FUNCTION check_variant_barcode_gtin();
@@ -0,0 +1,26 @@
+-- Versions of the search settings: synonym sets, stopwords and the
+-- merchandising rules that pin, boost or bury products. Admins edit the one
+-- DRAFT, preview it and publish it, which makes it LIVE and retires the
+-- version that was live before.
+CREATE TABLE IF NOT EXISTS search_settings (
+    version INTEGER PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'DRAFT' CHECK (status IN ('DRAFT', 'LIVE', 'RETIRED')),
+    synonyms JSONB NOT NULL DEFAULT '[]',
+    stopwords TEXT[] NOT NULL DEFAULT '{}',
+    rules JSONB NOT NULL DEFAULT '[]',
+    created_by VARCHAR(100) NOT NULL,
+    published_by VARCHAR(100),
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_search_settings_draft ON search_settings(status) WHERE status = 'DRAFT';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_search_settings_live ON search_settings(status) WHERE status = 'LIVE';
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_search_settings_updated_at
+    BEFORE UPDATE ON search_settings
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
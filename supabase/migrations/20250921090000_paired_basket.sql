@@ -0,0 +1,22 @@
+-- Orders whose items have been counted into co_purchases, so that an
+-- order.created event delivered twice is only counted once
+CREATE TABLE IF NOT EXISTS co_purchase_orders (
+    order_id UUID PRIMARY KEY,
+    ordered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- How many orders contained both products. Every pair is stored in both
+-- directions, so the products bought with a product are one index range.
+CREATE TABLE IF NOT EXISTS co_purchases (
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    related_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    order_count INTEGER NOT NULL DEFAULT 0,
+    last_ordered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (product_id, related_id),
+    CHECK (product_id <> related_id)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_co_purchases_ranking ON co_purchases(product_id, order_count DESC, last_ordered_at DESC);
+CREATE INDEX IF NOT EXISTS idx_co_purchases_related_id ON co_purchases(related_id);
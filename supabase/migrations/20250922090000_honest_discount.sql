@@ -0,0 +1,212 @@
+-- Prices a product, variant or seller listing has been sold at. A row is
+-- written whenever the price changes; price is NULL while a variant or
+-- listing uses the price it inherits. Rows of deleted variants and listings
+-- are kept, so that the record of past prices outlives them.
+CREATE TABLE IF NOT EXISTS price_history (
+    id BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL CHECK (entity_type IN ('PRODUCT', 'VARIANT', 'SELLER_PRODUCT')),
+    entity_id UUID NOT NULL,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID,
+    seller_id UUID,
+    price DECIMAL(12,2),
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- compare_at_price is the crossed-out "was" price. reference_price is the
+-- lowest price in effect during the 30 days before the current price took
+-- effect, maintained by the triggers below; a compare-at price above it
+-- would advertise a discount that is not real.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS compare_at_price DECIMAL(12,2);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reference_price DECIMAL(12,2);
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS compare_at_price DECIMAL(12,2);
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS reference_price DECIMAL(12,2);
+ALTER TABLE seller_products ADD COLUMN IF NOT EXISTS compare_at_price DECIMAL(12,2);
+ALTER TABLE seller_products ADD COLUMN IF NOT EXISTS reference_price DECIMAL(12,2);
+
+-- The lowest price in effect during the 30 days before now: the prices set
+-- since then and the one that was in effect when the period began. Periods
+-- with an inherited price are not counted.
+CREATE OR REPLACE FUNCTION lowest_recent_price(kind TEXT, entity UUID)
+RETURNS DECIMAL AS $$
+    SELECT MIN(price) FROM (
+        SELECT price FROM price_history
+        WHERE entity_type = kind AND entity_id = entity AND changed_at > NOW() - INTERVAL '30 days'
+        UNION ALL
+        (SELECT price FROM price_history
+         WHERE entity_type = kind AND entity_id = entity AND changed_at <= NOW() - INTERVAL '30 days'
+         ORDER BY changed_at DESC, id DESC
+         LIMIT 1)
+    ) prices;
+$$ LANGUAGE sql STABLE;
+
+-- The compare-at price to show: only one above the price and not above the
+-- reference price. A price change can leave a stored compare-at price
+-- invalid; reads hide it until it is valid again or replaced.
+CREATE OR REPLACE FUNCTION valid_compare_at_price(compare_at DECIMAL, price DECIMAL, reference DECIMAL)
+RETURNS DECIMAL AS $$
+    SELECT CASE WHEN compare_at > price AND compare_at <= reference THEN compare_at END;
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION check_compare_at_price(compare_at DECIMAL, price DECIMAL, reference DECIMAL)
+RETURNS VOID AS $$
+BEGIN
+    IF price IS NULL THEN
+        RAISE EXCEPTION 'compare_at_price needs a price of its own'
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'compare_at_price_valid';
+    ELSIF compare_at <= price THEN
+        RAISE EXCEPTION 'compare_at_price % must be above the price %', compare_at, price
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'compare_at_price_valid';
+    ELSIF reference IS NULL THEN
+        RAISE EXCEPTION 'compare_at_price needs a price of the last 30 days to compare with'
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'compare_at_price_valid';
+    ELSIF compare_at > reference THEN
+        RAISE EXCEPTION 'compare_at_price % must not be above %, the lowest price of the 30 days before the price changed', compare_at, reference
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'compare_at_price_valid';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- BEFORE triggers set the reference price of a new price and validate a
+-- compare-at price as it is set; AFTER triggers record the new price once
+-- the row exists.
+CREATE OR REPLACE FUNCTION set_product_reference_price()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        NEW.reference_price := NULL;
+    ELSIF NEW.base_price IS DISTINCT FROM OLD.base_price THEN
+        NEW.reference_price := lowest_recent_price('PRODUCT', NEW.id);
+    END IF;
+
+    IF NEW.compare_at_price IS NOT NULL
+       AND (TG_OP = 'INSERT' OR NEW.compare_at_price IS DISTINCT FROM OLD.compare_at_price) THEN
+        PERFORM check_compare_at_price(NEW.compare_at_price, NEW.base_price, NEW.reference_price);
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_product_price()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.base_price IS NOT DISTINCT FROM OLD.base_price THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO price_history (entity_type, entity_id, product_id, price)
+    VALUES ('PRODUCT', NEW.id, NEW.id, NEW.base_price);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION set_variant_reference_price()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        NEW.reference_price := NULL;
+    ELSIF NEW.price IS DISTINCT FROM OLD.price THEN
+        NEW.reference_price := lowest_recent_price('VARIANT', NEW.id);
+    END IF;
+
+    IF NEW.compare_at_price IS NOT NULL
+       AND (TG_OP = 'INSERT' OR NEW.compare_at_price IS DISTINCT FROM OLD.compare_at_price) THEN
+        PERFORM check_compare_at_price(NEW.compare_at_price, NEW.price, NEW.reference_price);
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_variant_price()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.price IS NOT DISTINCT FROM OLD.price THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO price_history (entity_type, entity_id, product_id, variant_id, price)
+    VALUES ('VARIANT', NEW.id, NEW.product_id, NEW.id, NEW.price);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION set_seller_product_reference_price()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        NEW.reference_price := NULL;
+    ELSIF NEW.price IS DISTINCT FROM OLD.price THEN
+        NEW.reference_price := lowest_recent_price('SELLER_PRODUCT', NEW.id);
+    END IF;
+
+    IF NEW.compare_at_price IS NOT NULL
+       AND (TG_OP = 'INSERT' OR NEW.compare_at_price IS DISTINCT FROM OLD.compare_at_price) THEN
+        PERFORM check_compare_at_price(NEW.compare_at_price, NEW.price, NEW.reference_price);
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_seller_product_price()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.price IS NOT DISTINCT FROM OLD.price THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO price_history (entity_type, entity_id, product_id, variant_id, seller_id, price)
+    VALUES ('SELLER_PRODUCT', NEW.id, NEW.product_id, NEW.variant_id, NEW.seller_id, NEW.price);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER products_reference_price
+    BEFORE INSERT OR UPDATE OF base_price, compare_at_price ON products
+    FOR EACH ROW
+    EXECUTE FUNCTION set_product_reference_price();
+
+CREATE TRIGGER products_price_history
+    AFTER INSERT OR UPDATE OF base_price ON products
+    FOR EACH ROW
+    EXECUTE FUNCTION record_product_price();
+
+CREATE TRIGGER product_variants_reference_price
+    BEFORE INSERT OR UPDATE OF price, compare_at_price ON product_variants
+    FOR EACH ROW
+    EXECUTE FUNCTION set_variant_reference_price();
+
+CREATE TRIGGER product_variants_price_history
+    AFTER INSERT OR UPDATE OF price ON product_variants
+    FOR EACH ROW
+    EXECUTE FUNCTION record_variant_price();
+
+CREATE TRIGGER seller_products_reference_price
+    BEFORE INSERT OR UPDATE OF price, compare_at_price ON seller_products
+    FOR EACH ROW
+    EXECUTE FUNCTION set_seller_product_reference_price();
+
+CREATE TRIGGER seller_products_price_history
+    AFTER INSERT OR UPDATE OF price ON seller_products
+    FOR EACH ROW
+    EXECUTE FUNCTION record_seller_product_price();
+
+-- What was charged before this migration is unknown: the current prices
+-- start the history
+INSERT INTO price_history (entity_type, entity_id, product_id, price)
+SELECT 'PRODUCT', id, id, base_price FROM products;
+
+INSERT INTO price_history (entity_type, entity_id, product_id, variant_id, price)
+SELECT 'VARIANT', id, product_id, id, price FROM product_variants;
+
+INSERT INTO price_history (entity_type, entity_id, product_id, variant_id, seller_id, price)
+SELECT 'SELLER_PRODUCT', id, product_id, variant_id, seller_id, price FROM seller_products;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_price_history_entity ON price_history(entity_type, entity_id, changed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_product ON price_history(product_id, changed_at DESC);
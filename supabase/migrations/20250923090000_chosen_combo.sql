@@ -0,0 +1,5 @@
+-- The options chosen for a bundle product, as resolved and priced by the
+-- catalog service. Cart items carry the selection until checkout; order items
+-- keep it as part of the order. NULL for items that are not bundles.
+ALTER TABLE IF EXISTS cart_items ADD COLUMN IF NOT EXISTS bundle_selection JSONB;
+ALTER TABLE IF EXISTS order_items ADD COLUMN IF NOT EXISTS bundle_selection JSONB;
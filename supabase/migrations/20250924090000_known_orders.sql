@@ -0,0 +1,26 @@
+-- The retention purge only removes products once ordered_products covers
+-- every order, not just those reported by order.created events since the
+-- consumer started. A completed backfill is recorded here.
+CREATE TABLE IF NOT EXISTS ordered_products_backfill (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Backfill from the order service's order items when they live in the same
+-- database. Otherwise the purge stays off until the backfill is loaded by hand
+-- (see the catalog README).
+DO $$
+BEGIN
+    IF to_regclass('order_items') IS NOT NULL AND to_regclass('orders') IS NOT NULL THEN
+        INSERT INTO ordered_products (product_id, first_ordered_at, last_ordered_at)
+        SELECT oi.product_id, MIN(o.created_at), MAX(o.created_at)
+        FROM order_items oi
+        JOIN orders o ON o.id = oi.order_id
+        GROUP BY oi.product_id
+        ON CONFLICT (product_id) DO UPDATE
+        SET first_ordered_at = LEAST(ordered_products.first_ordered_at, EXCLUDED.first_ordered_at),
+            last_ordered_at = GREATEST(ordered_products.last_ordered_at, EXCLUDED.last_ordered_at);
+
+        INSERT INTO ordered_products_backfill (id) VALUES (TRUE) ON CONFLICT (id) DO NOTHING;
+    END IF;
+END $$;
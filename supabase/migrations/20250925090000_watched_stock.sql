@@ -0,0 +1,116 @@
+-- Stock alerts waiting to be published to Kafka. Triggers write them in the
+-- transaction that changes the stock, comparing the row before and after the
+-- change, so every write path raises them and concurrent writes cannot hide
+-- a crossing. The catalog service publishes and deletes them.
+CREATE TABLE IF NOT EXISTS stock_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    product_id UUID NOT NULL,
+    variant_id UUID,
+    seller_id UUID,
+    stock INTEGER NOT NULL,
+    min_stock INTEGER NOT NULL,
+    state VARCHAR(20) NOT NULL CHECK (state IN ('LOW', 'DEPLETED')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION stock_state(stock INTEGER, min_stock INTEGER)
+RETURNS TEXT AS $$
+    SELECT CASE
+        WHEN stock <= 0 THEN 'DEPLETED'
+        WHEN stock <= min_stock THEN 'LOW'
+        ELSE 'IN_STOCK'
+    END;
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Alerts fire when stock drops to zero or below its threshold, not while it
+-- stays there
+CREATE OR REPLACE FUNCTION stock_worsened(previous_state TEXT, new_state TEXT)
+RETURNS BOOLEAN AS $$
+    SELECT (new_state = 'DEPLETED' AND previous_state <> 'DEPLETED')
+        OR (new_state = 'LOW' AND previous_state = 'IN_STOCK');
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION record_product_stock_alert()
+RETURNS TRIGGER AS $$
+DECLARE
+    new_state TEXT := stock_state(NEW.base_stock, NEW.min_stock);
+BEGIN
+    IF NEW.deleted_at IS NULL AND stock_worsened(stock_state(OLD.base_stock, OLD.min_stock), new_state) THEN
+        INSERT INTO stock_alerts (product_id, stock, min_stock, state)
+        VALUES (NEW.id, NEW.base_stock, NEW.min_stock, new_state);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Variants use the threshold of their product. A variant without stock of
+-- its own has nothing to alert on.
+CREATE OR REPLACE FUNCTION record_variant_stock_alert()
+RETURNS TRIGGER AS $$
+DECLARE
+    threshold INTEGER;
+    previous_state TEXT := 'IN_STOCK';
+    new_state TEXT;
+BEGIN
+    IF NEW.stock IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    SELECT min_stock INTO threshold FROM products WHERE id = NEW.product_id;
+    new_state := stock_state(NEW.stock, COALESCE(threshold, 0));
+    IF OLD.stock IS NOT NULL THEN
+        previous_state := stock_state(OLD.stock, COALESCE(threshold, 0));
+    END IF;
+
+    IF stock_worsened(previous_state, new_state) THEN
+        INSERT INTO stock_alerts (product_id, variant_id, stock, min_stock, state)
+        VALUES (NEW.product_id, NEW.id, NEW.stock, COALESCE(threshold, 0), new_state);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Seller listings fall back to the product's threshold. A new listing, and
+-- one that had no stock override, counts as in stock before the change.
+CREATE OR REPLACE FUNCTION record_seller_product_stock_alert()
+RETURNS TRIGGER AS $$
+DECLARE
+    product_min_stock INTEGER;
+    previous_state TEXT := 'IN_STOCK';
+    new_state TEXT;
+BEGIN
+    IF NEW.stock IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    SELECT min_stock INTO product_min_stock FROM products WHERE id = NEW.product_id;
+    new_state := stock_state(NEW.stock, COALESCE(NEW.min_stock, product_min_stock, 0));
+    IF TG_OP = 'UPDATE' AND OLD.stock IS NOT NULL THEN
+        previous_state := stock_state(OLD.stock, COALESCE(OLD.min_stock, product_min_stock, 0));
+    END IF;
+
+    IF stock_worsened(previous_state, new_state) THEN
+        INSERT INTO stock_alerts (product_id, variant_id, seller_id, stock, min_stock, state)
+        VALUES (NEW.product_id, NEW.variant_id, NEW.seller_id, NEW.stock, COALESCE(NEW.min_stock, product_min_stock, 0), new_state);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER products_stock_alert
+    AFTER UPDATE OF base_stock, min_stock ON products
+    FOR EACH ROW
+    EXECUTE FUNCTION record_product_stock_alert();
+
+CREATE TRIGGER product_variants_stock_alert
+    AFTER UPDATE OF stock ON product_variants
+    FOR EACH ROW
+    EXECUTE FUNCTION record_variant_stock_alert();
+
+CREATE TRIGGER seller_products_stock_alert
+    AFTER INSERT OR UPDATE OF stock, min_stock ON seller_products
+    FOR EACH ROW
+    EXECUTE FUNCTION record_seller_product_stock_alert();